@@ -0,0 +1,85 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+)
+
+var errInvalidNumberOfFrames = errors.New("iconvg: invalid number of frames")
+
+// animationFrameDelay is the delay, in 100ths of a second, of each frame of a
+// RenderAnimation GIF.
+const animationFrameDelay = 10
+
+// RenderAnimation renders the IconVG graphic src to a looping animated GIF of
+// width × height frames, each shown for a tenth of a second. The i'th frame
+// is rendered with the palette paletteAt(t), where t is i/frames, so that t
+// ranges over [0, 1) and the animation loops seamlessly if paletteAt(1)
+// equals paletteAt(0).
+//
+// As for EncodePalettedPNG, the GIF's palette consists of transparent black,
+// and then each frame's custom palette and the flat colors that its paths are
+// filled with, up to a total of 256 colors. Each pixel is quantized to the
+// nearest palette color. GIF has no partial transparency, so the
+// anti-aliased edges of shapes drawn over transparent pixels are approximate.
+func RenderAnimation(src []byte, frames int, paletteAt func(t float32) Palette, width, height int) ([]byte, error) {
+	if frames <= 0 {
+		return nil, errInvalidNumberOfFrames
+	}
+
+	rgbas := make([]*image.RGBA, frames)
+	pal := color.Palette{color.RGBA{}}
+	seen := map[color.RGBA]bool{{}: true}
+	var c colorCollector
+	for i := range rgbas {
+		p := paletteAt(float32(i) / float32(frames))
+		opts := &DecodeOptions{Palette: &p}
+		rgba, err := rasterize(src, width, height, opts)
+		if err != nil {
+			return nil, err
+		}
+		rgbas[i] = rgba
+		if err := Decode(&c, src, opts); err != nil {
+			return nil, err
+		}
+		for _, list := range [2][]color.RGBA{c.metadata.Palette[:], c.colors} {
+			for _, x := range list {
+				if len(pal) == 256 {
+					break
+				}
+				if !seen[x] {
+					seen[x] = true
+					pal = append(pal, x)
+				}
+			}
+		}
+	}
+
+	g := &gif.GIF{
+		Image:    make([]*image.Paletted, frames),
+		Delay:    make([]int, frames),
+		Disposal: make([]byte, frames),
+	}
+	for i, rgba := range rgbas {
+		dst := image.NewPaletted(rgba.Bounds(), pal)
+		draw.Draw(dst, dst.Bounds(), rgba, image.Point{}, draw.Src)
+		g.Image[i] = dst
+		g.Delay[i] = animationFrameDelay
+		// Each frame replaces, rather than is composited over, the previous
+		// one.
+		g.Disposal[i] = gif.DisposalBackground
+	}
+	buf := new(bytes.Buffer)
+	if err := gif.EncodeAll(buf, g); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func TestRenderAnimation(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, PaletteIndexColor(0))
+	e.StartPath(0, -16, -16)
+	e.AbsHLineTo(+16)
+	e.AbsVLineTo(+16)
+	e.AbsHLineTo(-16)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	colors := []color.RGBA{
+		{0xff, 0x00, 0x00, 0xff},
+		{0x00, 0xff, 0x00, 0xff},
+		{0x00, 0x00, 0xff, 0xff},
+		{0x00, 0x00, 0x00, 0xff},
+	}
+	var gotTs []float32
+	paletteAt := func(t float32) Palette {
+		gotTs = append(gotTs, t)
+		p := DefaultPalette
+		p[0] = colors[int(t*4)]
+		return p
+	}
+	b, err := RenderAnimation(ivgData, 4, paletteAt, 16, 16)
+	if err != nil {
+		t.Fatalf("RenderAnimation: %v", err)
+	}
+	if want := []float32{0, 0.25, 0.5, 0.75}; len(gotTs) != len(want) {
+		t.Fatalf("paletteAt calls: got %v, want %v", gotTs, want)
+	} else {
+		for i := range want {
+			if gotTs[i] != want[i] {
+				t.Fatalf("paletteAt calls: got %v, want %v", gotTs, want)
+			}
+		}
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("gif.DecodeAll: %v", err)
+	}
+	if len(g.Image) != len(colors) {
+		t.Fatalf("number of frames: got %d, want %d", len(g.Image), len(colors))
+	}
+	for i, m := range g.Image {
+		if got := color.RGBAModel.Convert(m.At(8, 8)); got != colors[i] {
+			t.Errorf("frame #%d: center: got %v, want %v", i, got, colors[i])
+		}
+		if got := color.RGBAModel.Convert(m.At(0, 0)); got.(color.RGBA).A != 0 {
+			t.Errorf("frame #%d: corner: got %v, want transparent", i, got)
+		}
+	}
+
+	if _, err := RenderAnimation(ivgData, 0, paletteAt, 16, 16); err != errInvalidNumberOfFrames {
+		t.Errorf("zero frames: got %v, want %v", err, errInvalidNumberOfFrames)
+	}
+}
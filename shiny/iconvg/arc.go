@@ -0,0 +1,193 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"math"
+)
+
+// arcToCubics approximates the elliptical arc from (x1, y1) to (x2, y2) by one
+// or more cubic Bézier curves, calling cubeTo for each one. All coordinates
+// are absolute, in graphic coordinate space.
+//
+// If the two end points are the same, the SVG specification says to omit the
+// arc entirely, so it returns true without calling cubeTo. Otherwise, it
+// returns false, without calling cubeTo, if the radii are zero or NaN, in
+// which case the SVG specification says to treat the arc as a straight line.
+func arcToCubics(x1, y1, rx, ry, xAxisRotation float32, largeArc, sweep bool, x2, y2 float32,
+	cubeTo func(x1, y1, x2, y2, x, y float32)) bool {
+
+	return arcToCubicsN(x1, y1, rx, ry, xAxisRotation, largeArc, sweep, x2, y2, quarterArcSegments, cubeTo)
+}
+
+// quarterArcSegments returns the number of cubic Bézier curves that
+// arcToCubics uses for an arc: one per quarter turn, or part thereof.
+func quarterArcSegments(rx, ry, deltaTheta float64) int {
+	return int(math.Ceil(math.Abs(deltaTheta) / (math.Pi/2 + 0.001)))
+}
+
+// arcToCubicsN is like arcToCubics, except that the number of cubic Bézier
+// curves is given by the segments function of the arc's radii, after any
+// scaling up to fit the end points, and its signed angular extent, in
+// radians.
+func arcToCubicsN(x1, y1, rx, ry, xAxisRotation float32, largeArc, sweep bool, x2, y2 float32,
+	segments func(rx, ry, deltaTheta float64) int, cubeTo func(x1, y1, x2, y2, x, y float32)) bool {
+
+	// Coincident end points would otherwise lead to a division by zero, in
+	// step 2 below, and NaN coordinates.
+	if x1 == x2 && y1 == y2 {
+		return true
+	}
+
+	// We follow the "Conversion from endpoint to center parameterization"
+	// algorithm as per
+	// https://www.w3.org/TR/SVG/implnote.html#ArcConversionEndpointToCenter
+
+	// There seems to be a bug in the spec's "implementation notes".
+	//
+	// Actual implementations, such as
+	//	- https://git.gnome.org/browse/librsvg/tree/rsvg-path.c
+	//	- http://svn.apache.org/repos/asf/xmlgraphics/batik/branches/svg11/sources/org/apache/batik/ext/awt/geom/ExtendedGeneralPath.java
+	//	- https://java.net/projects/svgsalamander/sources/svn/content/trunk/svg-core/src/main/java/com/kitfox/svg/pathcmd/Arc.java
+	//	- https://github.com/millermedeiros/SVGParser/blob/master/com/millermedeiros/geom/SVGArc.as
+	// do something slightly different (marked with a †).
+
+	// (†) The Abs isn't part of the spec. Neither is checking that Rx and Ry
+	// are non-zero (and non-NaN).
+	Rx := math.Abs(float64(rx))
+	Ry := math.Abs(float64(ry))
+	if !(Rx > 0 && Ry > 0) {
+		return false
+	}
+
+	fx1 := float64(x1)
+	fy1 := float64(y1)
+	fx2 := float64(x2)
+	fy2 := float64(y2)
+
+	phi := 2 * math.Pi * float64(xAxisRotation)
+
+	// Step 1: Compute (x1′, y1′)
+	halfDx := (fx1 - fx2) / 2
+	halfDy := (fy1 - fy2) / 2
+	cosPhi := math.Cos(phi)
+	sinPhi := math.Sin(phi)
+	x1Prime := +cosPhi*halfDx + sinPhi*halfDy
+	y1Prime := -sinPhi*halfDx + cosPhi*halfDy
+
+	// Step 2: Compute (cx′, cy′)
+	rxSq := Rx * Rx
+	rySq := Ry * Ry
+	x1PrimeSq := x1Prime * x1Prime
+	y1PrimeSq := y1Prime * y1Prime
+
+	// (†) Check that the radii are large enough.
+	radiiCheck := x1PrimeSq/rxSq + y1PrimeSq/rySq
+	if radiiCheck > 1 {
+		c := math.Sqrt(radiiCheck)
+		Rx *= c
+		Ry *= c
+		rxSq = Rx * Rx
+		rySq = Ry * Ry
+	}
+
+	denom := rxSq*y1PrimeSq + rySq*x1PrimeSq
+	step2 := 0.0
+	if a := rxSq*rySq/denom - 1; a > 0 {
+		step2 = math.Sqrt(a)
+	}
+	if largeArc == sweep {
+		step2 = -step2
+	}
+	cxPrime := +step2 * Rx * y1Prime / Ry
+	cyPrime := -step2 * Ry * x1Prime / Rx
+
+	// Step 3: Compute (cx, cy) from (cx′, cy′)
+	cx := +cosPhi*cxPrime - sinPhi*cyPrime + (fx1+fx2)/2
+	cy := +sinPhi*cxPrime + cosPhi*cyPrime + (fy1+fy2)/2
+
+	// Step 4: Compute θ1 and Δθ
+	ax := (+x1Prime - cxPrime) / Rx
+	ay := (+y1Prime - cyPrime) / Ry
+	bx := (-x1Prime - cxPrime) / Rx
+	by := (-y1Prime - cyPrime) / Ry
+	theta1 := angle(1, 0, ax, ay)
+	deltaTheta := angle(ax, ay, bx, by)
+	if sweep {
+		if deltaTheta < 0 {
+			deltaTheta += 2 * math.Pi
+		}
+	} else {
+		if deltaTheta > 0 {
+			deltaTheta -= 2 * math.Pi
+		}
+	}
+
+	// This ends the
+	// https://www.w3.org/TR/SVG/implnote.html#ArcConversionEndpointToCenter
+	// algorithm. What follows below is specific to this implementation.
+
+	// We approximate an arc by one or more cubic Bézier curves.
+	n := segments(Rx, Ry, deltaTheta)
+	if n < 1 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		arcSegmentTo(cx, cy,
+			theta1+deltaTheta*float64(i+0)/float64(n),
+			theta1+deltaTheta*float64(i+1)/float64(n),
+			Rx, Ry, cosPhi, sinPhi, cubeTo,
+		)
+	}
+	return true
+}
+
+// arcSegmentTo approximates an arc by a cubic Bézier curve. The mathematical
+// formulae for the control points are the same as that used by librsvg.
+func arcSegmentTo(cx, cy, theta1, theta2, rx, ry, cosPhi, sinPhi float64,
+	cubeTo func(x1, y1, x2, y2, x, y float32)) {
+
+	halfDeltaTheta := (theta2 - theta1) * 0.5
+	q := math.Sin(halfDeltaTheta * 0.5)
+	t := (8 * q * q) / (3 * math.Sin(halfDeltaTheta))
+	cos1 := math.Cos(theta1)
+	sin1 := math.Sin(theta1)
+	cos2 := math.Cos(theta2)
+	sin2 := math.Sin(theta2)
+	x1 := rx * (+cos1 - t*sin1)
+	y1 := ry * (+sin1 + t*cos1)
+	x2 := rx * (+cos2 + t*sin2)
+	y2 := ry * (+sin2 - t*cos2)
+	x3 := rx * (+cos2)
+	y3 := ry * (+sin2)
+	cubeTo(
+		float32(cx+cosPhi*x1-sinPhi*y1),
+		float32(cy+sinPhi*x1+cosPhi*y1),
+		float32(cx+cosPhi*x2-sinPhi*y2),
+		float32(cy+sinPhi*x2+cosPhi*y2),
+		float32(cx+cosPhi*x3-sinPhi*y3),
+		float32(cy+sinPhi*x3+cosPhi*y3),
+	)
+}
+
+// angle returns the angle between the u and v vectors.
+func angle(ux, uy, vx, vy float64) float64 {
+	uNorm := math.Sqrt(ux*ux + uy*uy)
+	vNorm := math.Sqrt(vx*vx + vy*vy)
+	norm := uNorm * vNorm
+	cos := (ux*vx + uy*vy) / norm
+	ret := 0.0
+	if cos <= -1 {
+		ret = math.Pi
+	} else if cos >= +1 {
+		ret = 0
+	} else {
+		ret = math.Acos(cos)
+	}
+	if ux*vy < uy*vx {
+		return -ret
+	}
+	return +ret
+}
@@ -0,0 +1,207 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestArcToCubicsDegenerate(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		x1, y1, rx, ry float32
+		x2, y2         float32
+		want           bool
+	}{
+		{"coincident end points", 3, 4, 5, 5, 3, 4, true},
+		{"coincident end points, zero radii", 3, 4, 0, 0, 3, 4, true},
+		{"zero rx", 0, 0, 0, 5, 10, 0, false},
+		{"zero ry", 0, 0, 5, 0, 10, 0, false},
+		{"NaN radius", 0, 0, float32(math.NaN()), 5, 10, 0, false},
+	}
+	for _, tc := range testCases {
+		for _, sweep := range []bool{false, true} {
+			nCalls := 0
+			got := arcToCubics(tc.x1, tc.y1, tc.rx, tc.ry, 0, false, sweep, tc.x2, tc.y2,
+				func(x1, y1, x2, y2, x, y float32) { nCalls++ })
+			if got != tc.want || nCalls != 0 {
+				t.Errorf("%s, sweep=%t: got %t with %d cubeTo calls, want %t with none",
+					tc.desc, sweep, got, nCalls, tc.want)
+			}
+		}
+	}
+}
+
+func TestDecodeDegenerateArcs(t *testing.T) {
+	encode := func(arcs bool) []byte {
+		var e Encoder
+		e.Reset(Metadata{
+			ViewBox: DefaultViewBox,
+			Palette: DefaultPalette,
+		})
+		e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+		e.StartPath(0, -20, -20)
+		if arcs {
+			e.AbsArcTo(10, 10, 0, false, true, -20, -20)
+			e.AbsArcTo(0, 0, 0, true, false, -20, -20)
+		}
+		e.AbsLineTo(+20, -20)
+		if arcs {
+			e.RelArcTo(10, 5, 0.25, true, true, 0, 0)
+		}
+		e.AbsLineTo(+20, +20)
+		e.AbsLineTo(-20, +20)
+		e.ClosePathEndPath()
+		b, err := e.Bytes()
+		if err != nil {
+			t.Fatalf("Bytes: %v", err)
+		}
+		return b
+	}
+	withArcs, withoutArcs := encode(true), encode(false)
+
+	var c pathCollector
+	if err := Decode(&c, withArcs, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got, want := len(c.path.Segments), 4; got != want {
+		t.Errorf("number of segments: got %d, want %d", got, want)
+	}
+	for i, s := range c.path.Segments {
+		for _, p := range s.Args {
+			if math.IsNaN(float64(p[0])) || math.IsNaN(float64(p[1])) {
+				t.Errorf("segment #%d: got NaN coordinate: %v", i, s)
+			}
+		}
+	}
+
+	got, err := rasterize(withArcs, 64, 64, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	want, err := rasterize(withoutArcs, 64, 64, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	for i := range got.Pix {
+		if got.Pix[i] != want.Pix[i] {
+			t.Fatalf("rasterized images differ at byte %d", i)
+		}
+	}
+}
+
+// countingArcApproximator is an ArcApproximator that counts its calls, and
+// approximates every arc by a single degenerate cubic Bézier curve.
+type countingArcApproximator int
+
+func (c *countingArcApproximator) ApproximateArc(x1, y1, rx, ry, xAxisRotation float32, largeArc, sweep bool, x2, y2 float32,
+	cubeTo func(x1, y1, x2, y2, x, y float32)) bool {
+
+	*c++
+	cubeTo(x1, y1, x2, y2, x2, y2)
+	return true
+}
+
+func TestDecodeArcApproximator(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, -20, 0)
+	e.AbsArcTo(20, 20, 0, false, true, +20, 0)
+	e.RelArcTo(20, 20, 0, false, true, -40, 0)
+	// This smooth cubic Bézier curve follows an arc, so its first control
+	// point is the current point, regardless of how the arc is approximated.
+	e.AbsSmoothCubeTo(-30, 30, -20, 20)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var c countingArcApproximator
+	var r pathsRecorder
+	if err := Decode(&r, ivgData, &DecodeOptions{ArcApproximator: &c}); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if c != 2 {
+		t.Errorf("calls: got %d, want 2", c)
+	}
+	if len(r.paths) != 1 {
+		t.Fatalf("paths: got %d, want 1", len(r.paths))
+	}
+	segs := r.paths[0].Segments
+	if len(segs) != 4 {
+		t.Fatalf("segments: got %d, want 4", len(segs))
+	}
+	if got, want := segs[2].Args[2], (f32.Vec2{-20, 0}); got != want {
+		t.Errorf("second arc's end point: got %v, want %v", got, want)
+	}
+	if got, want := segs[3].Args[0], (f32.Vec2{-20, 0}); got != want {
+		t.Errorf("smooth curve's first control point: got %v, want %v", got, want)
+	}
+
+	near := func(p, q f32.Vec2) bool {
+		return abs32(p[0]-q[0]) < 1e-4 && abs32(p[1]-q[1]) < 1e-4
+	}
+	counts := map[ArcTolerance]int{}
+	for _, tol := range []ArcTolerance{0.001, 0.1, 100} {
+		if err := Decode(&r, ivgData, &DecodeOptions{ArcApproximator: tol}); err != nil {
+			t.Fatalf("tolerance %v: Decode: %v", tol, err)
+		}
+		segs := r.paths[0].Segments
+		counts[tol] = len(segs)
+		if got, want := segs[len(segs)-1].Args[0], (f32.Vec2{-20, 0}); !near(got, want) {
+			t.Errorf("tolerance %v: smooth curve's first control point: got %v, want %v", tol, got, want)
+		}
+	}
+	if !(counts[0.001] > counts[0.1] && counts[0.1] > counts[100]) {
+		t.Errorf("segment counts should decrease as tolerance increases: got %v", counts)
+	}
+	// Each arc is a half turn, so a generous tolerance needs only one curve
+	// per arc.
+	if got, want := counts[100], 4; got != want {
+		t.Errorf("tolerance 100: segments: got %d, want %d", got, want)
+	}
+}
+
+func TestArcToleranceAccuracy(t *testing.T) {
+	const r = 50
+	for _, tol := range []ArcTolerance{0.001, 0.01, 0.1, 1} {
+		n, maxErr := 0, 0.0
+		p0 := [2]float64{r, 0}
+		ok := tol.ApproximateArc(r, 0, r, r, 0, false, true, -r, 0, func(x1, y1, x2, y2, x, y float32) {
+			n++
+			// Sample the curve, and measure each sample's distance from the
+			// circle.
+			for i := 1; i < 16; i++ {
+				u := float64(i) / 16
+				v := 1 - u
+				a, b, c, d := v*v*v, 3*v*v*u, 3*v*u*u, u*u*u
+				px := a*p0[0] + b*float64(x1) + c*float64(x2) + d*float64(x)
+				py := a*p0[1] + b*float64(y1) + c*float64(y2) + d*float64(y)
+				maxErr = math.Max(maxErr, math.Abs(math.Hypot(px, py)-r))
+			}
+			p0 = [2]float64{float64(x), float64(y)}
+		})
+		if !ok {
+			t.Errorf("tolerance %v: ApproximateArc returned false", tol)
+			continue
+		}
+		if n == 0 {
+			t.Errorf("tolerance %v: no curves", tol)
+		}
+		// Allow for float32 rounding.
+		if maxErr > float64(tol)+1e-4 {
+			t.Errorf("tolerance %v: %d curves: maximum error %v", tol, n, maxErr)
+		}
+	}
+}
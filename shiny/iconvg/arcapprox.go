@@ -0,0 +1,136 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"math"
+)
+
+// ArcApproximator approximates elliptical arcs by cubic Bézier curves. It can
+// be set as a DecodeOptions field to override how Destinations convert arcs.
+type ArcApproximator interface {
+	// ApproximateArc approximates the elliptical arc from (x1, y1) to (x2,
+	// y2), with the same parameters as a Destination's AbsArcTo method, by
+	// calling cubeTo for each of zero or more cubic Bézier curves. All
+	// coordinates are absolute.
+	//
+	// It returns false, without calling cubeTo, if the arc should instead be
+	// drawn as a straight line, which the SVG specification requires if
+	// either radius is zero.
+	ApproximateArc(x1, y1, rx, ry, xAxisRotation float32, largeArc, sweep bool, x2, y2 float32,
+		cubeTo func(x1, y1, x2, y2, x, y float32)) bool
+}
+
+// ArcTolerance is an ArcApproximator that uses the fewest cubic Bézier curves
+// that are each within the given distance, in graphic coordinate space, of the
+// true arc. Each curve spans at most half a turn, so a very large
+// tolerance trades precision for speed.
+//
+// For comparison, the default conversion, used when a DecodeOptions'
+// ArcApproximator is nil, is one curve per quarter turn, which is within
+// 0.03% of the larger radius.
+type ArcTolerance float32
+
+// ApproximateArc implements the ArcApproximator interface.
+func (t ArcTolerance) ApproximateArc(x1, y1, rx, ry, xAxisRotation float32, largeArc, sweep bool, x2, y2 float32,
+	cubeTo func(x1, y1, x2, y2, x, y float32)) bool {
+
+	return arcToCubicsN(x1, y1, rx, ry, xAxisRotation, largeArc, sweep, x2, y2, t.segments, cubeTo)
+}
+
+func (t ArcTolerance) segments(rx, ry, deltaTheta float64) int {
+	// The maximum distance between a circular arc of radius r, spanning θ
+	// radians, and its cubic Bézier approximation is
+	//	r × 2 sin⁶(θ/4) / (27 cos²(θ/4)).
+	// Bounding an ellipse by its larger radius, find the largest θ, up to π,
+	// whose error is within the tolerance, by bisection.
+	r := math.Max(rx, ry)
+	maxErr := func(theta float64) float64 {
+		s, c := math.Sin(theta/4), math.Cos(theta/4)
+		return r * 2 * s * s * s * s * s * s / (27 * c * c)
+	}
+	lo, hi := 0.0, math.Pi
+	if maxErr(hi) > float64(t) {
+		for i := 0; i < 32; i++ {
+			mid := (lo + hi) / 2
+			if maxErr(mid) > float64(t) {
+				hi = mid
+			} else {
+				lo = mid
+			}
+		}
+		hi = lo
+	}
+	if !(hi > 0) {
+		// A zero or negative tolerance would otherwise need infinitely many
+		// curves. Use a generous but finite number.
+		return 1024
+	}
+	return int(math.Ceil(math.Abs(deltaTheta) / hi))
+}
+
+// arcDestination is a Destination that forwards each method call to an inner
+// Destination, except that arcs are converted to cubic Bézier curves by an
+// ArcApproximator. Like the teeDestination that it embeds, it also forwards
+// the optional finisher and autoCloser methods.
+type arcDestination struct {
+	// The teeDestination forwards each method call to both the inner
+	// Destination and the penTracker.
+	teeDestination
+	pen    penTracker
+	approx ArcApproximator
+}
+
+func newArcDestination(inner Destination, approx ArcApproximator) *arcDestination {
+	d := &arcDestination{approx: approx}
+	d.teeDestination = teeDestination{d0: inner, d1: &d.pen}
+	return d
+}
+
+func (d *arcDestination) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	p := d.pen.pen
+	if !d.approx.ApproximateArc(p[0], p[1], rx, ry, xAxisRotation, largeArc, sweep, x, y, d.AbsCubeTo) {
+		d.AbsLineTo(x, y)
+		return
+	}
+	// A smooth curve that follows an arc does not reflect the arc's last
+	// control point.
+	d.pen.prevSmoothType = smoothTypeNone
+}
+
+func (d *arcDestination) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	p := d.pen.rel(x, y)
+	d.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, p[0], p[1])
+}
+
+// The inner Destination sees an arc's cubic Bézier curves, which a smooth
+// cubic Bézier curve would reflect, so one that does not follow a cubic
+// Bézier curve, as the penTracker sees it, is made explicit.
+
+func (d *arcDestination) AbsSmoothCubeTo(x2, y2, x, y float32) {
+	if d.pen.prevSmoothType == smoothTypeCube {
+		d.teeDestination.AbsSmoothCubeTo(x2, y2, x, y)
+		return
+	}
+	p := d.pen.pen
+	d.AbsCubeTo(p[0], p[1], x2, y2, x, y)
+}
+
+func (d *arcDestination) RelSmoothCubeTo(x2, y2, x, y float32) {
+	if d.pen.prevSmoothType == smoothTypeCube {
+		d.teeDestination.RelSmoothCubeTo(x2, y2, x, y)
+		return
+	}
+	p := d.pen.pen
+	d.AbsCubeTo(p[0], p[1], p[0]+x2, p[1]+y2, p[0]+x, p[1]+y)
+}
+
+// penTracker is a Destination that only tracks the current point and the
+// state needed for smooth curves.
+type penTracker struct {
+	segmenter
+}
+
+func (t *penTracker) Reset(m Metadata) { t.segmenter.reset(m, nil) }
@@ -0,0 +1,103 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/draw"
+	"io"
+	"math"
+	"unicode/utf8"
+)
+
+// ASCIIRasterizer is a Destination that rasterizes an IconVG graphic to text,
+// using Unicode braille characters, for previewing in a terminal. Each
+// character is a cell of 2 by 4 dots, and a dot is raised if its pixel is at
+// least half covered.
+//
+// Call WriteTo after decoding to write the text.
+type ASCIIRasterizer struct {
+	// Columns is the width of the text, in characters. If zero, it is 40.
+	Columns int
+
+	// Rows is the height of the text, in characters. If zero, it is chosen to
+	// preserve the graphic's aspect ratio, assuming that terminal character
+	// cells are twice as tall as they are wide, and so braille dots are
+	// square.
+	Rows int
+
+	teeDestination
+
+	z   Rasterizer
+	dst *image.Alpha
+}
+
+// Reset resets the ASCIIRasterizer for the given Metadata.
+func (a *ASCIIRasterizer) Reset(m Metadata) {
+	cols, rows := a.Columns, a.Rows
+	if cols <= 0 {
+		cols = 40
+	}
+	if rows <= 0 {
+		dx, dy := m.ViewBox.AspectRatio()
+		rows = int(math.Ceil(float64(2*cols) * float64(dy) / float64(dx) / 4))
+		if rows < 1 || dx <= 0 {
+			rows = 1
+		}
+	}
+
+	r := image.Rect(0, 0, 2*cols, 4*rows)
+	if a.dst == nil || a.dst.Rect != r {
+		a.dst = image.NewAlpha(r)
+	} else {
+		for i := range a.dst.Pix {
+			a.dst.Pix[i] = 0
+		}
+	}
+	a.z.SetDstImage(a.dst, r, draw.Over)
+	a.teeDestination.d0 = &a.z
+	a.z.Reset(m)
+}
+
+// WriteTo writes the rasterized graphic, one line of braille characters per
+// row, to w.
+func (a *ASCIIRasterizer) WriteTo(w io.Writer) (n int64, err error) {
+	if a.dst == nil {
+		return 0, nil
+	}
+	// dotBits are the bits of the Unicode braille patterns' offsets from
+	// U+2800 for each dot in a cell, indexed by y and then x.
+	dotBits := [4][2]byte{
+		{0x01, 0x08},
+		{0x02, 0x10},
+		{0x04, 0x20},
+		{0x40, 0x80},
+	}
+
+	r := a.dst.Rect
+	line := make([]byte, 0, utf8.UTFMax*r.Dx()/2+1)
+	for y := r.Min.Y; y < r.Max.Y; y += 4 {
+		line = line[:0]
+		for x := r.Min.X; x < r.Max.X; x += 2 {
+			bits := byte(0)
+			for dy := 0; dy < 4; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					if a.dst.AlphaAt(x+dx, y+dy).A >= 0x80 {
+						bits |= dotBits[dy][dx]
+					}
+				}
+			}
+			var buf [utf8.UTFMax]byte
+			line = append(line, buf[:utf8.EncodeRune(buf[:], 0x2800+rune(bits))]...)
+		}
+		line = append(line, '\n')
+		m, err := w.Write(line)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
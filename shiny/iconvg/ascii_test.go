@@ -0,0 +1,55 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestASCIIRasterizer(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	a := &ASCIIRasterizer{Columns: 12}
+	if err := Decode(a, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if _, err := a.WriteTo(buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	got := buf.String()
+
+	// The action-info graphic is square, so 12 columns of 2 dots need 6 rows
+	// of 4 dots.
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("got %d lines, want 6:\n%s", len(lines), got)
+	}
+	for i, line := range lines {
+		if n := utf8.RuneCountInString(line); n != 12 {
+			t.Errorf("line #%d: got %d characters, want 12", i, n)
+		}
+	}
+	// The corners are outside the circle, and the middle left and right are
+	// inside it, so they should be blank and full braille cells.
+	const blank, full = '⠀', '⣿'
+	if r := []rune(lines[0]); r[0] != blank || r[11] != blank {
+		t.Errorf("top corners: got %q, want blank", lines[0])
+	}
+	if r := []rune(lines[2]); r[2] != full || r[9] != full {
+		t.Errorf("middle row: got %q, want full cells at both sides", lines[2])
+	}
+	// The "i" in the middle is a hole.
+	if r := []rune(lines[3]); r[5] == full || r[6] == full {
+		t.Errorf("middle row: got %q, want a hole in the middle", lines[3])
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/image/math/f32"
+)
+
+var (
+	errPathNotClosed   = errors.New("iconvg: path is not closed")
+	errPathNotMonotone = errors.New("iconvg: path is not monotone in x")
+	errPathNotSimple   = errors.New("iconvg: path is not a simple polygon")
+)
+
+// PathCheck checks a structural property of a path. It is passed the path's
+// segments, in graphic coordinate space, and returns a non-nil error if the
+// property does not hold.
+type PathCheck func(segs []Segment) error
+
+// AssertDestination returns a Destination that forwards each method call to
+// inner, which may be nil, and also runs each check on each path once that
+// path has ended. The first check that fails aborts Decode, which returns the
+// check's error, annotated with the path's index.
+func AssertDestination(inner Destination, checks ...PathCheck) Destination {
+	return &teeDestination{
+		d0: inner,
+		d1: &pathChecker{checks: checks},
+	}
+}
+
+// pathChecker is a Destination that runs PathChecks on each path.
+type pathChecker struct {
+	segmenter
+	checks []PathCheck
+	segs   []Segment
+	nPaths int
+	err    error
+}
+
+func (c *pathChecker) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	c.segs = c.segs[:0]
+	c.nPaths = 0
+	c.err = nil
+}
+
+func (c *pathChecker) abortErr() error { return c.err }
+
+func (c *pathChecker) beginPath()           { c.segs = c.segs[:0] }
+func (c *pathChecker) addSegment(s Segment) { c.segs = append(c.segs, s) }
+
+func (c *pathChecker) endPath() {
+	if c.err == nil {
+		for _, check := range c.checks {
+			if err := check(c.segs); err != nil {
+				c.err = fmt.Errorf("%v (path #%d)", err, c.nPaths)
+				break
+			}
+		}
+	}
+	c.nPaths++
+}
+
+// CheckClosed is a PathCheck that checks that each of the path's sub-paths
+// explicitly ends where it started. IconVG implicitly closes every sub-path,
+// so this checks that doing so adds no extra line segment.
+func CheckClosed(segs []Segment) error {
+	for _, sub := range splitSubpaths(segs) {
+		if sub[0].Args[0] != sub[len(sub)-1].end() {
+			return errPathNotClosed
+		}
+	}
+	return nil
+}
+
+// CheckSimple is a PathCheck that checks that the path is a simple polygon: a
+// single sub-path whose edges do not intersect each other, other than
+// adjacent edges meeting at their shared vertex. Curves are first flattened
+// to line segments.
+func CheckSimple(segs []Segment) error {
+	if len(splitSubpaths(segs)) != 1 {
+		return errPathNotSimple
+	}
+	polygon := closedPolygon(segs)
+	n := len(polygon) - 1
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if j == i+1 || (i == 0 && j == n-1) {
+				// Adjacent edges.
+				continue
+			}
+			if edgesIntersect(polygon[i], polygon[i+1], polygon[j], polygon[j+1]) {
+				return errPathNotSimple
+			}
+		}
+	}
+	return nil
+}
+
+// CheckMonotoneX is a PathCheck that checks that each of the path's sub-paths
+// is monotone with respect to the X axis: that its boundary can be split into
+// two chains whose X coordinates are each non-decreasing. Equivalently, any
+// vertical line crosses the boundary at most twice. Curves are first
+// flattened to line segments.
+func CheckMonotoneX(segs []Segment) error {
+	for _, sub := range splitSubpaths(segs) {
+		polygon := closedPolygon(sub)
+		changes, prev := 0, 0
+		for i := 1; i < len(polygon); i++ {
+			dir := 0
+			if dx := polygon[i][0] - polygon[i-1][0]; dx > 0 {
+				dir = +1
+			} else if dx < 0 {
+				dir = -1
+			}
+			if dir == 0 {
+				continue
+			}
+			if prev != 0 && dir != prev {
+				changes++
+			}
+			prev = dir
+		}
+		// Account for the change, if any, when wrapping around from the
+		// last edge to the first.
+		for i := 1; i < len(polygon); i++ {
+			if dx := polygon[i][0] - polygon[i-1][0]; dx != 0 {
+				if (dx > 0) != (prev > 0) {
+					changes++
+				}
+				break
+			}
+		}
+		if changes > 2 {
+			return errPathNotMonotone
+		}
+	}
+	return nil
+}
+
+// closedPolygon returns the flattened sub-path, with its first point repeated
+// at the end if it is not already there.
+func closedPolygon(segs []Segment) []f32.Vec2 {
+	polygon := flatten(nil, segs, flattenTolerance)
+	if len(polygon) > 0 && polygon[0] != polygon[len(polygon)-1] {
+		polygon = append(polygon, polygon[0])
+	}
+	return polygon
+}
+
+// edgesIntersect returns whether the line segments p0-p1 and q0-q1 intersect,
+// including touching or overlapping.
+func edgesIntersect(p0, p1, q0, q1 f32.Vec2) bool {
+	orient := func(a, b, c f32.Vec2) int {
+		x := (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+		if x > 0 {
+			return +1
+		} else if x < 0 {
+			return -1
+		}
+		return 0
+	}
+	onSegment := func(a, b, c f32.Vec2) bool {
+		return min32(a[0], b[0]) <= c[0] && c[0] <= max32(a[0], b[0]) &&
+			min32(a[1], b[1]) <= c[1] && c[1] <= max32(a[1], b[1])
+	}
+
+	o0, o1 := orient(p0, p1, q0), orient(p0, p1, q1)
+	o2, o3 := orient(q0, q1, p0), orient(q0, q1, p1)
+	if o0 != o1 && o2 != o3 {
+		return true
+	}
+	return (o0 == 0 && onSegment(p0, p1, q0)) ||
+		(o1 == 0 && onSegment(p0, p1, q1)) ||
+		(o2 == 0 && onSegment(q0, q1, p0)) ||
+		(o3 == 0 && onSegment(q0, q1, p1))
+}
+
+func min32(x, y float32) float32 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func max32(x, y float32) float32 {
+	if x > y {
+		return x
+	}
+	return y
+}
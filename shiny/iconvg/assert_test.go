@@ -0,0 +1,82 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// encodePolygon returns an IconVG graphic consisting of one path, a polygon
+// with the given vertices, as (x, y) pairs.
+func encodePolygon(xy ...float32) []byte {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.StartPath(0, xy[0], xy[1])
+	for i := 2; i < len(xy); i += 2 {
+		e.AbsLineTo(xy[i], xy[i+1])
+	}
+	e.ClosePathEndPath()
+	b, err := e.Bytes()
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestAssertDestination(t *testing.T) {
+	var (
+		square  = encodePolygon(0, 0, 10, 0, 10, 10, 0, 10, 0, 0)
+		open    = encodePolygon(0, 0, 10, 0, 10, 10, 0, 10)
+		bowtie  = encodePolygon(0, 0, 10, 10, 10, 0, 0, 10, 0, 0)
+		cShape  = encodePolygon(0, 0, 10, 0, 10, 2, 2, 2, 2, 8, 10, 8, 10, 10, 0, 10, 0, 0)
+		checkOf = map[string]PathCheck{
+			"closed":   CheckClosed,
+			"simple":   CheckSimple,
+			"monotone": CheckMonotoneX,
+		}
+	)
+
+	testCases := []struct {
+		desc    string
+		src     []byte
+		check   string
+		wantErr error
+	}{
+		{"square", square, "closed", nil},
+		{"square", square, "simple", nil},
+		{"square", square, "monotone", nil},
+		{"open", open, "closed", errPathNotClosed},
+		{"open", open, "simple", nil},
+		{"bowtie", bowtie, "simple", errPathNotSimple},
+		{"bowtie", bowtie, "monotone", nil},
+		{"C", cShape, "simple", nil},
+		{"C", cShape, "monotone", errPathNotMonotone},
+	}
+	for _, tc := range testCases {
+		err := Decode(AssertDestination(nil, checkOf[tc.check]), tc.src, nil)
+		if tc.wantErr == nil {
+			if err != nil {
+				t.Errorf("%s, %s: got %v, want nil", tc.desc, tc.check, err)
+			}
+		} else if err == nil || !strings.HasPrefix(err.Error(), tc.wantErr.Error()) {
+			t.Errorf("%s, %s: got %v, want %v", tc.desc, tc.check, err, tc.wantErr)
+		}
+	}
+}
+
+func TestAssertDestinationInner(t *testing.T) {
+	c := &CanvasScript{}
+	if err := Decode(AssertDestination(c, CheckClosed, CheckSimple), encodePolygon(0, 0, 10, 0, 0, 10, 0, 0), nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !bytes.Contains(c.Bytes(), []byte("ctx.lineTo(10, 0);")) {
+		t.Errorf("inner Destination was not called:\n%s", c.Bytes())
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"math"
+)
+
+var errInvalidAtlasSize = errors.New("iconvg: invalid atlas size")
+
+// BuildAtlas rasterizes an IconVG graphic at each of the given sizes, and
+// packs the results left-to-right, top-aligned, into a single sprite sheet.
+// It returns that image and, for each size, the rectangle within the image
+// that holds that size's rendering.
+//
+// Each size is a height in pixels, which must be positive. The corresponding
+// width preserves the ViewBox's aspect ratio, rounded to the nearest pixel.
+// The ViewBox is as Decode sees it: opts.ViewBoxOverride if that is non-nil.
+// Repeated sizes are only rendered once.
+func BuildAtlas(src []byte, sizes []int, opts *DecodeOptions) (*image.RGBA, map[int]image.Rectangle, error) {
+	m, err := decodeMetadata(src, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	dx, dy := m.ViewBox.AspectRatio()
+
+	rects := map[int]image.Rectangle{}
+	unique := make([]int, 0, len(sizes))
+	x, height := 0, 0
+	for _, size := range sizes {
+		if size <= 0 {
+			return nil, nil, errInvalidAtlasSize
+		}
+		if _, ok := rects[size]; ok {
+			continue
+		}
+		w := 1
+		if dy > 0 {
+			if fw := math.Floor(float64(size)*float64(dx)/float64(dy) + 0.5); fw > 1 {
+				w = int(fw)
+			}
+		}
+		rects[size] = image.Rect(x, 0, x+w, size)
+		unique = append(unique, size)
+		x += w
+		if height < size {
+			height = size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, x, height))
+	var z Rasterizer
+	for _, size := range unique {
+		z.SetDstImage(dst, rects[size], draw.Src)
+		if err := Decode(&z, src, opts); err != nil {
+			return nil, nil, err
+		}
+	}
+	return dst, rects, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestBuildAtlas(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	atlas, rects, err := BuildAtlas(ivgData, []int{16, 48, 24, 16}, nil)
+	if err != nil {
+		t.Fatalf("BuildAtlas: %v", err)
+	}
+	if got, want := atlas.Bounds(), image.Rect(0, 0, 16+48+24, 48); got != want {
+		t.Errorf("atlas bounds: got %v, want %v", got, want)
+	}
+	wantRects := map[int]image.Rectangle{
+		16: image.Rect(0, 0, 16, 16),
+		48: image.Rect(16, 0, 64, 48),
+		24: image.Rect(64, 0, 88, 24),
+	}
+	if len(rects) != len(wantRects) {
+		t.Errorf("rects: got %v, want %v", rects, wantRects)
+	}
+	for size, want := range wantRects {
+		if got := rects[size]; got != want {
+			t.Errorf("size %d: got %v, want %v", size, got, want)
+			continue
+		}
+		single, err := rasterize(ivgData, size, size, nil)
+		if err != nil {
+			t.Fatalf("rasterize: %v", err)
+		}
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				if got, want := atlas.RGBAAt(want.Min.X+x, y), single.RGBAAt(x, y); !closeRGBA(got, want, 1) {
+					t.Fatalf("size %d, at (%d, %d): got %v, want %v", size, x, y, got, want)
+				}
+			}
+		}
+	}
+
+	// A ViewBoxOverride twice as wide as it is high gives cells twice as
+	// wide as they are high.
+	override := &DecodeOptions{
+		ViewBoxOverride: &Rectangle{Min: f32.Vec2{-64, -32}, Max: f32.Vec2{+64, +32}},
+	}
+	atlas, rects, err = BuildAtlas(ivgData, []int{16, 24}, override)
+	if err != nil {
+		t.Fatalf("BuildAtlas with override: %v", err)
+	}
+	if got, want := atlas.Bounds(), image.Rect(0, 0, 32+48, 24); got != want {
+		t.Errorf("atlas bounds with override: got %v, want %v", got, want)
+	}
+	if got, want := rects[24], image.Rect(32, 0, 80, 24); got != want {
+		t.Errorf("size 24 with override: got %v, want %v", got, want)
+	}
+
+	if _, _, err := BuildAtlas(ivgData, []int{16, 0}, nil); err != errInvalidAtlasSize {
+		t.Errorf("zero size: got %v, want %v", err, errInvalidAtlasSize)
+	}
+}
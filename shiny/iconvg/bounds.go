@@ -0,0 +1,80 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"errors"
+	"math"
+)
+
+var errNoVisiblePaths = errors.New("iconvg: no visible paths")
+
+// BoundsCollector is a Destination that computes the bounding box of an IconVG
+// graphic's paths, in graphic coordinate space. Curves are flattened to line
+// segments, to within 1/64th of a unit, before their bounds are computed.
+// Paths at every level of detail are included, but fully transparent paths
+// are not.
+type BoundsCollector struct {
+	pointCollector
+}
+
+// Bounds returns the bounding box of the paths decoded since the last Reset.
+// It returns false if there were no such paths.
+func (b *BoundsCollector) Bounds() (r Rectangle, ok bool) {
+	if len(b.points) == 0 {
+		return Rectangle{}, false
+	}
+	r = Rectangle{Min: b.points[0], Max: b.points[0]}
+	for _, p := range b.points[1:] {
+		r.Min[0] = float32(math.Min(float64(r.Min[0]), float64(p[0])))
+		r.Min[1] = float32(math.Min(float64(r.Min[1]), float64(p[1])))
+		r.Max[0] = float32(math.Max(float64(r.Max[0]), float64(p[0])))
+		r.Max[1] = float32(math.Max(float64(r.Max[1]), float64(p[1])))
+	}
+	return r, true
+}
+
+// TrimViewBox re-encodes an IconVG graphic so that its ViewBox is the tight
+// bounding box of its paths, as computed by a BoundsCollector and then rounded
+// outwards to a multiple of 1/64th of a unit. This removes any padding around
+// the graphic's content.
+//
+// The paths' coordinates are unchanged: as a ViewBox's Min need not be the
+// origin, only the ViewBox itself needs to move. Rendering the re-encoded
+// graphic is therefore equivalent to rendering the corresponding part of the
+// original graphic.
+func TrimViewBox(src []byte) ([]byte, error) {
+	var b BoundsCollector
+	if err := Decode(&b, src, nil); err != nil {
+		return nil, err
+	}
+	r, ok := b.Bounds()
+	if !ok {
+		return nil, errNoVisiblePaths
+	}
+	r.Min[0] = float32(math.Floor(float64(r.Min[0])*64) / 64)
+	r.Min[1] = float32(math.Floor(float64(r.Min[1])*64) / 64)
+	r.Max[0] = float32(math.Ceil(float64(r.Max[0])*64) / 64)
+	r.Max[1] = float32(math.Ceil(float64(r.Max[1])*64) / 64)
+
+	e := &viewBoxEncoder{viewBox: r}
+	if err := Decode(e, src, nil); err != nil {
+		return nil, err
+	}
+	return e.Bytes()
+}
+
+// viewBoxEncoder is an Encoder that replaces the decoded Metadata's ViewBox.
+// It also uses high resolution coordinates, so that re-encoding is lossless.
+type viewBoxEncoder struct {
+	Encoder
+	viewBox Rectangle
+}
+
+func (e *viewBoxEncoder) Reset(m Metadata) {
+	m.ViewBox = e.viewBox
+	e.Encoder.Reset(m)
+	e.Encoder.HighResolutionCoordinates = true
+}
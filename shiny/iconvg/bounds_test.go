@@ -0,0 +1,55 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/draw"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrimViewBox(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	trimmed, err := TrimViewBox(ivgData)
+	if err != nil {
+		t.Fatalf("TrimViewBox: %v", err)
+	}
+	m, err := DecodeMetadata(trimmed)
+	if err != nil {
+		t.Fatalf("DecodeMetadata: %v", err)
+	}
+	// The action-info graphic is a circle of radius 20, centered on the
+	// origin, in a 48 by 48 ViewBox.
+	if got, want := m.ViewBox, (Rectangle{Min: [2]float32{-20, -20}, Max: [2]float32{+20, +20}}); got != want {
+		t.Fatalf("ViewBox: got %v, want %v", got, want)
+	}
+
+	// Rendering the trimmed graphic at the same scale, offset by the removed
+	// padding, should match rendering the original graphic.
+	want, err := rasterize(ivgData, 48, 48, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	got := image.NewRGBA(image.Rect(0, 0, 48, 48))
+	var z Rasterizer
+	z.SetDstImage(got, image.Rect(4, 4, 44, 44), draw.Src)
+	if err := Decode(&z, trimmed, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if err := checkApproxEqual(got, want); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTrimViewBoxEmpty(t *testing.T) {
+	if _, err := TrimViewBox([]byte("\x89IVG\x00")); err != errNoVisiblePaths {
+		t.Fatalf("got %v, want %v", err, errNoVisiblePaths)
+	}
+}
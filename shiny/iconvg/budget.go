@@ -0,0 +1,176 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"errors"
+	"math"
+
+	"golang.org/x/image/math/f32"
+)
+
+var errBudgetExceeded = errors.New("iconvg: cannot fit within byte budget")
+
+// BudgetReport describes how EncodeWithinBudget fit a graphic to a budget.
+type BudgetReport struct {
+	// Size is the size, in bytes, of the encoded form.
+	Size int
+
+	// Quantum is the multiple, in graphic coordinate space, that coordinates
+	// were rounded to. It is zero if the graphic was not re-encoded.
+	Quantum float32
+
+	// MaxError is an upper bound, in graphic coordinate space, on how far any
+	// point on any path's outline moved. It is zero if the graphic was not
+	// re-encoded.
+	MaxError float32
+}
+
+// EncodeWithinBudget returns an IconVG graphic's encoded form, re-encoded if
+// necessary to be at most maxBytes long.
+//
+// If src is already short enough, it is returned unchanged. Otherwise, the
+// graphic is re-encoded with progressively coarser coordinates, rounded to a
+// multiple of 1/64th, then 1/32nd, 1/16th, and so on, of a unit, and with
+// nearly collinear points (within that multiple) of straight lines removed.
+// Arcs are converted to cubic Bézier curves. It stops once the result is
+// short enough, and returns an error if it still is not once the multiple
+// exceeds 1/64th of the ViewBox's larger dimension.
+//
+// src is decoded with the given options, which may be nil. A re-encoded
+// graphic has the Metadata as Decode sees it, such as with opts'
+// ViewBoxOverride, but one that is already short enough is returned as is,
+// apart from having its opts.SkipBytes prefix removed.
+func EncodeWithinBudget(src []byte, maxBytes int, opts *DecodeOptions) ([]byte, BudgetReport, error) {
+	m, err := decodeMetadata(src, opts)
+	if err != nil {
+		return nil, BudgetReport{}, err
+	}
+	encoded := src
+	if opts != nil {
+		encoded = src[opts.SkipBytes:]
+	}
+	if len(encoded) <= maxBytes {
+		if err := Decode(nil, src, opts); err != nil {
+			return nil, BudgetReport{}, err
+		}
+		return encoded, BudgetReport{Size: len(encoded)}, nil
+	}
+
+	dx, dy := m.ViewBox.AspectRatio()
+	limit := max32(dx, dy) / 64
+	for q := float32(1.0 / 64); q <= limit; q *= 2 {
+		b := &budgetEncoder{maxBytes: maxBytes}
+		b.pathRewriter = pathRewriter{
+			inner:   &b.e,
+			rewrite: simplifyRewriter(q),
+		}
+		if err := Decode(b, src, opts); err != nil {
+			return nil, BudgetReport{}, err
+		}
+		dst, err := b.e.Bytes()
+		if err == errExceedsMaxBytes {
+			continue
+		} else if err != nil {
+			return nil, BudgetReport{}, err
+		}
+		return dst, BudgetReport{
+			Size:    len(dst),
+			Quantum: q,
+			// Rounding moves a point by up to half a diagonal, and removing a
+			// point moves the outline by up to q.
+			MaxError: q*math.Sqrt2/2 + q,
+		}, nil
+	}
+	return nil, BudgetReport{}, errBudgetExceeded
+}
+
+// budgetEncoder is a Destination that re-encodes a graphic, with its paths
+// rewritten, subject to a maximum size.
+type budgetEncoder struct {
+	pathRewriter
+	e        Encoder
+	maxBytes int
+}
+
+func (b *budgetEncoder) Reset(m Metadata) {
+	b.pathRewriter.Reset(m)
+	b.e.MaxBytes = b.maxBytes
+}
+
+// simplifyRewriter returns a pathRewriter rewrite function that rounds
+// coordinates to a multiple of q, and then removes zero length segments and
+// the points between consecutive lines that are within q of the line joining
+// their neighbors.
+func simplifyRewriter(q float32) func(segs []Segment) []Segment {
+	round := func(p f32.Vec2) f32.Vec2 {
+		return f32.Vec2{
+			float32(math.Floor(float64(p[0]/q)+0.5)) * q,
+			float32(math.Floor(float64(p[1]/q)+0.5)) * q,
+		}
+	}
+
+	var ret []Segment
+	// dropped are the points removed from the current run of lines.
+	var dropped []f32.Vec2
+	return func(segs []Segment) []Segment {
+		ret, dropped = ret[:0], dropped[:0]
+		var pen f32.Vec2
+		for _, s := range segs {
+			n := 1
+			switch s.Op {
+			case SegmentOpQuadTo:
+				n = 2
+			case SegmentOpCubeTo:
+				n = 3
+			}
+			for i := 0; i < n; i++ {
+				s.Args[i] = round(s.Args[i])
+			}
+			if s.Op != SegmentOpMoveTo {
+				degenerate := true
+				for _, p := range s.Args[:n] {
+					degenerate = degenerate && p == pen
+				}
+				if degenerate {
+					continue
+				}
+			}
+
+			if k := len(ret); s.Op != SegmentOpLineTo || k < 2 || ret[k-1].Op != SegmentOpLineTo {
+				dropped = dropped[:0]
+			} else {
+				// Drop the previous line's end point if it, and every point
+				// already dropped from this run of lines, is close enough to
+				// the line from that line's start to this line's end.
+				a, b := ret[k-2].end(), s.Args[0]
+				dropped = append(dropped, ret[k-1].Args[0])
+				ok := true
+				for _, p := range dropped {
+					ok = ok && distanceToLine(p, a, b) <= q
+				}
+				if ok {
+					ret = ret[:k-1]
+				} else {
+					dropped = dropped[:0]
+				}
+			}
+			ret = append(ret, s)
+			pen = s.end()
+		}
+		return ret
+	}
+}
+
+// distanceToLine returns the distance from p to the line segment a-b.
+func distanceToLine(p, a, b f32.Vec2) float32 {
+	dx, dy := float64(b[0]-a[0]), float64(b[1]-a[1])
+	px, py := float64(p[0]-a[0]), float64(p[1]-a[1])
+	t := 0.0
+	if d := dx*dx + dy*dy; d > 0 {
+		t = math.Max(0, math.Min(1, (px*dx+py*dy)/d))
+	}
+	return float32(math.Hypot(px-t*dx, py-t*dy))
+}
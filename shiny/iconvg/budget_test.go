@@ -0,0 +1,98 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestEncoderMaxBytes(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.MaxBytes = 8
+	e.StartPath(0, 0, 0)
+	e.AbsLineTo(10, 0)
+	e.AbsLineTo(0, 10)
+	e.ClosePathEndPath()
+	if _, err := e.Bytes(); err != errExceedsMaxBytes {
+		t.Fatalf("got %v, want %v", err, errExceedsMaxBytes)
+	}
+	e.MaxBytes = 0
+	if _, err := e.Bytes(); err != nil {
+		t.Fatalf("no limit: %v", err)
+	}
+}
+
+func TestEncodeWithinBudget(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/video-005.primitive.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	got, report, err := EncodeWithinBudget(ivgData, len(ivgData), nil)
+	if err != nil {
+		t.Fatalf("unchanged: %v", err)
+	}
+	if !bytes.Equal(got, ivgData) || report != (BudgetReport{Size: len(ivgData)}) {
+		t.Errorf("unchanged: got %d bytes, %+v", len(got), report)
+	}
+
+	budget := len(ivgData) * 3 / 4
+	got, report, err = EncodeWithinBudget(ivgData, budget, nil)
+	if err != nil {
+		t.Fatalf("EncodeWithinBudget: %v", err)
+	}
+	if len(got) > budget || report.Size != len(got) {
+		t.Errorf("got %d bytes, report size %d, want at most %d", len(got), report.Size, budget)
+	}
+	if report.Quantum <= 1.0/64 || report.MaxError <= report.Quantum {
+		t.Errorf("report: got %+v", report)
+	}
+	if err := Decode(nil, got, nil); err != nil {
+		t.Errorf("Decode: %v", err)
+	}
+	m0, _ := DecodeMetadata(ivgData)
+	m1, _ := DecodeMetadata(got)
+	if m0 != m1 {
+		t.Errorf("metadata: got %v, want %v", m1, m0)
+	}
+
+	if _, _, err := EncodeWithinBudget(ivgData, 16, nil); err != errBudgetExceeded {
+		t.Errorf("tiny budget: got %v, want %v", err, errBudgetExceeded)
+	}
+}
+
+func TestSimplifyRewriter(t *testing.T) {
+	line := func(x, y float32) Segment {
+		return Segment{Op: SegmentOpLineTo, Args: [3]f32.Vec2{{x, y}}}
+	}
+	in := []Segment{
+		{Op: SegmentOpMoveTo, Args: [3]f32.Vec2{{0, 0}}},
+		line(10, 0.1),
+		line(20, 0),
+		line(20, 0.1),
+		line(20, 10),
+		line(0, 10),
+	}
+	want := []Segment{
+		{Op: SegmentOpMoveTo, Args: [3]f32.Vec2{{0, 0}}},
+		line(20, 0),
+		line(20, 10),
+		line(0, 10),
+	}
+	got := simplifyRewriter(0.25)(in)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
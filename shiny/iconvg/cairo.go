@@ -0,0 +1,120 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"golang.org/x/image/math/f32"
+)
+
+// FillRule is a rule for which points are inside a path, as passed to a
+// Context's SetFillRule method. Its values match cairo's cairo_fill_rule_t.
+type FillRule int
+
+const (
+	// FillRuleWinding is the non-zero winding rule, which IconVG uses.
+	FillRuleWinding FillRule = 0
+	// FillRuleEvenOdd is the even-odd rule.
+	FillRuleEvenOdd FillRule = 1
+)
+
+// Context is a minimal cairo-style immediate mode drawing context, such as a
+// cgo binding of cairo_t. A CairoDestination draws to it with only these
+// primitive calls.
+//
+// Coordinates are absolute, in graphic coordinate space. Color components are
+// in the range [0, 1], and are not alpha-premultiplied.
+type Context interface {
+	MoveTo(x, y float64)
+	LineTo(x, y float64)
+	CurveTo(x1, y1, x2, y2, x3, y3 float64)
+	ClosePath()
+	SetSourceRGBA(r, g, b, a float64)
+	SetFillRule(rule FillRule)
+	Fill()
+}
+
+// CairoDestination returns a Destination that draws to a cairo-style
+// Context. Relative drawing ops are made absolute, quadratic Bézier curves
+// and arcs are converted to cubic ones, and each path is filled, after
+// setting the context's source color and, always, the winding fill rule.
+//
+// Paths are selected by their level of detail as if the graphic was rendered
+// at a height, in pixels, equal to the height of the ViewBox. Paths filled
+// with gradients, and fully transparent paths, are not drawn.
+//
+// The Destination is initially Reset with m, so that it can be driven
+// directly, but Decode Resets it again with the graphic's Metadata.
+func CairoDestination(ctx Context, m Metadata) Destination {
+	d := &cairoDestination{ctx: ctx}
+	d.Reset(m)
+	return d
+}
+
+type cairoDestination struct {
+	segmenter
+	ctx     Context
+	last    f32.Vec2
+	visible bool
+	subpath bool
+}
+
+func (d *cairoDestination) Reset(m Metadata) {
+	d.segmenter.reset(m, d)
+	d.visible = false
+}
+
+func (d *cairoDestination) beginPath() {
+	_, h := d.metadata.ViewBox.AspectRatio()
+	d.visible = d.lod0 <= h && h < d.lod1 &&
+		d.fill.A != 0 && validAlphaPremulColor(d.fill)
+	if !d.visible {
+		return
+	}
+	a := float64(d.fill.A)
+	d.ctx.SetSourceRGBA(float64(d.fill.R)/a, float64(d.fill.G)/a, float64(d.fill.B)/a, a/0xff)
+	d.subpath = false
+}
+
+func (d *cairoDestination) addSegment(s Segment) {
+	if !d.visible {
+		return
+	}
+	switch s.Op {
+	case SegmentOpMoveTo:
+		if d.subpath {
+			d.ctx.ClosePath()
+		}
+		d.subpath = true
+		d.ctx.MoveTo(float64(s.Args[0][0]), float64(s.Args[0][1]))
+	case SegmentOpLineTo:
+		d.ctx.LineTo(float64(s.Args[0][0]), float64(s.Args[0][1]))
+	case SegmentOpQuadTo:
+		c1, c2 := quadToCubic(d.last, s.Args[0], s.Args[1])
+		d.curveTo(c1, c2, s.Args[1])
+	case SegmentOpCubeTo:
+		d.curveTo(s.Args[0], s.Args[1], s.Args[2])
+	}
+	d.last = s.end()
+}
+
+func (d *cairoDestination) curveTo(p1, p2, p3 f32.Vec2) {
+	d.ctx.CurveTo(
+		float64(p1[0]), float64(p1[1]),
+		float64(p2[0]), float64(p2[1]),
+		float64(p3[0]), float64(p3[1]),
+	)
+}
+
+func (d *cairoDestination) endPath() {
+	if !d.visible {
+		return
+	}
+	if d.subpath {
+		d.ctx.ClosePath()
+	}
+	d.ctx.SetFillRule(FillRuleWinding)
+	d.ctx.Fill()
+	d.visible = false
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// contextRecorder is a Context that records its calls.
+type contextRecorder struct {
+	calls []string
+}
+
+func (r *contextRecorder) MoveTo(x, y float64) {
+	r.calls = append(r.calls, fmt.Sprintf("MoveTo(%g, %g)", x, y))
+}
+
+func (r *contextRecorder) LineTo(x, y float64) {
+	r.calls = append(r.calls, fmt.Sprintf("LineTo(%g, %g)", x, y))
+}
+
+func (r *contextRecorder) CurveTo(x1, y1, x2, y2, x3, y3 float64) {
+	r.calls = append(r.calls, fmt.Sprintf("CurveTo(%g, %g, %g, %g, %g, %g)", x1, y1, x2, y2, x3, y3))
+}
+
+func (r *contextRecorder) ClosePath() {
+	r.calls = append(r.calls, "ClosePath()")
+}
+
+func (r *contextRecorder) SetSourceRGBA(red, green, blue, alpha float64) {
+	r.calls = append(r.calls, fmt.Sprintf("SetSourceRGBA(%g, %g, %g, %g)", red, green, blue, alpha))
+}
+
+func (r *contextRecorder) SetFillRule(rule FillRule) {
+	r.calls = append(r.calls, fmt.Sprintf("SetFillRule(%d)", rule))
+}
+
+func (r *contextRecorder) Fill() {
+	r.calls = append(r.calls, "Fill()")
+}
+
+func TestCairoDestination(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x66, 0x66}))
+	e.StartPath(0, -30, -30)
+	e.RelLineTo(+60, 0)
+	e.AbsQuadTo(+30, +30, -30, +30)
+	e.ClosePathAbsMoveTo(-3, -3)
+	e.AbsCubeTo(0, -6, +3, -3, 0, 0)
+	e.ClosePathEndPath()
+	// A fully transparent path is not drawn.
+	e.SetCReg(0, false, RGBAColor(color.RGBA{}))
+	e.StartPath(0, -1, -1)
+	e.AbsLineTo(+1, +1)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var r contextRecorder
+	if err := Decode(CairoDestination(&r, Metadata{}), ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := strings.Join([]string{
+		"SetSourceRGBA(0, 0, 1, 0.4)",
+		"MoveTo(-30, -30)",
+		"LineTo(30, -30)",
+		"CurveTo(30, 10, 10, 30, -30, 30)",
+		"ClosePath()",
+		"MoveTo(-3, -3)",
+		"CurveTo(0, -6, 3, -3, 0, 0)",
+		"ClosePath()",
+		"SetFillRule(0)",
+		"Fill()",
+	}, "\n")
+	if got := strings.Join(r.calls, "\n"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
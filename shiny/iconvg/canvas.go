@@ -0,0 +1,156 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/math/f32"
+)
+
+// CanvasScript is a Destination that produces JavaScript that draws an IconVG
+// graphic on an HTML5 canvas's 2D rendering context.
+//
+// The script scales the graphic's ViewBox to the canvas' width and height,
+// and selects paths by their level of detail according to the canvas' height,
+// at the time that the script runs. Arcs are approximated by cubic Bézier
+// curves. Paths filled with gradients are not drawn.
+type CanvasScript struct {
+	// Context is the JavaScript expression for the CanvasRenderingContext2D
+	// to draw on, such as "ctx" or `canvas.getContext("2d")`. If empty, it is
+	// "ctx".
+	//
+	// It is inserted into the script verbatim, without validation or
+	// escaping, and is evaluated each time the context is used. It must be a
+	// trusted expression, written by the programmer, and never derived from
+	// untrusted input, such as a URL or a graphic's contents, as that could
+	// inject arbitrary JavaScript into the script.
+	Context string
+
+	segmenter
+
+	buf     []byte
+	visible bool
+	lod     bool
+	subpath bool
+}
+
+// Bytes returns the JavaScript.
+func (c *CanvasScript) Bytes() []byte {
+	return c.buf
+}
+
+// Reset resets the CanvasScript for the given Metadata.
+func (c *CanvasScript) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	c.buf = c.buf[:0]
+	c.visible = false
+	c.lod = false
+
+	ctx := c.ctx()
+	dx, dy := m.ViewBox.AspectRatio()
+	c.printf("%s.setTransform(1, 0, 0, 1, 0, 0);\n", ctx)
+	c.printf("%s.scale(%s.canvas.width / %s, %s.canvas.height / %s);\n",
+		ctx, ctx, jsNumber(dx), ctx, jsNumber(dy))
+	c.printf("%s.translate(%s, %s);\n", ctx, jsNumber(-m.ViewBox.Min[0]), jsNumber(-m.ViewBox.Min[1]))
+}
+
+func (c *CanvasScript) ctx() string {
+	if c.Context == "" {
+		return "ctx"
+	}
+	return c.Context
+}
+
+func (c *CanvasScript) printf(format string, args ...interface{}) {
+	if c.lod && format != "}\n" {
+		// Indent the statements inside an LOD if block.
+		c.buf = append(c.buf, '\t')
+	}
+	c.buf = append(c.buf, fmt.Sprintf(format, args...)...)
+}
+
+// jsNumber formats f as a JavaScript numeric literal.
+func jsNumber(f float32) string {
+	switch {
+	case math.IsNaN(float64(f)):
+		return "NaN"
+	case math.IsInf(float64(f), +1):
+		return "Infinity"
+	case math.IsInf(float64(f), -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
+
+func (c *CanvasScript) beginPath() {
+	// Gradients, and invalid colors, are skipped.
+	c.visible = c.fill.A != 0 && validAlphaPremulColor(c.fill)
+	if !c.visible {
+		return
+	}
+	ctx := c.ctx()
+	c.lod = false
+	if c.lod0 != 0 || c.lod1 != positiveInfinity {
+		c.printf("if (%s.canvas.height >= %s && %s.canvas.height < %s) {\n",
+			ctx, jsNumber(c.lod0), ctx, jsNumber(c.lod1))
+		c.lod = true
+	}
+	c.printf("%s.beginPath();\n", ctx)
+	c.subpath = false
+}
+
+func (c *CanvasScript) addSegment(s Segment) {
+	if !c.visible {
+		return
+	}
+	ctx := c.ctx()
+	switch s.Op {
+	case SegmentOpMoveTo:
+		if c.subpath {
+			c.printf("%s.closePath();\n", ctx)
+		}
+		c.subpath = true
+		c.printf("%s.moveTo(%s);\n", ctx, jsPoints(s.Args[:1]))
+	case SegmentOpLineTo:
+		c.printf("%s.lineTo(%s);\n", ctx, jsPoints(s.Args[:1]))
+	case SegmentOpQuadTo:
+		c.printf("%s.quadraticCurveTo(%s);\n", ctx, jsPoints(s.Args[:2]))
+	case SegmentOpCubeTo:
+		c.printf("%s.bezierCurveTo(%s);\n", ctx, jsPoints(s.Args[:3]))
+	}
+}
+
+func (c *CanvasScript) endPath() {
+	if !c.visible {
+		return
+	}
+	ctx := c.ctx()
+	a := uint32(c.fill.A)
+	c.printf("%s.closePath();\n", ctx)
+	// Canvas colors are not alpha-premultiplied.
+	c.printf("%s.fillStyle = \"rgba(%d, %d, %d, %.4g)\";\n", ctx,
+		uint32(c.fill.R)*0xff/a, uint32(c.fill.G)*0xff/a, uint32(c.fill.B)*0xff/a, float64(a)/0xff)
+	c.printf("%s.fill();\n", ctx)
+	if c.lod {
+		c.printf("}\n")
+		c.lod = false
+	}
+}
+
+func jsPoints(points []f32.Vec2) string {
+	var b []byte
+	for i, p := range points {
+		if i != 0 {
+			b = append(b, ", "...)
+		}
+		b = append(b, jsNumber(p[0])...)
+		b = append(b, ", "...)
+		b = append(b, jsNumber(p[1])...)
+	}
+	return string(b)
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanvasScript(t *testing.T) {
+	testCases := []struct {
+		filename string
+		context  string
+		want     []string
+		wantN    map[string]int
+	}{{
+		filename: "action-info.lores",
+		want: []string{
+			"ctx.scale(ctx.canvas.width / 48, ctx.canvas.height / 48);\n",
+			"ctx.translate(24, 24);\n",
+			"ctx.moveTo(0, -20);\n",
+			"ctx.bezierCurveTo(-11.046875, -20, -20, -11.046875, -20, 0);\n",
+			"ctx.fillStyle = \"rgba(0, 0, 0, 1)\";\n",
+		},
+		wantN: map[string]int{
+			"ctx.beginPath();": 1,
+			"ctx.moveTo(":      3,
+			"ctx.closePath();": 3,
+			"ctx.fill();":      1,
+		},
+	}, {
+		filename: "lod-polygon",
+		context:  "canvas.getContext(\"2d\")",
+		want: []string{
+			"if (canvas.getContext(\"2d\").canvas.height >= 0 && canvas.getContext(\"2d\").canvas.height < 80) {\n" +
+				"\tcanvas.getContext(\"2d\").beginPath();\n",
+			"if (canvas.getContext(\"2d\").canvas.height >= 80 && canvas.getContext(\"2d\").canvas.height < Infinity) {\n",
+		},
+		wantN: map[string]int{
+			".beginPath();": 4,
+			".fill();":      4,
+			"\n}\n":         2,
+		},
+	}}
+
+	for _, tc := range testCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/" + tc.filename + ".ivg"))
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		c := &CanvasScript{Context: tc.context}
+		if err := Decode(c, ivgData, nil); err != nil {
+			t.Errorf("%s: Decode: %v", tc.filename, err)
+			continue
+		}
+		got := c.Bytes()
+		for _, want := range tc.want {
+			if !bytes.Contains(got, []byte(want)) {
+				t.Errorf("%s: output does not contain %q:\n%s", tc.filename, want, got)
+			}
+		}
+		for s, wantN := range tc.wantN {
+			if n := bytes.Count(got, []byte(s)); n != wantN {
+				t.Errorf("%s: got %d instances of %q, want %d", tc.filename, n, s, wantN)
+			}
+		}
+	}
+}
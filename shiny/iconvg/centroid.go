@@ -0,0 +1,222 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"math"
+
+	"golang.org/x/image/math/f32"
+)
+
+// DefaultOpticalCenterBias is the bias that OpticalCenter uses, as a
+// proportion of the graphic's height.
+const DefaultOpticalCenterBias = 0.05
+
+// CentroidCollector is a Destination that records the area-weighted centroid,
+// or center of mass, of each of an IconVG graphic's paths, in graphic
+// coordinate space. It is the visual center of the path's filled shape, for
+// placing labels or badges, and unlike the center of the path's bounding box,
+// it is not skewed by thin protrusions.
+//
+// Curves are flattened to line segments, to within 1/64th of a unit, before
+// the polygon centroid formula is applied to each sub-path. A sub-path is a
+// hole, whose area is subtracted, if it is nested inside an odd number of the
+// same path's other sub-paths, regardless of the direction it winds in. A path
+// whose area is zero, such as a degenerate line, has the mean of its flattened
+// points as its centroid.
+//
+// Fully transparent paths are skipped, as are paths outside of the level of
+// detail range.
+type CentroidCollector struct {
+	// Height is the height, in pixels, that the graphic is intended to be
+	// rendered at, which selects paths by their level of detail.
+	//
+	// If zero, it is the height of the ViewBox.
+	Height float32
+
+	segmenter
+
+	centroids []f32.Vec2
+	// areas are the areas of each visible path, parallel to centroids.
+	areas   []float64
+	bounds  Rectangle
+	segs    []Segment
+	visible bool
+}
+
+// Centroids returns the centroid of each visible path, in the order that they
+// were decoded.
+func (c *CentroidCollector) Centroids() []f32.Vec2 {
+	return c.centroids
+}
+
+// Reset resets the CentroidCollector for the given Metadata.
+func (c *CentroidCollector) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	c.centroids = c.centroids[:0]
+	c.areas = c.areas[:0]
+	c.segs = c.segs[:0]
+	c.visible = false
+}
+
+func (c *CentroidCollector) height() float32 {
+	if c.Height > 0 {
+		return c.Height
+	}
+	_, dy := c.metadata.ViewBox.AspectRatio()
+	return dy
+}
+
+func (c *CentroidCollector) beginPath() {
+	h := c.height()
+	c.visible = c.lod0 <= h && h < c.lod1 && (c.fill.A != 0 || c.fill.B&0x80 != 0)
+	c.segs = c.segs[:0]
+}
+
+func (c *CentroidCollector) addSegment(s Segment) {
+	if c.visible {
+		c.segs = append(c.segs, s)
+	}
+}
+
+func (c *CentroidCollector) endPath() {
+	if !c.visible {
+		return
+	}
+	var rings [][]f32.Vec2
+	for _, sub := range splitSubpaths(c.segs) {
+		ring := flatten(nil, sub, flattenTolerance)
+		if len(c.centroids) == 0 && len(rings) == 0 {
+			c.bounds = Rectangle{Min: ring[0], Max: ring[0]}
+		}
+		for _, p := range ring {
+			c.bounds.Min[0] = min32(c.bounds.Min[0], p[0])
+			c.bounds.Min[1] = min32(c.bounds.Min[1], p[1])
+			c.bounds.Max[0] = max32(c.bounds.Max[0], p[0])
+			c.bounds.Max[1] = max32(c.bounds.Max[1], p[1])
+		}
+		rings = append(rings, ring)
+	}
+	ctr, area := centroidArea(rings)
+	c.centroids = append(c.centroids, ctr)
+	c.areas = append(c.areas, area)
+}
+
+// OpticalCenter returns the optical center of an IconVG graphic, in graphic
+// coordinate space: the point that looks centered to a human, for aligning
+// the graphic with other content. It is OpticalCenterWithBias with the
+// DefaultOpticalCenterBias.
+func OpticalCenter(src []byte, opts *DecodeOptions) (f32.Vec2, error) {
+	return OpticalCenterWithBias(src, DefaultOpticalCenterBias, opts)
+}
+
+// OpticalCenterWithBias returns the optical center of an IconVG graphic, in
+// graphic coordinate space. This heuristic starts with the area-weighted
+// centroid of all of the paths that a CentroidCollector records, which pulls
+// the center towards the graphic's visual mass, such as the wide end of a
+// triangle. The eye perceives the geometric center of a shape as slightly
+// low, so the point is then moved up by bias times the height of the visible
+// paths' bounding box.
+//
+// Overlapping paths' areas are counted once for each path. If the visible
+// paths all have zero area, their centroids are averaged. It returns an error
+// if there are no visible paths.
+func OpticalCenterWithBias(src []byte, bias float32, opts *DecodeOptions) (f32.Vec2, error) {
+	var c CentroidCollector
+	if err := Decode(&c, src, opts); err != nil {
+		return f32.Vec2{}, err
+	}
+	if len(c.centroids) == 0 {
+		return f32.Vec2{}, errNoVisiblePaths
+	}
+	var x, y, total float64
+	for i, ctr := range c.centroids {
+		a := math.Abs(c.areas[i])
+		x += a * float64(ctr[0])
+		y += a * float64(ctr[1])
+		total += a
+	}
+	if total == 0 {
+		x, y = 0, 0
+		for _, ctr := range c.centroids {
+			x += float64(ctr[0])
+			y += float64(ctr[1])
+		}
+		total = float64(len(c.centroids))
+	}
+	h := c.bounds.Max[1] - c.bounds.Min[1]
+	return f32.Vec2{
+		float32(x / total),
+		float32(y/total) - bias*h,
+	}, nil
+}
+
+// centroidArea returns the area-weighted centroid, and the area, of the
+// implicitly closed rings, treating those nested inside an odd number of the
+// others as holes.
+func centroidArea(rings [][]f32.Vec2) (f32.Vec2, float64) {
+	// Accumulate in float64, relative to an origin near the rings, to limit
+	// the loss of precision.
+	var origin f32.Vec2
+	for _, ring := range rings {
+		if len(ring) > 0 {
+			origin = ring[0]
+			break
+		}
+	}
+
+	var area, cx, cy, sx, sy float64
+	n := 0
+	for i, ring := range rings {
+		ringArea, ringX, ringY := 0.0, 0.0, 0.0
+		for j := range ring {
+			x0 := float64(ring[j][0] - origin[0])
+			y0 := float64(ring[j][1] - origin[1])
+			k := (j + 1) % len(ring)
+			x1 := float64(ring[k][0] - origin[0])
+			y1 := float64(ring[k][1] - origin[1])
+			cross := x0*y1 - x1*y0
+			ringArea += cross
+			ringX += (x0 + x1) * cross
+			ringY += (y0 + y1) * cross
+			sx += x0
+			sy += y0
+		}
+		n += len(ring)
+		if ringArea == 0 {
+			continue
+		}
+
+		depth := 0
+		for j, other := range rings {
+			if j != i && len(ring) > 0 && polygonContains(other, ring[0]) {
+				depth++
+			}
+		}
+		// Make the area positive for outer rings and negative for holes.
+		if (ringArea < 0) == (depth%2 == 0) {
+			ringArea, ringX, ringY = -ringArea, -ringX, -ringY
+		}
+		area += ringArea
+		cx += ringX
+		cy += ringY
+	}
+
+	if area == 0 {
+		if n == 0 {
+			return origin, 0
+		}
+		return f32.Vec2{
+			origin[0] + float32(sx/float64(n)),
+			origin[1] + float32(sy/float64(n)),
+		}, 0
+	}
+	// The ring areas above are doubled, so the centroid's denominator is
+	// 3×area instead of the usual 6×area.
+	return f32.Vec2{
+		origin[0] + float32(cx/(3*area)),
+		origin[1] + float32(cy/(3*area)),
+	}, area / 2
+}
@@ -0,0 +1,131 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestCentroidCollector(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	// A right triangle, whose centroid is the mean of its vertices.
+	e.StartPath(0, 0, 0)
+	e.AbsLineTo(6, 0)
+	e.AbsLineTo(0, 3)
+	e.ClosePathEndPath()
+	// A 20×20 square with a 10×10 hole towards its top-left. Both wind in
+	// the same direction.
+	e.StartPath(0, -20, -20)
+	e.AbsHLineTo(0)
+	e.AbsVLineTo(0)
+	e.AbsHLineTo(-20)
+	e.ClosePathAbsMoveTo(-18, -18)
+	e.AbsHLineTo(-8)
+	e.AbsVLineTo(-8)
+	e.AbsHLineTo(-18)
+	e.ClosePathEndPath()
+	// A degenerate line.
+	e.StartPath(0, 10, 10)
+	e.AbsLineTo(20, 20)
+	e.ClosePathEndPath()
+	// A fully transparent path.
+	e.SetCReg(0, false, RGBAColor(color.RGBA{}))
+	e.StartPath(0, 0, 0)
+	e.AbsLineTo(1, 0)
+	e.AbsLineTo(0, 1)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var c CentroidCollector
+	if err := Decode(&c, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	// The whole square, area 400, is centered on (-10, -10), and the hole,
+	// area 100, on (-13, -13). So the centroid is (400×-10 - 100×-13) / 300
+	// = -9 on each axis.
+	want := []f32.Vec2{
+		{2, 1},
+		{-9, -9},
+		{15, 15},
+	}
+	got := c.Centroids()
+	if len(got) != len(want) {
+		t.Fatalf("got %d centroids, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if math.Abs(float64(got[i][0]-want[i][0])) > 1e-4 || math.Abs(float64(got[i][1]-want[i][1])) > 1e-4 {
+			t.Errorf("centroid #%d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestOpticalCenter(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if _, err := OpticalCenter(ivgData, nil); err != errNoVisiblePaths {
+		t.Errorf("no paths: got %v, want %v", err, errNoVisiblePaths)
+	}
+
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, -20, -20)
+	e.AbsHLineTo(+20)
+	e.AbsVLineTo(+20)
+	e.AbsHLineTo(-20)
+	e.ClosePathEndPath()
+	e.StartPath(0, +10, +10)
+	e.AbsHLineTo(+20)
+	e.AbsVLineTo(+20)
+	e.AbsHLineTo(+10)
+	e.ClosePathEndPath()
+	ivgData, err = e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	// The paths' areas are 1600 and 100, centered on (0, 0) and (15, 15), so
+	// their area-weighted centroid is 1500/1700 on each axis. Their bounding
+	// box is 40 units high.
+	const c = 1500.0 / 1700
+	testCases := []struct {
+		bias float32
+		want f32.Vec2
+	}{
+		{0, f32.Vec2{c, c}},
+		{DefaultOpticalCenterBias, f32.Vec2{c, c - 2}},
+		{0.25, f32.Vec2{c, c - 10}},
+	}
+	for _, tc := range testCases {
+		got, err := OpticalCenterWithBias(ivgData, tc.bias, nil)
+		if err != nil {
+			t.Errorf("bias %v: %v", tc.bias, err)
+			continue
+		}
+		if math.Abs(float64(got[0]-tc.want[0])) > 1e-4 || math.Abs(float64(got[1]-tc.want[1])) > 1e-4 {
+			t.Errorf("bias %v: got %v, want %v", tc.bias, got, tc.want)
+		}
+	}
+	if got, _ := OpticalCenter(ivgData, nil); math.Abs(float64(got[1]-(c-2))) > 1e-4 {
+		t.Errorf("OpticalCenter: got %v, want the default bias", got)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+
+	"golang.org/x/image/math/f32"
+)
+
+// CGPathOp is a CoreGraphics path operation. Its names match the CoreGraphics
+// C functions that add each kind of element to a CGMutablePath.
+type CGPathOp uint8
+
+const (
+	CGPathMoveToPoint CGPathOp = iota
+	CGPathAddLineToPoint
+	CGPathAddQuadCurveToPoint
+	CGPathAddCurveToPoint
+	CGPathCloseSubpath
+)
+
+// CGPathElement is one operation of a CGPathDescription.
+type CGPathElement struct {
+	// Op is the operation.
+	Op CGPathOp
+	// Points is up to three (x, y) coordinates, in the same order as the
+	// CoreGraphics function's arguments: the control points, if any, come
+	// before the end point. It is unused for CGPathCloseSubpath.
+	Points [3]f32.Vec2
+}
+
+// CGPathDescription describes one CoreGraphics path, and how to fill it.
+type CGPathDescription struct {
+	// Fill is the path's alpha-premultiplied fill color. CoreGraphics fills
+	// it with the non-zero winding rule, which is its default.
+	Fill color.RGBA
+	// Elements are the path's operations, in order.
+	Elements []CGPathElement
+}
+
+// CGPathExporter is a Destination that converts an IconVG graphic's paths to
+// descriptions of CoreGraphics paths, for embedding IconVG graphics as native
+// paths in iOS and macOS apps. The descriptions can be used directly, such as
+// by a bridge to CoreGraphics, or converted to Swift source code.
+//
+// Relative drawing ops are made absolute, and arcs are converted to cubic
+// Bézier curves. Paths are selected by level of detail as if the graphic was
+// rendered at a height, in pixels, equal to the height of the ViewBox. Paths
+// filled with gradients, and fully transparent paths, are skipped.
+type CGPathExporter struct {
+	// FlipY is whether to flip the Y axis, for CoreGraphics' default
+	// coordinate system, in which Y increases upwards. The graphic is
+	// flipped about the ViewBox's horizontal center line, so that the
+	// ViewBox still covers the same rectangle.
+	FlipY bool
+
+	segmenter
+
+	paths   []CGPathDescription
+	visible bool
+	subpath bool
+}
+
+// Reset resets the CGPathExporter for the given Metadata.
+func (e *CGPathExporter) Reset(m Metadata) {
+	e.segmenter.reset(m, e)
+	e.paths = e.paths[:0]
+	e.visible = false
+}
+
+// Paths returns the descriptions of the paths decoded since the last Reset.
+func (e *CGPathExporter) Paths() []CGPathDescription {
+	return e.paths
+}
+
+// Swift returns Swift source code that builds the paths. For each path, with
+// index i, it declares a CGMutablePath named pathI and a CGColor named
+// colorI, in the sRGB color space. Its numbers have just enough digits to be
+// exactly converted back to float32s.
+func (e *CGPathExporter) Swift() []byte {
+	var b []byte
+	for i, p := range e.paths {
+		name := "path" + strconv.Itoa(i)
+		b = append(b, fmt.Sprintf("let %s = CGMutablePath()\n", name)...)
+		for _, el := range p.Elements {
+			q := &el.Points
+			switch el.Op {
+			case CGPathMoveToPoint:
+				b = append(b, fmt.Sprintf("%s.move(to: %s)\n", name, swiftPoint(q[0]))...)
+			case CGPathAddLineToPoint:
+				b = append(b, fmt.Sprintf("%s.addLine(to: %s)\n", name, swiftPoint(q[0]))...)
+			case CGPathAddQuadCurveToPoint:
+				b = append(b, fmt.Sprintf("%s.addQuadCurve(to: %s, control: %s)\n",
+					name, swiftPoint(q[1]), swiftPoint(q[0]))...)
+			case CGPathAddCurveToPoint:
+				b = append(b, fmt.Sprintf("%s.addCurve(to: %s, control1: %s, control2: %s)\n",
+					name, swiftPoint(q[2]), swiftPoint(q[0]), swiftPoint(q[1]))...)
+			case CGPathCloseSubpath:
+				b = append(b, fmt.Sprintf("%s.closeSubpath()\n", name)...)
+			}
+		}
+		c := p.Fill
+		a := float64(c.A)
+		b = append(b, fmt.Sprintf("let color%d = CGColor(srgbRed: %s, green: %s, blue: %s, alpha: %s)\n", i,
+			svgNumber64(float64(c.R)/a), svgNumber64(float64(c.G)/a), svgNumber64(float64(c.B)/a),
+			svgNumber64(a/0xff))...)
+	}
+	return b
+}
+
+func swiftPoint(p f32.Vec2) string {
+	return "CGPoint(x: " + svgNumber(p[0]) + ", y: " + svgNumber(p[1]) + ")"
+}
+
+func (e *CGPathExporter) beginPath() {
+	_, h := e.metadata.ViewBox.AspectRatio()
+	e.visible = e.lod0 <= h && h < e.lod1 &&
+		e.fill.A != 0 && validAlphaPremulColor(e.fill)
+	if !e.visible {
+		return
+	}
+	e.subpath = false
+	e.paths = append(e.paths, CGPathDescription{Fill: e.fill})
+}
+
+func (e *CGPathExporter) addSegment(s Segment) {
+	if !e.visible {
+		return
+	}
+	p := &e.paths[len(e.paths)-1]
+	el := CGPathElement{}
+	n := 0
+	switch s.Op {
+	case SegmentOpMoveTo:
+		if e.subpath {
+			p.Elements = append(p.Elements, CGPathElement{Op: CGPathCloseSubpath})
+		}
+		e.subpath = true
+		el.Op, n = CGPathMoveToPoint, 1
+	case SegmentOpLineTo:
+		el.Op, n = CGPathAddLineToPoint, 1
+	case SegmentOpQuadTo:
+		el.Op, n = CGPathAddQuadCurveToPoint, 2
+	case SegmentOpCubeTo:
+		el.Op, n = CGPathAddCurveToPoint, 3
+	}
+	copy(el.Points[:n], s.Args[:n])
+	if e.FlipY {
+		vb := &e.metadata.ViewBox
+		for i := 0; i < n; i++ {
+			el.Points[i][1] = vb.Min[1] + vb.Max[1] - el.Points[i][1]
+		}
+	}
+	p.Elements = append(p.Elements, el)
+}
+
+func (e *CGPathExporter) endPath() {
+	if !e.visible {
+		return
+	}
+	if e.subpath {
+		p := &e.paths[len(e.paths)-1]
+		p.Elements = append(p.Elements, CGPathElement{Op: CGPathCloseSubpath})
+	}
+	e.visible = false
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestCGPathExporter(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: Rectangle{Max: f32.Vec2{+64, +64}},
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x80, 0x80}))
+	e.StartPath(0, 8, 8)
+	e.AbsQuadTo(16, 8, 16, 16)
+	e.AbsCubeTo(16, 24, 8, 24, 8, 16)
+	e.ClosePathAbsMoveTo(32, 32)
+	e.AbsLineTo(48, 32)
+	e.ClosePathEndPath()
+	// A fully transparent path is skipped.
+	e.SetCReg(0, false, RGBAColor(color.RGBA{}))
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(+8)
+	e.AbsVLineTo(+8)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	x := CGPathExporter{FlipY: true}
+	if err := Decode(&x, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := []CGPathDescription{{
+		Fill: color.RGBA{0x00, 0x00, 0x80, 0x80},
+		Elements: []CGPathElement{
+			{Op: CGPathMoveToPoint, Points: [3]f32.Vec2{{8, 56}}},
+			{Op: CGPathAddQuadCurveToPoint, Points: [3]f32.Vec2{{16, 56}, {16, 48}}},
+			{Op: CGPathAddCurveToPoint, Points: [3]f32.Vec2{{16, 40}, {8, 40}, {8, 48}}},
+			{Op: CGPathCloseSubpath},
+			{Op: CGPathMoveToPoint, Points: [3]f32.Vec2{{32, 32}}},
+			{Op: CGPathAddLineToPoint, Points: [3]f32.Vec2{{48, 32}}},
+			{Op: CGPathCloseSubpath},
+		},
+	}}
+	if got := x.Paths(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Paths:\ngot  %v\nwant %v", got, want)
+	}
+
+	wantSwift := "let path0 = CGMutablePath()\n" +
+		"path0.move(to: CGPoint(x: 8, y: 56))\n" +
+		"path0.addQuadCurve(to: CGPoint(x: 16, y: 48), control: CGPoint(x: 16, y: 56))\n" +
+		"path0.addCurve(to: CGPoint(x: 8, y: 48), control1: CGPoint(x: 16, y: 40), control2: CGPoint(x: 8, y: 40))\n" +
+		"path0.closeSubpath()\n" +
+		"path0.move(to: CGPoint(x: 32, y: 32))\n" +
+		"path0.addLine(to: CGPoint(x: 48, y: 32))\n" +
+		"path0.closeSubpath()\n" +
+		"let color0 = CGColor(srgbRed: 0, green: 0, blue: 1, alpha: 0.5019608)\n"
+	if got := string(x.Swift()); got != wantSwift {
+		t.Errorf("Swift:\ngot:\n%s\nwant:\n%s", got, wantSwift)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+)
+
+// EventKind is the kind of an Event.
+type EventKind uint8
+
+const (
+	// EventStartPath starts a path. Its Event's Fill, LOD0 and LOD1 fields
+	// are set.
+	EventStartPath EventKind = iota
+	// EventSegment is one of the current path's segments. Its Event's Segment
+	// field is set.
+	EventSegment
+	// EventEndPath ends the current path.
+	EventEndPath
+)
+
+// Event is a drawing event sent by DecodeToChannel.
+type Event struct {
+	// Kind is the kind of event.
+	Kind EventKind
+
+	// Path is the index of the path that the event is part of.
+	Path int
+
+	// Fill is the path's fill color, CREG[CSEL-ADJ]. It is either an
+	// alpha-premultiplied color or, if Fill.A is zero and Fill.B has its high
+	// bit set, a gradient.
+	Fill color.RGBA
+
+	// LOD0 and LOD1 are the path's level of detail range.
+	LOD0, LOD1 float32
+
+	// Segment is the segment, in graphic coordinate space. Arcs are
+	// converted to cubic Bézier curves.
+	Segment Segment
+}
+
+// eventBufferSize is the capacity of DecodeToChannel's event channel.
+const eventBufferSize = 64
+
+// DecodeToChannel decodes an IconVG graphic in a new goroutine, sending its
+// drawing events, in order, on the returned event channel. The event channel
+// is closed when decoding is complete. After that, the error channel receives
+// the decoding error, if any, and is then closed.
+//
+// The caller must receive every event, such as by ranging over the event
+// channel, or the goroutine will never finish. The graphic's metadata, which
+// is not sent, can be decoded by DecodeMetadata.
+//
+// The src bytes should not be modified until the event channel is closed.
+func DecodeToChannel(src []byte, opts *DecodeOptions) (<-chan Event, <-chan error) {
+	events := make(chan Event, eventBufferSize)
+	errc := make(chan error, 1)
+	go func() {
+		d := channelDestination{events: events, path: -1}
+		err := Decode(&d, src, opts)
+		close(events)
+		if err != nil {
+			errc <- err
+		}
+		close(errc)
+	}()
+	return events, errc
+}
+
+// channelDestination is a Destination that sends Events on a channel.
+type channelDestination struct {
+	segmenter
+	events chan<- Event
+	path   int
+}
+
+func (d *channelDestination) Reset(m Metadata) {
+	d.segmenter.reset(m, d)
+}
+
+func (d *channelDestination) beginPath() {
+	d.path++
+	d.events <- Event{
+		Kind: EventStartPath,
+		Path: d.path,
+		Fill: d.fill,
+		LOD0: d.lod0,
+		LOD1: d.lod1,
+	}
+}
+
+func (d *channelDestination) addSegment(s Segment) {
+	d.events <- Event{
+		Kind:    EventSegment,
+		Path:    d.path,
+		Segment: s,
+	}
+}
+
+func (d *channelDestination) endPath() {
+	d.events <- Event{
+		Kind: EventEndPath,
+		Path: d.path,
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeToChannel(t *testing.T) {
+	for _, tc := range testdataTestCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		var want pathsRecorder
+		if err := Decode(&want, ivgData, nil); err != nil {
+			t.Errorf("%s: Decode: %v", tc.filename, err)
+			continue
+		}
+
+		var got []ResolvedPath
+		ended := true
+		events, errc := DecodeToChannel(ivgData, nil)
+		for ev := range events {
+			switch ev.Kind {
+			case EventStartPath:
+				if !ended || ev.Path != len(got) {
+					t.Errorf("%s: unexpected start of path %d", tc.filename, ev.Path)
+				}
+				ended = false
+				got = append(got, ResolvedPath{Fill: ev.Fill, LOD0: ev.LOD0, LOD1: ev.LOD1})
+			case EventSegment:
+				p := &got[len(got)-1]
+				p.Segments = append(p.Segments, ev.Segment)
+			case EventEndPath:
+				ended = true
+			}
+		}
+		if err := <-errc; err != nil {
+			t.Errorf("%s: DecodeToChannel: %v", tc.filename, err)
+			continue
+		}
+		if !ended {
+			t.Errorf("%s: last path was not ended", tc.filename)
+		}
+		if !reflect.DeepEqual(got, want.paths) {
+			t.Errorf("%s: paths differ from Decode's", tc.filename)
+		}
+	}
+}
+
+func TestDecodeToChannelError(t *testing.T) {
+	events, errc := DecodeToChannel([]byte("not an IconVG graphic"), nil)
+	for range events {
+		t.Error("got an event, want none")
+	}
+	if err := <-errc; err == nil {
+		t.Error("got nil error, want non-nil")
+	}
+	if _, ok := <-errc; ok {
+		t.Error("error channel was not closed")
+	}
+}
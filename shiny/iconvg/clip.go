@@ -0,0 +1,35 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/draw"
+)
+
+// RenderClipped rasterizes the IconVG graphic content to a new w × h image,
+// masked by the IconVG graphic clip: each pixel of content is multiplied by
+// the alpha of the same pixel of clip, rasterized to the same size. Pixels
+// outside of clip's filled region are fully transparent. Clip's colors are
+// otherwise ignored, but a partially transparent clip path only partially
+// reveals the content beneath it.
+//
+// Each graphic's ViewBox is scaled to fill the image, so the two are aligned
+// by their view boxes, not by their graphic coordinates. To align them by
+// their graphic coordinates instead, set opts' ViewBoxOverride, which, like
+// the rest of opts, applies to both graphics.
+func RenderClipped(content []byte, clip []byte, w, h int, opts *DecodeOptions) (*image.RGBA, error) {
+	src, err := rasterize(content, w, h, opts)
+	if err != nil {
+		return nil, err
+	}
+	mask, err := rasterize(clip, w, h, opts)
+	if err != nil {
+		return nil, err
+	}
+	dst := image.NewRGBA(src.Bounds())
+	draw.DrawMask(dst, dst.Bounds(), src, image.Point{}, mask, image.Point{}, draw.Src)
+	return dst, nil
+}
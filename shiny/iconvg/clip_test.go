@@ -0,0 +1,75 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestRenderClipped(t *testing.T) {
+	// The content is a red square that fills its ViewBox.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0xff, 0x00, 0x00, 0xff}))
+	e.StartPath(0, -32, -32)
+	e.AbsHLineTo(+32)
+	e.AbsVLineTo(+32)
+	e.AbsHLineTo(-32)
+	e.ClosePathEndPath()
+	content, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	// The clip, with a different ViewBox, covers the left half with an
+	// opaque blue, and the top right quarter with a half-transparent green.
+	// Resetting e would re-use the buffer that content refers to.
+	e = Encoder{}
+	e.Reset(Metadata{
+		ViewBox: Rectangle{Max: f32.Vec2{+2, +2}},
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0xff, 0xff}))
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(+1)
+	e.AbsVLineTo(+2)
+	e.AbsHLineTo(0)
+	e.ClosePathEndPath()
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x80, 0x00, 0x80}))
+	e.StartPath(0, +1, 0)
+	e.AbsHLineTo(+2)
+	e.AbsVLineTo(+1)
+	e.AbsHLineTo(+1)
+	e.ClosePathEndPath()
+	clip, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	got, err := RenderClipped(content, clip, 16, 16, nil)
+	if err != nil {
+		t.Fatalf("RenderClipped: %v", err)
+	}
+	testCases := []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{4, 4, color.RGBA{0xff, 0x00, 0x00, 0xff}},
+		{4, 12, color.RGBA{0xff, 0x00, 0x00, 0xff}},
+		{12, 4, color.RGBA{0x80, 0x00, 0x00, 0x80}},
+		{12, 12, color.RGBA{}},
+	}
+	for _, tc := range testCases {
+		if c := got.RGBAAt(tc.x, tc.y); c != tc.want {
+			t.Errorf("at (%d, %d): got %v, want %v", tc.x, tc.y, c, tc.want)
+		}
+	}
+}
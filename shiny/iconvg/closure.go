@@ -0,0 +1,92 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+// SubpathClosure is how a sub-path is closed.
+//
+// Every IconVG sub-path is closed, as IconVG graphics are filled, not
+// stroked: the opcodes that end a sub-path, "z; M", "z; m" and "z; end path",
+// all close it first. Whether closing adds an edge, though, hints at what the
+// author intended. A sub-path converted from an open SVG path, meant to be
+// stroked, typically relies on the close to add its final edge.
+type SubpathClosure uint8
+
+const (
+	// SubpathReturnsToStart means that the sub-path's segments end where it
+	// started, so that closing it adds no edge.
+	SubpathReturnsToStart SubpathClosure = iota
+	// SubpathClosedImplicitly means that closing the sub-path adds an edge,
+	// from where its segments end back to where it started.
+	SubpathClosedImplicitly
+	// SubpathClosedAtEnd means that the graphic ended in the middle of the
+	// sub-path's path, and the AutoClosePaths decode option closed it.
+	SubpathClosedAtEnd
+)
+
+// closureTolerance is how close, in graphic coordinate space, a sub-path's end
+// must be to its start for it to return to its start. It allows for the
+// rounding of arcs' end points, when arcs are converted to Bézier curves.
+const closureTolerance = 1.0 / 1024
+
+// ClosureAnalyzer is a Destination that reports how each sub-path of each
+// path of an IconVG graphic is closed, for linters that flag graphics that
+// rely on implicit closing.
+//
+// To detect SubpathClosedAtEnd, the ClosureAnalyzer must be the Destination
+// passed to Decode, without decode options that wrap it, such as
+// NormalizeWinding or ArcApproximator.
+type ClosureAnalyzer struct {
+	// Report has an element for each path decoded since the last Reset, in
+	// order, including paths that are fully transparent or outside of every
+	// level of detail. Each element has an element for each of that path's
+	// sub-paths.
+	Report [][]SubpathClosure
+
+	segmenter
+	segs  []Segment
+	atEnd bool
+}
+
+// Reset resets the ClosureAnalyzer for the given Metadata.
+func (a *ClosureAnalyzer) Reset(m Metadata) {
+	a.segmenter.reset(m, a)
+	a.Report = nil
+	a.segs = a.segs[:0]
+	a.atEnd = false
+}
+
+// ImplicitlyClosed returns whether any sub-path in the Report is closed
+// implicitly or at the end of the graphic, instead of returning to its start.
+func (a *ClosureAnalyzer) ImplicitlyClosed() bool {
+	for _, p := range a.Report {
+		for _, c := range p {
+			if c != SubpathReturnsToStart {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *ClosureAnalyzer) autoClosePath() { a.atEnd = true }
+
+func (a *ClosureAnalyzer) beginPath()           { a.segs = a.segs[:0] }
+func (a *ClosureAnalyzer) addSegment(s Segment) { a.segs = append(a.segs, s) }
+
+func (a *ClosureAnalyzer) endPath() {
+	subpaths := splitSubpaths(a.segs)
+	report := make([]SubpathClosure, len(subpaths))
+	for i, sub := range subpaths {
+		start, end := sub[0].Args[0], sub[len(sub)-1].end()
+		if abs32(end[0]-start[0]) > closureTolerance || abs32(end[1]-start[1]) > closureTolerance {
+			report[i] = SubpathClosedImplicitly
+		}
+	}
+	if a.atEnd && len(report) > 0 {
+		report[len(report)-1] = SubpathClosedAtEnd
+	}
+	a.atEnd = false
+	a.Report = append(a.Report, report)
+}
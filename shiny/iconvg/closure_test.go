@@ -0,0 +1,85 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestClosureAnalyzer(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	// A square that returns to its start, and a triangle that does not.
+	e.StartPath(0, -20, -20)
+	e.AbsHLineTo(-10)
+	e.AbsVLineTo(-10)
+	e.AbsHLineTo(-20)
+	e.AbsVLineTo(-20)
+	e.ClosePathAbsMoveTo(0, 0)
+	e.AbsLineTo(10, 0)
+	e.AbsLineTo(10, 10)
+	e.ClosePathEndPath()
+	// A circle, made of two arcs, whose end points are rounded.
+	e.StartPath(0, -10, 10)
+	e.AbsArcTo(7, 7, 0, false, false, 4, 10)
+	e.AbsArcTo(7, 7, 0, false, false, -10, 10)
+	e.ClosePathEndPath()
+	// A triangle, without its end path opcode.
+	e.StartPath(0, 20, 20)
+	e.AbsLineTo(30, 20)
+	e.AbsLineTo(30, 30)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if ivgData[len(ivgData)-1] != 0xe1 {
+		t.Fatalf("last byte: got %#02x, want 0xe1", ivgData[len(ivgData)-1])
+	}
+	ivgData = ivgData[:len(ivgData)-1]
+
+	var a ClosureAnalyzer
+	if err := Decode(&a, ivgData, &DecodeOptions{AutoClosePaths: true}); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := [][]SubpathClosure{
+		{SubpathReturnsToStart, SubpathClosedImplicitly},
+		{SubpathReturnsToStart},
+		{SubpathClosedAtEnd},
+	}
+	if !reflect.DeepEqual(a.Report, want) {
+		t.Errorf("Report: got %v, want %v", a.Report, want)
+	}
+	if !a.ImplicitlyClosed() {
+		t.Errorf("ImplicitlyClosed: got false, want true")
+	}
+
+	// The cowbell, converted from SVG, relies on implicit closing for most
+	// of its sub-paths.
+	ivgData, err = ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if err := Decode(&a, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want = [][]SubpathClosure{
+		{SubpathReturnsToStart},
+		{SubpathClosedImplicitly},
+		{SubpathClosedImplicitly},
+		{SubpathClosedImplicitly},
+		{SubpathReturnsToStart},
+		{SubpathClosedImplicitly, SubpathClosedImplicitly},
+	}
+	if !reflect.DeepEqual(a.Report, want) {
+		t.Errorf("cowbell: Report: got %v, want %v", a.Report, want)
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+)
+
+// ColorsUsed returns the distinct alpha-premultiplied colors that an IconVG
+// graphic's paths are filled with, in order of first use. For a path filled
+// with a gradient, the colors of the gradient's stops are included instead.
+//
+// Paths at every level of detail are included, as are fully transparent
+// paths.
+func ColorsUsed(src []byte, opts *DecodeOptions) ([]color.RGBA, error) {
+	c := colorCollector{stops: true}
+	if err := Decode(&c, src, opts); err != nil {
+		return nil, err
+	}
+	return c.colors, nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestColorsUsed(t *testing.T) {
+	red := color.RGBA{0xff, 0x00, 0x00, 0xff}
+	blue := color.RGBA{0x00, 0x00, 0x80, 0x80}
+	green := color.RGBA{0x00, 0xff, 0x00, 0xff}
+
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	square := func() {
+		e.StartPath(0, -10, -10)
+		e.AbsHLineTo(+10)
+		e.AbsVLineTo(+10)
+		e.AbsHLineTo(-10)
+		e.ClosePathEndPath()
+	}
+	for _, c := range []color.RGBA{red, blue, red} {
+		e.SetCReg(0, false, RGBAColor(c))
+		square()
+	}
+	e.SetLinearGradient(10, 10, -10, 0, +10, 0, GradientSpreadNone, []GradientStop{
+		{Offset: 0, Color: green},
+		{Offset: 1, Color: red},
+	})
+	square()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	got, err := ColorsUsed(ivgData, nil)
+	if err != nil {
+		t.Fatalf("ColorsUsed: %v", err)
+	}
+	want := []color.RGBA{red, blue, green}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+var errRenderMismatch = errors.New("iconvg: rendering differs from the reference image")
+
+// CompareRender rasterizes the IconVG graphic src to the size of want's
+// bounds, and returns the root mean square difference between the two
+// images, over every channel of every pixel, with each channel's value
+// scaled to the range [0, 1]. A diff of 0 means that they are identical, and
+// a diff of 1 means that they are as different as possible.
+//
+// It also returns an error if the diff exceeds the tolerance, so that golden
+// image tests can simply check the error.
+func CompareRender(src []byte, want image.Image, tolerance float64, opts *DecodeOptions) (diff float64, err error) {
+	b := want.Bounds()
+	if b.Empty() {
+		return 0, nil
+	}
+	got, err := rasterize(src, b.Dx(), b.Dy(), opts)
+	if err != nil {
+		return 0, err
+	}
+
+	sum := 0.0
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			r0, g0, b0, a0 := got.At(x, y).RGBA()
+			r1, g1, b1, a1 := want.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			for _, d := range [4]float64{
+				float64(r0) - float64(r1),
+				float64(g0) - float64(g1),
+				float64(b0) - float64(b1),
+				float64(a0) - float64(a1),
+			} {
+				d /= 0xffff
+				sum += d * d
+			}
+		}
+	}
+	diff = math.Sqrt(sum / float64(4*b.Dx()*b.Dy()))
+	if diff > tolerance {
+		return diff, errRenderMismatch
+	}
+	return diff, nil
+}
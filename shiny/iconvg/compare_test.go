@@ -0,0 +1,44 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/draw"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareRender(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	want, err := decodePNG(filepath.FromSlash("testdata/action-info.lores.png"))
+	if err != nil {
+		t.Fatalf("decodePNG: %v", err)
+	}
+
+	diff, err := CompareRender(ivgData, want, 0.01, nil)
+	if err != nil {
+		t.Fatalf("golden image: diff %v: %v", diff, err)
+	}
+
+	// A reference image that is offset from the origin is compared by its
+	// bounds' size.
+	b := want.Bounds()
+	offset := image.NewRGBA(b.Add(image.Point{100, 200}))
+	draw.Draw(offset, offset.Bounds(), want, b.Min, draw.Src)
+	if diff1, err := CompareRender(ivgData, offset, 0.01, nil); err != nil || diff1 != diff {
+		t.Errorf("offset: got %v, %v, want %v, nil", diff1, err, diff)
+	}
+
+	// A blank image differs.
+	diff, err = CompareRender(ivgData, image.NewRGBA(b), 0.01, nil)
+	if err != errRenderMismatch || diff <= 0.01 {
+		t.Errorf("blank: got %v, %v, want > 0.01, %v", diff, err, errRenderMismatch)
+	}
+}
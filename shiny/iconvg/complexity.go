@@ -0,0 +1,86 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+// PathComplexity is a breakdown of one path's drawing ops, as recorded by a
+// ComplexityCollector.
+type PathComplexity struct {
+	// Lines, Quads, Cubes and Arcs are the number of line, quadratic Bézier,
+	// cubic Bézier and arc drawing ops. Horizontal and vertical lines count
+	// as lines, and smooth curves count as curves of their degree. Implicitly
+	// repeated ops are counted separately, and the implicit lines that close
+	// sub-paths are not counted.
+	Lines, Quads, Cubes, Arcs int
+
+	// Points is the number of points, both end points and control points,
+	// that the path is drawn with, including each sub-path's start point.
+	// Arcs are converted to cubic Bézier curves, of 3 points each, so an
+	// arc's points depend on how far around it turns.
+	Points int
+}
+
+// ComplexityCollector is a Destination that records the complexity of each
+// path of an IconVG graphic, for finding which paths are expensive to draw.
+type ComplexityCollector struct {
+	segmenter
+	paths []PathComplexity
+	// inArc is whether segments are being emitted for an arc.
+	inArc bool
+}
+
+// PerPath returns the complexity of each path, in the order that they are
+// encoded, including paths that would not be drawn, such as those outside of
+// a level of detail range.
+func (c *ComplexityCollector) PerPath() []PathComplexity {
+	return c.paths
+}
+
+// Reset resets the ComplexityCollector for the given Metadata.
+func (c *ComplexityCollector) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	c.paths = c.paths[:0]
+	c.inArc = false
+}
+
+func (c *ComplexityCollector) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	c.paths[len(c.paths)-1].Arcs++
+	c.inArc = true
+	c.segmenter.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	c.inArc = false
+}
+
+func (c *ComplexityCollector) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	c.paths[len(c.paths)-1].Arcs++
+	c.inArc = true
+	c.segmenter.RelArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	c.inArc = false
+}
+
+func (c *ComplexityCollector) beginPath() {
+	c.paths = append(c.paths, PathComplexity{})
+}
+
+func (c *ComplexityCollector) addSegment(s Segment) {
+	p := &c.paths[len(c.paths)-1]
+	switch s.Op {
+	case SegmentOpMoveTo:
+		p.Points++
+	case SegmentOpLineTo:
+		if !c.inArc {
+			p.Lines++
+		}
+		p.Points++
+	case SegmentOpQuadTo:
+		p.Quads++
+		p.Points += 2
+	case SegmentOpCubeTo:
+		if !c.inArc {
+			p.Cubes++
+		}
+		p.Points += 3
+	}
+}
+
+func (c *ComplexityCollector) endPath() {}
@@ -0,0 +1,51 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestComplexityCollector(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, -20, -20)
+	e.AbsHLineTo(+20)
+	e.AbsVLineTo(+20)
+	e.AbsLineTo(-20, +20)
+	e.ClosePathAbsMoveTo(-10, -10)
+	e.AbsQuadTo(0, -15, +10, -10)
+	e.AbsSmoothQuadTo(+10, 0)
+	e.AbsCubeTo(+5, +5, -5, +5, -10, 0)
+	e.ClosePathEndPath()
+	e.StartPath(0, -20, 0)
+	// A half turn arc, converted to 2 cubic Bézier curves.
+	e.AbsArcTo(20, 20, 0, false, true, +20, 0)
+	// A zero radius arc, converted to a line.
+	e.AbsArcTo(0, 0, 0, false, true, -20, 0)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var c ComplexityCollector
+	if err := Decode(&c, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := []PathComplexity{
+		{Lines: 3, Quads: 2, Cubes: 1, Points: 1 + 3 + 1 + 2 + 2 + 3},
+		{Arcs: 2, Points: 1 + 6 + 1},
+	}
+	if got := c.PerPath(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"math"
+)
+
+// contrastSize is the height, in pixels, that an IconVG graphic is rasterized
+// at to find its dominant color.
+const contrastSize = 64
+
+// ContrastRatio returns the WCAG 2.0 contrast ratio, from 1 to 21, between an
+// IconVG graphic's dominant color and the background color, which should be
+// opaque. The Web Content Accessibility Guidelines recommend a ratio of at
+// least 3 for graphical objects, and 4.5 for normal text.
+//
+// The dominant color is the color, composited over the background, that
+// covers the most pixels when the graphic is rasterized. It returns an error
+// if the graphic has no visible pixels.
+func ContrastRatio(src []byte, background color.RGBA, opts *DecodeOptions) (float32, error) {
+	c, err := dominantColor(src, background, opts)
+	if err != nil {
+		return 0, err
+	}
+	return contrastRatio(c, background), nil
+}
+
+// AuditContrast returns the indexes, in increasing order, of the palette
+// colors whose WCAG 2.0 contrast ratio with the background color, which
+// should be opaque, is less than min. Each palette color is composited over
+// the background before its contrast ratio is measured, so a fully
+// transparent color has a ratio of 1.
+func (p Palette) AuditContrast(background color.RGBA, min float32) []int {
+	var ret []int
+	for i, c := range p {
+		if contrastRatio(srcOver(c, background), background) < min {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// contrastRatio returns the WCAG 2.0 contrast ratio, from 1 to 21, between
+// two colors.
+func contrastRatio(c0, c1 color.RGBA) float32 {
+	l0, l1 := relativeLuminance(c0), relativeLuminance(c1)
+	if l0 < l1 {
+		l0, l1 = l1, l0
+	}
+	return float32((l0 + 0.05) / (l1 + 0.05))
+}
+
+// srcOver returns the alpha-premultiplied color s composited over the
+// background, using Porter-Duff src-over.
+func srcOver(s, background color.RGBA) color.RGBA {
+	a := 0xff - uint32(s.A)
+	return color.RGBA{
+		R: s.R + uint8(uint32(background.R)*a/0xff),
+		G: s.G + uint8(uint32(background.G)*a/0xff),
+		B: s.B + uint8(uint32(background.B)*a/0xff),
+		A: s.A + uint8(uint32(background.A)*a/0xff),
+	}
+}
+
+// dominantColor returns the most common color of the graphic's non-transparent
+// pixels, composited over the background, when rasterized at contrastSize
+// pixels high. Ties are broken in favor of the color that, in raster order,
+// reached that count first.
+func dominantColor(src []byte, background color.RGBA, opts *DecodeOptions) (color.RGBA, error) {
+	m, err := decodeMetadata(src, opts)
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	width := contrastSize
+	if dx, dy := m.ViewBox.AspectRatio(); dy > 0 {
+		if w := int(contrastSize*dx/dy + 0.5); w > 0 {
+			width = w
+		}
+	}
+	rgba, err := rasterize(src, width, contrastSize, opts)
+	if err != nil {
+		return color.RGBA{}, err
+	}
+
+	counts := map[color.RGBA]int{}
+	best, bestCount := color.RGBA{}, 0
+	for i := 0; i+4 <= len(rgba.Pix); i += 4 {
+		s := color.RGBA{rgba.Pix[i+0], rgba.Pix[i+1], rgba.Pix[i+2], rgba.Pix[i+3]}
+		if s.A == 0 {
+			continue
+		}
+		c := srcOver(s, background)
+		counts[c]++
+		if n := counts[c]; n > bestCount {
+			best, bestCount = c, n
+		}
+	}
+	if bestCount == 0 {
+		return color.RGBA{}, errNoVisiblePaths
+	}
+	return best, nil
+}
+
+// relativeLuminance returns the WCAG 2.0 relative luminance, from 0 to 1, of
+// the alpha-premultiplied color c, ignoring its alpha.
+func relativeLuminance(c color.RGBA) float64 {
+	if c.A == 0 {
+		return 0
+	}
+	linear := func(x uint8) float64 {
+		f := float64(x) / float64(c.A)
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
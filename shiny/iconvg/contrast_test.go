@@ -0,0 +1,100 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestContrastRatio(t *testing.T) {
+	encode := func(c color.RGBA) []byte {
+		var e Encoder
+		e.Reset(Metadata{
+			ViewBox: DefaultViewBox,
+			Palette: DefaultPalette,
+		})
+		e.SetCReg(0, false, RGBAColor(c))
+		e.StartPath(0, -24, -24)
+		e.AbsHLineTo(+24)
+		e.AbsVLineTo(+24)
+		e.AbsHLineTo(-24)
+		e.ClosePathEndPath()
+		b, err := e.Bytes()
+		if err != nil {
+			t.Fatalf("Bytes: %v", err)
+		}
+		return b
+	}
+
+	black := color.RGBA{0x00, 0x00, 0x00, 0xff}
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	testCases := []struct {
+		desc     string
+		fill, bg color.RGBA
+		want     float64
+	}{
+		{"black on white", black, white, 21},
+		{"white on black", white, black, 21},
+		{"black on black", black, black, 1},
+		// #777777 on white is a well known borderline case, just under 4.5.
+		{"gray on white", color.RGBA{0x77, 0x77, 0x77, 0xff}, white, 4.48},
+		// Translucent black over white is the same as opaque #808080.
+		{"translucent", color.RGBA{0x00, 0x00, 0x00, 0x7f}, white, 3.95},
+	}
+	for _, tc := range testCases {
+		got, err := ContrastRatio(encode(tc.fill), tc.bg, nil)
+		if err != nil {
+			t.Errorf("%s: ContrastRatio: %v", tc.desc, err)
+			continue
+		}
+		if math.Abs(float64(got)-tc.want) > 0.01 {
+			t.Errorf("%s: got %.3f, want %.2f", tc.desc, got, tc.want)
+		}
+	}
+
+	if _, err := ContrastRatio(encode(color.RGBA{}), white, nil); err != errNoVisiblePaths {
+		t.Errorf("transparent: got %v, want %v", err, errNoVisiblePaths)
+	}
+}
+
+func TestAuditContrast(t *testing.T) {
+	var p Palette
+	for i := range p {
+		p[i] = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	}
+	p[1] = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	// Mid gray has a contrast ratio with white of just under 4.5.
+	p[2] = color.RGBA{0x77, 0x77, 0x77, 0xff}
+	p[3] = color.RGBA{}
+	// Half-transparent black, over white, is mid gray.
+	p[4] = color.RGBA{0x00, 0x00, 0x00, 0x80}
+	// Dark gray passes.
+	p[5] = color.RGBA{0x55, 0x55, 0x55, 0xff}
+
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	if got, want := p.AuditContrast(white, 4.5), []int{1, 2, 3, 4}; !equalInts(got, want) {
+		t.Errorf("min 4.5: got %v, want %v", got, want)
+	}
+	if got, want := p.AuditContrast(white, 3), []int{1, 3}; !equalInts(got, want) {
+		t.Errorf("min 3: got %v, want %v", got, want)
+	}
+	if got := p.AuditContrast(white, 1); len(got) != 0 {
+		t.Errorf("min 1: got %v, want none", got)
+	}
+}
+
+func equalInts(x, y []int) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
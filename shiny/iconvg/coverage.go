@@ -0,0 +1,32 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+// CoverageFunc decodes the IconVG graphic src into a function that returns the
+// graphic's coverage at the point (x, y), in graphic coordinate space, for
+// renderers, such as GPU shaders, that sample a graphic on demand instead of
+// rasterizing it at a fixed size.
+//
+// The coverage is 1 if any path contains the point and 0 otherwise, ignoring
+// the paths' colors other than skipping fully transparent ones, as per
+// HitTester, whose flattening, level of detail and non-zero winding fill rule
+// apply. Each query only tests the paths whose bounding boxes contain the
+// point. Callers that want anti-aliased edges can average the coverage of
+// several samples within each pixel.
+//
+// The returned function does not modify any shared state, so it is safe to
+// call concurrently.
+func CoverageFunc(src []byte, opts *DecodeOptions) (func(x, y float32) float32, error) {
+	h, err := NewHitTester(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(x, y float32) float32 {
+		if h.Contains(x, y) {
+			return 1
+		}
+		return 0
+	}, nil
+}
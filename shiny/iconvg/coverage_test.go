@@ -0,0 +1,93 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestCoverageFunc(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x80, 0x80}))
+	// A square with a square hole, as its inner sub-path winds the other way.
+	e.StartPath(0, -30, -30)
+	e.AbsHLineTo(+30)
+	e.AbsVLineTo(+30)
+	e.AbsHLineTo(-30)
+	e.ClosePathAbsMoveTo(-20, -20)
+	e.AbsVLineTo(+20)
+	e.AbsHLineTo(+20)
+	e.AbsVLineTo(-20)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	coverage, err := CoverageFunc(ivgData, nil)
+	if err != nil {
+		t.Fatalf("CoverageFunc: %v", err)
+	}
+	testCases := []struct {
+		x, y float32
+		want float32
+	}{
+		{-25, -25, 1},
+		{+25, 0, 1},
+		{0, 0, 0},
+		{-31, 0, 0},
+		{+40, +40, 0},
+	}
+	for _, tc := range testCases {
+		if got := coverage(tc.x, tc.y); got != tc.want {
+			t.Errorf("(%v, %v): got %v, want %v", tc.x, tc.y, got, tc.want)
+		}
+	}
+}
+
+func TestCoverageFuncMatchesRasterizer(t *testing.T) {
+	// Pixels that the Rasterizer leaves fully transparent must have zero
+	// coverage at their centers, and fully opaque ones must have full
+	// coverage, as the graphic's colors are opaque.
+	const size = 64
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	coverage, err := CoverageFunc(ivgData, nil)
+	if err != nil {
+		t.Fatalf("CoverageFunc: %v", err)
+	}
+	m, err := DecodeMetadata(ivgData)
+	if err != nil {
+		t.Fatalf("DecodeMetadata: %v", err)
+	}
+	dst, err := rasterize(ivgData, size, size, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	vb := m.ViewBox
+	dx, dy := vb.AspectRatio()
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			gx := vb.Min[0] + dx*(float32(x)+0.5)/size
+			gy := vb.Min[1] + dy*(float32(y)+0.5)/size
+			got := coverage(gx, gy)
+			switch a := dst.RGBAAt(x, y).A; {
+			case a == 0x00 && got != 0:
+				t.Errorf("(%d, %d): transparent pixel: got coverage %v, want 0", x, y, got)
+			case a == 0xff && got != 1:
+				t.Errorf("(%d, %d): opaque pixel: got coverage %v, want 1", x, y, got)
+			}
+		}
+	}
+}
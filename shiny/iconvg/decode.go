@@ -7,10 +7,14 @@ package iconvg
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"image/color"
+	"time"
+	"unicode/utf8"
 )
 
 var (
+	errDeadlineExceeded                = errors.New("iconvg: deadline exceeded")
 	errInconsistentMetadataChunkLength = errors.New("iconvg: inconsistent metadata chunk length")
 	errInvalidColor                    = errors.New("iconvg: invalid color")
 	errInvalidMagicIdentifier          = errors.New("iconvg: invalid magic identifier")
@@ -18,11 +22,16 @@ var (
 	errInvalidMetadataIdentifier       = errors.New("iconvg: invalid metadata identifier")
 	errInvalidNumber                   = errors.New("iconvg: invalid number")
 	errInvalidNumberOfMetadataChunks   = errors.New("iconvg: invalid number of metadata chunks")
+	errInvalidPaletteOverride          = errors.New("iconvg: invalid palette override")
 	errInvalidSuggestedPalette         = errors.New("iconvg: invalid suggested palette")
 	errInvalidViewBox                  = errors.New("iconvg: invalid view box")
-	errUnsupportedDrawingOpcode        = errors.New("iconvg: unsupported drawing opcode")
-	errUnsupportedMetadataIdentifier   = errors.New("iconvg: unsupported metadata identifier")
+	errReservedDrawingOpcode           = errors.New("iconvg: reserved drawing opcode")
+	errSmoothCurveChain                = errors.New("iconvg: smooth curve does not follow a matching curve")
+	errTooManyFlattenedSegments        = errors.New("iconvg: too many flattened segments")
+	errTooManyOpcodes                  = errors.New("iconvg: too many opcodes")
+	errUnclosedPath                    = errors.New("iconvg: unclosed path")
 	errUnsupportedStylingOpcode        = errors.New("iconvg: unsupported styling opcode")
+	errViewBoxTooLarge                 = errors.New("iconvg: view box too large")
 )
 
 var midDescriptions = [...]string{
@@ -67,13 +76,191 @@ type Destination interface {
 	RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32)
 }
 
-type printer func(b []byte, format string, args ...interface{})
+// printer is called for each part of an IconVG graphic's encoded form, in
+// order, when disassembling or tracing.
+type printer func(b []byte, k TraceEventKind, format string, args ...interface{})
 
 // DecodeOptions are the optional parameters to the Decode function.
 type DecodeOptions struct {
 	// Palette is an optional 64 color palette. If one isn't provided, the
 	// IconVG graphic's suggested palette will be used.
 	Palette *Palette
+
+	// Deadline is an optional time after which decoding is aborted, returning
+	// an error. The zero value means no deadline. It is checked periodically,
+	// not after every opcode, so decoding can run slightly past it.
+	Deadline time.Time
+
+	// Trace is an optional callback that is passed a TraceEvent for each part
+	// of the encoded form, in order, as it is decoded.
+	Trace func(ev TraceEvent)
+
+	// PaletteOverrides optionally replaces individual palette entries, keyed
+	// by their index in [0, 64). They apply on top of whichever palette is
+	// otherwise in effect: Palette if it is non-nil, or else the IconVG
+	// graphic's suggested palette. The colors must be valid
+	// alpha-premultiplied colors.
+	PaletteOverrides map[int]color.RGBA
+
+	// AutoClosePaths is whether to accept an IconVG graphic that ends in the
+	// middle of a path, as produced by some lenient encoders. If so, the path
+	// is closed and ended, as if by a final ClosePathEndPath opcode. If not,
+	// such a graphic is rejected with an error.
+	AutoClosePaths bool
+
+	// NormalizeWinding is whether, and how, to reverse sub-paths so that
+	// outer contours wind in one direction and holes in the other, as some
+	// font formats and tessellators require. If it is not WindingAsIs, each
+	// path is buffered until it ends, and then passed to the Destination with
+	// absolute drawing ops, and with arcs converted to cubic Bézier curves.
+	NormalizeWinding Winding
+
+	// NormalizeToUnitSquare is whether to scale and translate the graphic so
+	// that its ViewBox fits, centered, in the unit square from (0, 0) to
+	// (1, 1), preserving its aspect ratio. The larger of the ViewBox's
+	// dimensions becomes 1. The Destination is Reset with the ViewBox in the
+	// normalized coordinate space, and arc radii and gradients are scaled
+	// accordingly. It has no effect if the ViewBox is empty.
+	NormalizeToUnitSquare bool
+
+	// AllowedOpcodes is an optional set of the opcodes that the IconVG
+	// graphic may use. If non-nil, decoding stops with an error, naming the
+	// opcode, at the first opcode that is not in the set. The check happens
+	// before the opcode is passed to the Destination.
+	AllowedOpcodes *OpcodeSet
+
+	// Profile is an optional callback that is passed a ProfileEvent for each
+	// opcode, after it is decoded, for profiling the decoder.
+	Profile func(ev ProfileEvent)
+
+	// Stats is an optional DecodeStats that, if non-nil, is reset and then
+	// filled in as the graphic is decoded. If decoding fails, it summarizes
+	// the opcodes decoded before the failure.
+	Stats *DecodeStats
+
+	// MergeToSilhouette is whether to merge all of the graphic's visible
+	// paths into a single path, whose filled region is the union of theirs,
+	// for making masks or drop shadows. The merged path is passed to the
+	// Destination after the rest of the graphic has been decoded.
+	//
+	// Each path's sub-paths are first made to wind as per
+	// WindingOuterClockwise, so that overlapping paths do not cancel out
+	// under the non-zero winding rule. The merged path is filled with the
+	// first visible path's flat color, or opaque black if that path is filled
+	// with a gradient. Paths with different level of detail ranges are not
+	// merged with each other, so there is one merged path per range.
+	MergeToSilhouette bool
+
+	// SkipBytes is the number of leading bytes, such as a container format's
+	// header, to skip before the magic identifier. The magic identifier must
+	// immediately follow them. Offsets, such as those in TraceEvents, are
+	// still relative to the start of the source bytes.
+	SkipBytes int
+
+	// ArcApproximator is an optional ArcApproximator that, if non-nil,
+	// converts each arc to cubic Bézier curves before it is passed to the
+	// Destination, so that the Destination never sees an arc. ArcTolerance
+	// is one such ArcApproximator. If nil, each Destination converts arcs as
+	// it sees fit, such as one cubic Bézier curve per quarter turn.
+	ArcApproximator ArcApproximator
+
+	// MaxViewBoxArea is the largest ViewBox area, width times height, that
+	// the IconVG graphic may declare, to guard against graphics that would
+	// need huge rasterization buffers. Decoding stops with an error as soon
+	// as a larger ViewBox is decoded. If zero, there is no limit. The default
+	// ViewBox, used if the graphic declares none, is not checked.
+	MaxViewBoxArea float32
+
+	// OnDeprecated is an optional callback that is called for each decoded
+	// opcode that is deprecated but still valid, with a suggestion of what
+	// to use instead, for linting IconVG graphics. It is called after the
+	// opcode is passed to the Destination. No opcodes are currently
+	// deprecated.
+	OnDeprecated func(opcode byte, suggestion string)
+
+	// MaxFlattenedSegments is the most line segments that the graphic's paths
+	// may be flattened to, to bound the memory and time that rasterizing an
+	// untrusted graphic takes. Curves are counted as if flattened to within
+	// 1/64th of a unit of graphic coordinate space, and every path is counted,
+	// whatever its level of detail. Decoding stops with an error as soon as
+	// the limit is exceeded. If zero, there is no limit.
+	MaxFlattenedSegments int
+
+	// ViewBoxOverride, if non-nil, replaces the graphic's ViewBox, so that
+	// the Destination maps that rectangle, instead of the graphic's own,
+	// onto its output. For example, overriding the default ViewBox with (-32,
+	// -32) - (0, 0) zooms into the top left quadrant. A Rasterizer clips the
+	// geometry outside of it. Like a ViewBox decoded from the graphic, the
+	// override must be finite, with Min no greater than Max.
+	ViewBoxOverride *Rectangle
+
+	// OnBrokenSmoothChain is an optional callback that is called for each
+	// smooth quadTo or smooth cubeTo opcode (T, t, S or s) that does not
+	// immediately follow a curve of the same kind in the same path, with the
+	// index of that path, counting from zero. Such a curve has no previous
+	// control point to reflect, so its implicit control point degenerates to
+	// the current point, which is often an authoring mistake. It is called
+	// before the opcode is passed to the Destination.
+	OnBrokenSmoothChain func(path int)
+
+	// StrictSmoothChains is whether a smooth curve that does not follow a
+	// curve of the same kind, as described for OnBrokenSmoothChain, is an
+	// error. If so, decoding stops, after calling OnBrokenSmoothChain, before
+	// the opcode is passed to the Destination.
+	StrictSmoothChains bool
+
+	// MaxOpcodes is the most styling and drawing opcodes, in total, that the
+	// graphic may have, to bound the work that decoding an untrusted graphic
+	// takes, however little or much geometry each opcode makes. An opcode's
+	// implicit repetitions count as one opcode. Decoding stops with an error,
+	// before the opcode over the limit is passed to the Destination. If zero,
+	// there is no limit.
+	MaxOpcodes int
+
+	// RecoverMetadata is whether to skip over a metadata chunk that cannot be
+	// decoded, such as one with an invalid ViewBox or suggested palette, and
+	// carry on with the rest of the graphic, to salvage partially corrupt
+	// graphics. The chunk's declared length says where it ends, so a chunk
+	// whose length itself is invalid, or runs past the end of the graphic,
+	// still stops decoding with an error, as does a ViewBox larger than
+	// MaxViewBoxArea. A skipped chunk leaves the Metadata as it was before
+	// the chunk, so that, for example, the ViewBox stays at its default.
+	RecoverMetadata bool
+
+	// OnSkippedMetadataChunk is an optional callback that is called for each
+	// metadata chunk skipped because of RecoverMetadata, with the chunk's
+	// index, counting from 0, and the error that decoding it returned.
+	OnSkippedMetadataChunk func(chunk int, err error)
+
+	// Background is an optional color that EncodePalettedPNG composites the
+	// graphic over, instead of transparent black. Decode itself ignores it:
+	// to composite a Rasterizer's output over a background, use the
+	// Rasterizer's SetBackground method.
+	Background color.Color
+}
+
+// deprecatedStylingOpcodes and deprecatedDrawingOpcodes hold, for each
+// deprecated opcode, a suggestion of what to use instead. A non-deprecated
+// opcode's suggestion is empty.
+var (
+	deprecatedStylingOpcodes [256]string
+	deprecatedDrawingOpcodes [256]string
+)
+
+// OpcodeSet is a set of opcodes. Styling and drawing opcodes are listed
+// separately, as the same byte value means different things in the two
+// modes. For example, Styling[0xc7] is the opcode that sets the level of
+// detail, and Drawing[0xe1] is the opcode that ends a path.
+type OpcodeSet struct {
+	Styling [256]bool
+	Drawing [256]bool
+}
+
+// validViewBox returns whether r is finite, with Min no greater than Max.
+func validViewBox(r Rectangle) bool {
+	return r.Min[0] <= r.Max[0] && r.Min[1] <= r.Max[1] &&
+		!isNaNOrInfinity(r.Min[0]) && !isNaNOrInfinity(r.Min[1]) &&
+		!isNaNOrInfinity(r.Max[0]) && !isNaNOrInfinity(r.Max[1])
 }
 
 // DecodeMetadata decodes only the metadata in an IconVG graphic.
@@ -86,6 +273,67 @@ func DecodeMetadata(src []byte) (m Metadata, err error) {
 	return m, nil
 }
 
+// decodeMetadata decodes only the metadata in an IconVG graphic, as Decode
+// sees it with the given options: after skipping opts.SkipBytes, recovering
+// from bad chunks if opts.RecoverMetadata, and with opts' Palette,
+// PaletteOverrides and ViewBoxOverride applied. opts' Trace, Stats and
+// OnSkippedMetadataChunk are ignored, so that they only see the later call to
+// Decode.
+func decodeMetadata(src []byte, opts *DecodeOptions) (m Metadata, err error) {
+	m.ViewBox = DefaultViewBox
+	m.Palette = DefaultPalette
+	if opts != nil {
+		if opts.Palette != nil {
+			m.Palette = *opts.Palette
+		}
+		o := *opts
+		o.Trace, o.Stats, o.OnSkippedMetadataChunk = nil, nil, nil
+		opts = &o
+	}
+	if err = decode(nil, nil, &m, true, src, opts); err != nil {
+		return Metadata{}, err
+	}
+	return m, nil
+}
+
+// DecodeMetadataChunks returns the encoded metadata chunks in an IconVG
+// graphic, in order, including those whose MIDs (Metadata Identifiers) this
+// package does not know. Each chunk's data is returned as is, without being
+// decoded or validated.
+func DecodeMetadataChunks(src []byte) ([]MetadataChunk, error) {
+	b := buffer(src)
+	if !bytes.HasPrefix(b, magicBytes) {
+		return nil, errInvalidMagicIdentifier
+	}
+	b = b[len(magic):]
+
+	nMetadataChunks, n := b.decodeNatural()
+	if n == 0 {
+		return nil, errInvalidNumberOfMetadataChunks
+	}
+	b = b[n:]
+
+	var chunks []MetadataChunk
+	for i := uint32(0); i < nMetadataChunks; i++ {
+		length, n := b.decodeNatural()
+		if n == 0 || int64(len(b)-n) < int64(length) {
+			return nil, errInvalidMetadataChunkLength
+		}
+		chunk := b[n : n+int(length)]
+		b = b[n+int(length):]
+
+		mid, n := chunk.decodeNatural()
+		if n == 0 {
+			return nil, errInvalidMetadataIdentifier
+		}
+		chunks = append(chunks, MetadataChunk{
+			MID:  mid,
+			Data: append([]byte(nil), chunk[n:]...),
+		})
+	}
+	return chunks, nil
+}
+
 // Decode decodes an IconVG graphic.
 func Decode(dst Destination, src []byte, opts *DecodeOptions) error {
 	m := Metadata{
@@ -99,53 +347,298 @@ func Decode(dst Destination, src []byte, opts *DecodeOptions) error {
 }
 
 func decode(dst Destination, p printer, m *Metadata, metadataOnly bool, src buffer, opts *DecodeOptions) (err error) {
-	if !bytes.HasPrefix(src, magicBytes) {
-		return errInvalidMagicIdentifier
+	if p == nil && opts != nil && opts.Trace != nil {
+		p = tracePrinter(src, opts.Trace)
 	}
-	if p != nil {
-		p(src[:len(magic)], "IconVG Magic identifier\n")
+	var stats *DecodeStats
+	if opts != nil && opts.Stats != nil {
+		stats = opts.Stats
+		*stats = DecodeStats{}
 	}
-	src = src[len(magic):]
-
-	nMetadataChunks, n := src.decodeNatural()
-	if n == 0 {
-		return errInvalidNumberOfMetadataChunks
-	}
-	if p != nil {
-		p(src[:n], "Number of metadata chunks: %d\n", nMetadataChunks)
+	srcLen := len(src)
+	src, err = decodeHeader(p, m, src, opts)
+	if err != nil {
+		return err
 	}
-	src = src[n:]
-
-	for ; nMetadataChunks > 0; nMetadataChunks-- {
-		src, err = decodeMetadataChunk(p, m, src, opts)
-		if err != nil {
-			return err
+	if opts != nil && opts.ViewBoxOverride != nil {
+		if !validViewBox(*opts.ViewBoxOverride) {
+			return errInvalidViewBox
 		}
+		m.ViewBox = *opts.ViewBoxOverride
+	}
+	if stats != nil {
+		stats.Bytes = srcLen - len(src)
 	}
 	if metadataOnly {
 		return nil
 	}
 	if dst != nil {
+		if opts != nil && opts.NormalizeWinding != WindingAsIs {
+			dst = &pathRewriter{
+				inner:   dst,
+				rewrite: windingRewriter(opts.NormalizeWinding),
+			}
+		}
+		if opts != nil && opts.NormalizeToUnitSquare {
+			dst = unitSquareDestination(dst, m.ViewBox)
+		}
+		if opts != nil && opts.MergeToSilhouette {
+			dst = &silhouetteDestination{inner: dst}
+		}
+		if opts != nil && opts.MaxFlattenedSegments > 0 {
+			dst = &teeDestination{
+				d0: dst,
+				d1: &flattenLimiter{max: opts.MaxFlattenedSegments},
+			}
+		}
+		if opts != nil && opts.ArcApproximator != nil {
+			dst = newArcDestination(dst, opts.ArcApproximator)
+		}
 		dst.Reset(*m)
 	}
 
+	var deadline time.Time
+	var allowed *OpcodeSet
+	var profile func(ProfileEvent)
+	var onDeprecated func(byte, string)
+	var onBrokenSmoothChain func(int)
+	strictSmoothChains := false
+	maxOpcodes := 0
+	if opts != nil {
+		deadline = opts.Deadline
+		allowed = opts.AllowedOpcodes
+		profile = opts.Profile
+		onDeprecated = opts.OnDeprecated
+		onBrokenSmoothChain = opts.OnBrokenSmoothChain
+		strictSmoothChains = opts.StrictSmoothChains
+		maxOpcodes = opts.MaxOpcodes
+	}
+	checkSmoothChains := onBrokenSmoothChain != nil || strictSmoothChains
+	// nPaths is the number of paths started so far, and prevSmoothType is the
+	// kind of curve, if any, drawn by the current path's previous op.
+	nPaths, prevSmoothType := 0, uint8(smoothTypeNone)
+
+	a, _ := dst.(aborter)
 	mf := modeFunc(decodeStyling)
-	for len(src) > 0 {
+	drawing := false
+	for i := 0; len(src) > 0; i++ {
+		// Checking the clock is relatively expensive, so only do so every 256
+		// opcodes.
+		if i&0xff == 0 && !deadline.IsZero() && !time.Now().Before(deadline) {
+			return errDeadlineExceeded
+		}
+		if maxOpcodes > 0 && i >= maxOpcodes {
+			return errTooManyOpcodes
+		}
+		opcode := src[0]
+		if allowed != nil {
+			if drawing && !allowed.Drawing[opcode] {
+				return fmt.Errorf("iconvg: disallowed drawing opcode %#02x", opcode)
+			} else if !drawing && !allowed.Styling[opcode] {
+				return fmt.Errorf("iconvg: disallowed styling opcode %#02x", opcode)
+			}
+		}
+		if drawing && checkSmoothChains {
+			this, want := drawingSmoothTypes(opcode)
+			if want != smoothTypeNone && want != prevSmoothType {
+				if onBrokenSmoothChain != nil {
+					onBrokenSmoothChain(nPaths - 1)
+				}
+				if strictSmoothChains {
+					return errSmoothCurveChain
+				}
+			}
+			prevSmoothType = this
+		}
+		var start time.Time
+		if profile != nil {
+			start = time.Now()
+		}
+		n := len(src)
 		mf, src, err = mf(dst, p, src)
 		if err != nil {
 			return err
 		}
+		if profile != nil {
+			profile(ProfileEvent{
+				Opcode:   opcode,
+				Drawing:  drawing,
+				Duration: time.Since(start),
+			})
+		}
+		if onDeprecated != nil {
+			suggestion := deprecatedStylingOpcodes[opcode]
+			if drawing {
+				suggestion = deprecatedDrawingOpcodes[opcode]
+			}
+			if suggestion != "" {
+				onDeprecated(opcode, suggestion)
+			}
+		}
+		if stats != nil {
+			stats.Opcodes++
+			stats.Bytes = srcLen - len(src)
+			if drawing && opcode < 0xe0 {
+				nReps, arc := drawingReps(opcode)
+				if stats.MaxReps < nReps {
+					stats.MaxReps = nReps
+				}
+				if arc {
+					stats.Arcs += nReps
+				}
+			}
+		}
+		if a != nil {
+			if err := a.abortErr(); err != nil {
+				return err
+			}
+		}
+		// Track whether we are in the middle of a path, which is started by
+		// the 0xc0 to 0xc6 styling opcodes and ended by the 0xe1 drawing
+		// opcode.
+		ended := false
+		if !drawing {
+			drawing = 0xc0 <= opcode && opcode < 0xc7
+			if drawing {
+				nPaths++
+				prevSmoothType = smoothTypeNone
+			}
+		} else if opcode == 0xe1 {
+			drawing, ended = false, true
+		}
+		if stats != nil {
+			if drawing || ended {
+				stats.TrailingBytes = 0
+			} else {
+				stats.TrailingBytes += n - len(src)
+			}
+		}
+	}
+
+	if drawing {
+		if opts == nil || !opts.AutoClosePaths {
+			return errUnclosedPath
+		}
+		if dst != nil {
+			if c, ok := dst.(autoCloser); ok {
+				c.autoClosePath()
+			}
+			dst.ClosePathEndPath()
+		}
+		if a != nil {
+			if err := a.abortErr(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if f, ok := dst.(finisher); ok {
+		f.finish()
+		if a != nil {
+			return a.abortErr()
+		}
 	}
 	return nil
 }
 
+// drawingSmoothTypes returns the kind of curve, if any, that a drawing opcode
+// draws and, for a smooth quadTo or cubeTo opcode, the kind of curve that
+// must precede it for its implicit control point to be a reflection. Only
+// the opcode's first repetition can break the chain, as its other
+// repetitions follow a curve of the same kind.
+func drawingSmoothTypes(opcode byte) (this, want uint8) {
+	if opcode >= 0xe0 {
+		return smoothTypeNone, smoothTypeNone
+	}
+	switch opcode >> 4 {
+	case 0x04, 0x05:
+		return smoothTypeQuad, smoothTypeQuad
+	case 0x06, 0x07:
+		return smoothTypeQuad, smoothTypeNone
+	case 0x08, 0x09:
+		return smoothTypeCube, smoothTypeCube
+	case 0x0a, 0x0b:
+		return smoothTypeCube, smoothTypeNone
+	}
+	return smoothTypeNone, smoothTypeNone
+}
+
+// decodeHeader decodes the magic identifier and metadata, returning the
+// remaining source bytes: the styling and drawing opcodes.
+func decodeHeader(p printer, m *Metadata, src buffer, opts *DecodeOptions) (src1 buffer, err error) {
+	if opts != nil && opts.SkipBytes != 0 {
+		if opts.SkipBytes < 0 || len(src) < opts.SkipBytes {
+			return nil, errInvalidMagicIdentifier
+		}
+		src = src[opts.SkipBytes:]
+	}
+	if !bytes.HasPrefix(src, magicBytes) {
+		return nil, errInvalidMagicIdentifier
+	}
+	if p != nil {
+		p(src[:len(magic)], TraceMagic, "IconVG Magic identifier\n")
+	}
+	src = src[len(magic):]
+
+	nMetadataChunks, n := src.decodeNatural()
+	if n == 0 {
+		return nil, errInvalidNumberOfMetadataChunks
+	}
+	if p != nil {
+		p(src[:n], TraceMetadata, "Number of metadata chunks: %d\n", nMetadataChunks)
+	}
+	src = src[n:]
+
+	recoverMetadata := opts != nil && opts.RecoverMetadata
+	for i := uint32(0); i < nMetadataChunks; i++ {
+		var saved Metadata
+		if recoverMetadata {
+			saved = *m
+		}
+		src1, err := decodeMetadataChunk(p, m, src, opts)
+		if err != nil {
+			if !recoverMetadata || err == errViewBoxTooLarge {
+				return nil, err
+			}
+			if src1 = skipMetadataChunk(src); src1 == nil {
+				return nil, err
+			}
+			*m = saved
+			if opts.OnSkippedMetadataChunk != nil {
+				opts.OnSkippedMetadataChunk(int(i), err)
+			}
+		}
+		src = src1
+	}
+
+	if opts != nil {
+		for i, c := range opts.PaletteOverrides {
+			if i < 0 || len(m.Palette) <= i || !validAlphaPremulColor(c) {
+				return nil, errInvalidPaletteOverride
+			}
+			m.Palette[i] = c
+		}
+	}
+	return src, nil
+}
+
+// skipMetadataChunk returns the bytes after the metadata chunk at the start of
+// src, as per the chunk's declared length, or nil if that length is invalid.
+func skipMetadataChunk(src buffer) buffer {
+	length, n := src.decodeNatural()
+	if n == 0 || int64(len(src)-n) < int64(length) {
+		return nil
+	}
+	return src[n+int(length):]
+}
+
 func decodeMetadataChunk(p printer, m *Metadata, src buffer, opts *DecodeOptions) (src1 buffer, err error) {
 	length, n := src.decodeNatural()
 	if n == 0 {
 		return nil, errInvalidMetadataChunkLength
 	}
 	if p != nil {
-		p(src[:n], "Metadata chunk length: %d\n", length)
+		p(src[:n], TraceMetadataChunk, "Metadata chunk length: %d\n", length)
 	}
 	src = src[n:]
 	lenSrcWant := int64(len(src)) - int64(length)
@@ -154,35 +647,47 @@ func decodeMetadataChunk(p printer, m *Metadata, src buffer, opts *DecodeOptions
 	if n == 0 {
 		return nil, errInvalidMetadataIdentifier
 	}
-	if mid >= uint32(len(midDescriptions)) {
-		return nil, errUnsupportedMetadataIdentifier
-	}
 	if p != nil {
-		p(src[:n], "Metadata Identifier: %d (%s)\n", mid, midDescriptions[mid])
+		desc := "unknown"
+		if mid < uint32(len(midDescriptions)) {
+			desc = midDescriptions[mid]
+		} else if mid == midComment {
+			desc = "comment"
+		}
+		p(src[:n], TraceMetadata, "Metadata Identifier: %d (%s)\n", mid, desc)
 	}
 	src = src[n:]
 
 	switch mid {
 	case midViewBox:
-		if m.ViewBox.Min[0], src, err = decodeNumber(p, src, buffer.decodeCoordinate); err != nil {
+		if m.ViewBox.Min[0], src, err = decodeCoordinate(p, src); err != nil {
 			return nil, errInvalidViewBox
 		}
-		if m.ViewBox.Min[1], src, err = decodeNumber(p, src, buffer.decodeCoordinate); err != nil {
+		if m.ViewBox.Min[1], src, err = decodeCoordinate(p, src); err != nil {
 			return nil, errInvalidViewBox
 		}
-		if m.ViewBox.Max[0], src, err = decodeNumber(p, src, buffer.decodeCoordinate); err != nil {
+		if m.ViewBox.Max[0], src, err = decodeCoordinate(p, src); err != nil {
 			return nil, errInvalidViewBox
 		}
-		if m.ViewBox.Max[1], src, err = decodeNumber(p, src, buffer.decodeCoordinate); err != nil {
+		if m.ViewBox.Max[1], src, err = decodeCoordinate(p, src); err != nil {
 			return nil, errInvalidViewBox
 		}
-		if m.ViewBox.Min[0] > m.ViewBox.Max[0] || m.ViewBox.Min[1] > m.ViewBox.Max[1] ||
-			isNaNOrInfinity(m.ViewBox.Min[0]) || isNaNOrInfinity(m.ViewBox.Min[1]) ||
-			isNaNOrInfinity(m.ViewBox.Max[0]) || isNaNOrInfinity(m.ViewBox.Max[1]) {
+		if !validViewBox(m.ViewBox) {
 			return nil, errInvalidViewBox
 		}
+		if opts != nil && opts.MaxViewBoxArea > 0 {
+			w := float64(m.ViewBox.Max[0]) - float64(m.ViewBox.Min[0])
+			h := float64(m.ViewBox.Max[1]) - float64(m.ViewBox.Min[1])
+			if w*h > float64(opts.MaxViewBoxArea) {
+				return nil, errViewBoxTooLarge
+			}
+		}
 
 	case midSuggestedPalette:
+		// The format does not allow a suggested palette to span multiple
+		// chunks: MIDs cannot be repeated, and each chunk's explicit colors
+		// start at index 0. The implicit colors, beyond the explicit ones,
+		// are left as they were initialized, to DefaultPalette.
 		if len(src) == 0 {
 			return nil, errInvalidSuggestedPalette
 		}
@@ -197,7 +702,7 @@ func decodeMetadataChunk(p printer, m *Metadata, src buffer, opts *DecodeOptions
 			decode = buffer.decodeColor3Direct
 		}
 		if p != nil {
-			p(src[:1], "    %d palette colors, %d bytes per color\n", length, 1+format)
+			p(src[:1], TraceOperand, "    %d palette colors, %d bytes per color\n", length, 1+format)
 		}
 		src = src[1:]
 
@@ -211,7 +716,7 @@ func decodeMetadataChunk(p printer, m *Metadata, src buffer, opts *DecodeOptions
 				rgba = color.RGBA{0x00, 0x00, 0x00, 0xff}
 			}
 			if p != nil {
-				p(src[:n], "    RGBA %02x%02x%02x%02x\n", rgba.R, rgba.G, rgba.B, rgba.A)
+				p(src[:n], TraceOperand, "    RGBA %02x%02x%02x%02x\n", rgba.R, rgba.G, rgba.B, rgba.A)
 			}
 			src = src[n:]
 			if opts == nil || opts.Palette == nil {
@@ -220,7 +725,27 @@ func decodeMetadataChunk(p printer, m *Metadata, src buffer, opts *DecodeOptions
 		}
 
 	default:
-		return nil, errUnsupportedMetadataIdentifier
+		// The chunk's data is the rest of the chunk. A comment that is not
+		// valid UTF-8, or a chunk with an unknown MID, is skipped rather than
+		// rejected. DecodeMetadataChunks returns its data.
+		if lenSrcWant < 0 || int64(len(src)) < lenSrcWant {
+			return nil, errInconsistentMetadataChunkLength
+		}
+		n := int(int64(len(src)) - lenSrcWant)
+		verb := "    % x\n"
+		if mid == midComment && utf8.Valid(src[:n]) {
+			m.Comment = string(src[:n])
+			verb = "    %q\n"
+		}
+		// Trace the data 4 bytes at a time, like other operands.
+		for i := 0; p != nil && i < n; i += 4 {
+			j := i + 4
+			if j > n {
+				j = n
+			}
+			p(src[i:j], TraceOperand, verb, src[i:j])
+		}
+		src = src[n:]
 	}
 
 	if int64(len(src)) != lenSrcWant {
@@ -243,7 +768,7 @@ func decodeStyling(dst Destination, p printer, src buffer) (modeFunc, buffer, er
 		if opcode < 0x40 {
 			opcode &= 0x3f
 			if p != nil {
-				p(src[:1], "Set CSEL = %d\n", opcode)
+				p(src[:1], TraceOpcode, "Set CSEL = %d\n", opcode)
 			}
 			src = src[1:]
 			if dst != nil {
@@ -252,7 +777,7 @@ func decodeStyling(dst Destination, p printer, src buffer) (modeFunc, buffer, er
 		} else {
 			opcode &= 0x3f
 			if p != nil {
-				p(src[:1], "Set NSEL = %d\n", opcode)
+				p(src[:1], TraceOpcode, "Set NSEL = %d\n", opcode)
 			}
 			src = src[1:]
 			if dst != nil {
@@ -269,6 +794,9 @@ func decodeStyling(dst Destination, p printer, src buffer) (modeFunc, buffer, er
 	case opcode == 0xc7:
 		return decodeSetLOD(dst, p, src)
 	}
+	// The remaining opcodes, 0xc8 to 0xff, are reserved by the
+	// specification. In particular, IconVG has no opcodes for groups of
+	// paths: each path is composited onto the destination by itself.
 	return nil, nil, errUnsupportedStylingOpcode
 }
 
@@ -294,9 +822,9 @@ func decodeSetCReg(dst Destination, p printer, src buffer, opcode byte) (modeFun
 	}
 	if p != nil {
 		if incr {
-			p(src[:1], "Set CREG[CSEL-0] to a %d byte%s color; CSEL++\n", nBytes, directness)
+			p(src[:1], TraceOpcode, "Set CREG[CSEL-0] to a %d byte%s color; CSEL++\n", nBytes, directness)
 		} else {
-			p(src[:1], "Set CREG[CSEL-%d] to a %d byte%s color\n", adj, nBytes, directness)
+			p(src[:1], TraceOpcode, "Set CREG[CSEL-%d] to a %d byte%s color\n", adj, nBytes, directness)
 		}
 	}
 	src = src[1:]
@@ -322,9 +850,9 @@ func printColor(src []byte, p printer, c Color, prefix string) {
 	switch c.typ {
 	case ColorTypeRGBA:
 		if rgba := c.rgba(); validAlphaPremulColor(rgba) {
-			p(src, "    %sRGBA %02x%02x%02x%02x\n", prefix, rgba.R, rgba.G, rgba.B, rgba.A)
+			p(src, TraceOperand, "    %sRGBA %02x%02x%02x%02x\n", prefix, rgba.R, rgba.G, rgba.B, rgba.A)
 		} else if rgba.A == 0 && rgba.B&0x80 != 0 {
-			p(src, "    %sgradient (NSTOPS=%d, CBASE=%d, NBASE=%d, %s, %s)\n",
+			p(src, TraceOperand, "    %sgradient (NSTOPS=%d, CBASE=%d, NBASE=%d, %s, %s)\n",
 				prefix,
 				rgba.R&0x3f,
 				rgba.G&0x3f,
@@ -333,22 +861,22 @@ func printColor(src []byte, p printer, c Color, prefix string) {
 				gradientSpreadNames[rgba.G>>6],
 			)
 		} else {
-			p(src, "    %snonsensical color\n", prefix)
+			p(src, TraceOperand, "    %snonsensical color\n", prefix)
 		}
 	case ColorTypePaletteIndex:
-		p(src, "    %scustomPalette[%d]\n", prefix, c.paletteIndex())
+		p(src, TraceOperand, "    %scustomPalette[%d]\n", prefix, c.paletteIndex())
 	case ColorTypeCReg:
-		p(src, "    %sCREG[%d]\n", prefix, c.cReg())
+		p(src, TraceOperand, "    %sCREG[%d]\n", prefix, c.cReg())
 	case ColorTypeBlend:
 		t, c0, c1 := c.blend()
-		p(src[:1], "    blend %d:%d c0:c1\n", 0xff-t, t)
+		p(src[:1], TraceOperand, "    blend %d:%d c0:c1\n", 0xff-t, t)
 		printColor(src[1:2], p, decodeColor1(c0), "    c0: ")
 		printColor(src[2:3], p, decodeColor1(c1), "    c1: ")
 	}
 }
 
 func decodeSetNReg(dst Destination, p printer, src buffer, opcode byte) (modeFunc, buffer, error) {
-	decode, typ, adj := buffer.decodeZeroToOne, "zero-to-one", opcode&0x07
+	decode, typ, k, adj := buffer.decodeZeroToOne, "zero-to-one", TraceOperand, opcode&0x07
 	incr := adj == 7
 	if incr {
 		adj = 0
@@ -358,13 +886,13 @@ func decodeSetNReg(dst Destination, p printer, src buffer, opcode byte) (modeFun
 	case 0:
 		decode, typ = buffer.decodeReal, "real"
 	case 1:
-		decode, typ = buffer.decodeCoordinate, "coordinate"
+		decode, typ, k = buffer.decodeCoordinate, "coordinate", TraceCoordinate
 	}
 	if p != nil {
 		if incr {
-			p(src[:1], "Set NREG[NSEL-0] to a %s number; NSEL++\n", typ)
+			p(src[:1], TraceOpcode, "Set NREG[NSEL-0] to a %s number; NSEL++\n", typ)
 		} else {
-			p(src[:1], "Set NREG[NSEL-%d] to a %s number\n", adj, typ)
+			p(src[:1], TraceOpcode, "Set NREG[NSEL-%d] to a %s number\n", adj, typ)
 		}
 	}
 	src = src[1:]
@@ -374,7 +902,7 @@ func decodeSetNReg(dst Destination, p printer, src buffer, opcode byte) (modeFun
 		return nil, nil, errInvalidNumber
 	}
 	if p != nil {
-		p(src[:n], "    %g\n", f)
+		p(src[:n], k, "    %g\n", f)
 	}
 	src = src[n:]
 
@@ -388,15 +916,15 @@ func decodeSetNReg(dst Destination, p printer, src buffer, opcode byte) (modeFun
 func decodeStartPath(dst Destination, p printer, src buffer, opcode byte) (modeFunc, buffer, error) {
 	adj := opcode & 0x07
 	if p != nil {
-		p(src[:1], "Start path, filled with CREG[CSEL-%d]; M (absolute moveTo)\n", adj)
+		p(src[:1], TracePathStart, "Start path, filled with CREG[CSEL-%d]; M (absolute moveTo)\n", adj)
 	}
 	src = src[1:]
 
-	x, src, err := decodeNumber(p, src, buffer.decodeCoordinate)
+	x, src, err := decodeCoordinate(p, src)
 	if err != nil {
 		return nil, nil, err
 	}
-	y, src, err := decodeNumber(p, src, buffer.decodeCoordinate)
+	y, src, err := decodeCoordinate(p, src)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -410,7 +938,7 @@ func decodeStartPath(dst Destination, p printer, src buffer, opcode byte) (modeF
 
 func decodeSetLOD(dst Destination, p printer, src buffer) (modeFunc, buffer, error) {
 	if p != nil {
-		p(src[:1], "Set LOD\n")
+		p(src[:1], TraceOpcode, "Set LOD\n")
 	}
 	src = src[1:]
 
@@ -429,6 +957,19 @@ func decodeSetLOD(dst Destination, p printer, src buffer) (modeFunc, buffer, err
 	return decodeStyling, src, nil
 }
 
+// drawingReps returns the number of times that a drawing opcode less than
+// 0xe0 is implicitly repeated, including the first time, and whether it is an
+// arcTo opcode. It matches decodeDrawing.
+func drawingReps(opcode byte) (nReps int, arc bool) {
+	switch opcode >> 4 {
+	case 0x00, 0x01, 0x02, 0x03:
+		return 1 + int(opcode&0x1f), false
+	case 0x0c, 0x0d:
+		return 1 + int(opcode&0x0f), true
+	}
+	return 1 + int(opcode&0x0f), false
+}
+
 func decodeDrawing(dst Destination, p printer, src buffer) (mf modeFunc, src1 buffer, err error) {
 	var coords [6]float32
 
@@ -477,13 +1018,13 @@ func decodeDrawing(dst Destination, p printer, src buffer) (mf modeFunc, src1 bu
 		}
 
 		if p != nil {
-			p(src[:1], "%s, %d reps\n", op, nReps)
+			p(src[:1], TraceOpcode, "%s, %d reps\n", op, nReps)
 		}
 		src = src[1:]
 
 		for i := 0; i < nReps; i++ {
 			if p != nil && i != 0 {
-				p(nil, "%s, implicit\n", op)
+				p(src[:0], TraceOpcode, "%s, implicit\n", op)
 			}
 			var largeArc, sweep bool
 			if op[0] != 'A' && op[0] != 'a' {
@@ -544,7 +1085,7 @@ func decodeDrawing(dst Destination, p printer, src buffer) (mf modeFunc, src1 bu
 
 	case opcode == 0xe1:
 		if p != nil {
-			p(src[:1], "z (closePath); end path\n")
+			p(src[:1], TracePathEnd, "z (closePath); end path\n")
 		}
 		src = src[1:]
 		if dst != nil {
@@ -554,7 +1095,7 @@ func decodeDrawing(dst Destination, p printer, src buffer) (mf modeFunc, src1 bu
 
 	case opcode == 0xe2:
 		if p != nil {
-			p(src[:1], "z (closePath); M (absolute moveTo)\n")
+			p(src[:1], TraceOpcode, "z (closePath); M (absolute moveTo)\n")
 		}
 		src = src[1:]
 		src, err = decodeCoordinates(coords[:2], p, src)
@@ -567,7 +1108,7 @@ func decodeDrawing(dst Destination, p printer, src buffer) (mf modeFunc, src1 bu
 
 	case opcode == 0xe3:
 		if p != nil {
-			p(src[:1], "z (closePath); m (relative moveTo)\n")
+			p(src[:1], TraceOpcode, "z (closePath); m (relative moveTo)\n")
 		}
 		src = src[1:]
 		src, err = decodeCoordinates(coords[:2], p, src)
@@ -580,7 +1121,7 @@ func decodeDrawing(dst Destination, p printer, src buffer) (mf modeFunc, src1 bu
 
 	case opcode == 0xe6:
 		if p != nil {
-			p(src[:1], "H (absolute horizontal lineTo)\n")
+			p(src[:1], TraceOpcode, "H (absolute horizontal lineTo)\n")
 		}
 		src = src[1:]
 		src, err = decodeCoordinates(coords[:1], p, src)
@@ -593,7 +1134,7 @@ func decodeDrawing(dst Destination, p printer, src buffer) (mf modeFunc, src1 bu
 
 	case opcode == 0xe7:
 		if p != nil {
-			p(src[:1], "h (relative horizontal lineTo)\n")
+			p(src[:1], TraceOpcode, "h (relative horizontal lineTo)\n")
 		}
 		src = src[1:]
 		src, err = decodeCoordinates(coords[:1], p, src)
@@ -606,7 +1147,7 @@ func decodeDrawing(dst Destination, p printer, src buffer) (mf modeFunc, src1 bu
 
 	case opcode == 0xe8:
 		if p != nil {
-			p(src[:1], "V (absolute vertical lineTo)\n")
+			p(src[:1], TraceOpcode, "V (absolute vertical lineTo)\n")
 		}
 		src = src[1:]
 		src, err = decodeCoordinates(coords[:1], p, src)
@@ -619,7 +1160,7 @@ func decodeDrawing(dst Destination, p printer, src buffer) (mf modeFunc, src1 bu
 
 	case opcode == 0xe9:
 		if p != nil {
-			p(src[:1], "v (relative vertical lineTo)\n")
+			p(src[:1], TraceOpcode, "v (relative vertical lineTo)\n")
 		}
 		src = src[1:]
 		src, err = decodeCoordinates(coords[:1], p, src)
@@ -631,7 +1172,10 @@ func decodeDrawing(dst Destination, p printer, src buffer) (mf modeFunc, src1 bu
 		}
 
 	default:
-		return nil, nil, errUnsupportedDrawingOpcode
+		// The remaining opcodes, 0xe0, 0xe4, 0xe5 and 0xea to 0xff, are
+		// reserved by the specification. A future version of IconVG may use
+		// 0xe0, 0xe4 and 0xe5 for paths that are stroked instead of filled.
+		return nil, nil, errReservedDrawingOpcode
 	}
 	return decodeDrawing, src, nil
 }
@@ -644,14 +1188,25 @@ func decodeNumber(p printer, src buffer, dnf decodeNumberFunc) (float32, buffer,
 		return 0, nil, errInvalidNumber
 	}
 	if p != nil {
-		p(src[:n], "    %+g\n", x)
+		p(src[:n], TraceOperand, "    %+g\n", x)
+	}
+	return x, src[n:], nil
+}
+
+func decodeCoordinate(p printer, src buffer) (float32, buffer, error) {
+	x, n := src.decodeCoordinate()
+	if n == 0 {
+		return 0, nil, errInvalidNumber
+	}
+	if p != nil {
+		p(src[:n], TraceCoordinate, "    %+g\n", x)
 	}
 	return x, src[n:], nil
 }
 
 func decodeCoordinates(coords []float32, p printer, src buffer) (src1 buffer, err error) {
 	for i := range coords {
-		coords[i], src, err = decodeNumber(p, src, buffer.decodeCoordinate)
+		coords[i], src, err = decodeCoordinate(p, src)
 		if err != nil {
 			return nil, err
 		}
@@ -665,18 +1220,21 @@ func decodeAngle(p printer, src buffer) (float32, buffer, error) {
 		return 0, nil, errInvalidNumber
 	}
 	if p != nil {
-		p(src[:n], "    %v × 360 degrees (%v degrees)\n", x, x*360)
+		p(src[:n], TraceOperand, "    %v × 360 degrees (%v degrees)\n", x, x*360)
 	}
 	return x, src[n:], nil
 }
 
+// decodeArcToFlags decodes an arc's large-arc-flag and sweep-flag. The IconVG
+// format is not versioned and has only one layout for these: a single natural
+// number whose 0x01 and 0x02 bits are the two flags. Other bits are ignored.
 func decodeArcToFlags(p printer, src buffer) (bool, bool, buffer, error) {
 	x, n := src.decodeNatural()
 	if n == 0 {
 		return false, false, nil, errInvalidNumber
 	}
 	if p != nil {
-		p(src[:n], "    %#x (largeArc=%d, sweep=%d)\n", x, (x>>0)&0x01, (x>>1)&0x01)
+		p(src[:n], TraceOperand, "    %#x (largeArc=%d, sweep=%d)\n", x, (x>>0)&0x01, (x>>1)&0x01)
 	}
 	return (x>>0)&0x01 != 0, (x>>1)&0x01 != 0, src[n:], nil
 }
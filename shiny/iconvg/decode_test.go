@@ -12,10 +12,15 @@ import (
 	"image/draw"
 	"image/png"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"golang.org/x/image/math/f32"
 )
 
 // disassemble returns a disassembly of an encoded IconVG graphic. Users of
@@ -23,7 +28,7 @@ import (
 // file, but it can be useful for debugging.
 func disassemble(src []byte) ([]byte, error) {
 	w := new(bytes.Buffer)
-	p := func(b []byte, format string, args ...interface{}) {
+	p := func(b []byte, k TraceEventKind, format string, args ...interface{}) {
 		const hex = "0123456789abcdef"
 		var buf [14]byte
 		for i := range buf {
@@ -46,6 +51,13 @@ func disassemble(src []byte) ([]byte, error) {
 var (
 	_ Destination = (*Encoder)(nil)
 	_ Destination = (*Rasterizer)(nil)
+	_ Destination = (*SFNTGlyphBuilder)(nil)
+	_ Destination = (*CanvasScript)(nil)
+	_ Destination = (*BoundsCollector)(nil)
+	_ Destination = (*SVGEncoder)(nil)
+	_ Destination = (*StreamEncoder)(nil)
+	_ Destination = (*ASCIIRasterizer)(nil)
+	_ Destination = (*TopologyAnalyzer)(nil)
 )
 
 func encodePNG(dstFilename string, src image.Image) error {
@@ -250,3 +262,812 @@ func TestBlendColor(t *testing.T) {
 		t.Errorf("\ngot  %x\nwant %x", got, want)
 	}
 }
+
+func TestDecodeDeadline(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/video-005.primitive.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	testCases := []struct {
+		deadline time.Time
+		want     error
+	}{
+		{time.Time{}, nil},
+		{time.Now().Add(time.Hour), nil},
+		{time.Now().Add(-time.Hour), errDeadlineExceeded},
+	}
+	for _, tc := range testCases {
+		var z Rasterizer
+		if got := Decode(&z, ivgData, &DecodeOptions{Deadline: tc.deadline}); got != tc.want {
+			t.Errorf("deadline %v: got %v, want %v", tc.deadline, got, tc.want)
+		}
+	}
+}
+
+// cRegRecorder is a Destination that records the CREG registers.
+type cRegRecorder struct {
+	segmenter
+}
+
+func (r *cRegRecorder) Reset(m Metadata) { r.segmenter.reset(m, nil) }
+
+func TestDecodeSetCReg(t *testing.T) {
+	pal := DefaultPalette
+	pal[2] = color.RGBA{0xff, 0xcc, 0x80, 0xff} // "Material Design Orange 200".
+
+	testCases := []struct {
+		desc string
+		in   []byte
+		want color.RGBA
+	}{
+		{"1 byte", []byte{0x80, 0x30}, color.RGBA{0x40, 0xff, 0xc0, 0xff}},
+		{"1 byte, translucent", []byte{0x80, 0x7e}, color.RGBA{0x80, 0x80, 0x80, 0x80}},
+		{"1 byte, palette", []byte{0x80, 0x82}, color.RGBA{0xff, 0xcc, 0x80, 0xff}},
+		{"2 bytes", []byte{0x88, 0x38, 0x0f}, color.RGBA{0x33, 0x88, 0x00, 0xff}},
+		{"2 bytes, translucent", []byte{0x88, 0xff, 0xf8}, color.RGBA{0xff, 0xff, 0xff, 0x88}},
+		{"3 bytes direct", []byte{0x90, 0x30, 0x66, 0x07}, color.RGBA{0x30, 0x66, 0x07, 0xff}},
+		{"4 bytes", []byte{0x98, 0x30, 0x66, 0x07, 0x80}, color.RGBA{0x30, 0x66, 0x07, 0x80}},
+		{"3 bytes indirect", []byte{0xa0, 0x40, 0x7f, 0x82}, color.RGBA{0x40, 0x33, 0x20, 0x40}},
+		{"4 bytes, CSEL++", []byte{0x9f, 0x30, 0x66, 0x07, 0x80}, color.RGBA{0x30, 0x66, 0x07, 0x80}},
+	}
+	for _, tc := range testCases {
+		src := append([]byte("\x89IVG\x00"), tc.in...)
+		var r cRegRecorder
+		if err := Decode(&r, src, &DecodeOptions{Palette: &pal}); err != nil {
+			t.Errorf("%s: Decode: %v", tc.desc, err)
+			continue
+		}
+		if got := r.cReg[0]; got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.desc, got, tc.want)
+		}
+	}
+}
+
+func TestDecodePaletteOverrides(t *testing.T) {
+	orange := color.RGBA{0xff, 0xcc, 0x80, 0xff}
+	green := color.RGBA{0x00, 0x40, 0x00, 0x40}
+	pal := DefaultPalette
+	pal[3] = color.RGBA{0x00, 0x00, 0xff, 0xff}
+
+	testCases := []struct {
+		desc    string
+		opts    DecodeOptions
+		want    map[int]color.RGBA
+		wantErr error
+	}{{
+		desc: "default palette",
+		opts: DecodeOptions{
+			PaletteOverrides: map[int]color.RGBA{2: orange},
+		},
+		want: map[int]color.RGBA{2: orange, 3: DefaultPalette[3]},
+	}, {
+		desc: "overrides apply after Palette",
+		opts: DecodeOptions{
+			Palette:          &pal,
+			PaletteOverrides: map[int]color.RGBA{2: orange, 3: green},
+		},
+		want: map[int]color.RGBA{2: orange, 3: green, 4: DefaultPalette[4]},
+	}, {
+		desc: "index out of range",
+		opts: DecodeOptions{
+			PaletteOverrides: map[int]color.RGBA{64: orange},
+		},
+		wantErr: errInvalidPaletteOverride,
+	}, {
+		desc: "not alpha-premultiplied",
+		opts: DecodeOptions{
+			PaletteOverrides: map[int]color.RGBA{2: {0xff, 0x00, 0x00, 0x80}},
+		},
+		wantErr: errInvalidPaletteOverride,
+	}}
+	for _, tc := range testCases {
+		// The source's CREG[0] is set from CUSTOM_PALETTE[2].
+		src := []byte("\x89IVG\x00\x80\x82")
+		var r cRegRecorder
+		if err := Decode(&r, src, &tc.opts); err != tc.wantErr {
+			t.Errorf("%s: Decode: got %v, want %v", tc.desc, err, tc.wantErr)
+			continue
+		}
+		if tc.wantErr != nil {
+			continue
+		}
+		for i, want := range tc.want {
+			if got := r.metadata.Palette[i]; got != want {
+				t.Errorf("%s: Palette[%d]: got %v, want %v", tc.desc, i, got, want)
+			}
+		}
+		if got, want := r.cReg[0], tc.want[2]; got != want {
+			t.Errorf("%s: CREG[0]: got %v, want %v", tc.desc, got, want)
+		}
+	}
+}
+
+func TestDecodePartialSuggestedPalette(t *testing.T) {
+	// The suggested palette has 2 explicit colors, in the 4 byte encoding.
+	src := []byte("\x89IVG\x02\x14\x02\xc1\xff\xcc\x80\xff\x00\x40\x00\x40")
+	m, err := DecodeMetadata(src)
+	if err != nil {
+		t.Fatalf("DecodeMetadata: %v", err)
+	}
+	want := DefaultPalette
+	want[0] = color.RGBA{0xff, 0xcc, 0x80, 0xff}
+	want[1] = color.RGBA{0x00, 0x40, 0x00, 0x40}
+	if m.Palette != want {
+		t.Errorf("Palette:\ngot  %v\nwant %v", m.Palette, want)
+	}
+}
+
+func TestRasterizerSetClip(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	bounds := image.Rect(0, 0, 64, 64)
+	background := color.RGBA{0x00, 0x00, 0x80, 0x80}
+	render := func(clip image.Rectangle) *image.RGBA {
+		dst := image.NewRGBA(bounds)
+		draw.Draw(dst, bounds, image.NewUniform(background), image.Point{}, draw.Src)
+		var z Rasterizer
+		z.SetDstImage(dst, image.Rect(8, 8, 56, 56), draw.Over)
+		z.SetClip(clip)
+		if err := Decode(&z, ivgData, nil); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		return dst
+	}
+
+	unclipped := render(image.Rectangle{})
+	clip := image.Rect(20, 0, 44, 40)
+	clipped := render(clip)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			got := clipped.RGBAAt(x, y)
+			if !(image.Point{x, y}).In(clip) {
+				if got != background {
+					t.Fatalf("at (%d, %d): got %v, want %v", x, y, got, background)
+				}
+				continue
+			}
+			// Clipped and unclipped drawing take different code paths, which
+			// can round differently.
+			want := unclipped.RGBAAt(x, y)
+			if !closeRGBA(got, want, 1) {
+				t.Fatalf("at (%d, %d): got %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestRasterizerSetBackground(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	bounds := image.Rect(0, 0, 48, 48)
+	background := color.RGBA{0xff, 0xff, 0xff, 0xff}
+
+	icon, err := rasterize(ivgData, 48, 48, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	want := image.NewRGBA(bounds)
+	draw.Draw(want, bounds, image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(want, bounds, icon, image.Point{}, draw.Over)
+
+	// The background should replace whatever was in dst, within the dst
+	// rectangle, and the graphic is composited over it, even if the
+	// SetDstImage operator is draw.Src.
+	for _, op := range []draw.Op{draw.Over, draw.Src} {
+		got := image.NewRGBA(bounds)
+		draw.Draw(got, bounds, image.NewUniform(color.RGBA{0x80, 0x00, 0x00, 0x80}), image.Point{}, draw.Src)
+		var z Rasterizer
+		z.SetDstImage(got, bounds, op)
+		z.SetBackground(background)
+		if err := Decode(&z, ivgData, nil); err != nil {
+			t.Fatalf("op=%v: Decode: %v", op, err)
+		}
+
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				g, w := got.RGBAAt(x, y), want.RGBAAt(x, y)
+				if g.A != 0xff {
+					t.Fatalf("op=%v: at (%d, %d): got %v, want an opaque color", op, x, y, g)
+				}
+				// Compositing each path over the background, rather than the
+				// whole graphic at once, can round differently where paths'
+				// anti-aliased edges overlap.
+				if !closeRGBA(g, w, 3) {
+					t.Fatalf("op=%v: at (%d, %d): got %v, want %v", op, x, y, g, w)
+				}
+			}
+		}
+	}
+}
+
+func closeRGBA(c0, c1 color.RGBA, delta uint8) bool {
+	close := func(x, y uint8) bool {
+		if x < y {
+			x, y = y, x
+		}
+		return x-y <= delta
+	}
+	return close(c0.R, c1.R) && close(c0.G, c1.G) && close(c0.B, c1.B) && close(c0.A, c1.A)
+}
+
+func TestIsIconVG(t *testing.T) {
+	testCases := []struct {
+		src  string
+		want bool
+	}{
+		{"", false},
+		{"\x89", false},
+		{"\x89IV", false},
+		{"\x89IVG", true},
+		{"\x89IVG\x00", true},
+		{"\x89PNG\r\n\x1a\n", false},
+	}
+	for _, tc := range testCases {
+		if got := IsIconVG([]byte(tc.src)); got != tc.want {
+			t.Errorf("src=%q: got %t, want %t", tc.src, got, tc.want)
+		}
+	}
+	if MagicLen != 4 {
+		t.Errorf("MagicLen: got %d, want 4", MagicLen)
+	}
+}
+
+func TestDecodeArcToFlags(t *testing.T) {
+	testCases := []struct {
+		in                      buffer
+		wantLargeArc, wantSweep bool
+		wantRest                int
+	}{
+		{buffer{0x00}, false, false, 0},
+		{buffer{0x02}, true, false, 0},
+		{buffer{0x04}, false, true, 0},
+		{buffer{0x06}, true, true, 0},
+		// Bits other than 0x01 and 0x02 are ignored.
+		{buffer{0x0a}, true, false, 0},
+		// A two-byte natural, 0x0003, followed by an unrelated byte.
+		{buffer{0x0d, 0x00, 0x80}, true, true, 1},
+	}
+	for _, tc := range testCases {
+		largeArc, sweep, rest, err := decodeArcToFlags(nil, tc.in)
+		if err != nil {
+			t.Errorf("in=%x: %v", tc.in, err)
+			continue
+		}
+		if largeArc != tc.wantLargeArc || sweep != tc.wantSweep || len(rest) != tc.wantRest {
+			t.Errorf("in=%x: got %t, %t, %d bytes left, want %t, %t, %d bytes left",
+				tc.in, largeArc, sweep, len(rest), tc.wantLargeArc, tc.wantSweep, tc.wantRest)
+		}
+	}
+	if _, _, _, err := decodeArcToFlags(nil, buffer{0x01}); err != errInvalidNumber {
+		t.Errorf("truncated: got %v, want %v", err, errInvalidNumber)
+	}
+}
+
+func TestDecodeAutoClosePaths(t *testing.T) {
+	// A triangle path that is started but never ended.
+	src := []byte("\x89IVG\x00\xc0\x80\x80\x00\xc0\x80\x00\xc0\xc0")
+
+	if err := Decode(nil, src, nil); err != errUnclosedPath {
+		t.Fatalf("strict: got %v, want %v", err, errUnclosedPath)
+	}
+
+	c := &CanvasScript{}
+	if err := Decode(c, src, &DecodeOptions{AutoClosePaths: true}); err != nil {
+		t.Fatalf("AutoClosePaths: %v", err)
+	}
+	if got, want := bytes.Count(c.Bytes(), []byte("ctx.fill();")), 1; got != want {
+		t.Fatalf("AutoClosePaths: got %d filled paths, want %d:\n%s", got, want, c.Bytes())
+	}
+}
+
+func TestDecodeReservedDrawingOpcodes(t *testing.T) {
+	for opcode := 0xe0; opcode < 0x100; opcode++ {
+		reserved := opcode == 0xe0 || opcode == 0xe4 || opcode == 0xe5 || opcode >= 0xea
+		// Start a path, then the opcode and its operands (if assigned), and
+		// then end the path (if the opcode did not).
+		src := []byte("\x89IVG\x00\xc0\x80\x80")
+		src = append(src, byte(opcode))
+		switch {
+		case opcode == 0xe2 || opcode == 0xe3:
+			src = append(src, 0x80, 0x80, 0xe1)
+		case 0xe6 <= opcode && opcode <= 0xe9:
+			src = append(src, 0x80, 0xe1)
+		}
+
+		err := Decode(nil, src, nil)
+		if reserved {
+			if err != errReservedDrawingOpcode {
+				t.Errorf("opcode %#02x: Decode: got %v, want %v", opcode, err, errReservedDrawingOpcode)
+			}
+			if _, err := DecodeLazy(src, nil); err != errReservedDrawingOpcode {
+				t.Errorf("opcode %#02x: DecodeLazy: got %v, want %v", opcode, err, errReservedDrawingOpcode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("opcode %#02x: Decode: %v", opcode, err)
+		}
+	}
+}
+
+func TestDecodeReservedStylingOpcodes(t *testing.T) {
+	for opcode := 0xc8; opcode < 0x100; opcode++ {
+		src := []byte{0x89, 'I', 'V', 'G', 0x00, byte(opcode)}
+		if err := Decode(nil, src, nil); err != errUnsupportedStylingOpcode {
+			t.Errorf("opcode %#02x: Decode: got %v, want %v", opcode, err, errUnsupportedStylingOpcode)
+		}
+		if _, err := DecodeLazy(src, nil); err != errUnsupportedStylingOpcode {
+			t.Errorf("opcode %#02x: DecodeLazy: got %v, want %v", opcode, err, errUnsupportedStylingOpcode)
+		}
+	}
+}
+
+func TestDecodeAllowedOpcodes(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/lod-polygon.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	all := &OpcodeSet{}
+	for i := range all.Styling {
+		all.Styling[i] = true
+		all.Drawing[i] = true
+	}
+	if err := Decode(nil, ivgData, &DecodeOptions{AllowedOpcodes: all}); err != nil {
+		t.Fatalf("all opcodes allowed: Decode: %v", err)
+	}
+
+	noLOD := *all
+	noLOD.Styling[0xc7] = false
+	err = Decode(nil, ivgData, &DecodeOptions{AllowedOpcodes: &noLOD})
+	if got, want := fmt.Sprint(err), "iconvg: disallowed styling opcode 0xc7"; got != want {
+		t.Errorf("no LOD: got %q, want %q", got, want)
+	}
+
+	// The first path is started, but cannot be ended.
+	noEndPath := *all
+	noEndPath.Drawing[0xe1] = false
+	var r pathsRecorder
+	err = Decode(&r, ivgData, &DecodeOptions{AllowedOpcodes: &noEndPath})
+	if got, want := fmt.Sprint(err), "iconvg: disallowed drawing opcode 0xe1"; got != want {
+		t.Errorf("no end path: got %q, want %q", got, want)
+	}
+	if len(r.paths) != 1 {
+		t.Errorf("no end path: got %d paths started, want 1", len(r.paths))
+	}
+
+	if err := Decode(nil, ivgData, &DecodeOptions{AllowedOpcodes: &OpcodeSet{}}); err == nil {
+		t.Errorf("no opcodes allowed: got nil error, want non-nil")
+	}
+}
+
+func TestDecodeInvalidGradientStops(t *testing.T) {
+	red := color.RGBA{0xff, 0x00, 0x00, 0xff}
+	blue := color.RGBA{0x00, 0x00, 0xff, 0xff}
+	testCases := []struct {
+		offsets  []float32
+		wantDraw bool
+	}{
+		{[]float32{0, 1}, true},
+		{[]float32{0, 0.5, 1}, true},
+		{[]float32{0.5, 0.5}, false},
+		{[]float32{1, 0}, false},
+		{[]float32{0, 0.75, 0.25}, false},
+		{[]float32{-0.5, 1}, false},
+		{[]float32{0, 1.5}, false},
+	}
+	for _, tc := range testCases {
+		var stops []GradientStop
+		for i, o := range tc.offsets {
+			c := red
+			if i%2 == 1 {
+				c = blue
+			}
+			stops = append(stops, GradientStop{Offset: o, Color: c})
+		}
+		var e Encoder
+		e.Reset(Metadata{
+			ViewBox: DefaultViewBox,
+			Palette: DefaultPalette,
+		})
+		e.SetLinearGradient(10, 10, -32, 0, +32, 0, GradientSpreadPad, stops)
+		e.StartPath(0, -32, -32)
+		e.AbsHLineTo(+32)
+		e.AbsVLineTo(+32)
+		e.AbsHLineTo(-32)
+		e.ClosePathEndPath()
+		src, err := e.Bytes()
+		if err != nil {
+			t.Errorf("offsets=%v: Bytes: %v", tc.offsets, err)
+			continue
+		}
+		dst, err := rasterize(src, 8, 8, nil)
+		if err != nil {
+			t.Errorf("offsets=%v: rasterize: %v", tc.offsets, err)
+			continue
+		}
+		gotDraw := dst.RGBAAt(4, 4).A != 0
+		if gotDraw != tc.wantDraw {
+			t.Errorf("offsets=%v: drawn: got %t, want %t", tc.offsets, gotDraw, tc.wantDraw)
+		}
+	}
+}
+
+func TestDecodeSkipBytes(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	const skip = 5
+	embedded := append([]byte("\x00\x01\x02\x03\x04"), ivgData...)
+
+	var want, got pathsRecorder
+	if err := Decode(&want, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	firstOffset := -1
+	if err := Decode(&got, embedded, &DecodeOptions{
+		SkipBytes: skip,
+		Trace: func(ev TraceEvent) {
+			if firstOffset < 0 {
+				firstOffset = ev.Offset
+			}
+		},
+	}); err != nil {
+		t.Fatalf("Decode(embedded): %v", err)
+	}
+	if !reflect.DeepEqual(got.paths, want.paths) {
+		t.Errorf("Decode(embedded): paths differ")
+	}
+	if firstOffset != skip {
+		t.Errorf("first trace event's offset: got %d, want %d", firstOffset, skip)
+	}
+
+	l, err := DecodeLazy(embedded, &DecodeOptions{SkipBytes: skip})
+	if err != nil {
+		t.Fatalf("DecodeLazy: %v", err)
+	}
+	if l.NumPaths() != len(want.paths) {
+		t.Fatalf("NumPaths: got %d, want %d", l.NumPaths(), len(want.paths))
+	}
+	for i := range want.paths {
+		p, err := l.ResolvePath(i)
+		if err != nil {
+			t.Fatalf("ResolvePath(%d): %v", i, err)
+		}
+		if !reflect.DeepEqual(*p, want.paths[i]) {
+			t.Errorf("ResolvePath(%d): got %v, want %v", i, *p, want.paths[i])
+		}
+	}
+
+	for _, badSkip := range []int{0, skip - 1, skip + 1, -1, len(embedded) + 1} {
+		err := Decode(nil, embedded, &DecodeOptions{SkipBytes: badSkip})
+		if err != errInvalidMagicIdentifier {
+			t.Errorf("SkipBytes=%d: got %v, want %v", badSkip, err, errInvalidMagicIdentifier)
+		}
+	}
+}
+
+func TestSkipBytesHelpers(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/video-005.primitive.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	const skip = 4
+	embedded := append([]byte("\x00\x01\x02\x03"), ivgData...)
+	opts := &DecodeOptions{SkipBytes: skip}
+
+	wantAtlas, _, err := BuildAtlas(ivgData, []int{16, 32}, nil)
+	if err != nil {
+		t.Fatalf("BuildAtlas: %v", err)
+	}
+	if gotAtlas, _, err := BuildAtlas(embedded, []int{16, 32}, opts); err != nil {
+		t.Errorf("BuildAtlas(embedded): %v", err)
+	} else if !bytes.Equal(gotAtlas.Pix, wantAtlas.Pix) {
+		t.Errorf("BuildAtlas(embedded): pixels differ")
+	}
+
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	wantRatio, err := ContrastRatio(ivgData, white, nil)
+	if err != nil {
+		t.Fatalf("ContrastRatio: %v", err)
+	}
+	if gotRatio, err := ContrastRatio(embedded, white, opts); err != nil {
+		t.Errorf("ContrastRatio(embedded): %v", err)
+	} else if gotRatio != wantRatio {
+		t.Errorf("ContrastRatio(embedded): got %v, want %v", gotRatio, wantRatio)
+	}
+
+	for _, maxBytes := range []int{len(ivgData), len(ivgData) * 3 / 4} {
+		want, _, err := EncodeWithinBudget(ivgData, maxBytes, nil)
+		if err != nil {
+			t.Fatalf("maxBytes=%d: EncodeWithinBudget: %v", maxBytes, err)
+		}
+		if got, _, err := EncodeWithinBudget(embedded, maxBytes, opts); err != nil {
+			t.Errorf("maxBytes=%d: EncodeWithinBudget(embedded): %v", maxBytes, err)
+		} else if !bytes.Equal(got, want) {
+			t.Errorf("maxBytes=%d: EncodeWithinBudget(embedded): got % x, want % x", maxBytes, got, want)
+		}
+	}
+}
+
+func TestDecodeMaxViewBoxArea(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: Rectangle{Max: f32.Vec2{+1000, +2000}},
+		Palette: DefaultPalette,
+	})
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	testCases := []struct {
+		max     float32
+		wantErr error
+	}{
+		{0, nil},
+		{2e6, nil},
+		{2e6 - 1, errViewBoxTooLarge},
+		{1, errViewBoxTooLarge},
+	}
+	for _, tc := range testCases {
+		if err := Decode(nil, ivgData, &DecodeOptions{MaxViewBoxArea: tc.max}); err != tc.wantErr {
+			t.Errorf("max %v: got %v, want %v", tc.max, err, tc.wantErr)
+		}
+	}
+}
+
+func TestDecodeViewBoxOverride(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	// A square that fills the top left quadrant.
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, -32, -32)
+	e.AbsHLineTo(0)
+	e.AbsVLineTo(0)
+	e.AbsHLineTo(-32)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	quadrant := Rectangle{Min: f32.Vec2{-32, -32}}
+	got, err := rasterize(ivgData, 16, 16, &DecodeOptions{ViewBoxOverride: &quadrant})
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	// Zoomed in, the square fills the whole image.
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			if a := got.RGBAAt(x, y).A; a != 0xff {
+				t.Fatalf("at (%d, %d): got alpha %#02x, want 0xff", x, y, a)
+			}
+		}
+	}
+
+	var r pathsRecorder
+	if err := Decode(&r, ivgData, &DecodeOptions{ViewBoxOverride: &quadrant}); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if r.metadata.ViewBox != quadrant {
+		t.Errorf("ViewBox: got %v, want %v", r.metadata.ViewBox, quadrant)
+	}
+
+	nan := float32(math.NaN())
+	for _, r := range []Rectangle{
+		{Min: f32.Vec2{0, 0}, Max: f32.Vec2{-1, 1}},
+		{Min: f32.Vec2{0, 0}, Max: f32.Vec2{1, nan}},
+		{Min: f32.Vec2{0, 0}, Max: f32.Vec2{float32(math.Inf(+1)), 1}},
+	} {
+		r := r
+		if err := Decode(nil, ivgData, &DecodeOptions{ViewBoxOverride: &r}); err != errInvalidViewBox {
+			t.Errorf("%v: got %v, want %v", r, err, errInvalidViewBox)
+		}
+	}
+}
+
+func TestDecodeOnDeprecated(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var got []string
+	opts := &DecodeOptions{
+		OnDeprecated: func(opcode byte, suggestion string) {
+			got = append(got, fmt.Sprintf("%#02x: %s", opcode, suggestion))
+		},
+	}
+	if err := Decode(nil, ivgData, opts); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("no deprecated opcodes: got %q", got)
+	}
+
+	// Pretend that the end path drawing opcode is deprecated. The same byte
+	// value as a styling opcode is not.
+	defer func(old [256]string) { deprecatedDrawingOpcodes = old }(deprecatedDrawingOpcodes)
+	deprecatedDrawingOpcodes[0xe1] = "use something else"
+	if err := Decode(nil, ivgData, opts); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	r, err := RecordRaw(ivgData, nil)
+	if err != nil {
+		t.Fatalf("RecordRaw: %v", err)
+	}
+	if want := r.NumPaths(); len(got) != want {
+		t.Errorf("got %d calls, want %d", len(got), want)
+	}
+	for _, g := range got {
+		if g != "0xe1: use something else" {
+			t.Errorf("got %q", g)
+			break
+		}
+	}
+}
+
+func TestDecodeSmoothChains(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	// Path 0 has a well-formed chain, and then a smooth quadTo after a
+	// lineTo.
+	e.StartPath(0, 0, 0)
+	e.AbsQuadTo(4, 0, 4, 4)
+	e.AbsSmoothQuadTo(8, 8)
+	e.AbsLineTo(8, 12)
+	e.AbsSmoothQuadTo(12, 12)
+	e.ClosePathEndPath()
+	// Path 1 is well-formed.
+	e.StartPath(0, 0, 0)
+	e.AbsCubeTo(4, 0, 8, 4, 8, 8)
+	e.RelSmoothCubeTo(0, 4, 4, 4)
+	e.ClosePathEndPath()
+	// Path 2 has a smooth cubeTo after a quadTo, and then, in a new
+	// sub-path, a smooth cubeTo after a moveTo.
+	e.StartPath(0, 0, 0)
+	e.AbsQuadTo(4, 0, 4, 4)
+	e.AbsSmoothCubeTo(8, 4, 8, 8)
+	e.ClosePathAbsMoveTo(16, 16)
+	e.AbsSmoothCubeTo(20, 16, 20, 20)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var got []int
+	opts := &DecodeOptions{
+		OnBrokenSmoothChain: func(path int) {
+			got = append(got, path)
+		},
+	}
+	if err := Decode(nil, ivgData, opts); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if want := []int{0, 2, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got = nil
+	opts.StrictSmoothChains = true
+	if err := Decode(nil, ivgData, opts); err != errSmoothCurveChain {
+		t.Errorf("strict: got %v, want %v", err, errSmoothCurveChain)
+	}
+	if want := []int{0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("strict: got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeMaxOpcodes(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var stats DecodeStats
+	if err := Decode(nil, ivgData, &DecodeOptions{Stats: &stats}); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	n := stats.Opcodes
+
+	if err := Decode(nil, ivgData, &DecodeOptions{MaxOpcodes: n}); err != nil {
+		t.Errorf("MaxOpcodes=%d: %v", n, err)
+	}
+	if err := Decode(nil, ivgData, &DecodeOptions{MaxOpcodes: n - 1}); err != errTooManyOpcodes {
+		t.Errorf("MaxOpcodes=%d: got %v, want %v", n-1, err, errTooManyOpcodes)
+	}
+}
+
+func TestDecodeRecoverMetadata(t *testing.T) {
+	const (
+		// badViewBox is a ViewBox chunk whose Min, (+10, +10), is greater
+		// than its Max, (0, 0).
+		badViewBox = "\x0a\x00\x94\x94\x80\x80"
+		// badPalette is a suggested palette chunk, 4 bytes long, whose 4
+		// colors need 6 bytes.
+		badPalette = "\x08\x02\x03\xff\xff"
+		// comment is a Comment chunk, "hi".
+		comment = "\x08\xfd\xffhi"
+	)
+	var e Encoder
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(8)
+	e.AbsVLineTo(8)
+	e.AbsHLineTo(0)
+	e.ClosePathEndPath()
+	square, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	// Strip the magic identifier and the zero metadata chunks.
+	square = square[len(magic)+1:]
+
+	testCases := []struct {
+		desc        string
+		src         string
+		wantErr     error
+		wantSkipped []int
+	}{{
+		desc:        "bad ViewBox",
+		src:         "\x89IVG\x04" + badViewBox + comment + string(square),
+		wantErr:     errInvalidViewBox,
+		wantSkipped: []int{0},
+	}, {
+		desc:        "bad palette",
+		src:         "\x89IVG\x06" + comment + badPalette + badViewBox + string(square),
+		wantErr:     errInconsistentMetadataChunkLength,
+		wantSkipped: []int{1, 2},
+	}}
+
+	for _, tc := range testCases {
+		if err := Decode(nil, []byte(tc.src), nil); err != tc.wantErr {
+			t.Errorf("%s: without recovery: got %v, want %v", tc.desc, err, tc.wantErr)
+		}
+
+		var skipped []int
+		var r pathsRecorder
+		err := Decode(&r, []byte(tc.src), &DecodeOptions{
+			RecoverMetadata: true,
+			OnSkippedMetadataChunk: func(chunk int, err error) {
+				skipped = append(skipped, chunk)
+			},
+		})
+		if err != nil {
+			t.Errorf("%s: with recovery: %v", tc.desc, err)
+			continue
+		}
+		if !reflect.DeepEqual(skipped, tc.wantSkipped) {
+			t.Errorf("%s: skipped chunks: got %v, want %v", tc.desc, skipped, tc.wantSkipped)
+		}
+		if got := r.metadata.ViewBox; got != DefaultViewBox {
+			t.Errorf("%s: ViewBox: got %v, want %v", tc.desc, got, DefaultViewBox)
+		}
+		if got := r.metadata.Palette; got != DefaultPalette {
+			t.Errorf("%s: Palette: got %v, want %v", tc.desc, got, DefaultPalette)
+		}
+		if got, want := r.metadata.Comment, "hi"; got != want {
+			t.Errorf("%s: Comment: got %q, want %q", tc.desc, got, want)
+		}
+		if got := len(r.paths); got != 1 {
+			t.Errorf("%s: got %d paths, want 1", tc.desc, got)
+		}
+	}
+
+	// A chunk whose declared length runs past the end of the graphic cannot
+	// be skipped.
+	src := []byte("\x89IVG\x02\x7e\x00\x94\x94\x80\x80")
+	if err := Decode(nil, src, &DecodeOptions{RecoverMetadata: true}); err != errInvalidViewBox {
+		t.Errorf("overlong chunk: got %v, want %v", err, errInvalidViewBox)
+	}
+}
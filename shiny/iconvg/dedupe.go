@@ -0,0 +1,149 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"golang.org/x/image/math/f32"
+)
+
+// defaultDedupeEpsilon is the DedupeAnalyzer's default Epsilon.
+const defaultDedupeEpsilon = 1.0 / 64
+
+// SubpathGroup is a set of sub-paths, as reported by a DedupeAnalyzer, that
+// have the same geometry up to translation.
+type SubpathGroup struct {
+	// Segments are the shared geometry: the first instance's segments,
+	// translated so that its MoveTo is at the origin.
+	Segments []Segment
+
+	// Instances are where the geometry occurs, in decoding order. There are
+	// always at least two.
+	Instances []SubpathInstance
+}
+
+// SubpathInstance is one occurrence of a SubpathGroup's geometry.
+type SubpathInstance struct {
+	// Path is the index of the path, counting every path decoded since the
+	// last Reset.
+	Path int
+
+	// Subpath is the index of the sub-path within that path.
+	Subpath int
+
+	// Offset is the translation from the SubpathGroup's Segments to this
+	// instance, which is also the point that the instance's MoveTo is at.
+	Offset f32.Vec2
+}
+
+// DedupeAnalyzer is a Destination that finds sub-paths that repeat the same
+// geometry up to translation, such as a grid of dots, across all of an
+// IconVG graphic's paths. Fill colors and levels of detail are ignored.
+//
+// Two sub-paths match if they have the same sequence of segment ops, and each
+// of their points, relative to their MoveTo, differ by at most Epsilon in
+// each coordinate. Each sub-path is compared with the first instance of each
+// group, so a chain of sub-paths that each differ only slightly from the
+// last does not form one group.
+type DedupeAnalyzer struct {
+	// Epsilon is the tolerance, in graphic coordinate space, for matching
+	// sub-paths. If zero, it is 1/64th of a unit.
+	Epsilon float32
+
+	segmenter
+	segs   []Segment
+	path   int
+	groups []SubpathGroup
+	// byOps maps a sub-path's sequence of segment ops to the indexes of the
+	// groups with that sequence.
+	byOps map[string][]int
+}
+
+// Reset resets the DedupeAnalyzer for the given Metadata.
+func (a *DedupeAnalyzer) Reset(m Metadata) {
+	a.segmenter.reset(m, a)
+	a.segs = a.segs[:0]
+	a.path = 0
+	a.groups = nil
+	a.byOps = map[string][]int{}
+}
+
+// RepeatedSubpaths returns the groups of sub-paths with two or more
+// instances, in the order of their first instances.
+func (a *DedupeAnalyzer) RepeatedSubpaths() []SubpathGroup {
+	var ret []SubpathGroup
+	for _, g := range a.groups {
+		if len(g.Instances) >= 2 {
+			ret = append(ret, g)
+		}
+	}
+	return ret
+}
+
+func (a *DedupeAnalyzer) beginPath()           { a.segs = a.segs[:0] }
+func (a *DedupeAnalyzer) addSegment(s Segment) { a.segs = append(a.segs, s) }
+
+func (a *DedupeAnalyzer) endPath() {
+	eps := a.Epsilon
+	if eps <= 0 {
+		eps = defaultDedupeEpsilon
+	}
+	for i, sub := range splitSubpaths(a.segs) {
+		origin := sub[0].Args[0]
+		translated := make([]Segment, len(sub))
+		ops := make([]byte, len(sub))
+		for j, s := range sub {
+			for k := 0; k < segmentNArgs(s.Op); k++ {
+				s.Args[k] = f32.Vec2{s.Args[k][0] - origin[0], s.Args[k][1] - origin[1]}
+			}
+			translated[j] = s
+			ops[j] = byte(s.Op)
+		}
+		inst := SubpathInstance{Path: a.path, Subpath: i, Offset: origin}
+
+		key := string(ops)
+		matched := false
+		for _, gi := range a.byOps[key] {
+			if segmentsNear(a.groups[gi].Segments, translated, eps) {
+				a.groups[gi].Instances = append(a.groups[gi].Instances, inst)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			a.byOps[key] = append(a.byOps[key], len(a.groups))
+			a.groups = append(a.groups, SubpathGroup{
+				Segments:  translated,
+				Instances: []SubpathInstance{inst},
+			})
+		}
+	}
+	a.path++
+}
+
+// segmentNArgs returns the number of points that a segment with the given op
+// has.
+func segmentNArgs(op SegmentOp) int {
+	switch op {
+	case SegmentOpQuadTo:
+		return 2
+	case SegmentOpCubeTo:
+		return 3
+	}
+	return 1
+}
+
+// segmentsNear returns whether s0 and s1, which have the same ops, have
+// points within eps of each other in each coordinate.
+func segmentsNear(s0, s1 []Segment, eps float32) bool {
+	for i := range s0 {
+		for k := 0; k < segmentNArgs(s0[i].Op); k++ {
+			p, q := s0[i].Args[k], s1[i].Args[k]
+			if abs32(p[0]-q[0]) > eps || abs32(p[1]-q[1]) > eps {
+				return false
+			}
+		}
+	}
+	return true
+}
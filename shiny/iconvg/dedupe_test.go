@@ -0,0 +1,87 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestDedupeAnalyzer(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.HighResolutionCoordinates = true
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	// A diamond at (-16, -16) and a triangle at (+16, -16).
+	e.StartPath(0, -16, -20)
+	e.RelLineTo(+4, +4)
+	e.RelLineTo(-4, +4)
+	e.RelLineTo(-4, -4)
+	e.ClosePathAbsMoveTo(+16, -20)
+	e.RelLineTo(+4, +4)
+	e.RelLineTo(-8, 0)
+	e.ClosePathEndPath()
+	// Two more diamonds, one of them very slightly off, in another path.
+	e.StartPath(0, -16, +12)
+	e.RelLineTo(+4, +4)
+	e.RelLineTo(-4, +4)
+	e.RelLineTo(-4, -4)
+	e.ClosePathAbsMoveTo(+16, +12)
+	e.RelLineTo(+4.001, +4)
+	e.RelLineTo(-4, +4)
+	e.RelLineTo(-4, -4)
+	// A diamond that is too far off to match.
+	e.ClosePathAbsMoveTo(0, 0)
+	e.RelLineTo(+4.5, +4)
+	e.RelLineTo(-4, +4)
+	e.RelLineTo(-4, -4)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var a DedupeAnalyzer
+	if err := Decode(&a, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	groups := a.RepeatedSubpaths()
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+	wantInstances := []SubpathInstance{
+		{Path: 0, Subpath: 0, Offset: f32.Vec2{-16, -20}},
+		{Path: 1, Subpath: 0, Offset: f32.Vec2{-16, +12}},
+		{Path: 1, Subpath: 1, Offset: f32.Vec2{+16, +12}},
+	}
+	if got := groups[0].Instances; !reflect.DeepEqual(got, wantInstances) {
+		t.Errorf("Instances: got %v, want %v", got, wantInstances)
+	}
+	wantSegments := []Segment{
+		{Op: SegmentOpMoveTo, Args: [3]f32.Vec2{{0, 0}}},
+		{Op: SegmentOpLineTo, Args: [3]f32.Vec2{{+4, +4}}},
+		{Op: SegmentOpLineTo, Args: [3]f32.Vec2{{0, +8}}},
+		{Op: SegmentOpLineTo, Args: [3]f32.Vec2{{-4, +4}}},
+	}
+	if got := groups[0].Segments; !reflect.DeepEqual(got, wantSegments) {
+		t.Errorf("Segments: got %v, want %v", got, wantSegments)
+	}
+
+	// With a tighter tolerance, the slightly off diamond no longer matches.
+	a = DedupeAnalyzer{Epsilon: 1.0 / 4096}
+	if err := Decode(&a, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	groups = a.RepeatedSubpaths()
+	if len(groups) != 1 || len(groups[0].Instances) != 2 {
+		t.Errorf("tight tolerance: got %v, want 1 group of 2 instances", groups)
+	}
+}
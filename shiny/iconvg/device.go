@@ -0,0 +1,51 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"errors"
+	"image"
+
+	"golang.org/x/image/math/f32"
+)
+
+var errEmptyDeviceBounds = errors.New("iconvg: empty device bounds")
+
+// DecodeToDevice decodes an IconVG graphic, like Decode, except that the
+// geometry is transformed from the ViewBox's coordinate space to device
+// (pixel) coordinates before being forwarded to dst. Destinations such as
+// plotters, or wrappers of other drawing libraries, can then use the
+// coordinates as is.
+//
+// The transformation is a uniform scale, which preserves the graphic's aspect
+// ratio, and a translation, so that the ViewBox fits, centered, in bounds. As
+// the scale is uniform, arcs are still arcs, with their radii multiplied by
+// the scale factor, and gradients are scaled accordingly. dst is Reset with
+// the ViewBox in device coordinates, which is bounds if its aspect ratio
+// matches the graphic's. Paths' levels of detail are unchanged, and still
+// refer to the height of the ViewBox when rendered.
+//
+// The ViewBox is the graphic's, or opts.ViewBoxOverride if that is non-nil.
+// opts.NormalizeToUnitSquare is ignored. It returns an error if bounds is
+// empty.
+func DecodeToDevice(dst Destination, src []byte, bounds image.Rectangle, opts *DecodeOptions) error {
+	if bounds.Empty() {
+		return errEmptyDeviceBounds
+	}
+	m, err := decodeMetadata(src, opts)
+	if err != nil {
+		return err
+	}
+	var o DecodeOptions
+	if opts != nil {
+		o = *opts
+		o.NormalizeToUnitSquare = false
+	}
+	r := Rectangle{
+		Min: f32.Vec2{float32(bounds.Min.X), float32(bounds.Min.Y)},
+		Max: f32.Vec2{float32(bounds.Max.X), float32(bounds.Max.Y)},
+	}
+	return Decode(fitDestination(dst, m.ViewBox, r), src, &o)
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+// arcsRecorder is a pathsRecorder that also records its absolute arcs' radii.
+type arcsRecorder struct {
+	pathsRecorder
+	radii []f32.Vec2
+}
+
+func (r *arcsRecorder) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	r.radii = append(r.radii, f32.Vec2{rx, ry})
+	r.pathsRecorder.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+}
+
+func TestDecodeToDevice(t *testing.T) {
+	// A graphic, twice as wide as it is high, with an arc.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: Rectangle{Min: f32.Vec2{0, 0}, Max: f32.Vec2{40, 20}},
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, 4, 4)
+	e.AbsLineTo(36, 4)
+	e.AbsArcTo(8, 6, 0, false, true, 24, 16)
+	e.ClosePathEndPath()
+	src, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	// The scale factor is 2.5, limited by the bounds' width, and the graphic
+	// is centered vertically.
+	var r arcsRecorder
+	if err := DecodeToDevice(&r, src, image.Rect(10, 10, 110, 110), nil); err != nil {
+		t.Fatalf("DecodeToDevice: %v", err)
+	}
+	wantViewBox := Rectangle{Min: f32.Vec2{10, 35}, Max: f32.Vec2{110, 85}}
+	if got := r.metadata.ViewBox; got != wantViewBox {
+		t.Errorf("ViewBox: got %v, want %v", got, wantViewBox)
+	}
+	if len(r.paths) != 1 {
+		t.Fatalf("got %d paths, want 1", len(r.paths))
+	}
+	segs := r.paths[0].Segments
+	if got, want := segs[0].Args[0], (f32.Vec2{20, 45}); got != want {
+		t.Errorf("start: got %v, want %v", got, want)
+	}
+	if got, want := segs[len(segs)-1].end(), (f32.Vec2{70, 75}); got != want {
+		t.Errorf("end: got %v, want %v", got, want)
+	}
+	if got, want := r.radii, []f32.Vec2{{20, 15}}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("radii: got %v, want %v", got, want)
+	}
+
+	// The ViewBoxOverride, if any, is what is fitted to the bounds.
+	r = arcsRecorder{}
+	opts := &DecodeOptions{
+		ViewBoxOverride: &Rectangle{Min: f32.Vec2{0, 0}, Max: f32.Vec2{20, 20}},
+	}
+	if err := DecodeToDevice(&r, src, image.Rect(0, 0, 100, 100), opts); err != nil {
+		t.Fatalf("DecodeToDevice with override: %v", err)
+	}
+	if got, want := r.metadata.ViewBox, (Rectangle{Max: f32.Vec2{100, 100}}); got != want {
+		t.Errorf("ViewBox with override: got %v, want %v", got, want)
+	}
+	if got, want := r.paths[0].Segments[0].Args[0], (f32.Vec2{20, 20}); got != want {
+		t.Errorf("start with override: got %v, want %v", got, want)
+	}
+
+	// An embedded graphic, after a 3 byte prefix, is fitted the same way.
+	r = arcsRecorder{}
+	embedded := append([]byte("\x00\x01\x02"), src...)
+	if err := DecodeToDevice(&r, embedded, image.Rect(10, 10, 110, 110), &DecodeOptions{SkipBytes: 3}); err != nil {
+		t.Fatalf("DecodeToDevice with SkipBytes: %v", err)
+	}
+	if got := r.metadata.ViewBox; got != wantViewBox {
+		t.Errorf("ViewBox with SkipBytes: got %v, want %v", got, wantViewBox)
+	}
+
+	if err := DecodeToDevice(&r, src, image.Rectangle{}, nil); err != errEmptyDeviceBounds {
+		t.Errorf("empty bounds: got %v, want %v", err, errEmptyDeviceBounds)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ebiten
+
+package iconvg
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/math/f32"
+)
+
+// EbitenFill is one path of an IconVG graphic, converted by EbitenPath.
+type EbitenFill struct {
+	// Path is the path's outline. Each sub-path is closed.
+	Path *vector.Path
+
+	// Color is the path's alpha-premultiplied fill color.
+	Color color.RGBA
+}
+
+// EbitenPath converts an IconVG graphic to Ebiten vector paths, one per
+// IconVG path, in drawing order. The graphic's ViewBox is mapped to the
+// rectangle from (0, 0) to (width, height), and paths are selected by their
+// level of detail at that height. Arcs are converted to cubic Bézier curves.
+//
+// Paths filled with gradients, and fully transparent paths, are skipped.
+// IconVG paths are filled under the non-zero winding rule, so the paths
+// should be drawn with Ebiten's vector.FillRuleNonZero.
+//
+// EbitenPath is only built with the "ebiten" build tag, so that the package
+// does not otherwise depend on Ebiten.
+func EbitenPath(src []byte, width, height float32, opts *DecodeOptions) ([]EbitenFill, error) {
+	c := ebitenCollector{width: width, height: height}
+	if err := Decode(&c, src, opts); err != nil {
+		return nil, err
+	}
+	return c.fills, nil
+}
+
+// ebitenCollector is a Destination that builds Ebiten vector paths.
+type ebitenCollector struct {
+	segmenter
+	width, height  float32
+	scaleX, scaleY float32
+	fills          []EbitenFill
+	path           *vector.Path
+	subpath        bool
+}
+
+func (c *ebitenCollector) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	dx, dy := m.ViewBox.AspectRatio()
+	c.scaleX, c.scaleY = c.width/dx, c.height/dy
+	c.fills = nil
+	c.path = nil
+}
+
+// transform maps p from graphic coordinate space to the destination size.
+func (c *ebitenCollector) transform(p f32.Vec2) (x, y float32) {
+	return (p[0] - c.metadata.ViewBox.Min[0]) * c.scaleX,
+		(p[1] - c.metadata.ViewBox.Min[1]) * c.scaleY
+}
+
+func (c *ebitenCollector) beginPath() {
+	c.path = nil
+	if !(c.lod0 <= c.height && c.height < c.lod1) ||
+		c.fill.A == 0 || !validAlphaPremulColor(c.fill) {
+		return
+	}
+	c.path = &vector.Path{}
+	c.fills = append(c.fills, EbitenFill{Path: c.path, Color: c.fill})
+	c.subpath = false
+}
+
+func (c *ebitenCollector) addSegment(s Segment) {
+	if c.path == nil {
+		return
+	}
+	switch s.Op {
+	case SegmentOpMoveTo:
+		if c.subpath {
+			c.path.Close()
+		}
+		c.subpath = true
+		x, y := c.transform(s.Args[0])
+		c.path.MoveTo(x, y)
+	case SegmentOpLineTo:
+		x, y := c.transform(s.Args[0])
+		c.path.LineTo(x, y)
+	case SegmentOpQuadTo:
+		x1, y1 := c.transform(s.Args[0])
+		x, y := c.transform(s.Args[1])
+		c.path.QuadTo(x1, y1, x, y)
+	case SegmentOpCubeTo:
+		x1, y1 := c.transform(s.Args[0])
+		x2, y2 := c.transform(s.Args[1])
+		x, y := c.transform(s.Args[2])
+		c.path.CubicTo(x1, y1, x2, y2, x, y)
+	}
+}
+
+func (c *ebitenCollector) endPath() {
+	if c.path != nil && c.subpath {
+		c.path.Close()
+	}
+	c.path = nil
+}
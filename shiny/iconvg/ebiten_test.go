@@ -0,0 +1,39 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ebiten
+
+package iconvg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestEbitenPath(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	fills, err := EbitenPath(ivgData, 48, 48, nil)
+	if err != nil {
+		t.Fatalf("EbitenPath: %v", err)
+	}
+	if len(fills) == 0 {
+		t.Fatal("got no paths")
+	}
+	for i, f := range fills {
+		if f.Path == nil {
+			t.Errorf("path #%d: nil Path", i)
+		}
+		if f.Color.A == 0 {
+			t.Errorf("path #%d: transparent Color", i)
+		}
+	}
+
+	if _, err := EbitenPath(ivgData[:len(ivgData)-1], 48, 48, nil); err == nil {
+		t.Fatal("EbitenPath of a truncated graphic: got nil error, want non-nil")
+	}
+}
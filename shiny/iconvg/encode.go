@@ -8,15 +8,20 @@ import (
 	"errors"
 	"image/color"
 	"math"
+	"unicode/utf8"
 
 	"golang.org/x/image/math/f32"
 )
 
 var (
 	errCSELUsedAsBothGradientAndStop = errors.New("iconvg: CSEL used as both gradient and stop")
+	errCommentAfterOps               = errors.New("iconvg: comment set after styling or drawing ops")
 	errDrawingOpsUsedInStylingMode   = errors.New("iconvg: drawing ops used in styling mode")
+	errExceedsMaxBytes               = errors.New("iconvg: encoded form exceeds MaxBytes")
+	errInvalidComment                = errors.New("iconvg: invalid comment")
 	errInvalidSelectorAdjustment     = errors.New("iconvg: invalid selector adjustment")
 	errInvalidIncrementingAdjustment = errors.New("iconvg: invalid incrementing adjustment")
+	errInvalidSegment                = errors.New("iconvg: invalid segment")
 	errStylingOpsUsedInDrawingMode   = errors.New("iconvg: styling ops used in drawing mode")
 	errTooManyGradientStops          = errors.New("iconvg: too many gradient stops")
 )
@@ -51,6 +56,10 @@ type Encoder struct {
 	// encoding format.
 	HighResolutionCoordinates bool
 
+	// MaxBytes, if positive, is the maximum size of the encoded form. Bytes
+	// returns an error if it is exceeded. See also EncodeWithinBudget.
+	MaxBytes int
+
 	// highResolutionCoordinates is a local copy, copied during StartPath, to
 	// avoid having to specify the semantics of modifying the exported field
 	// while drawing.
@@ -61,6 +70,9 @@ type Encoder struct {
 	metadata Metadata
 	err      error
 
+	// metadataLen is the length of buf's magic identifier and metadata.
+	metadataLen int
+
 	lod0 float32
 	lod1 float32
 	cSel uint8
@@ -81,12 +93,16 @@ func (e *Encoder) Bytes() ([]byte, error) {
 	if e.mode == modeInitial {
 		e.appendDefaultMetadata()
 	}
+	if e.MaxBytes > 0 && len(e.buf) > e.MaxBytes {
+		return nil, errExceedsMaxBytes
+	}
 	return []byte(e.buf), nil
 }
 
 // Reset resets the Encoder for the given Metadata.
 //
-// This includes setting e.HighResolutionCoordinates to false.
+// This includes setting e.HighResolutionCoordinates to false and e.MaxBytes
+// to zero.
 func (e *Encoder) Reset(m Metadata) {
 	*e = Encoder{
 		buf:      append(e.buf[:0], magic...),
@@ -104,6 +120,10 @@ func (e *Encoder) Reset(m Metadata) {
 	if mcSuggestedPalette {
 		nMetadataChunks++
 	}
+	mcComment := m.Comment != ""
+	if mcComment {
+		nMetadataChunks++
+	}
 	e.buf.encodeNatural(uint32(nMetadataChunks))
 
 	if mcViewBox {
@@ -167,12 +187,51 @@ func (e *Encoder) Reset(m Metadata) {
 		e.buf.encodeNatural(uint32(len(e.altBuf)))
 		e.buf = append(e.buf, e.altBuf...)
 	}
+
+	if mcComment {
+		if !utf8.ValidString(m.Comment) {
+			e.err = errInvalidComment
+		}
+		e.altBuf = e.altBuf[:0]
+		e.altBuf.encodeNatural(midComment)
+		e.altBuf = append(e.altBuf, m.Comment...)
+
+		e.buf.encodeNatural(uint32(len(e.altBuf)))
+		e.buf = append(e.buf, e.altBuf...)
+	}
+
+	e.metadataLen = len(e.buf)
 }
 
 func (e *Encoder) appendDefaultMetadata() {
 	e.buf = append(e.buf[:0], magic...)
 	e.buf = append(e.buf, 0x00) // There are zero metadata chunks.
 	e.mode = modeStyling
+	e.metadataLen = len(e.buf)
+}
+
+// SetComment sets the Metadata's Comment, such as the name and version of the
+// tool that generated the graphic, to embed in the encoded form. As the
+// metadata precedes the styling and drawing ops, it must be called before
+// them. Calling Reset with the Comment field set is equivalent.
+func (e *Encoder) SetComment(text string) {
+	if e.err != nil {
+		return
+	}
+	m := e.metadata
+	if e.mode == modeInitial {
+		m = Metadata{
+			ViewBox: DefaultViewBox,
+			Palette: DefaultPalette,
+		}
+	} else if len(e.buf) != e.metadataLen {
+		e.err = errCommentAfterOps
+		return
+	}
+	m.Comment = text
+	highResolutionCoordinates, maxBytes := e.HighResolutionCoordinates, e.MaxBytes
+	e.Reset(m)
+	e.HighResolutionCoordinates, e.MaxBytes = highResolutionCoordinates, maxBytes
 }
 
 func (e *Encoder) CSel() uint8 {
@@ -322,6 +381,10 @@ func (e *Encoder) SetLOD(lod0, lod1 float32) {
 // CREG[cBase+n-1]. Similarly, the offsets of the n stops are encoded at
 // NREG[nBase+0], NREG[nBase+1], ..., NREG[nBase+n-1]. Additional parameters
 // are stored at NREG[nBase-4], NREG[nBase-3], NREG[nBase-2] and NREG[nBase-1].
+// Each stop's offset is stored in full: the format has no run-length or delta
+// encoding of stops. The offsets should be strictly increasing and within [0,
+// 1], otherwise the gradient is invalid and paths filled with it are not
+// drawn.
 //
 // The CSEL and NSEL selector registers maintain the same values after the
 // method returns as they had when the method was called.
@@ -405,6 +468,12 @@ func (e *Encoder) SetCircularGradient(cBase, nBase uint8, cx, cy, rx, ry float32
 	}, spread, stops)
 }
 
+// SetRadialGradient is like SetCircularGradient except that the circle is
+// defined by its center (cx, cy) and its radius r, which must be positive.
+func (e *Encoder) SetRadialGradient(cBase, nBase uint8, cx, cy, r float32, spread GradientSpread, stops []GradientStop) {
+	e.SetCircularGradient(cBase, nBase, cx, cy, r, 0, spread, stops)
+}
+
 // SetEllipticalGradient is like SetGradient with radial=true except that the
 // transformation matrix is implicitly defined by a center (cx, cy) and two
 // axis vectors (rx, ry) and (sx, sy) such that (cx+rx, cy+ry) and (cx+sx,
@@ -558,6 +627,84 @@ func (e *Encoder) flushDrawOps() {
 	e.drawArgs = e.drawArgs[:0]
 }
 
+// EncodeSegments returns the encoded form of a graphic with the given
+// Metadata and a single path, made of the given absolute segments, filled
+// with the initial CREG[0] (the first custom palette color).
+//
+// The segments' sub-paths must each start with a MoveTo. For each segment,
+// the shorter of the absolute and relative encodings is chosen, and
+// consecutive segments with the same drawing op share an opcode.
+// Coordinates are quantized to 1/64th of a unit where possible, as per the
+// Encoder's default.
+func EncodeSegments(m Metadata, segs []Segment) ([]byte, error) {
+	var e Encoder
+	e.Reset(m)
+	if len(segs) == 0 {
+		return e.Bytes()
+	}
+	if segs[0].Op != SegmentOpMoveTo {
+		return nil, errInvalidSegment
+	}
+	e.StartPath(0, segs[0].Args[0][0], segs[0].Args[0][1])
+	start := f32.Vec2{e.quantize(segs[0].Args[0][0]), e.quantize(segs[0].Args[0][1])}
+	pen, rel := start, false
+
+	var absArgs, relArgs [6]float32
+	for _, s := range segs[1:] {
+		n, absOp, relOp := 0, byte(0), byte(0)
+		switch s.Op {
+		case SegmentOpMoveTo:
+			// The implicit close path moves the pen to the sub-path's start.
+			pen, n, absOp, relOp = start, 1, 'Y', 'y'
+		case SegmentOpLineTo:
+			n, absOp, relOp = 1, 'L', 'l'
+		case SegmentOpQuadTo:
+			n, absOp, relOp = 2, 'Q', 'q'
+		case SegmentOpCubeTo:
+			n, absOp, relOp = 3, 'C', 'c'
+		default:
+			return nil, errInvalidSegment
+		}
+
+		// Relative coordinates are only used if they decode to exactly the
+		// same points as absolute ones, so that rounding errors cannot
+		// accumulate. Ties favor the previous choice, which can avoid a new
+		// opcode.
+		absLen, relLen, exact := 0, 0, true
+		for i, p := range s.Args[:n] {
+			for j := 0; j < 2; j++ {
+				a := e.quantize(p[j])
+				r := e.quantize(p[j] - pen[j])
+				absLen += e.coordinateLen(a)
+				relLen += e.coordinateLen(r)
+				exact = exact && pen[j]+r == a
+				absArgs[2*i+j], relArgs[2*i+j] = a, r
+			}
+		}
+		rel = exact && (relLen < absLen || (relLen == absLen && rel))
+		op, args := absOp, &absArgs
+		if rel {
+			op, args = relOp, &relArgs
+		}
+		e.draw(op, args[0], args[1], args[2], args[3], args[4], args[5])
+
+		end := s.Args[n-1]
+		pen = f32.Vec2{e.quantize(end[0]), e.quantize(end[1])}
+		if s.Op == SegmentOpMoveTo {
+			start = pen
+		}
+	}
+	e.ClosePathEndPath()
+	return e.Bytes()
+}
+
+// coordinateLen returns the number of bytes that the coordinate number
+// encoding of f takes.
+func (e *Encoder) coordinateLen(f float32) int {
+	b := buffer(e.scratch[:0])
+	return b.encodeCoordinate(f)
+}
+
 func (e *Encoder) quantize(coord float32) float32 {
 	if !e.highResolutionCoordinates && (-128 <= coord && coord < 128) {
 		x := math.Floor(float64(coord*64 + 0.5))
@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"math"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"testing"
 
@@ -585,6 +586,98 @@ func TestEncodeGradient(t *testing.T) {
 	testEncode(t, &e, "testdata/gradient.ivg")
 }
 
+func TestEncodeGradientRoundTrip(t *testing.T) {
+	stops := []GradientStop{
+		{Offset: 0.000, Color: color.RGBA{0xff, 0x00, 0x00, 0xff}},
+		{Offset: 0.375, Color: color.RGBA{0x00, 0x40, 0x00, 0x40}},
+		{Offset: 1.000, Color: color.RGBA{0x00, 0x00, 0xff, 0xff}},
+	}
+	testCases := []struct {
+		desc string
+		set  func(e *Encoder)
+		want SceneGradient
+	}{{
+		desc: "linear",
+		set: func(e *Encoder) {
+			e.SetLinearGradient(10, 10, -8, 0, +8, 0, GradientSpreadPad, stops)
+		},
+		want: SceneGradient{
+			Transform: f32.Aff3{1.0 / 16, 0, 0.5, 0, 0, 0},
+			Spread:    GradientSpreadPad,
+		},
+	}, {
+		desc: "radial",
+		set: func(e *Encoder) {
+			e.SetRadialGradient(10, 10, 4, -4, 20, GradientSpreadReflect, stops)
+		},
+		want: SceneGradient{
+			Radial:    true,
+			Transform: f32.Aff3{1.0 / 20, 0, -0.2, 0, 1.0 / 20, 0.2},
+			Spread:    GradientSpreadReflect,
+		},
+	}, {
+		desc: "explicit",
+		set: func(e *Encoder) {
+			e.SetGradient(20, 30, true, f32.Aff3{0.1, 0.2, 0.3, -0.4, 0.5, 3}, GradientSpreadRepeat, stops)
+		},
+		want: SceneGradient{
+			Radial:    true,
+			Transform: f32.Aff3{0.1, 0.2, 0.3, -0.4, 0.5, 3},
+			Spread:    GradientSpreadRepeat,
+		},
+	}}
+
+	for _, tc := range testCases {
+		var e Encoder
+		tc.set(&e)
+		e.StartPath(0, -30, -30)
+		e.AbsHLineTo(+30)
+		e.AbsVLineTo(+30)
+		e.AbsHLineTo(-30)
+		e.ClosePathEndPath()
+		ivgData, err := e.Bytes()
+		if err != nil {
+			t.Errorf("%s: Bytes: %v", tc.desc, err)
+			continue
+		}
+		scene, err := BuildScene(ivgData, nil)
+		if err != nil {
+			t.Errorf("%s: BuildScene: %v", tc.desc, err)
+			continue
+		}
+		if len(scene.Paths) != 1 || scene.Paths[0].Gradient == nil {
+			t.Errorf("%s: got %d paths, want 1 gradient-filled path", tc.desc, len(scene.Paths))
+			continue
+		}
+		got := scene.Paths[0].Gradient
+
+		// Real numbers are encoded with up to 30 bits of precision.
+		const tolerance = 1e-6
+		if got.Radial != tc.want.Radial || got.Spread != tc.want.Spread {
+			t.Errorf("%s: got radial=%t, spread=%d, want radial=%t, spread=%d",
+				tc.desc, got.Radial, got.Spread, tc.want.Radial, tc.want.Spread)
+		}
+		for i, g := range got.Transform {
+			if w := tc.want.Transform[i]; math.Abs(float64(g-w)) > tolerance {
+				t.Errorf("%s: Transform[%d]: got %v, want %v", tc.desc, i, g, w)
+			}
+		}
+		if len(got.Stops) != len(stops) {
+			t.Errorf("%s: got %d stops, want %d", tc.desc, len(got.Stops), len(stops))
+			continue
+		}
+		for i, g := range got.Stops {
+			w := stops[i]
+			if math.Abs(float64(g.Offset-w.Offset)) > tolerance {
+				t.Errorf("%s: stop #%d: Offset: got %v, want %v", tc.desc, i, g.Offset, w.Offset)
+			}
+			if g.Color != w.Color {
+				t.Errorf("%s: stop #%d: Color: got %v, want %v", tc.desc, i, g.Color, w.Color)
+			}
+		}
+	}
+}
+
 func TestEncodeLODPolygon(t *testing.T) {
 	var e Encoder
 
@@ -699,3 +792,145 @@ func TestEncodeVideo005Primitive(t *testing.T) {
 
 	testEncode(t, &e, "testdata/video-005.primitive.ivg")
 }
+
+func TestEncodeSegments(t *testing.T) {
+	v := func(x, y float32) f32.Vec2 { return f32.Vec2{x, y} }
+	segs := []Segment{
+		{Op: SegmentOpMoveTo, Args: [3]f32.Vec2{v(-30, -30)}},
+		{Op: SegmentOpLineTo, Args: [3]f32.Vec2{v(-29, -30)}},
+		{Op: SegmentOpLineTo, Args: [3]f32.Vec2{v(-28, -29.5)}},
+		{Op: SegmentOpQuadTo, Args: [3]f32.Vec2{v(20, -30), v(30, 0)}},
+		{Op: SegmentOpCubeTo, Args: [3]f32.Vec2{v(30, 10), v(31, 11), v(29.25, 12)}},
+		{Op: SegmentOpMoveTo, Args: [3]f32.Vec2{v(100, 100)}},
+		{Op: SegmentOpLineTo, Args: [3]f32.Vec2{v(101, 101)}},
+		{Op: SegmentOpLineTo, Args: [3]f32.Vec2{v(102, 100)}},
+	}
+	m := Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	}
+	got, err := EncodeSegments(m, segs)
+	if err != nil {
+		t.Fatalf("EncodeSegments: %v", err)
+	}
+
+	var c pathCollector
+	if err := Decode(&c, got, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(c.path.Segments, segs) {
+		t.Errorf("round trip:\ngot  %v\nwant %v", c.path.Segments, segs)
+	}
+
+	// Compare with encoding every segment with absolute coordinates.
+	var e Encoder
+	e.Reset(m)
+	e.StartPath(0, -30, -30)
+	e.AbsLineTo(-29, -30)
+	e.AbsLineTo(-28, -29.5)
+	e.AbsQuadTo(20, -30, 30, 0)
+	e.AbsCubeTo(30, 10, 31, 11, 29.25, 12)
+	e.ClosePathAbsMoveTo(100, 100)
+	e.AbsLineTo(101, 101)
+	e.AbsLineTo(102, 100)
+	e.ClosePathEndPath()
+	abs, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if len(got) >= len(abs) {
+		t.Errorf("got %d bytes, want fewer than %d", len(got), len(abs))
+	}
+
+	if _, err := EncodeSegments(m, segs[1:]); err != errInvalidSegment {
+		t.Errorf("no initial MoveTo: got %v, want %v", err, errInvalidSegment)
+	}
+}
+
+func TestEncodeComment(t *testing.T) {
+	const comment = "made by gopher-draw 1.0, © the Gophers"
+	var e Encoder
+	e.HighResolutionCoordinates = true
+	e.SetComment(comment)
+	if !e.HighResolutionCoordinates {
+		t.Errorf("SetComment reset HighResolutionCoordinates")
+	}
+	e.StartPath(0, 0, 0)
+	e.AbsLineTo(10, 0)
+	e.AbsLineTo(10, 10)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	m, err := DecodeMetadata(ivgData)
+	if err != nil {
+		t.Fatalf("DecodeMetadata: %v", err)
+	}
+	want := Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+		Comment: comment,
+	}
+	if m != want {
+		t.Errorf("DecodeMetadata: got %v, want %v", m, want)
+	}
+	if _, err := disassemble(ivgData); err != nil {
+		t.Errorf("disassemble: %v", err)
+	}
+
+	// Setting the Comment via Reset gives the same encoded form.
+	e = Encoder{}
+	e.Reset(want)
+	e.HighResolutionCoordinates = true
+	e.StartPath(0, 0, 0)
+	e.AbsLineTo(10, 0)
+	e.AbsLineTo(10, 10)
+	e.ClosePathEndPath()
+	if got, err := e.Bytes(); err != nil {
+		t.Fatalf("Reset: Bytes: %v", err)
+	} else if !bytes.Equal(got, ivgData) {
+		t.Errorf("Reset: got % x, want % x", got, ivgData)
+	}
+
+	e = Encoder{}
+	e.SetCSel(1)
+	e.SetComment(comment)
+	if _, err := e.Bytes(); err != errCommentAfterOps {
+		t.Errorf("after ops: got %v, want %v", err, errCommentAfterOps)
+	}
+
+	e = Encoder{}
+	e.SetComment("\xff")
+	if _, err := e.Bytes(); err != errInvalidComment {
+		t.Errorf("invalid UTF-8: got %v, want %v", err, errInvalidComment)
+	}
+
+	// The decoder skips, rather than rejects, a chunk with an unknown MID and
+	// a comment that is not valid UTF-8. The metadata has 2 chunks: one 3
+	// bytes long, with MID 7, and one 4 bytes long, with MID midComment.
+	other := append(append([]byte(nil), magic...),
+		0x04,
+		0x06, 0x0e, 'h', 'i',
+		0x08, 0xfd, 0xff, 'a', 0xff,
+	)
+	m, err = DecodeMetadata(other)
+	if err != nil {
+		t.Fatalf("other: DecodeMetadata: %v", err)
+	}
+	if m.Comment != "" {
+		t.Errorf("other: Comment: got %q, want %q", m.Comment, "")
+	}
+	chunks, err := DecodeMetadataChunks(other)
+	if err != nil {
+		t.Fatalf("other: DecodeMetadataChunks: %v", err)
+	}
+	wantChunks := []MetadataChunk{
+		{MID: 7, Data: []byte("hi")},
+		{MID: midComment, Data: []byte("a\xff")},
+	}
+	if !reflect.DeepEqual(chunks, wantChunks) {
+		t.Errorf("other: DecodeMetadataChunks: got %v, want %v", chunks, wantChunks)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/math/f32"
+)
+
+// EPSExporter is a Destination that converts an IconVG graphic to an
+// Encapsulated PostScript (EPS) file.
+//
+// Each path becomes a newpath ... fill sequence, with coordinates in
+// PostScript points. IconVG paths are always filled under the non-zero
+// winding rule, which is PostScript's fill operator, so eofill is never used.
+// PostScript's Y axis increases upwards, so the graphic is flipped vertically
+// to keep it the right way up. Quadratic Bézier curves are converted to cubic
+// ones, and arcs are approximated by cubic ones. Paths filled with gradients
+// are not drawn.
+//
+// PostScript has no transparency, so translucent colors are blended with
+// white, as if printed on white paper, and fully transparent paths are not
+// drawn.
+type EPSExporter struct {
+	// Width is the width, in PostScript points, of the picture. The height
+	// follows from the ViewBox's aspect ratio. If zero, one unit of graphic
+	// coordinate space is one point.
+	//
+	// PostScript has no concept of level of detail, so paths are selected as
+	// if the IconVG graphic was rendered at a height, in pixels, equal to the
+	// picture's height in points.
+	Width float32
+
+	segmenter
+
+	buf     []byte
+	scale   float32
+	height  float32
+	visible bool
+	subpath bool
+	last    f32.Vec2
+}
+
+// Bytes returns the EPS file.
+func (e *EPSExporter) Bytes() []byte {
+	b := append([]byte(nil), e.buf...)
+	return append(b, "showpage\n%%EOF\n"...)
+}
+
+// Reset resets the EPSExporter for the given Metadata, and starts a file
+// whose bounding box is its ViewBox.
+func (e *EPSExporter) Reset(m Metadata) {
+	e.segmenter.reset(m, e)
+	e.buf = e.buf[:0]
+	e.visible = false
+
+	dx, dy := m.ViewBox.AspectRatio()
+	e.scale = 1
+	if e.Width > 0 && dx > 0 {
+		e.scale = e.Width / dx
+	}
+	e.height = dy * e.scale
+	w, h := dx*e.scale, e.height
+	e.buf = append(e.buf, "%!PS-Adobe-3.0 EPSF-3.0\n"...)
+	e.buf = append(e.buf, fmt.Sprintf("%%%%BoundingBox: 0 0 %d %d\n",
+		int(math.Ceil(float64(w))), int(math.Ceil(float64(h))))...)
+	e.buf = append(e.buf, fmt.Sprintf("%%%%HiResBoundingBox: 0 0 %s %s\n",
+		tikzNumber(w), tikzNumber(h))...)
+	e.buf = append(e.buf, "%%EndComments\n"...)
+}
+
+// point appends p, in graphic coordinate space, as two PostScript operands.
+func (e *EPSExporter) point(p f32.Vec2) {
+	vb := &e.metadata.ViewBox
+	// PostScript, like TeX, does not parse every number with an exponent, so
+	// use the same fixed point notation as TikZExporter.
+	e.buf = append(e.buf, tikzNumber((p[0]-vb.Min[0])*e.scale)...)
+	e.buf = append(e.buf, ' ')
+	e.buf = append(e.buf, tikzNumber((vb.Max[1]-p[1])*e.scale)...)
+	e.buf = append(e.buf, ' ')
+}
+
+func (e *EPSExporter) beginPath() {
+	// Gradients, and invalid colors, are skipped.
+	e.visible = e.lod0 <= e.height && e.height < e.lod1 &&
+		e.fill.A != 0 && validAlphaPremulColor(e.fill)
+	if !e.visible {
+		return
+	}
+	// Blend the alpha-premultiplied color with white.
+	c := srcOver(e.fill, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	for _, x := range [3]uint8{c.R, c.G, c.B} {
+		e.buf = strconv.AppendFloat(e.buf, float64(x)/0xff, 'f', 4, 64)
+		e.buf = append(e.buf, ' ')
+	}
+	e.buf = append(e.buf, "setrgbcolor\nnewpath\n"...)
+	e.subpath = false
+}
+
+func (e *EPSExporter) addSegment(s Segment) {
+	if !e.visible {
+		return
+	}
+	switch s.Op {
+	case SegmentOpMoveTo:
+		if e.subpath {
+			e.buf = append(e.buf, "closepath\n"...)
+		}
+		e.subpath = true
+		e.point(s.Args[0])
+		e.buf = append(e.buf, "moveto"...)
+	case SegmentOpLineTo:
+		e.point(s.Args[0])
+		e.buf = append(e.buf, "lineto"...)
+	case SegmentOpQuadTo:
+		c1, c2 := quadToCubic(e.last, s.Args[0], s.Args[1])
+		e.point(c1)
+		e.point(c2)
+		e.point(s.Args[1])
+		e.buf = append(e.buf, "curveto"...)
+	case SegmentOpCubeTo:
+		e.point(s.Args[0])
+		e.point(s.Args[1])
+		e.point(s.Args[2])
+		e.buf = append(e.buf, "curveto"...)
+	}
+	e.buf = append(e.buf, '\n')
+	e.last = s.end()
+}
+
+func (e *EPSExporter) endPath() {
+	if !e.visible {
+		return
+	}
+	e.buf = append(e.buf, "closepath fill\n"...)
+	e.visible = false
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+)
+
+func TestEPSExporter(t *testing.T) {
+	var enc Encoder
+	enc.Reset(Metadata{
+		ViewBox: Rectangle{Min: [2]float32{0, 0}, Max: [2]float32{40, 20}},
+		Palette: DefaultPalette,
+	})
+	enc.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x80, 0x80}))
+	enc.StartPath(0, 0, 0)
+	enc.AbsHLineTo(10)
+	enc.AbsQuadTo(10, 10, 0, 10)
+	enc.ClosePathAbsMoveTo(20, 5)
+	enc.AbsCubeTo(30, 5, 30, 15, 20, 15)
+	enc.ClosePathEndPath()
+	ivgData, err := enc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	e := &EPSExporter{Width: 80}
+	if err := Decode(e, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	got := e.Bytes()
+	want := "%!PS-Adobe-3.0 EPSF-3.0\n" +
+		"%%BoundingBox: 0 0 80 40\n" +
+		"%%HiResBoundingBox: 0 0 80 40\n" +
+		"%%EndComments\n" +
+		// Half-transparent dark blue, over white, is a light blue.
+		"0.4980 0.4980 1.0000 setrgbcolor\n" +
+		"newpath\n" +
+		"0 40 moveto\n" +
+		"20 40 lineto\n" +
+		"20 26.6667 13.3333 20 0 20 curveto\n" +
+		"closepath\n" +
+		"40 30 moveto\n" +
+		"60 30 60 10 40 10 curveto\n" +
+		"closepath fill\n" +
+		"showpage\n%%EOF\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Fully transparent paths are not drawn.
+	enc.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	enc.SetCReg(0, false, RGBAColor(color.RGBA{}))
+	enc.StartPath(0, 0, 0)
+	enc.AbsHLineTo(10)
+	enc.AbsVLineTo(10)
+	enc.ClosePathEndPath()
+	if ivgData, err = enc.Bytes(); err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	if err := Decode(e, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got := e.Bytes(); bytes.Contains(got, []byte("fill")) {
+		t.Errorf("transparent path: got:\n%s", got)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+// FeatureSet is a set of the capabilities that an IconVG graphic exercises,
+// as returned by Features. Renderers with limited backends can check it
+// before rendering.
+//
+// IconVG has no groups, so there is no corresponding feature.
+type FeatureSet uint32
+
+const (
+	// FeatureGradients means that at least one path is filled with a
+	// gradient.
+	FeatureGradients FeatureSet = 1 << iota
+	// FeatureArcs means that the graphic uses arcTo drawing opcodes.
+	FeatureArcs
+	// FeatureLOD means that at least one path has a level of detail range
+	// other than the default, [0, +∞).
+	FeatureLOD
+	// FeatureCustomPalette means that the graphic uses colors from the custom
+	// palette, directly or via a blend, so that its rendering depends on the
+	// palette passed to Decode.
+	FeatureCustomPalette
+)
+
+// Features returns the capabilities that the IconVG graphic src exercises. It
+// decodes the graphic, without rendering it.
+func Features(src []byte) (FeatureSet, error) {
+	var c featureCollector
+	if err := Decode(&c, src, nil); err != nil {
+		return 0, err
+	}
+	return c.features, nil
+}
+
+// featureCollector is a Destination that records the capabilities that a
+// graphic exercises.
+type featureCollector struct {
+	segmenter
+	features FeatureSet
+}
+
+func (c *featureCollector) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	c.features = 0
+}
+
+func (c *featureCollector) SetCReg(adj uint8, incr bool, x Color) {
+	switch x.typ {
+	case ColorTypePaletteIndex:
+		c.features |= FeatureCustomPalette
+	case ColorTypeBlend:
+		_, c0, c1 := x.blend()
+		if decodeColor1(c0).typ == ColorTypePaletteIndex || decodeColor1(c1).typ == ColorTypePaletteIndex {
+			c.features |= FeatureCustomPalette
+		}
+	}
+	c.segmenter.SetCReg(adj, incr, x)
+}
+
+func (c *featureCollector) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	c.features |= FeatureArcs
+	c.segmenter.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+}
+
+func (c *featureCollector) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	c.features |= FeatureArcs
+	c.segmenter.RelArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+}
+
+func (c *featureCollector) beginPath() {
+	if c.lod0 != 0 || c.lod1 != positiveInfinity {
+		c.features |= FeatureLOD
+	}
+	if c.fill.A == 0x00 && c.fill.B&0x80 != 0 {
+		c.features |= FeatureGradients
+	}
+}
+
+func (c *featureCollector) addSegment(s Segment) {}
+func (c *featureCollector) endPath()             {}
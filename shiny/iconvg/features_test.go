@@ -0,0 +1,43 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestFeatures(t *testing.T) {
+	testCases := []struct {
+		filename string
+		want     FeatureSet
+	}{
+		{"action-info.lores", 0},
+		{"arcs", FeatureArcs},
+		{"blank", 0},
+		{"cowbell", FeatureGradients},
+		{"elliptical", FeatureGradients},
+		{"favicon", FeatureArcs | FeatureCustomPalette},
+		{"gradient", FeatureGradients},
+		{"lod-polygon", FeatureLOD},
+		{"video-005.primitive", 0},
+	}
+	for _, tc := range testCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/" + tc.filename + ".ivg"))
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		got, err := Features(ivgData)
+		if err != nil {
+			t.Errorf("%s: Features: %v", tc.filename, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %#x, want %#x", tc.filename, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"golang.org/x/image/math/f32"
+)
+
+// flattenLimiter is a Destination that counts the line segments that the
+// paths would be flattened to, and aborts decoding once there are more than
+// max of them. It is teed alongside the Destination being decoded to.
+//
+// Curves are counted as if flattened to within flattenTolerance, which is
+// only an estimate of what any particular Destination does, but it grows with
+// the same things: the number of curves and how much they bend.
+type flattenLimiter struct {
+	segmenter
+	max  int
+	n    int
+	last f32.Vec2
+}
+
+func (l *flattenLimiter) Reset(m Metadata) {
+	l.segmenter.reset(m, l)
+	l.n = 0
+}
+
+func (l *flattenLimiter) abortErr() error {
+	if l.n > l.max {
+		return errTooManyFlattenedSegments
+	}
+	return nil
+}
+
+func (l *flattenLimiter) beginPath() {}
+
+func (l *flattenLimiter) addSegment(s Segment) {
+	switch s.Op {
+	case SegmentOpLineTo:
+		l.n++
+	case SegmentOpQuadTo:
+		c1, c2 := quadToCubic(l.last, s.Args[0], s.Args[1])
+		l.n += flattenCount(flattenTolerance, l.last, c1, c2, s.Args[1])
+	case SegmentOpCubeTo:
+		l.n += flattenCount(flattenTolerance, l.last, s.Args[0], s.Args[1], s.Args[2])
+	}
+	l.last = s.end()
+}
+
+func (l *flattenLimiter) endPath() {}
@@ -0,0 +1,57 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestMaxFlattenedSegments(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, -32, 0)
+	e.AbsLineTo(0, -32)
+	e.AbsLineTo(+32, 0)
+	// Each curve bends a lot, and so is flattened to many line segments.
+	for i := 0; i < 16; i++ {
+		e.AbsCubeTo(+32, +32, -32, -32, -32, +32)
+		e.AbsCubeTo(-32, -32, +32, +32, +32, 0)
+	}
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	var z Rasterizer
+	for _, tc := range []struct {
+		max     int
+		wantErr error
+	}{
+		{0, nil},
+		{2, errTooManyFlattenedSegments},
+		{100, errTooManyFlattenedSegments},
+		{1 << 20, nil},
+	} {
+		z.SetDstImage(dst, dst.Bounds(), draw.Src)
+		opts := &DecodeOptions{MaxFlattenedSegments: tc.max}
+		if err := Decode(&z, ivgData, opts); err != tc.wantErr {
+			t.Errorf("Rasterizer, max=%d: got %v, want %v", tc.max, err, tc.wantErr)
+		}
+		// The limit applies to any Destination, not just rasterizers.
+		var b BoundsCollector
+		if err := Decode(&b, ivgData, opts); err != tc.wantErr {
+			t.Errorf("BoundsCollector, max=%d: got %v, want %v", tc.max, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"golang.org/x/image/math/f32"
+)
+
+// FlipDestination returns a Destination that forwards each method call to
+// inner, with the geometry reflected horizontally, vertically or both, about
+// the center of m's ViewBox. For example, a horizontally flipped icon can be
+// used for right-to-left locales.
+//
+// A reflection in one axis reverses the direction in which an arc is swept,
+// so arcs' sweep flags are inverted and their x-axis rotations negated.
+// Gradients are also reflected, by adjusting the transformation matrices
+// held in inner's NREG registers for the duration of each gradient-filled
+// path, and restoring them afterwards.
+func FlipDestination(inner Destination, m Metadata, horizontal, vertical bool) Destination {
+	f := &scaleDestination{
+		inner: inner,
+		sx:    +1,
+		sy:    +1,
+	}
+	if horizontal {
+		f.sx, f.tx = -1, m.ViewBox.Min[0]+m.ViewBox.Max[0]
+	}
+	if vertical {
+		f.sy, f.ty = -1, m.ViewBox.Min[1]+m.ViewBox.Max[1]
+	}
+	return f
+}
+
+// unitSquareDestination returns a Destination that forwards each method call
+// to inner, with the geometry scaled and translated so that the ViewBox vb
+// fits, centered, in the unit square. It returns inner if vb is empty.
+func unitSquareDestination(inner Destination, vb Rectangle) Destination {
+	return fitDestination(inner, vb, Rectangle{Max: f32.Vec2{1, 1}})
+}
+
+// fitDestination returns a Destination that forwards each method call to
+// inner, with the geometry uniformly scaled and translated so that the
+// ViewBox vb fits, centered, in the non-empty rectangle r, preserving vb's
+// aspect ratio. It returns inner if vb is empty.
+func fitDestination(inner Destination, vb Rectangle, r Rectangle) Destination {
+	dx, dy := vb.AspectRatio()
+	rx, ry := r.AspectRatio()
+	s := min32(rx/dx, ry/dy)
+	if !(s > 0) || isNaNOrInfinity(s) {
+		return inner
+	}
+	return &scaleDestination{
+		inner: inner,
+		sx:    s,
+		sy:    s,
+		tx:    r.Min[0] + rx/2 - s*(vb.Min[0]+dx/2),
+		ty:    r.Min[1] + ry/2 - s*(vb.Min[1]+dy/2),
+	}
+}
+
+// scaleDestination is a Destination that applies the affine transformation
+// (x, y) → (sx*x + tx, sy*y + ty), where sx and sy are non-zero and have the
+// same magnitude, before forwarding to an inner Destination.
+type scaleDestination struct {
+	inner  Destination
+	sx, sy float32
+	tx, ty float32
+
+	// regs tracks the registers, to find the gradients' matrices.
+	regs segmenter
+	// restore is whether the NREG registers starting at nBase-6 need to be
+	// restored when the current path ends.
+	restore bool
+	nBase   uint8
+}
+
+func (f *scaleDestination) abortErr() error {
+	if a, ok := f.inner.(aborter); ok {
+		return a.abortErr()
+	}
+	return nil
+}
+
+func (f *scaleDestination) absX(x float32) float32   { return f.sx*x + f.tx }
+func (f *scaleDestination) absY(y float32) float32   { return f.sy*y + f.ty }
+func (f *scaleDestination) relX(x float32) float32   { return f.sx * x }
+func (f *scaleDestination) relY(y float32) float32   { return f.sy * y }
+func (f *scaleDestination) radius(r float32) float32 { return abs32(f.sx) * r }
+
+// arc returns the x-axis rotation and sweep flag of a transformed arc.
+func (f *scaleDestination) arc(xAxisRotation float32, sweep bool) (float32, bool) {
+	if (f.sx < 0) != (f.sy < 0) {
+		return -xAxisRotation, !sweep
+	}
+	return xAxisRotation, sweep
+}
+
+func (f *scaleDestination) Reset(m Metadata) {
+	f.regs.reset(m, nil)
+	f.restore = false
+	vb := &m.ViewBox
+	x0, x1 := f.absX(vb.Min[0]), f.absX(vb.Max[0])
+	y0, y1 := f.absY(vb.Min[1]), f.absY(vb.Max[1])
+	*vb = Rectangle{
+		Min: f32.Vec2{min32(x0, x1), min32(y0, y1)},
+		Max: f32.Vec2{max32(x0, x1), max32(y0, y1)},
+	}
+	f.inner.Reset(m)
+}
+
+func (f *scaleDestination) SetCSel(cSel uint8) {
+	f.regs.SetCSel(cSel)
+	f.inner.SetCSel(cSel)
+}
+
+func (f *scaleDestination) SetNSel(nSel uint8) {
+	f.regs.SetNSel(nSel)
+	f.inner.SetNSel(nSel)
+}
+
+func (f *scaleDestination) SetCReg(adj uint8, incr bool, c Color) {
+	f.regs.SetCReg(adj, incr, c)
+	f.inner.SetCReg(adj, incr, c)
+}
+
+func (f *scaleDestination) SetNReg(adj uint8, incr bool, x float32) {
+	f.regs.SetNReg(adj, incr, x)
+	f.inner.SetNReg(adj, incr, x)
+}
+
+func (f *scaleDestination) SetLOD(lod0, lod1 float32) {
+	f.inner.SetLOD(lod0, lod1)
+}
+
+// setMatrix sets inner's NREG[nBase-6] to NREG[nBase-1] registers to m, and
+// then restores inner's NSEL.
+func (f *scaleDestination) setMatrix(nBase uint8, m *[6]float32) {
+	f.inner.SetNSel(nBase)
+	for i, x := range m {
+		f.inner.SetNReg(uint8(6-i), false, x)
+	}
+	f.inner.SetNSel(f.regs.nSel)
+}
+
+func (f *scaleDestination) StartPath(adj uint8, x, y float32) {
+	c := f.regs.cReg[(f.regs.cSel-adj)&0x3f]
+	if (f.sx != 1 || f.sy != 1 || f.tx != 0 || f.ty != 0) && c.A == 0x00 && c.B&0x80 != 0 {
+		// The gradient's matrix, m, maps graphic space to gradient space.
+		// The transformed gradient's matrix is m composed with the inverse
+		// transformation, (x, y) → ((x - tx)/sx, (y - ty)/sy).
+		f.restore, f.nBase = true, c.B&0x3f
+		var m [6]float32
+		for i := range m {
+			m[i] = f.regs.nReg[(f.nBase-6+uint8(i))&0x3f]
+		}
+		m[0], m[1] = m[0]/f.sx, m[1]/f.sy
+		m[3], m[4] = m[3]/f.sx, m[4]/f.sy
+		m[2] -= m[0]*f.tx + m[1]*f.ty
+		m[5] -= m[3]*f.tx + m[4]*f.ty
+		f.setMatrix(f.nBase, &m)
+	}
+	f.inner.StartPath(adj, f.absX(x), f.absY(y))
+}
+
+func (f *scaleDestination) ClosePathEndPath() {
+	f.inner.ClosePathEndPath()
+	if f.restore {
+		f.restore = false
+		var m [6]float32
+		for i := range m {
+			m[i] = f.regs.nReg[(f.nBase-6+uint8(i))&0x3f]
+		}
+		f.setMatrix(f.nBase, &m)
+	}
+}
+
+func (f *scaleDestination) ClosePathAbsMoveTo(x, y float32) {
+	f.inner.ClosePathAbsMoveTo(f.absX(x), f.absY(y))
+}
+
+func (f *scaleDestination) ClosePathRelMoveTo(x, y float32) {
+	f.inner.ClosePathRelMoveTo(f.relX(x), f.relY(y))
+}
+
+func (f *scaleDestination) AbsHLineTo(x float32) { f.inner.AbsHLineTo(f.absX(x)) }
+func (f *scaleDestination) RelHLineTo(x float32) { f.inner.RelHLineTo(f.relX(x)) }
+func (f *scaleDestination) AbsVLineTo(y float32) { f.inner.AbsVLineTo(f.absY(y)) }
+func (f *scaleDestination) RelVLineTo(y float32) { f.inner.RelVLineTo(f.relY(y)) }
+
+func (f *scaleDestination) AbsLineTo(x, y float32) {
+	f.inner.AbsLineTo(f.absX(x), f.absY(y))
+}
+
+func (f *scaleDestination) RelLineTo(x, y float32) {
+	f.inner.RelLineTo(f.relX(x), f.relY(y))
+}
+
+func (f *scaleDestination) AbsSmoothQuadTo(x, y float32) {
+	f.inner.AbsSmoothQuadTo(f.absX(x), f.absY(y))
+}
+
+func (f *scaleDestination) RelSmoothQuadTo(x, y float32) {
+	f.inner.RelSmoothQuadTo(f.relX(x), f.relY(y))
+}
+
+func (f *scaleDestination) AbsQuadTo(x1, y1, x, y float32) {
+	f.inner.AbsQuadTo(f.absX(x1), f.absY(y1), f.absX(x), f.absY(y))
+}
+
+func (f *scaleDestination) RelQuadTo(x1, y1, x, y float32) {
+	f.inner.RelQuadTo(f.relX(x1), f.relY(y1), f.relX(x), f.relY(y))
+}
+
+func (f *scaleDestination) AbsSmoothCubeTo(x2, y2, x, y float32) {
+	f.inner.AbsSmoothCubeTo(f.absX(x2), f.absY(y2), f.absX(x), f.absY(y))
+}
+
+func (f *scaleDestination) RelSmoothCubeTo(x2, y2, x, y float32) {
+	f.inner.RelSmoothCubeTo(f.relX(x2), f.relY(y2), f.relX(x), f.relY(y))
+}
+
+func (f *scaleDestination) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
+	f.inner.AbsCubeTo(f.absX(x1), f.absY(y1), f.absX(x2), f.absY(y2), f.absX(x), f.absY(y))
+}
+
+func (f *scaleDestination) RelCubeTo(x1, y1, x2, y2, x, y float32) {
+	f.inner.RelCubeTo(f.relX(x1), f.relY(y1), f.relX(x2), f.relY(y2), f.relX(x), f.relY(y))
+}
+
+func (f *scaleDestination) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	xAxisRotation, sweep = f.arc(xAxisRotation, sweep)
+	f.inner.AbsArcTo(f.radius(rx), f.radius(ry), xAxisRotation, largeArc, sweep, f.absX(x), f.absY(y))
+}
+
+func (f *scaleDestination) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	xAxisRotation, sweep = f.arc(xAxisRotation, sweep)
+	f.inner.RelArcTo(f.radius(rx), f.radius(ry), xAxisRotation, largeArc, sweep, f.relX(x), f.relY(y))
+}
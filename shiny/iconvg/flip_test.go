@@ -0,0 +1,159 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestFlipDestination(t *testing.T) {
+	// A shape, with arcs whose sweep direction matters, that is asymmetric in
+	// both axes.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, -28, -8)
+	e.AbsArcTo(12, 8, 0.1, false, true, +4, -24)
+	e.RelArcTo(6, 6, 0, true, false, +20, +12)
+	e.AbsLineTo(+20, +28)
+	e.AbsArcTo(20, 20, 0, false, false, -28, -8)
+	e.ClosePathEndPath()
+	arcs, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	gradient, err := ioutil.ReadFile(filepath.FromSlash("testdata/gradient.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	const size = 64
+	for _, src := range []struct {
+		name string
+		data []byte
+	}{
+		{"arcs", arcs},
+		{"gradient", gradient},
+	} {
+		want, err := rasterize(src.data, size, size, nil)
+		if err != nil {
+			t.Fatalf("%s: rasterize: %v", src.name, err)
+		}
+		for _, flip := range []struct {
+			horizontal, vertical bool
+		}{{true, false}, {false, true}, {true, true}} {
+			m, err := DecodeMetadata(src.data)
+			if err != nil {
+				t.Fatalf("%s: DecodeMetadata: %v", src.name, err)
+			}
+			got := image.NewRGBA(image.Rect(0, 0, size, size))
+			var z Rasterizer
+			z.SetDstImage(got, got.Bounds(), draw.Src)
+			if err := Decode(FlipDestination(&z, m, flip.horizontal, flip.vertical), src.data, nil); err != nil {
+				t.Fatalf("%s: Decode: %v", src.name, err)
+			}
+
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					mx, my := x, y
+					if flip.horizontal {
+						mx = size - 1 - x
+					}
+					if flip.vertical {
+						my = size - 1 - y
+					}
+					// Curves are flattened differently when drawn in the
+					// other direction, so anti-aliased edges can differ
+					// slightly. A wrong sweep flag or gradient would differ
+					// by much more.
+					if g, w := got.RGBAAt(x, y), want.RGBAAt(mx, my); !closeRGBA(g, w, 8) {
+						t.Fatalf("%s, flip %+v: at (%d, %d): got %v, want %v",
+							src.name, flip, x, y, g, w)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeNormalizeToUnitSquare(t *testing.T) {
+	// A graphic, twice as wide as it is high, with an arc.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: Rectangle{Min: f32.Vec2{0, 0}, Max: f32.Vec2{40, 20}},
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, 4, 4)
+	e.AbsLineTo(36, 4)
+	e.AbsArcTo(8, 6, 0, false, true, 24, 16)
+	e.ClosePathEndPath()
+	wide, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var r pathsRecorder
+	if err := Decode(&r, wide, &DecodeOptions{NormalizeToUnitSquare: true}); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	wantViewBox := Rectangle{Min: f32.Vec2{0, 0.25}, Max: f32.Vec2{1, 0.75}}
+	if got := r.metadata.ViewBox; got != wantViewBox {
+		t.Errorf("ViewBox: got %v, want %v", got, wantViewBox)
+	}
+	if len(r.paths) != 1 {
+		t.Fatalf("got %d paths, want 1", len(r.paths))
+	}
+	segs := r.paths[0].Segments
+	if got, want := segs[0].Args[0], (f32.Vec2{0.1, 0.35}); got != want {
+		t.Errorf("start: got %v, want %v", got, want)
+	}
+	if got, want := segs[len(segs)-1].end(), (f32.Vec2{0.6, 0.65}); got != want {
+		t.Errorf("end: got %v, want %v", got, want)
+	}
+
+	gradient, err := ioutil.ReadFile(filepath.FromSlash("testdata/gradient.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	// The Rasterizer maps the ViewBox to the destination image, so
+	// normalizing should not change the rasterization.
+	for _, src := range []struct {
+		name string
+		data []byte
+		w, h int
+	}{
+		{"wide", wide, 64, 32},
+		{"gradient", gradient, 64, 64},
+	} {
+		want, err := rasterize(src.data, src.w, src.h, nil)
+		if err != nil {
+			t.Fatalf("%s: rasterize: %v", src.name, err)
+		}
+		got, err := rasterize(src.data, src.w, src.h, &DecodeOptions{NormalizeToUnitSquare: true})
+		if err != nil {
+			t.Fatalf("%s: rasterize: %v", src.name, err)
+		}
+		for y := 0; y < src.h; y++ {
+			for x := 0; x < src.w; x++ {
+				if g, w := got.RGBAAt(x, y), want.RGBAAt(x, y); !closeRGBA(g, w, 2) {
+					t.Fatalf("%s: at (%d, %d): got %v, want %v", src.name, x, y, g, w)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build fyne
+
+package iconvg
+
+import (
+	"image"
+	"sync"
+
+	"fyne.io/fyne/v2/canvas"
+)
+
+// FyneRaster returns a Fyne canvas object that draws an IconVG graphic,
+// stretched to fill the object. The graphic is rasterized at the object's
+// size in pixels, and again whenever that size changes, so that it stays
+// crisp. Between size changes, the last rasterization is reused.
+//
+// The src bytes are retained, and should not be modified while the object is
+// in use. The graphic is checked to be valid before FyneRaster returns.
+//
+// FyneRaster is only built with the "fyne" build tag, so that the package
+// does not otherwise depend on Fyne.
+func FyneRaster(src []byte, opts *DecodeOptions) (*canvas.Raster, error) {
+	if err := Decode(nil, src, opts); err != nil {
+		return nil, err
+	}
+	r := &fyneRasterizer{src: src, opts: opts}
+	return canvas.NewRaster(r.generate), nil
+}
+
+// fyneRasterizer caches the most recent rasterization of a FyneRaster.
+type fyneRasterizer struct {
+	src  []byte
+	opts *DecodeOptions
+
+	mu   sync.Mutex
+	size image.Point
+	dst  *image.RGBA
+}
+
+// generate is a canvas.Raster's Generator. Fyne may call it from multiple
+// goroutines.
+func (r *fyneRasterizer) generate(w, h int) image.Image {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if size := image.Pt(w, h); r.dst == nil || r.size != size {
+		dst, err := rasterize(r.src, w, h, r.opts)
+		if err != nil {
+			// The graphic was valid when FyneRaster was called, so this
+			// should only happen if r.src was modified, or a Deadline
+			// passed. Draw nothing.
+			dst = image.NewRGBA(image.Rect(0, 0, w, h))
+		}
+		r.size, r.dst = size, dst
+	}
+	return r.dst
+}
@@ -0,0 +1,42 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build fyne
+
+package iconvg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestFyneRaster(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if _, err := FyneRaster(ivgData, nil); err != nil {
+		t.Fatalf("FyneRaster: %v", err)
+	}
+	if _, err := FyneRaster(ivgData[:len(ivgData)-1], nil); err == nil {
+		t.Fatalf("FyneRaster of a truncated graphic: got nil error, want non-nil")
+	}
+
+	r := &fyneRasterizer{src: ivgData}
+	m0 := r.generate(24, 24)
+	if got, want := m0.Bounds().Dx(), 24; got != want {
+		t.Fatalf("width: got %d, want %d", got, want)
+	}
+	if m1 := r.generate(24, 24); m1 != m0 {
+		t.Errorf("same size: got a new image, want the cached one")
+	}
+	m2 := r.generate(48, 32)
+	if m2 == m0 {
+		t.Errorf("new size: got the cached image, want a new one")
+	}
+	if got, want := m2.Bounds().Size().Y, 32; got != want {
+		t.Errorf("height: got %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"strconv"
+
+	"golang.org/x/image/math/f32"
+)
+
+// GeoJSONExporter is a Destination that converts an IconVG graphic's filled
+// shapes to a GeoJSON (RFC 7946) MultiPolygon geometry.
+//
+// Curves are flattened to line segments, to within 1/64th of a unit of
+// graphic coordinate space. Each path's sub-paths become linear rings, which
+// are outer rings or holes according to IconVG's non-zero winding fill rule.
+// Sub-paths that do not bound the filled region, such as one nested inside,
+// and winding the same way as, another, are dropped. Each outer ring, and the
+// holes directly inside it, form one polygon. As GeoJSON requires, outer
+// rings are counter-clockwise, holes are clockwise, and every ring's last
+// position is the same as its first.
+//
+// Polygons from different paths are not merged, and may overlap. Paths are
+// selected by level of detail as if the graphic was rendered at a height
+// equal to the ViewBox's height. Fully transparent paths are skipped.
+type GeoJSONExporter struct {
+	// Window is the rectangle, in GeoJSON coordinates such as longitude and
+	// latitude, that the ViewBox maps to. GeoJSON's Y axis increases upwards
+	// (northwards), so the graphic is flipped vertically to keep it the
+	// right way up.
+	//
+	// If zero, it is the ViewBox, flipped about its horizontal center line.
+	Window Rectangle
+
+	segmenter
+
+	polygons [][][]f32.Vec2
+	segs     []Segment
+	visible  bool
+}
+
+// Bytes returns the GeoJSON MultiPolygon geometry object.
+func (e *GeoJSONExporter) Bytes() []byte {
+	b := []byte(`{"type":"MultiPolygon","coordinates":[`)
+	for i, polygon := range e.polygons {
+		if i != 0 {
+			b = append(b, ',')
+		}
+		b = append(b, '[')
+		for j, ring := range polygon {
+			if j != 0 {
+				b = append(b, ',')
+			}
+			b = append(b, '[')
+			for k, p := range ring {
+				if k != 0 {
+					b = append(b, ',')
+				}
+				b = append(b, '[')
+				b = strconv.AppendFloat(b, float64(p[0]), 'g', -1, 32)
+				b = append(b, ',')
+				b = strconv.AppendFloat(b, float64(p[1]), 'g', -1, 32)
+				b = append(b, ']')
+			}
+			b = append(b, ']')
+		}
+		b = append(b, ']')
+	}
+	return append(b, "]}\n"...)
+}
+
+// Reset resets the GeoJSONExporter for the given Metadata.
+func (e *GeoJSONExporter) Reset(m Metadata) {
+	e.segmenter.reset(m, e)
+	e.polygons = nil
+	e.segs = e.segs[:0]
+	e.visible = false
+}
+
+// transform maps p from graphic coordinate space to GeoJSON coordinates.
+func (e *GeoJSONExporter) transform(p f32.Vec2) f32.Vec2 {
+	vb, w := &e.metadata.ViewBox, e.Window
+	if w == (Rectangle{}) {
+		w = *vb
+	}
+	dx, dy := vb.AspectRatio()
+	wdx, wdy := w.AspectRatio()
+	x, y := float32(0), float32(0)
+	if dx != 0 {
+		x = (p[0] - vb.Min[0]) / dx
+	}
+	if dy != 0 {
+		y = (p[1] - vb.Min[1]) / dy
+	}
+	return f32.Vec2{w.Min[0] + x*wdx, w.Max[1] - y*wdy}
+}
+
+func (e *GeoJSONExporter) beginPath() {
+	_, h := e.metadata.ViewBox.AspectRatio()
+	e.visible = e.lod0 <= h && h < e.lod1 && (e.fill.A != 0 || e.fill.B&0x80 != 0)
+	e.segs = e.segs[:0]
+}
+
+func (e *GeoJSONExporter) addSegment(s Segment) {
+	if e.visible {
+		e.segs = append(e.segs, s)
+	}
+}
+
+func (e *GeoJSONExporter) endPath() {
+	if !e.visible {
+		return
+	}
+	var rings [][]f32.Vec2
+	for _, sub := range splitSubpaths(e.segs) {
+		if ring := flatten(nil, sub, flattenTolerance); len(ring) >= 3 {
+			rings = append(rings, ring)
+		}
+	}
+
+	roles := classifySubpaths(rings)
+
+	// polygonIndexes maps outer rings to their index in e.polygons.
+	polygonIndexes := map[int]int{}
+	for i, ring := range rings {
+		if roles[i] == subpathOuter {
+			polygonIndexes[i] = len(e.polygons)
+			e.polygons = append(e.polygons, [][]f32.Vec2{e.geoRing(ring, true)})
+		}
+	}
+	for i, ring := range rings {
+		if roles[i] != subpathHole {
+			continue
+		}
+		// A hole's outer ring is the smallest one that contains it.
+		best, bestArea := -1, float32(0)
+		for j := range rings {
+			if roles[j] != subpathOuter || !polygonContains(rings[j], ring[0]) {
+				continue
+			}
+			if area := abs32(signedArea(rings[j])); best < 0 || area < bestArea {
+				best, bestArea = j, area
+			}
+		}
+		if best >= 0 {
+			k := polygonIndexes[best]
+			e.polygons[k] = append(e.polygons[k], e.geoRing(ring, false))
+		}
+	}
+}
+
+// geoRing returns the ring, in graphic coordinate space, as a closed GeoJSON
+// linear ring that winds counter-clockwise if outer, or clockwise otherwise.
+func (e *GeoJSONExporter) geoRing(ring []f32.Vec2, outer bool) []f32.Vec2 {
+	ret := make([]f32.Vec2, 0, len(ring)+1)
+	for _, p := range ring {
+		ret = append(ret, e.transform(p))
+	}
+	// With the Y axis increasing upwards, a positive signedArea means
+	// counter-clockwise.
+	if area := signedArea(ret); area != 0 && (area > 0) != outer {
+		for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+			ret[i], ret[j] = ret[j], ret[i]
+		}
+	}
+	return append(ret, ret[0])
+}
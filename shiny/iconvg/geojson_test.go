@@ -0,0 +1,148 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestGeoJSONExporter(t *testing.T) {
+	// Two clockwise squares, one with a counter-clockwise square hole, and a
+	// clockwise square island inside that hole.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.StartPath(0, -30, -30)
+	e.AbsLineTo(+10, -30)
+	e.AbsLineTo(+10, +10)
+	e.AbsLineTo(-30, +10)
+	e.ClosePathAbsMoveTo(-20, -20)
+	e.AbsLineTo(-20, 0)
+	e.AbsLineTo(0, 0)
+	e.AbsLineTo(0, -20)
+	e.ClosePathAbsMoveTo(-15, -15)
+	e.AbsLineTo(-5, -15)
+	e.AbsLineTo(-5, -5)
+	e.AbsLineTo(-15, -5)
+	e.ClosePathEndPath()
+	e.StartPath(0, 20, 20)
+	e.AbsLineTo(30, 20)
+	e.AbsLineTo(30, 30)
+	e.AbsLineTo(20, 30)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	g := &GeoJSONExporter{
+		// The window is 0.64 degrees square, so each unit is 0.01 degrees.
+		Window: Rectangle{Min: f32.Vec2{100, -20}, Max: f32.Vec2{100.64, -19.36}},
+	}
+	if err := Decode(g, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	var got struct {
+		Type        string
+		Coordinates [][][][2]float32
+	}
+	if err := json.Unmarshal(g.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal: %v\n%s", err, g.Bytes())
+	}
+	if got.Type != "MultiPolygon" {
+		t.Errorf("type: got %q, want %q", got.Type, "MultiPolygon")
+	}
+
+	// Round to 1/100th of a degree, to ignore floating point error.
+	for _, polygon := range got.Coordinates {
+		for _, ring := range polygon {
+			for i, p := range ring {
+				ring[i] = [2]float32{
+					float32(int(p[0]*100+0.5)) / 100,
+					-float32(int(-p[1]*100+0.5)) / 100,
+				}
+			}
+		}
+	}
+	want := [][][][2]float32{{
+		{{100.02, -19.78}, {100.42, -19.78}, {100.42, -19.38}, {100.02, -19.38}, {100.02, -19.78}},
+		{{100.32, -19.48}, {100.32, -19.68}, {100.12, -19.68}, {100.12, -19.48}, {100.32, -19.48}},
+	}, {
+		{{100.17, -19.63}, {100.27, -19.63}, {100.27, -19.53}, {100.17, -19.53}, {100.17, -19.63}},
+	}, {
+		{{100.52, -19.98}, {100.62, -19.98}, {100.62, -19.88}, {100.52, -19.88}, {100.52, -19.98}},
+	}}
+	if !reflect.DeepEqual(got.Coordinates, want) {
+		t.Errorf("coordinates:\ngot  %v\nwant %v", got.Coordinates, want)
+	}
+}
+
+func TestGeoJSONExporterHoles(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	g := &GeoJSONExporter{}
+	if err := Decode(g, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	// The circle is the outer ring, and the "i" is two holes.
+	if got, want := len(g.polygons), 1; got != want {
+		t.Fatalf("polygons: got %d, want %d", got, want)
+	}
+	if got, want := len(g.polygons[0]), 3; got != want {
+		t.Fatalf("rings: got %d, want %d", got, want)
+	}
+	for i, ring := range g.polygons[0] {
+		if ring[0] != ring[len(ring)-1] {
+			t.Errorf("ring #%d is not closed", i)
+		}
+		if area, outer := signedArea(ring), i == 0; (area > 0) != outer {
+			t.Errorf("ring #%d: got signed area %v, outer=%t", i, area, outer)
+		}
+	}
+}
+
+func TestGeoJSONExporterSameDirectionRings(t *testing.T) {
+	// Two clockwise concentric squares. Under the non-zero winding rule, the
+	// inner square is filled, not a hole.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.StartPath(0, -30, -30)
+	e.AbsHLineTo(+30)
+	e.AbsVLineTo(+30)
+	e.AbsHLineTo(-30)
+	e.ClosePathAbsMoveTo(-10, -10)
+	e.AbsHLineTo(+10)
+	e.AbsVLineTo(+10)
+	e.AbsHLineTo(-10)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	g := &GeoJSONExporter{}
+	if err := Decode(g, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got, want := len(g.polygons), 1; got != want {
+		t.Fatalf("polygons: got %d, want %d", got, want)
+	}
+	if got, want := len(g.polygons[0]), 1; got != want {
+		t.Fatalf("rings: got %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestHintingSnapsEdges(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.HighResolutionCoordinates = true
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	// At 16 × 16 pixels, this rectangle's edges are not on pixel boundaries:
+	// it spans from (3.3, 4.4) to (11.6, 9.7) in pixel space.
+	e.StartPath(0, -32+4*3.3, -32+4*4.4)
+	e.AbsHLineTo(-32 + 4*11.6)
+	e.AbsVLineTo(-32 + 4*9.7)
+	e.AbsHLineTo(-32 + 4*3.3)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	// crisp returns whether the rectangle's top and bottom edges (or, if
+	// vertical is false, its left and right edges) are on pixel boundaries,
+	// in which case every row (or column) of a 16 × 16 image is either empty
+	// or the same as the one through the middle of the rectangle.
+	crisp := func(m *image.Alpha, vertical bool) bool {
+		at := func(i, j int) uint8 {
+			if vertical {
+				return m.AlphaAt(j, i).A
+			}
+			return m.AlphaAt(i, j).A
+		}
+		const mid = 7
+		for i := 0; i < 16; i++ {
+			empty, same := true, true
+			for j := 0; j < 16; j++ {
+				empty = empty && at(i, j) == 0
+				same = same && at(i, j) == at(mid, j)
+			}
+			if !empty && !same {
+				return false
+			}
+		}
+		return true
+	}
+
+	testCases := []struct {
+		mode               HintingMode
+		wantRows, wantCols bool
+	}{
+		{HintingNone, false, false},
+		{HintingVertical, true, false},
+		{HintingFull, true, true},
+	}
+	for _, tc := range testCases {
+		dst := image.NewAlpha(image.Rect(0, 0, 16, 16))
+		var z Rasterizer
+		z.SetDstImage(dst, dst.Bounds(), draw.Src)
+		z.SetHinting(tc.mode)
+		if err := Decode(&z, ivgData, nil); err != nil {
+			t.Fatalf("mode=%d: Decode: %v", tc.mode, err)
+		}
+		if got := crisp(dst, true); got != tc.wantRows {
+			t.Errorf("mode=%d: top and bottom crisp: got %t, want %t", tc.mode, got, tc.wantRows)
+		}
+		if got := crisp(dst, false); got != tc.wantCols {
+			t.Errorf("mode=%d: left and right crisp: got %t, want %t", tc.mode, got, tc.wantCols)
+		}
+		if tc.mode != HintingFull {
+			continue
+		}
+		// Fully hinted, the rectangle is exactly (3, 4) - (12, 10).
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 16; x++ {
+				want := uint8(0x00)
+				if (image.Point{x, y}).In(image.Rect(3, 4, 12, 10)) {
+					want = 0xff
+				}
+				if got := dst.AlphaAt(x, y).A; got != want {
+					t.Errorf("at (%d, %d): got %#02x, want %#02x", x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestHintingGolden(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	testCases := []struct {
+		mode    HintingMode
+		variant string
+	}{
+		{HintingNone, "16"},
+		{HintingVertical, "16.hinting-vertical"},
+		{HintingFull, "16.hinting-full"},
+	}
+	var unhinted *image.RGBA
+	for _, tc := range testCases {
+		got := image.NewRGBA(image.Rect(0, 0, 16, 16))
+		var z Rasterizer
+		z.SetDstImage(got, got.Bounds(), draw.Src)
+		z.SetHinting(tc.mode)
+		if err := Decode(&z, ivgData, nil); err != nil {
+			t.Errorf("%s: Decode: %v", tc.variant, err)
+			continue
+		}
+
+		wantFilename := filepath.FromSlash("testdata/action-info.lores." + tc.variant + ".png")
+		if overwriteTestdataFiles {
+			if err := encodePNG(wantFilename, got); err != nil {
+				t.Errorf("%s: encodePNG: %v", tc.variant, err)
+			}
+			continue
+		}
+		want, err := decodePNG(wantFilename)
+		if err != nil {
+			t.Errorf("%s: decodePNG: %v", tc.variant, err)
+			continue
+		}
+		if err := checkApproxEqual(got, want); err != nil {
+			t.Errorf("%s: %v", tc.variant, err)
+		}
+
+		if tc.mode == HintingNone {
+			unhinted = got
+		} else if unhinted != nil && string(got.Pix) == string(unhinted.Pix) {
+			t.Errorf("%s: hinting had no effect", tc.variant)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"math"
+	"sort"
+
+	"golang.org/x/image/math/f32"
+)
+
+// HitTester answers whether points are inside an IconVG graphic's paths, for
+// interactive user interfaces.
+//
+// Its paths are those that would be drawn at a height equal to the ViewBox's
+// height, in terms of level of detail. Fully transparent paths are skipped.
+// Unless Exact is set, curves are flattened to line segments, to within 1/64th
+// of a unit. A point is inside a path under the non-zero winding fill rule,
+// the same as the Rasterizer. Points are in graphic coordinate space.
+type HitTester struct {
+	// Exact is whether to test points against the paths' curves exactly,
+	// instead of against their flattened line segments. Flattening can be
+	// noticeably inexact when zoomed in, within 1/64th of a unit of a curve,
+	// but the exact test is slower. Arcs are still approximated by cubic
+	// Bézier curves, to within 0.03% of their radii.
+	Exact bool
+
+	paths []hitPath
+}
+
+// hitPath is a path of a HitTester.
+type hitPath struct {
+	// index is the path's index amongst all of the graphic's paths.
+	index int
+	// bounds contains every point, including control points, of the path's
+	// segments, and so all of the path's curves.
+	bounds   Rectangle
+	polygons [][]f32.Vec2
+	subpaths [][]Segment
+}
+
+// NewHitTester decodes an IconVG graphic into a HitTester.
+func NewHitTester(src []byte, opts *DecodeOptions) (*HitTester, error) {
+	c := hitCollector{h: &HitTester{}}
+	if err := Decode(&c, src, opts); err != nil {
+		return nil, err
+	}
+	return c.h, nil
+}
+
+// Contains returns whether any path contains the point (x, y).
+func (h *HitTester) Contains(x, y float32) bool {
+	return h.PathAt(x, y) >= 0
+}
+
+// PathAt returns the index of the top-most (last drawn) path that contains
+// the point (x, y), or -1 if there is no such path. Paths are indexed in the
+// order that they are encoded, counting every path in the graphic, including
+// those that the HitTester skips.
+func (h *HitTester) PathAt(x, y float32) int {
+	p := f32.Vec2{x, y}
+	for i := len(h.paths) - 1; i >= 0; i-- {
+		hp := &h.paths[i]
+		if x < hp.bounds.Min[0] || hp.bounds.Max[0] < x || y < hp.bounds.Min[1] || hp.bounds.Max[1] < y {
+			continue
+		}
+		w := 0
+		if h.Exact {
+			for _, sub := range hp.subpaths {
+				w += exactWindingNumber(sub, p)
+			}
+		} else {
+			for _, polygon := range hp.polygons {
+				w += windingNumber(polygon, p)
+			}
+		}
+		if w != 0 {
+			return hp.index
+		}
+	}
+	return -1
+}
+
+// hitCollector is a Destination that builds a HitTester.
+type hitCollector struct {
+	segmenter
+	h       *HitTester
+	segs    []Segment
+	nPaths  int
+	visible bool
+}
+
+func (c *hitCollector) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	c.h.paths = c.h.paths[:0]
+	c.segs = c.segs[:0]
+	c.nPaths = 0
+}
+
+func (c *hitCollector) beginPath() {
+	_, h := c.metadata.ViewBox.AspectRatio()
+	c.visible = c.lod0 <= h && h < c.lod1 && (c.fill.A != 0 || c.fill.B&0x80 != 0)
+	c.segs = c.segs[:0]
+}
+
+func (c *hitCollector) addSegment(s Segment) {
+	if c.visible {
+		c.segs = append(c.segs, s)
+	}
+}
+
+func (c *hitCollector) endPath() {
+	index := c.nPaths
+	c.nPaths++
+	if !c.visible || len(c.segs) == 0 {
+		return
+	}
+	hp := hitPath{
+		index:  index,
+		bounds: Rectangle{Min: c.segs[0].Args[0], Max: c.segs[0].Args[0]},
+	}
+	for _, s := range c.segs {
+		n := 1
+		switch s.Op {
+		case SegmentOpQuadTo:
+			n = 2
+		case SegmentOpCubeTo:
+			n = 3
+		}
+		for _, p := range s.Args[:n] {
+			hp.bounds.Min[0] = min32(hp.bounds.Min[0], p[0])
+			hp.bounds.Min[1] = min32(hp.bounds.Min[1], p[1])
+			hp.bounds.Max[0] = max32(hp.bounds.Max[0], p[0])
+			hp.bounds.Max[1] = max32(hp.bounds.Max[1], p[1])
+		}
+	}
+	// The segments are copied, as c.segs is re-used for the next path.
+	segs := append([]Segment(nil), c.segs...)
+	for _, sub := range splitSubpaths(segs) {
+		hp.polygons = append(hp.polygons, flatten(nil, sub, flattenTolerance))
+		hp.subpaths = append(hp.subpaths, sub)
+	}
+	c.h.paths = append(c.h.paths, hp)
+}
+
+// windingNumber returns the winding number of the implicitly closed polygon
+// around the point p.
+func windingNumber(polygon []f32.Vec2, p f32.Vec2) int {
+	w := 0
+	for i := range polygon {
+		w += lineWindingNumber(polygon[i], polygon[(i+1)%len(polygon)], p)
+	}
+	return w
+}
+
+// lineWindingNumber returns the contribution of the edge a-b to a winding
+// number around the point p: +1 or -1 if the edge crosses the horizontal
+// ray from p in the +X direction, going down or up, and 0 otherwise.
+func lineWindingNumber(a, b, p f32.Vec2) int {
+	// cross is positive if p is to the right of the edge a-b, with the Y
+	// axis increasing down.
+	cross := (b[0]-a[0])*(p[1]-a[1]) - (b[1]-a[1])*(p[0]-a[0])
+	if a[1] <= p[1] {
+		if b[1] > p[1] && cross > 0 {
+			return +1
+		}
+	} else if b[1] <= p[1] && cross < 0 {
+		return -1
+	}
+	return 0
+}
+
+// exactWindingNumber returns the winding number of the implicitly closed
+// sub-path around the point p, with the same conventions as windingNumber
+// but without flattening curves.
+func exactWindingNumber(sub []Segment, p f32.Vec2) int {
+	w := 0
+	var pen f32.Vec2
+	for _, s := range sub {
+		switch s.Op {
+		case SegmentOpLineTo:
+			w += lineWindingNumber(pen, s.Args[0], p)
+		case SegmentOpQuadTo:
+			// Elevate the quadratic Bézier curve to a cubic one.
+			p1, p2 := s.Args[0], s.Args[1]
+			c1 := f32.Vec2{pen[0] + 2*(p1[0]-pen[0])/3, pen[1] + 2*(p1[1]-pen[1])/3}
+			c2 := f32.Vec2{p2[0] + 2*(p1[0]-p2[0])/3, p2[1] + 2*(p1[1]-p2[1])/3}
+			w += cubeWindingNumber(pen, c1, c2, p2, p)
+		case SegmentOpCubeTo:
+			w += cubeWindingNumber(pen, s.Args[0], s.Args[1], s.Args[2], p)
+		}
+		pen = s.end()
+	}
+	// Close the sub-path.
+	return w + lineWindingNumber(pen, sub[0].Args[0], p)
+}
+
+// cubeWindingNumber returns the contribution of the cubic Bézier curve from
+// p0 to p3, with control points p1 and p2, to a winding number around the
+// point p, with the same conventions as lineWindingNumber.
+//
+// The curve is split at its Y extrema into pieces that are monotonic in Y.
+// Each piece crosses the ray at most once, found by bisection.
+func cubeWindingNumber(p0, p1, p2, p3, p f32.Vec2) int {
+	x0, x1, x2, x3 := float64(p0[0]), float64(p1[0]), float64(p2[0]), float64(p3[0])
+	y0, y1, y2, y3 := float64(p0[1]), float64(p1[1]), float64(p2[1]), float64(p3[1])
+	px, py := float64(p[0]), float64(p[1])
+	if math.Min(math.Min(y0, y1), math.Min(y2, y3)) > py ||
+		math.Max(math.Max(y0, y1), math.Max(y2, y3)) < py ||
+		math.Max(math.Max(x0, x1), math.Max(x2, x3)) <= px {
+		return 0
+	}
+	eval := func(a, b, c, d, t float64) float64 {
+		s := 1 - t
+		return s*s*s*a + 3*s*s*t*b + 3*s*t*t*c + t*t*t*d
+	}
+
+	// The derivative of Y, divided by 3, is qa*t*t + 2*qb*t + qc.
+	qa := y3 - 3*y2 + 3*y1 - y0
+	qb := y2 - 2*y1 + y0
+	qc := y1 - y0
+	ts := []float64{0, 1}
+	addRoot := func(t float64) {
+		if 0 < t && t < 1 {
+			ts = append(ts, t)
+		}
+	}
+	if qa == 0 {
+		if qb != 0 {
+			addRoot(-qc / (2 * qb))
+		}
+	} else if disc := qb*qb - qa*qc; disc >= 0 {
+		sq := math.Sqrt(disc)
+		addRoot((-qb + sq) / qa)
+		addRoot((-qb - sq) / qa)
+	}
+	sort.Float64s(ts)
+
+	w := 0
+	for i := 1; i < len(ts); i++ {
+		ta, tb := ts[i-1], ts[i]
+		ya, yb := eval(y0, y1, y2, y3, ta), eval(y0, y1, y2, y3, tb)
+		dir := 0
+		if ya <= py && py < yb {
+			dir = +1
+		} else if yb <= py && py < ya {
+			dir = -1
+			ta, tb = tb, ta
+		} else {
+			continue
+		}
+		// Y increases from ta to tb, which may be decreasing in t.
+		for j := 0; j < 64; j++ {
+			tm := (ta + tb) / 2
+			if eval(y0, y1, y2, y3, tm) <= py {
+				ta = tm
+			} else {
+				tb = tm
+			}
+		}
+		if eval(x0, x1, x2, x3, (ta+tb)/2) > px {
+			w += dir
+		}
+	}
+	return w
+}
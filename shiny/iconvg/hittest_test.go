@@ -0,0 +1,166 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestHitTester(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	// Path #0 is a square with a square hole, as its inner sub-path winds the
+	// other way.
+	e.StartPath(0, -30, -30)
+	e.AbsHLineTo(+30)
+	e.AbsVLineTo(+30)
+	e.AbsHLineTo(-30)
+	e.ClosePathAbsMoveTo(-20, -20)
+	e.AbsVLineTo(+20)
+	e.AbsHLineTo(+20)
+	e.AbsVLineTo(-20)
+	e.ClosePathEndPath()
+	// Path #1 is fully transparent.
+	e.SetCReg(0, false, RGBAColor(color.RGBA{}))
+	e.StartPath(0, -32, -32)
+	e.AbsHLineTo(+32)
+	e.AbsVLineTo(+32)
+	e.AbsHLineTo(-32)
+	e.ClosePathEndPath()
+	// Path #2 is two overlapping squares that wind the same way, so their
+	// overlap is filled under the non-zero winding rule.
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0xff, 0x00, 0x00, 0xff}))
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(10)
+	e.AbsVLineTo(10)
+	e.AbsHLineTo(0)
+	e.ClosePathAbsMoveTo(5, 5)
+	e.AbsHLineTo(15)
+	e.AbsVLineTo(15)
+	e.AbsHLineTo(5)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	h, err := NewHitTester(ivgData, nil)
+	if err != nil {
+		t.Fatalf("NewHitTester: %v", err)
+	}
+	testCases := []struct {
+		x, y float32
+		want int
+	}{
+		{-31, 0, -1},
+		{-25, 0, 0},
+		{-15, 0, -1},
+		{+25, +25, 0},
+		{+7, +7, 2},
+		{+12, +12, 2},
+		{+2, +2, 2},
+		{+17, +17, -1},
+		{+25, +12, 0},
+	}
+	for _, tc := range testCases {
+		if got := h.PathAt(tc.x, tc.y); got != tc.want {
+			t.Errorf("PathAt(%v, %v): got %d, want %d", tc.x, tc.y, got, tc.want)
+		}
+		if got, want := h.Contains(tc.x, tc.y), tc.want >= 0; got != want {
+			t.Errorf("Contains(%v, %v): got %t, want %t", tc.x, tc.y, got, want)
+		}
+	}
+}
+
+func TestHitTesterMatchesRasterizer(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	h, err := NewHitTester(ivgData, nil)
+	if err != nil {
+		t.Fatalf("NewHitTester: %v", err)
+	}
+	m, err := DecodeMetadata(ivgData)
+	if err != nil {
+		t.Fatalf("DecodeMetadata: %v", err)
+	}
+
+	const size = 64
+	rgba, err := rasterize(ivgData, size, size, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	dx, dy := m.ViewBox.AspectRatio()
+	for _, exact := range []bool{false, true} {
+		h.Exact = exact
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				// Skip anti-aliased edges, and check the pixel's center.
+				a := rgba.RGBAAt(x, y).A
+				if a != 0x00 && a != 0xff {
+					continue
+				}
+				gx := m.ViewBox.Min[0] + (float32(x)+0.5)*dx/size
+				gy := m.ViewBox.Min[1] + (float32(y)+0.5)*dy/size
+				if got, want := h.Contains(gx, gy), a == 0xff; got != want {
+					t.Errorf("exact=%t: pixel (%d, %d): got %t, want %t", exact, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestHitTesterExact(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	// The curve is y = -120 × t × (1-t), where x = -30 + 60 × t.
+	e.StartPath(0, -30, 0)
+	e.AbsQuadTo(0, -60, +30, 0)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	h, err := NewHitTester(ivgData, nil)
+	if err != nil {
+		t.Fatalf("NewHitTester: %v", err)
+	}
+
+	// Test points just inside and just outside the curve, closer to it than
+	// the flattening tolerance.
+	const eps = 0.002
+	flattenedMismatches := 0
+	for i := 1; i < 64; i++ {
+		t64 := float64(i) / 64
+		x := float32(-30 + 60*t64)
+		y := float32(-120 * t64 * (1 - t64))
+		for _, exact := range []bool{false, true} {
+			h.Exact = exact
+			in, out := h.Contains(x, y+eps), h.Contains(x, y-eps)
+			if exact {
+				if !in || out {
+					t.Errorf("exact: x=%v: inside: got %t, outside: got %t", x, in, out)
+				}
+			} else if !in || out {
+				flattenedMismatches++
+			}
+		}
+	}
+	if flattenedMismatches == 0 {
+		t.Errorf("flattened: got no mismatches, so the test points are too far from the curve")
+	}
+}
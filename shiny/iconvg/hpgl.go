@@ -0,0 +1,172 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"io"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/math/f32"
+)
+
+// HPGLExporter is a Destination that converts the outlines of an IconVG
+// graphic's paths to HP-GL commands, for pen plotters and similar machines.
+//
+// The outlines are traced, not filled, so colors are ignored, other than that
+// fully transparent paths are skipped. Curves and arcs are flattened to line
+// segments, to within 1/64th of a unit. Each sub-path becomes one pen-down
+// stroke that ends where it started.
+//
+// The strokes are ordered to reduce the pen-up travel between them: starting
+// from the origin, the next stroke is always the one with the nearest point,
+// where that stroke then starts. This nearest-neighbor ordering is not
+// optimal, but is usually much better than the graphic's drawing order.
+type HPGLExporter struct {
+	// Width is the width, in plotter units, of the plot. The height follows
+	// from the ViewBox's aspect ratio. If zero, one unit of graphic
+	// coordinate space is one plotter unit. HP-GL plotter units are usually
+	// 0.025mm, or 1016 to the inch.
+	//
+	// HP-GL's Y axis increases upwards, so the graphic is flipped vertically
+	// to keep it the right way up, with the plot's bottom left corner at the
+	// origin.
+	Width float32
+
+	// Height is the height, in pixels, that the graphic is intended to be
+	// rendered at, which selects paths by their level of detail.
+	//
+	// If zero, it is the height of the ViewBox.
+	Height float32
+
+	segmenter
+
+	loops   [][][2]int
+	segs    []Segment
+	scale   float32
+	visible bool
+}
+
+// Reset resets the HPGLExporter for the given Metadata.
+func (e *HPGLExporter) Reset(m Metadata) {
+	e.segmenter.reset(m, e)
+	e.loops = e.loops[:0]
+	e.segs = e.segs[:0]
+	e.visible = false
+
+	dx, _ := m.ViewBox.AspectRatio()
+	e.scale = 1
+	if e.Width > 0 && dx > 0 {
+		e.scale = e.Width / dx
+	}
+}
+
+func (e *HPGLExporter) height() float32 {
+	if e.Height > 0 {
+		return e.Height
+	}
+	_, dy := e.metadata.ViewBox.AspectRatio()
+	return dy
+}
+
+func (e *HPGLExporter) beginPath() {
+	h := e.height()
+	e.visible = e.lod0 <= h && h < e.lod1 && (e.fill.A != 0 || e.fill.B&0x80 != 0)
+	e.segs = e.segs[:0]
+}
+
+func (e *HPGLExporter) addSegment(s Segment) {
+	if e.visible {
+		e.segs = append(e.segs, s)
+	}
+}
+
+func (e *HPGLExporter) endPath() {
+	if !e.visible {
+		return
+	}
+	vb := &e.metadata.ViewBox
+	for _, sub := range splitSubpaths(e.segs) {
+		var loop [][2]int
+		for _, p := range flatten(nil, sub, flattenTolerance) {
+			q := e.plotterPoint(p, vb)
+			if n := len(loop); n == 0 || loop[n-1] != q {
+				loop = append(loop, q)
+			}
+		}
+		if len(loop) > 1 && loop[len(loop)-1] == loop[0] {
+			loop = loop[:len(loop)-1]
+		}
+		e.loops = append(e.loops, loop)
+	}
+}
+
+// plotterPoint converts p from graphic coordinate space to plotter units.
+func (e *HPGLExporter) plotterPoint(p f32.Vec2, vb *Rectangle) [2]int {
+	x := (p[0] - vb.Min[0]) * e.scale
+	y := (vb.Max[1] - p[1]) * e.scale
+	return [2]int{
+		int(math.Floor(float64(x) + 0.5)),
+		int(math.Floor(float64(y) + 0.5)),
+	}
+}
+
+// WriteTo writes the HP-GL commands to w. They initialize the plotter, select
+// pen 1, draw each stroke with absolute coordinates, and then lift and put
+// away the pen. Each stroke is on its own line.
+func (e *HPGLExporter) WriteTo(w io.Writer) (n int64, err error) {
+	buf := []byte("IN;SP1;PA;\n")
+	for _, loop := range e.orderedLoops() {
+		buf = append(buf, "PU"...)
+		buf = appendHPGLPoint(buf, loop[0])
+		buf = append(buf, ";PD"...)
+		for i := 1; i <= len(loop); i++ {
+			if i != 1 {
+				buf = append(buf, ',')
+			}
+			buf = appendHPGLPoint(buf, loop[i%len(loop)])
+		}
+		buf = append(buf, ";\n"...)
+	}
+	buf = append(buf, "PU;SP0;\n"...)
+	m, err := w.Write(buf)
+	return int64(m), err
+}
+
+func appendHPGLPoint(buf []byte, p [2]int) []byte {
+	buf = strconv.AppendInt(buf, int64(p[0]), 10)
+	buf = append(buf, ',')
+	return strconv.AppendInt(buf, int64(p[1]), 10)
+}
+
+// orderedLoops returns the loops in nearest-neighbor order, each rotated to
+// start at its point that is nearest to the previous loop's start, where the
+// pen is after drawing that closed loop. Every loop has at least one point,
+// the start of its sub-path.
+func (e *HPGLExporter) orderedLoops() [][][2]int {
+	ret := make([][][2]int, 0, len(e.loops))
+	done := make([]bool, len(e.loops))
+	pen := [2]int{}
+	for range e.loops {
+		best, bestStart, bestDist := -1, 0, int64(math.MaxInt64)
+		for i, loop := range e.loops {
+			if done[i] {
+				continue
+			}
+			for j, p := range loop {
+				dx, dy := int64(p[0]-pen[0]), int64(p[1]-pen[1])
+				if d := dx*dx + dy*dy; d < bestDist {
+					best, bestStart, bestDist = i, j, d
+				}
+			}
+		}
+		done[best] = true
+		loop := e.loops[best]
+		rotated := append(append([][2]int(nil), loop[bestStart:]...), loop[:bestStart]...)
+		ret = append(ret, rotated)
+		pen = rotated[0]
+	}
+	return ret
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+)
+
+func TestHPGLExporter(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	// The first path's square is at the top right, far from the origin at
+	// the bottom left.
+	e.StartPath(0, +32, -32)
+	e.AbsHLineTo(+16)
+	e.AbsVLineTo(-16)
+	e.AbsHLineTo(+32)
+	e.ClosePathEndPath()
+	// The second path's square is at the bottom left. Its top right corner
+	// is encoded first.
+	e.StartPath(0, -16, +16)
+	e.AbsVLineTo(+32)
+	e.AbsHLineTo(-32)
+	e.AbsVLineTo(+16)
+	e.ClosePathEndPath()
+	// The third path is fully transparent.
+	e.SetCReg(0, false, RGBAColor(color.RGBA{}))
+	e.StartPath(0, -8, -8)
+	e.AbsHLineTo(+8)
+	e.AbsVLineTo(+8)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	x := HPGLExporter{Width: 640}
+	if err := Decode(&x, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := x.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	// The plot is 640 × 640, with the Y axis flipped. The bottom left square
+	// is drawn first, starting at its corner nearest the origin, and then the
+	// top right square, starting at its corner nearest the bottom left
+	// square's start.
+	want := "IN;SP1;PA;\n" +
+		"PU0,0;PD0,160,160,160,160,0,0,0;\n" +
+		"PU480,480;PD640,480,640,640,480,640,480,480;\n" +
+		"PU;SP0;\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"sort"
+
+	"golang.org/x/image/math/f32"
+)
+
+// ConvexHull returns the convex hull of an IconVG graphic's paths, in graphic
+// coordinate space. Curves are first flattened to line segments. Paths at
+// every level of detail are included, but fully transparent paths are not.
+//
+// The hull's vertices are in clockwise order, with the Y axis increasing
+// down, starting from the left-most (and then top-most) point. Collinear
+// points are omitted. If all of the points are collinear, the hull is the two
+// end points, or a single point if they are all the same. If the graphic has
+// no paths, the hull is empty.
+func ConvexHull(src []byte, opts *DecodeOptions) ([]f32.Vec2, error) {
+	var c pointCollector
+	if err := Decode(&c, src, opts); err != nil {
+		return nil, err
+	}
+	return convexHull(c.points), nil
+}
+
+// pointCollector is a Destination that records the points of its paths,
+// flattened to polylines. Fully transparent paths are skipped.
+type pointCollector struct {
+	segmenter
+	points  []f32.Vec2
+	segs    []Segment
+	visible bool
+}
+
+func (c *pointCollector) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	c.points = c.points[:0]
+	c.segs = c.segs[:0]
+}
+
+func (c *pointCollector) beginPath() {
+	c.visible = c.fill.A != 0 || c.fill.B&0x80 != 0
+	c.segs = c.segs[:0]
+}
+
+func (c *pointCollector) addSegment(s Segment) {
+	if c.visible {
+		c.segs = append(c.segs, s)
+	}
+}
+
+func (c *pointCollector) endPath() {
+	c.points = flatten(c.points, c.segs, flattenTolerance)
+}
+
+// convexHull returns the convex hull of the points, using Andrew's monotone
+// chain algorithm. It may re-order the points slice.
+func convexHull(points []f32.Vec2) []f32.Vec2 {
+	if len(points) <= 1 {
+		return append([]f32.Vec2(nil), points...)
+	}
+	sort.Sort(byXY(points))
+
+	// cross is positive if o, a, b make a clockwise turn, with the Y axis
+	// increasing down.
+	cross := func(o, a, b f32.Vec2) float32 {
+		return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+	}
+
+	// Build the upper chain, left to right, and then the lower chain, right
+	// to left. With the Y axis increasing down, this is clockwise.
+	hull := make([]f32.Vec2, 0, 2*len(points))
+	for _, p := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	for i, n := len(points)-2, len(hull)+1; i >= 0; i-- {
+		p := points[i]
+		for len(hull) >= n && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	// The last point is the same as the first.
+	hull = hull[:len(hull)-1]
+
+	if len(hull) == 2 && hull[0] == hull[1] {
+		hull = hull[:1]
+	}
+	return hull
+}
+
+// byXY sorts points by their X and then Y coordinates.
+type byXY []f32.Vec2
+
+func (p byXY) Len() int      { return len(p) }
+func (p byXY) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p byXY) Less(i, j int) bool {
+	if p[i][0] != p[j][0] {
+		return p[i][0] < p[j][0]
+	}
+	return p[i][1] < p[j][1]
+}
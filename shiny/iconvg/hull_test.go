@@ -0,0 +1,79 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestConvexHullPoints(t *testing.T) {
+	testCases := []struct {
+		desc string
+		in   []f32.Vec2
+		want []f32.Vec2
+	}{{
+		desc: "empty",
+		in:   nil,
+		want: nil,
+	}, {
+		desc: "single point",
+		in:   []f32.Vec2{{1, 2}},
+		want: []f32.Vec2{{1, 2}},
+	}, {
+		desc: "repeated point",
+		in:   []f32.Vec2{{1, 2}, {1, 2}, {1, 2}},
+		want: []f32.Vec2{{1, 2}},
+	}, {
+		desc: "collinear",
+		in:   []f32.Vec2{{2, 2}, {0, 0}, {3, 3}, {1, 1}},
+		want: []f32.Vec2{{0, 0}, {3, 3}},
+	}, {
+		desc: "vertical",
+		in:   []f32.Vec2{{0, 5}, {0, -5}, {0, 0}},
+		want: []f32.Vec2{{0, -5}, {0, 5}},
+	}, {
+		desc: "square with interior and edge points",
+		in:   []f32.Vec2{{0, 0}, {2, 2}, {1, 1}, {2, 0}, {0, 2}, {1, 0}, {0, 1}},
+		want: []f32.Vec2{{0, 0}, {2, 0}, {2, 2}, {0, 2}},
+	}}
+	for _, tc := range testCases {
+		got := convexHull(append([]f32.Vec2(nil), tc.in...))
+		if len(got) == 0 && len(tc.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.desc, got, tc.want)
+		}
+	}
+}
+
+func TestConvexHull(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	hull, err := ConvexHull(ivgData, nil)
+	if err != nil {
+		t.Fatalf("ConvexHull: %v", err)
+	}
+	// The action-info graphic is a circle of radius 20, centered on the
+	// origin, so its hull approximates that circle.
+	if len(hull) < 16 {
+		t.Fatalf("got %d vertices, want at least 16", len(hull))
+	}
+	for i, p := range hull {
+		if d := p[0]*p[0] + p[1]*p[1]; d < 19.9*19.9 || d > 20.1*20.1 {
+			t.Errorf("vertex #%d: %v is not on the circle", i, p)
+		}
+	}
+	if area := signedArea(hull); area < 3.14*19.9*19.9 || area > 3.15*20*20 {
+		t.Errorf("area: got %g, want approximately %g", area, 3.1416*20*20)
+	}
+}
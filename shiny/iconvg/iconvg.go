@@ -5,6 +5,7 @@
 package iconvg
 
 import (
+	"bytes"
 	"image/color"
 	"math"
 
@@ -15,6 +16,18 @@ const magic = "\x89IVG"
 
 var magicBytes = []byte(magic)
 
+// MagicLen is the length, in bytes, of the magic identifier that every IconVG
+// graphic starts with.
+const MagicLen = len(magic)
+
+// IsIconVG returns whether src starts with the IconVG magic identifier. It
+// only looks at the first MagicLen bytes, and does not otherwise check that
+// src is a valid IconVG graphic, so it is suitable for sniffing the format of
+// arbitrary, possibly short, data.
+func IsIconVG(src []byte) bool {
+	return bytes.HasPrefix(src, magicBytes)
+}
+
 var (
 	negativeInfinity = math.Float32frombits(0xff800000)
 	positiveInfinity = math.Float32frombits(0x7f800000)
@@ -27,6 +40,12 @@ func isNaNOrInfinity(f float32) bool {
 const (
 	midViewBox          = 0
 	midSuggestedPalette = 1
+
+	// midComment is the MID of the chunk that holds Metadata.Comment. It is
+	// not part of the file format: it is this package's own convention,
+	// chosen to be far above the MIDs that the format defines, so that other
+	// decoders can skip it as an unknown chunk.
+	midComment = 1<<14 - 1
 )
 
 var gradientShapeNames = [2]string{
@@ -81,6 +100,20 @@ type Metadata struct {
 	// the optional palette passed to Decode, or if no optional palette was
 	// given, the suggested palette within the IconVG graphic.
 	Palette Palette
+
+	// Comment is free-form UTF-8 text, such as the name and version of the
+	// tool that generated the graphic, or its author. It does not affect
+	// rendering. When encoding, an empty Comment is omitted.
+	Comment string
+}
+
+// MetadataChunk is an encoded metadata chunk.
+type MetadataChunk struct {
+	// MID is the chunk's Metadata Identifier, such as 1 for the suggested
+	// palette.
+	MID uint32
+	// Data is the chunk's MID-specific data, after the MID.
+	Data []byte
 }
 
 // DefaultViewBox is the default ViewBox. Its values should not be modified.
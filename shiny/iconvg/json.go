@@ -0,0 +1,324 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"encoding/json"
+	"errors"
+	"image/color"
+
+	"golang.org/x/image/math/f32"
+)
+
+var errInvalidJSONGraphic = errors.New("iconvg: invalid JSON graphic")
+
+// jsonGraphic is the JSON form of an IconVG graphic, as produced by ToJSON.
+type jsonGraphic struct {
+	ViewBox [4]float32 `json:"viewBox"`
+	Palette []string   `json:"palette,omitempty"`
+	Paths   []jsonPath `json:"paths"`
+}
+
+type jsonPath struct {
+	Fill     string        `json:"fill,omitempty"`
+	Gradient *jsonGradient `json:"gradient,omitempty"`
+	LOD0     float32       `json:"lod0"`
+	LOD1     *float32      `json:"lod1"`
+	Segments []jsonSegment `json:"segments"`
+}
+
+type jsonGradient struct {
+	Radial    bool       `json:"radial"`
+	Spread    string     `json:"spread"`
+	Transform [6]float32 `json:"transform"`
+	Stops     []jsonStop `json:"stops"`
+}
+
+type jsonStop struct {
+	Offset float32 `json:"offset"`
+	Color  string  `json:"color"`
+}
+
+type jsonSegment struct {
+	Kind          string       `json:"kind"`
+	Points        [][2]float32 `json:"points"`
+	Radii         *[2]float32  `json:"radii,omitempty"`
+	XAxisRotation float32      `json:"xAxisRotation,omitempty"`
+	LargeArc      bool         `json:"largeArc,omitempty"`
+	Sweep         bool         `json:"sweep,omitempty"`
+}
+
+var jsonSpreads = [4]string{
+	GradientSpreadNone:    "none",
+	GradientSpreadPad:     "pad",
+	GradientSpreadReflect: "reflect",
+	GradientSpreadRepeat:  "repeat",
+}
+
+// ToJSON decodes an IconVG graphic into a JSON document, for tools that are
+// not written in Go. The document is an object with these fields:
+//
+//	viewBox:  [minX, minY, maxX, maxY]
+//	palette:  the suggested palette, as 64 "#rrggbbaa" strings
+//	paths:    an array of paths, in drawing order
+//
+// Colors are in #rrggbbaa form, with straight alpha, as for
+// Palette.MarshalText. Each path is an object with these fields:
+//
+//	fill:      the flat fill color, if not filled with a gradient
+//	gradient:  {radial, spread, transform, stops: [{offset, color}, ...]}
+//	lod0:      the level of detail's lower bound
+//	lod1:      the level of detail's upper bound, or null for +∞
+//	segments:  an array of {kind, points}, in absolute coordinates
+//
+// A segment's kind is one of "moveTo", "lineTo", "quadTo", "cubeTo" or
+// "arcTo", and its points are its control points, if any, followed by its
+// end point. Arc segments also have radii, xAxisRotation, largeArc and sweep
+// fields, with the same meaning as the Destination's AbsArcTo arguments.
+// Fields that are zero or false may be omitted.
+//
+// Palette indexes and other register references are resolved, so that every
+// fill is a color or a gradient. Paths outside of every level of detail are
+// included. A path whose fill is not a valid alpha-premultiplied color,
+// which is never drawn, has a fill of "#00000000".
+func ToJSON(src []byte, opts *DecodeOptions) ([]byte, error) {
+	b := &jsonBuilder{}
+	if err := Decode(b, src, opts); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&b.graphic)
+}
+
+// jsonBuilder is a Destination that builds a jsonGraphic.
+type jsonBuilder struct {
+	segmenter
+	graphic jsonGraphic
+	// inArc is whether segments are being emitted for an arc.
+	inArc bool
+}
+
+func (b *jsonBuilder) Reset(m Metadata) {
+	b.segmenter.reset(m, b)
+	b.inArc = false
+	b.graphic = jsonGraphic{
+		ViewBox: [4]float32{
+			m.ViewBox.Min[0], m.ViewBox.Min[1],
+			m.ViewBox.Max[0], m.ViewBox.Max[1],
+		},
+		Palette: make([]string, len(m.Palette)),
+		Paths:   []jsonPath{},
+	}
+	for i, c := range m.Palette {
+		b.graphic.Palette[i] = jsonColor(c)
+	}
+}
+
+func (b *jsonBuilder) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	b.inArc = true
+	b.segmenter.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	b.inArc = false
+	b.addArc(rx, ry, xAxisRotation, largeArc, sweep, f32.Vec2{x, y})
+}
+
+func (b *jsonBuilder) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	end := b.rel(x, y)
+	b.inArc = true
+	b.segmenter.RelArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	b.inArc = false
+	b.addArc(rx, ry, xAxisRotation, largeArc, sweep, end)
+}
+
+// addArc adds an arc segment. Its end point is passed explicitly, as the pen
+// after the converted arc's last cubic Bézier curve can be slightly off.
+func (b *jsonBuilder) addArc(rx, ry, xAxisRotation float32, largeArc, sweep bool, end f32.Vec2) {
+	p := &b.graphic.Paths[len(b.graphic.Paths)-1]
+	p.Segments = append(p.Segments, jsonSegment{
+		Kind:          "arcTo",
+		Points:        [][2]float32{end},
+		Radii:         &[2]float32{rx, ry},
+		XAxisRotation: xAxisRotation,
+		LargeArc:      largeArc,
+		Sweep:         sweep,
+	})
+}
+
+func (b *jsonBuilder) beginPath() {
+	p := jsonPath{
+		LOD0:     b.lod0,
+		Segments: []jsonSegment{},
+	}
+	if b.lod1 != positiveInfinity {
+		lod1 := b.lod1
+		p.LOD1 = &lod1
+	}
+	if b.fill.A == 0 && b.fill.B&0x80 != 0 {
+		g := b.gradient(b.fill)
+		p.Gradient = &jsonGradient{
+			Radial:    g.Radial,
+			Spread:    jsonSpreads[g.Spread&3],
+			Transform: g.Transform,
+			Stops:     make([]jsonStop, len(g.Stops)),
+		}
+		for i, s := range g.Stops {
+			// The gradient method sets each stop's Color to a color.RGBA.
+			p.Gradient.Stops[i] = jsonStop{
+				Offset: s.Offset,
+				Color:  jsonColor(s.Color.(color.RGBA)),
+			}
+		}
+	} else {
+		p.Fill = jsonColor(b.fill)
+	}
+	b.graphic.Paths = append(b.graphic.Paths, p)
+}
+
+func (b *jsonBuilder) addSegment(s Segment) {
+	if b.inArc {
+		return
+	}
+	p := &b.graphic.Paths[len(b.graphic.Paths)-1]
+	j := jsonSegment{}
+	switch s.Op {
+	case SegmentOpMoveTo:
+		j.Kind, j.Points = "moveTo", [][2]float32{s.Args[0]}
+	case SegmentOpLineTo:
+		j.Kind, j.Points = "lineTo", [][2]float32{s.Args[0]}
+	case SegmentOpQuadTo:
+		j.Kind, j.Points = "quadTo", [][2]float32{s.Args[0], s.Args[1]}
+	case SegmentOpCubeTo:
+		j.Kind, j.Points = "cubeTo", [][2]float32{s.Args[0], s.Args[1], s.Args[2]}
+	}
+	p.Segments = append(p.Segments, j)
+}
+
+func (b *jsonBuilder) endPath() {}
+
+// jsonColor returns the #rrggbbaa form of c, or of transparent black if c is
+// not a valid alpha-premultiplied color.
+func jsonColor(c color.RGBA) string {
+	if !validAlphaPremulColor(c) {
+		c = color.RGBA{}
+	}
+	return string(appendStraightHex(nil, c))
+}
+
+// FromJSON encodes the JSON form of a graphic, as produced by ToJSON, as an
+// IconVG graphic. Missing palette entries are taken from the DefaultPalette.
+//
+// Re-encoding a decoded graphic gives an equivalent graphic, but not
+// necessarily the same bytes: for example, palette indexes are replaced by
+// the colors they refer to, and every drawing op is absolute.
+func FromJSON(data []byte) ([]byte, error) {
+	var g jsonGraphic
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, err
+	}
+	m := Metadata{
+		ViewBox: Rectangle{
+			Min: f32.Vec2{g.ViewBox[0], g.ViewBox[1]},
+			Max: f32.Vec2{g.ViewBox[2], g.ViewBox[3]},
+		},
+		Palette: DefaultPalette,
+	}
+	for i, s := range g.Palette {
+		if i >= len(m.Palette) {
+			return nil, errInvalidJSONGraphic
+		}
+		c, err := parseJSONColor(s)
+		if err != nil {
+			return nil, err
+		}
+		m.Palette[i] = c
+	}
+
+	var e Encoder
+	e.Reset(m)
+	e.HighResolutionCoordinates = true
+	// CSEL is kept past the stops of any gradient, which start at CREG[0].
+	e.SetCSel(63)
+	lod0, lod1 := float32(0), positiveInfinity
+	for _, p := range g.Paths {
+		if err := encodeJSONFill(&e, &p); err != nil {
+			return nil, err
+		}
+		pLOD1 := positiveInfinity
+		if p.LOD1 != nil {
+			pLOD1 = *p.LOD1
+		}
+		if p.LOD0 != lod0 || pLOD1 != lod1 {
+			lod0, lod1 = p.LOD0, pLOD1
+			e.SetLOD(lod0, lod1)
+		}
+		if err := encodeJSONSegments(&e, p.Segments); err != nil {
+			return nil, err
+		}
+	}
+	return e.Bytes()
+}
+
+func encodeJSONFill(e *Encoder, p *jsonPath) error {
+	if p.Gradient == nil {
+		c, err := parseJSONColor(p.Fill)
+		if err != nil {
+			return err
+		}
+		e.SetCReg(0, false, RGBAColor(c))
+		return nil
+	}
+	spread := GradientSpread(0xff)
+	for i, s := range jsonSpreads {
+		if s == p.Gradient.Spread {
+			spread = GradientSpread(i)
+		}
+	}
+	if spread == 0xff {
+		return errInvalidJSONGraphic
+	}
+	stops := make([]GradientStop, len(p.Gradient.Stops))
+	for i, s := range p.Gradient.Stops {
+		c, err := parseJSONColor(s.Color)
+		if err != nil {
+			return err
+		}
+		stops[i] = GradientStop{Offset: s.Offset, Color: c}
+	}
+	e.SetGradient(0, 6, p.Gradient.Radial, p.Gradient.Transform, spread, stops)
+	return nil
+}
+
+func encodeJSONSegments(e *Encoder, segs []jsonSegment) error {
+	if len(segs) == 0 || segs[0].Kind != "moveTo" || len(segs[0].Points) != 1 {
+		return errInvalidJSONGraphic
+	}
+	e.StartPath(0, segs[0].Points[0][0], segs[0].Points[0][1])
+	for _, s := range segs[1:] {
+		p := s.Points
+		switch {
+		case s.Kind == "moveTo" && len(p) == 1:
+			e.ClosePathAbsMoveTo(p[0][0], p[0][1])
+		case s.Kind == "lineTo" && len(p) == 1:
+			e.AbsLineTo(p[0][0], p[0][1])
+		case s.Kind == "quadTo" && len(p) == 2:
+			e.AbsQuadTo(p[0][0], p[0][1], p[1][0], p[1][1])
+		case s.Kind == "cubeTo" && len(p) == 3:
+			e.AbsCubeTo(p[0][0], p[0][1], p[1][0], p[1][1], p[2][0], p[2][1])
+		case s.Kind == "arcTo" && len(p) == 1 && s.Radii != nil:
+			e.AbsArcTo(s.Radii[0], s.Radii[1], s.XAxisRotation, s.LargeArc, s.Sweep, p[0][0], p[0][1])
+		default:
+			return errInvalidJSONGraphic
+		}
+	}
+	e.ClosePathEndPath()
+	return nil
+}
+
+// parseJSONColor parses a single color in #rrggbbaa form.
+func parseJSONColor(s string) (color.RGBA, error) {
+	colors, err := parsePaletteText([]byte(s))
+	if err != nil || len(colors) != 1 {
+		return color.RGBA{}, errInvalidJSONGraphic
+	}
+	return colors[0], nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestToJSON(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x80, 0x80}))
+	e.StartPath(0, -32, 0)
+	e.RelArcTo(16, 16, 0, false, true, +32, 0)
+	e.AbsLineTo(0, +16)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	got, err := ToJSON(ivgData, nil)
+	if err != nil {
+		t.Fatalf("ToJSON: %v", err)
+	}
+	var g struct {
+		ViewBox [4]float32
+		Paths   []map[string]interface{}
+	}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if g.ViewBox != [4]float32{-32, -32, +32, +32} {
+		t.Errorf("viewBox: got %v", g.ViewBox)
+	}
+	if len(g.Paths) != 1 {
+		t.Fatalf("got %d paths, want 1", len(g.Paths))
+	}
+	p := g.Paths[0]
+	if got, want := p["fill"], "#0000ff80"; got != want {
+		t.Errorf("fill: got %v, want %v", got, want)
+	}
+	if p["lod1"] != nil {
+		t.Errorf("lod1: got %v, want nil", p["lod1"])
+	}
+	segs, _ := json.Marshal(p["segments"])
+	wantSegs := `[{"kind":"moveTo","points":[[-32,0]]},` +
+		`{"kind":"arcTo","points":[[0,0]],"radii":[16,16],"sweep":true},` +
+		`{"kind":"lineTo","points":[[0,16]]}]`
+	if string(segs) != wantSegs {
+		t.Errorf("segments:\ngot  %s\nwant %s", segs, wantSegs)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, tc := range testdataTestCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		j0, err := ToJSON(ivgData, nil)
+		if err != nil {
+			t.Errorf("%s: ToJSON: %v", tc.filename, err)
+			continue
+		}
+		reencoded, err := FromJSON(j0)
+		if err != nil {
+			t.Errorf("%s: FromJSON: %v", tc.filename, err)
+			continue
+		}
+		j1, err := ToJSON(reencoded, nil)
+		if err != nil {
+			t.Errorf("%s: ToJSON (re-encoded): %v", tc.filename, err)
+			continue
+		}
+		if !bytes.Equal(j0, j1) {
+			t.Errorf("%s: JSON differs after a round trip", tc.filename)
+		}
+	}
+}
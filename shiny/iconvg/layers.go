@@ -0,0 +1,96 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+)
+
+// Layer is one path of an IconVG graphic, as reported by a LayerCollector.
+type Layer struct {
+	// Color is the path's alpha-premultiplied fill color. It is transparent
+	// black if Gradient is non-nil.
+	Color color.RGBA
+
+	// Gradient is the path's gradient fill, or nil if it is filled with a
+	// flat color.
+	Gradient *SceneGradient
+
+	// Bounds is the tight bounding box of the path, in graphic coordinate
+	// space.
+	Bounds Rectangle
+
+	// Index is the index of the path, counting every path decoded since the
+	// last Reset, including those that are not reported as layers.
+	Index int
+}
+
+// LayerCollector is a Destination that reports each path of an IconVG graphic
+// as a Layer, with its resolved fill and its bounding box, for tools that
+// slice a graphic into separately tintable layers. Curves are flattened to
+// line segments, to within 1/64th of a unit, before their bounds are
+// computed.
+//
+// Paths at every level of detail are included, but fully transparent paths,
+// and paths whose fill is neither a valid color nor a gradient, are not.
+type LayerCollector struct {
+	segmenter
+	layers  []Layer
+	segs    []Segment
+	index   int
+	visible bool
+}
+
+// Reset resets the LayerCollector for the given Metadata.
+func (c *LayerCollector) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	c.layers = nil
+	c.segs = c.segs[:0]
+	c.index = 0
+	c.visible = false
+}
+
+// Layers returns the layers decoded since the last Reset, in drawing order.
+func (c *LayerCollector) Layers() []Layer {
+	return c.layers
+}
+
+func (c *LayerCollector) beginPath() {
+	c.segs = c.segs[:0]
+	l := Layer{Index: c.index}
+	c.index++
+	if c.fill.A == 0 && c.fill.B&0x80 != 0 {
+		l.Gradient = c.gradient(c.fill)
+	} else if c.fill.A != 0 && validAlphaPremulColor(c.fill) {
+		l.Color = c.fill
+	} else {
+		c.visible = false
+		return
+	}
+	c.visible = true
+	c.layers = append(c.layers, l)
+}
+
+func (c *LayerCollector) addSegment(s Segment) {
+	if c.visible {
+		c.segs = append(c.segs, s)
+	}
+}
+
+func (c *LayerCollector) endPath() {
+	if !c.visible {
+		return
+	}
+	points := flatten(nil, c.segs, flattenTolerance)
+	r := Rectangle{Min: points[0], Max: points[0]}
+	for _, p := range points[1:] {
+		r.Min[0] = min32(r.Min[0], p[0])
+		r.Min[1] = min32(r.Min[1], p[1])
+		r.Max[0] = max32(r.Max[0], p[0])
+		r.Max[1] = max32(r.Max[1], p[1])
+	}
+	c.layers[len(c.layers)-1].Bounds = r
+	c.visible = false
+}
@@ -0,0 +1,69 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestLayerCollector(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	red := color.RGBA{0xff, 0x00, 0x00, 0xff}
+	e.SetCReg(0, false, RGBAColor(red))
+	e.StartPath(0, -30, -30)
+	e.AbsHLineTo(-10)
+	e.AbsVLineTo(-20)
+	e.ClosePathAbsMoveTo(+20, +20)
+	e.AbsLineTo(+25, +20)
+	e.ClosePathEndPath()
+	// A fully transparent path is not a layer, but still has an index.
+	e.SetCReg(0, false, RGBAColor(color.RGBA{}))
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(+8)
+	e.AbsVLineTo(+8)
+	e.ClosePathEndPath()
+	// A curve's bounds are tighter than its control points'.
+	e.SetCReg(0, false, PaletteIndexColor(0))
+	e.StartPath(0, -16, 0)
+	e.AbsQuadTo(0, -16, +16, 0)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var c LayerCollector
+	if err := Decode(&c, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := []Layer{{
+		Color:  red,
+		Bounds: Rectangle{Min: f32.Vec2{-30, -30}, Max: f32.Vec2{+25, +20}},
+		Index:  0,
+	}, {
+		Color:  DefaultPalette[0],
+		Bounds: Rectangle{Min: f32.Vec2{-16, -8}, Max: f32.Vec2{+16, 0}},
+		Index:  2,
+	}}
+	got := c.Layers()
+	if len(got) == 2 {
+		// The curve is flattened, so its bounds are only within 1/64th of a
+		// unit of the true bounds.
+		if d := got[1].Bounds.Min[1] - want[1].Bounds.Min[1]; 0 <= d && d <= 1.0/64 {
+			got[1].Bounds.Min[1] = want[1].Bounds.Min[1]
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"errors"
+	"image/color"
+)
+
+var errPathIndexOutOfRange = errors.New("iconvg: path index out of range")
+
+// LazyIcon is an IconVG graphic whose structure, but not its drawing opcodes'
+// coordinates, has been decoded. Each path's coordinates are decoded on
+// demand, by ResolvePath.
+//
+// Finding the structure only needs the length of each encoded number, which
+// is determined by its first byte, so it is cheaper than a full Decode.
+type LazyIcon struct {
+	// Metadata is the graphic's metadata, with the palette passed to
+	// DecodeLazy, if any, overriding the suggested palette, and similarly for
+	// the ViewBox.
+	Metadata Metadata
+
+	src       []byte
+	skip      int
+	headerLen int
+	paths     []lazySpan
+
+	// recoverMetadata is whether the header, which ResolvePath decodes again,
+	// needs the RecoverMetadata option.
+	recoverMetadata bool
+}
+
+// lazySpan is the [start, end) byte range of a path, from its start path
+// styling opcode to its end path drawing opcode, inclusive.
+type lazySpan struct {
+	start, end int
+}
+
+// ResolvedPath is a fully decoded path of a LazyIcon.
+type ResolvedPath struct {
+	// Fill is the path's fill color, CREG[CSEL-ADJ]. It is either an
+	// alpha-premultiplied color or, if Fill.A is zero and Fill.B has its high
+	// bit set, a gradient.
+	Fill color.RGBA
+
+	// LOD0 and LOD1 are the path's level of detail range.
+	LOD0, LOD1 float32
+
+	// Segments are the path's segments, in graphic coordinate space.
+	Segments []Segment
+}
+
+// DecodeLazy decodes the structure of an IconVG graphic. The opts'
+// AutoClosePaths, MaxViewBoxArea, OnSkippedMetadataChunk, Palette,
+// PaletteOverrides, RecoverMetadata, SkipBytes and ViewBoxOverride fields are
+// honored, as by Decode. Its other fields, such as Deadline and Trace, are
+// ignored, both by DecodeLazy and by the LazyIcon's ResolvePath method.
+//
+// The src bytes are retained by the LazyIcon, and should not be modified
+// while it is in use.
+func DecodeLazy(src []byte, opts *DecodeOptions) (*LazyIcon, error) {
+	return decodeLazy(src, opts, -1)
+}
+
+// DecodePath decodes only the index'th path of an IconVG graphic, returning
+// its segments, in graphic coordinate space, with arcs converted to cubic
+// Bézier curves. It is cheaper than a full Decode for a tool, such as an
+// editor, that needs only one path: the earlier paths' drawing opcodes are
+// skipped, without decoding their coordinates, and the later paths are not
+// looked at, so errors in them are not detected.
+//
+// The opts' fields are honored, or ignored, as for DecodeLazy.
+func DecodePath(src []byte, index int, opts *DecodeOptions) ([]Segment, error) {
+	if index < 0 {
+		return nil, errPathIndexOutOfRange
+	}
+	l, err := decodeLazy(src, opts, index+1)
+	if err != nil {
+		return nil, err
+	}
+	p, err := l.ResolvePath(index)
+	if err != nil {
+		return nil, err
+	}
+	return p.Segments, nil
+}
+
+// decodeLazy is like DecodeLazy, but stops after finding maxPaths paths, if
+// maxPaths is non-negative.
+func decodeLazy(src []byte, opts *DecodeOptions, maxPaths int) (*LazyIcon, error) {
+	l := &LazyIcon{
+		Metadata: Metadata{
+			ViewBox: DefaultViewBox,
+			Palette: DefaultPalette,
+		},
+		src: src,
+	}
+	if opts != nil && opts.Palette != nil {
+		l.Metadata.Palette = *opts.Palette
+	}
+	if opts != nil {
+		l.skip = opts.SkipBytes
+		l.recoverMetadata = opts.RecoverMetadata
+	}
+	rest, err := decodeHeader(nil, &l.Metadata, src, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil && opts.ViewBoxOverride != nil {
+		if !validViewBox(*opts.ViewBoxOverride) {
+			return nil, errInvalidViewBox
+		}
+		l.Metadata.ViewBox = *opts.ViewBoxOverride
+	}
+	l.headerLen = len(src) - len(rest)
+
+	for s := buffer(rest); len(s) > 0 && len(l.paths) != maxPaths; {
+		if opcode := s[0]; opcode < 0xc0 || 0xc7 <= opcode {
+			if _, s, err = decodeStyling(nil, nil, s); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		start := len(src) - len(s)
+		// Skip the start path opcode and its two coordinates.
+		if s, err = skipNumbers(s[1:], 2); err != nil {
+			return nil, err
+		}
+		ended := false
+		for len(s) > 0 && !ended {
+			if s, ended, err = skipDrawing(s); err != nil {
+				return nil, err
+			}
+		}
+		if !ended && (opts == nil || !opts.AutoClosePaths) {
+			return nil, errUnclosedPath
+		}
+		l.paths = append(l.paths, lazySpan{start, len(src) - len(s)})
+	}
+	return l, nil
+}
+
+// NumPaths returns the number of paths in the graphic.
+func (l *LazyIcon) NumPaths() int {
+	return len(l.paths)
+}
+
+// PathSpan returns the [start, end) byte offsets, in the source, of the i'th
+// path's encoded form, from its start path opcode to its end path opcode
+// inclusive.
+func (l *LazyIcon) PathSpan(i int) (start, end int) {
+	s := l.paths[i]
+	return s.start, s.end
+}
+
+// ResolvePath fully decodes the i'th path. It honors the options passed to
+// DecodeLazy as DecodeLazy does: for example, with RecoverMetadata, corrupt
+// metadata chunks are skipped again, but not reported again.
+func (l *LazyIcon) ResolvePath(i int) (*ResolvedPath, error) {
+	if i < 0 || len(l.paths) <= i {
+		return nil, errPathIndexOutOfRange
+	}
+	var c pathCollector
+	if err := Decode(&c, l.pathSource(i), &DecodeOptions{
+		Palette:         &l.Metadata.Palette,
+		AutoClosePaths:  true,
+		RecoverMetadata: l.recoverMetadata,
+	}); err != nil {
+		return nil, err
+	}
+	return &c.path, nil
+}
+
+// pathSource returns a synthesized graphic whose only path is the i'th path.
+//
+// It consists of the header, all of the styling opcodes that precede the i'th
+// path (which may affect its registers), and the path itself. The earlier
+// paths' drawing opcodes do not affect any registers, so they are skipped.
+func (l *LazyIcon) pathSource(i int) []byte {
+	src := append([]byte(nil), l.src[l.skip:l.headerLen]...)
+	prevEnd := l.headerLen
+	for _, s := range l.paths[:i+1] {
+		src = append(src, l.src[prevEnd:s.start]...)
+		prevEnd = s.end
+	}
+	s := l.paths[i]
+	return append(src, l.src[s.start:s.end]...)
+}
+
+// pathCollector is a Destination that records the last path.
+type pathCollector struct {
+	segmenter
+	path ResolvedPath
+}
+
+func (c *pathCollector) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	c.path = ResolvedPath{}
+}
+
+func (c *pathCollector) beginPath() {
+	c.path = ResolvedPath{
+		Fill: c.fill,
+		LOD0: c.lod0,
+		LOD1: c.lod1,
+	}
+}
+
+func (c *pathCollector) addSegment(s Segment) { c.path.Segments = append(c.path.Segments, s) }
+func (c *pathCollector) endPath()             {}
+
+// skipNumbers skips over n encoded numbers. All of the number encodings
+// (natural, real, coordinate and zero-to-one) have the same length, given
+// their first byte.
+func skipNumbers(src buffer, n int) (buffer, error) {
+	for ; n > 0; n-- {
+		_, m := src.decodeNatural()
+		if m == 0 {
+			return nil, errInvalidNumber
+		}
+		src = src[m:]
+	}
+	return src, nil
+}
+
+// skipDrawing skips over the next drawing opcode and its arguments, returning
+// whether that opcode ended the path.
+func skipDrawing(src buffer) (src1 buffer, ended bool, err error) {
+	opcode := src[0]
+	src = src[1:]
+	n := 0
+	switch {
+	case opcode < 0x40:
+		n = 2 * (1 + int(opcode&0x1f))
+	case opcode < 0x60:
+		n = 2 * (1 + int(opcode&0x0f))
+	case opcode < 0xa0:
+		n = 4 * (1 + int(opcode&0x0f))
+	case opcode < 0xe0:
+		n = 6 * (1 + int(opcode&0x0f))
+	case opcode == 0xe1:
+		return src, true, nil
+	case opcode == 0xe2 || opcode == 0xe3:
+		n = 2
+	case 0xe6 <= opcode && opcode <= 0xe9:
+		n = 1
+	default:
+		return nil, false, errReservedDrawingOpcode
+	}
+	src, err = skipNumbers(src, n)
+	return src, false, err
+}
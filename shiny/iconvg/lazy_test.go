@@ -0,0 +1,164 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+// pathsRecorder is a Destination that records every path.
+type pathsRecorder struct {
+	segmenter
+	paths []ResolvedPath
+}
+
+func (r *pathsRecorder) Reset(m Metadata) {
+	r.segmenter.reset(m, r)
+	r.paths = nil
+}
+
+func (r *pathsRecorder) beginPath() {
+	r.paths = append(r.paths, ResolvedPath{Fill: r.fill, LOD0: r.lod0, LOD1: r.lod1})
+}
+
+func (r *pathsRecorder) addSegment(s Segment) {
+	p := &r.paths[len(r.paths)-1]
+	p.Segments = append(p.Segments, s)
+}
+
+func (r *pathsRecorder) endPath() {}
+
+func TestDecodeLazy(t *testing.T) {
+	for _, tc := range testdataTestCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		var want pathsRecorder
+		if err := Decode(&want, ivgData, nil); err != nil {
+			t.Errorf("%s: Decode: %v", tc.filename, err)
+			continue
+		}
+		l, err := DecodeLazy(ivgData, nil)
+		if err != nil {
+			t.Errorf("%s: DecodeLazy: %v", tc.filename, err)
+			continue
+		}
+		if got, want := l.NumPaths(), len(want.paths); got != want {
+			t.Errorf("%s: NumPaths: got %d, want %d", tc.filename, got, want)
+			continue
+		}
+		for i := range want.paths {
+			if start, end := l.PathSpan(i); ivgData[start] < 0xc0 || 0xc6 < ivgData[start] || ivgData[end-1] != 0xe1 {
+				t.Errorf("%s: path #%d: bad span [%d, %d)", tc.filename, i, start, end)
+			}
+			got, err := l.ResolvePath(i)
+			if err != nil {
+				t.Errorf("%s: path #%d: ResolvePath: %v", tc.filename, i, err)
+				continue
+			}
+			if !reflect.DeepEqual(*got, want.paths[i]) {
+				t.Errorf("%s: path #%d:\ngot  %v\nwant %v", tc.filename, i, *got, want.paths[i])
+			}
+		}
+		if _, err := l.ResolvePath(l.NumPaths()); err != errPathIndexOutOfRange {
+			t.Errorf("%s: ResolvePath(NumPaths()): got %v, want %v", tc.filename, err, errPathIndexOutOfRange)
+		}
+	}
+}
+
+func TestDecodePath(t *testing.T) {
+	for _, tc := range testdataTestCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		var want pathsRecorder
+		if err := Decode(&want, ivgData, nil); err != nil {
+			t.Errorf("%s: Decode: %v", tc.filename, err)
+			continue
+		}
+		for i, p := range want.paths {
+			got, err := DecodePath(ivgData, i, nil)
+			if err != nil {
+				t.Errorf("%s: path #%d: DecodePath: %v", tc.filename, i, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, p.Segments) {
+				t.Errorf("%s: path #%d:\ngot  %v\nwant %v", tc.filename, i, got, p.Segments)
+			}
+
+			// The later paths are not looked at, so truncating the
+			// graphic after the path does not matter.
+			l, err := DecodeLazy(ivgData, nil)
+			if err != nil {
+				t.Fatalf("%s: DecodeLazy: %v", tc.filename, err)
+			}
+			_, end := l.PathSpan(i)
+			if got, err := DecodePath(ivgData[:end], i, nil); err != nil {
+				t.Errorf("%s: path #%d: truncated: DecodePath: %v", tc.filename, i, err)
+			} else if !reflect.DeepEqual(got, p.Segments) {
+				t.Errorf("%s: path #%d: truncated:\ngot  %v\nwant %v", tc.filename, i, got, p.Segments)
+			}
+		}
+		for _, i := range []int{-1, len(want.paths)} {
+			if _, err := DecodePath(ivgData, i, nil); err != errPathIndexOutOfRange {
+				t.Errorf("%s: DecodePath(%d): got %v, want %v", tc.filename, i, err, errPathIndexOutOfRange)
+			}
+		}
+	}
+}
+
+func TestDecodeLazyRecoverMetadata(t *testing.T) {
+	var e Encoder
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(8)
+	e.AbsVLineTo(8)
+	e.AbsHLineTo(0)
+	e.ClosePathEndPath()
+	square, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	var want pathsRecorder
+	if err := Decode(&want, square, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	// Replace the zero metadata chunks with a ViewBox chunk whose Min, (+10,
+	// +10), is greater than its Max, (0, 0).
+	src := append([]byte(magic+"\x02\x0a\x00\x94\x94\x80\x80"), square[len(magic)+1:]...)
+	override := Rectangle{Max: f32.Vec2{16, 16}}
+	opts := &DecodeOptions{
+		RecoverMetadata: true,
+		ViewBoxOverride: &override,
+	}
+	l, err := DecodeLazy(src, opts)
+	if err != nil {
+		t.Fatalf("DecodeLazy: %v", err)
+	}
+	if got := l.Metadata.ViewBox; got != override {
+		t.Errorf("ViewBox: got %v, want %v", got, override)
+	}
+	got, err := l.ResolvePath(0)
+	if err != nil {
+		t.Fatalf("ResolvePath: %v", err)
+	}
+	if !reflect.DeepEqual(got, &want.paths[0]) {
+		t.Errorf("ResolvePath:\ngot  %v\nwant %v", got, &want.paths[0])
+	}
+	if segs, err := DecodePath(src, 0, opts); err != nil {
+		t.Errorf("DecodePath: %v", err)
+	} else if !reflect.DeepEqual(segs, want.paths[0].Segments) {
+		t.Errorf("DecodePath:\ngot  %v\nwant %v", segs, want.paths[0].Segments)
+	}
+}
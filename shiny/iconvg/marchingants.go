@@ -0,0 +1,55 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+)
+
+var errInvalidFrameCount = errors.New("iconvg: invalid frame count")
+
+// marchingAntsDash is the length of each dash, and of each gap, of a marching
+// ants outline, as a multiple of the stroke width.
+const marchingAntsDash = 4
+
+// MarchingAnts renders the outline of the IconVG graphic src as an animated,
+// dashed stroke, commonly called marching ants, for selection and highlight
+// effects. It returns frames w × h RGBA images which, shown in order and
+// repeatedly, make the dashes move steadily along each sub-path, in the
+// direction that it is drawn.
+//
+// The stroke is width wide, in graphic coordinate space, and each path is
+// stroked with its own fill color or gradient, as by StrokeDestination. Its
+// dashes and gaps are each 4 times as long as the stroke is wide. Each frame
+// advances the dash pattern by 1/frames of the length of a dash and a gap, so
+// that the last frame leads seamlessly back to the first.
+//
+// It returns an error if frames is less than 1.
+func MarchingAnts(src []byte, frames int, width float32, w, h int) ([]*image.RGBA, error) {
+	if frames < 1 {
+		return nil, errInvalidFrameCount
+	}
+	dash := marchingAntsDash * width
+	o := StrokeOptions{
+		Width:  width,
+		Cap:    CapButt,
+		Dashes: []float32{dash, dash},
+	}
+	ret := make([]*image.RGBA, frames)
+	for i := range ret {
+		// Moving the pattern back moves the dashes forward.
+		o.DashOffset = -2 * dash * float32(i) / float32(frames)
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		var z Rasterizer
+		z.SetDstImage(dst, dst.Bounds(), draw.Src)
+		if err := Decode(StrokeDestination(&z, o), src, nil); err != nil {
+			return nil, err
+		}
+		ret[i] = dst
+	}
+	return ret, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"testing"
+)
+
+func TestMarchingAnts(t *testing.T) {
+	// A square, clockwise from its top left corner.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.StartPath(0, -20, -20)
+	e.AbsHLineTo(+20)
+	e.AbsVLineTo(+20)
+	e.AbsHLineTo(-20)
+	e.ClosePathEndPath()
+	square, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	// The graphic is rasterized at 1 pixel per unit. With a width of 2, the
+	// dashes and gaps are 8 units long, and each of the 4 frames moves them 4
+	// units to the right along the square's top edge. The pixels at x = 16
+	// and x = 24, just below that edge, are 8 units apart.
+	frames, err := MarchingAnts(square, 4, 2, 64, 64)
+	if err != nil {
+		t.Fatalf("MarchingAnts: %v", err)
+	}
+	if len(frames) != 4 {
+		t.Fatalf("got %d frames, want 4", len(frames))
+	}
+	testCases := []struct {
+		frame      int
+		at16, at24 bool
+	}{
+		{0, true, false},
+		{1, true, false},
+		{2, false, true},
+		{3, false, true},
+	}
+	for _, tc := range testCases {
+		m := frames[tc.frame]
+		if got := m.RGBAAt(16, 12).A == 0xff; got != tc.at16 {
+			t.Errorf("frame %d: x=16: got %t, want %t", tc.frame, got, tc.at16)
+		}
+		if got := m.RGBAAt(24, 12).A == 0xff; got != tc.at24 {
+			t.Errorf("frame %d: x=24: got %t, want %t", tc.frame, got, tc.at24)
+		}
+		// The square's inside is not drawn.
+		if got := m.RGBAAt(32, 32).A; got != 0 {
+			t.Errorf("frame %d: center alpha: got %#02x, want 0", tc.frame, got)
+		}
+	}
+
+	if _, err := MarchingAnts(square, 0, 2, 64, 64); err != errInvalidFrameCount {
+		t.Errorf("0 frames: got %v, want %v", err, errInvalidFrameCount)
+	}
+}
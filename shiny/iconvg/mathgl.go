@@ -0,0 +1,98 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build mathgl
+
+package iconvg
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// MGLCollector is a Destination that records an IconVG graphic's outline as
+// github.com/go-gl/mathgl vertices, flattened to line segments but not
+// triangulated. Each contour (sub-path) is a closed loop, suitable for line
+// loop rendering or for a GPU tessellator. Fully transparent paths are
+// skipped.
+//
+// The coordinates are in graphic coordinate space.
+//
+// MGLCollector is only built with the "mathgl" build tag, so that the
+// package does not otherwise depend on mathgl.
+type MGLCollector struct {
+	// Height is the height, in pixels, that the graphic is intended to be
+	// rendered at, which selects paths by their level of detail.
+	//
+	// If zero, it is the height of the ViewBox.
+	Height float32
+
+	segmenter
+
+	vertices []mgl32.Vec2
+	contours []int
+	segs     []Segment
+	visible  bool
+}
+
+// Vertices returns the vertices of every contour, concatenated.
+func (c *MGLCollector) Vertices() []mgl32.Vec2 {
+	return c.vertices
+}
+
+// Contours returns the vertices of each contour. A contour's last vertex is
+// implicitly joined to its first. The slices share Vertices' backing array.
+func (c *MGLCollector) Contours() [][]mgl32.Vec2 {
+	ret := make([][]mgl32.Vec2, len(c.contours))
+	start := 0
+	for i, end := range c.contours {
+		ret[i] = c.vertices[start:end:end]
+		start = end
+	}
+	return ret
+}
+
+// Reset resets the MGLCollector for the given Metadata.
+func (c *MGLCollector) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	c.vertices = c.vertices[:0]
+	c.contours = c.contours[:0]
+	c.segs = c.segs[:0]
+	c.visible = false
+}
+
+func (c *MGLCollector) height() float32 {
+	if c.Height > 0 {
+		return c.Height
+	}
+	_, dy := c.metadata.ViewBox.AspectRatio()
+	return dy
+}
+
+func (c *MGLCollector) beginPath() {
+	h := c.height()
+	c.visible = c.lod0 <= h && h < c.lod1 && (c.fill.A != 0 || c.fill.B&0x80 != 0)
+	c.segs = c.segs[:0]
+}
+
+func (c *MGLCollector) addSegment(s Segment) {
+	if c.visible {
+		c.segs = append(c.segs, s)
+	}
+}
+
+func (c *MGLCollector) endPath() {
+	if !c.visible {
+		return
+	}
+	for _, sub := range splitSubpaths(c.segs) {
+		points := flatten(nil, sub, flattenTolerance)
+		if n := len(points); n > 1 && points[0] == points[n-1] {
+			points = points[:n-1]
+		}
+		for _, p := range points {
+			c.vertices = append(c.vertices, mgl32.Vec2{p[0], p[1]})
+		}
+		c.contours = append(c.contours, len(c.vertices))
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build mathgl
+
+package iconvg
+
+import (
+	"testing"
+)
+
+func TestMGLCollector(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.StartPath(0, -10, -10)
+	e.AbsHLineTo(+10)
+	e.AbsVLineTo(+10)
+	e.AbsHLineTo(-10)
+	e.AbsVLineTo(-10)
+	e.ClosePathAbsMoveTo(0, 0)
+	e.AbsLineTo(5, 0)
+	e.AbsLineTo(0, 5)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var c MGLCollector
+	if err := Decode(&c, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	contours := c.Contours()
+	if len(contours) != 2 || len(contours[0]) != 4 || len(contours[1]) != 3 {
+		t.Fatalf("contours: got %v, want a square and a triangle", contours)
+	}
+	if got, want := len(c.Vertices()), 7; got != want {
+		t.Errorf("number of vertices: got %d, want %d", got, want)
+	}
+	if got := contours[1][2]; got[0] != 0 || got[1] != 5 {
+		t.Errorf("triangle's last vertex: got %v, want (0, 5)", got)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+)
+
+// OpacityDestination returns a Destination that forwards each method call to
+// inner, except that the fills of the paths listed in opacity are made more
+// transparent, for rendering variants of a graphic, such as dimming its
+// inactive parts, without editing it.
+//
+// The opacity map is keyed by path index: the number of paths that precede
+// the path in the graphic, including fully transparent paths and those
+// outside of every level of detail. Each value is a multiplier of the path's
+// fill's alpha, clamped to the range [0, 1]. A gradient fill's stops are all
+// multiplied. Paths that are not in the map are unchanged.
+//
+// The multiplied colors are set in inner's CREG registers for the duration of
+// each path, and restored afterwards, so that later paths that use the same
+// registers are unaffected.
+func OpacityDestination(inner Destination, opacity map[int]float32) Destination {
+	d := &opacityDestination{opacity: opacity}
+	d.teeDestination = teeDestination{d0: inner, d1: &d.regs}
+	return d
+}
+
+type opacityDestination struct {
+	// The teeDestination forwards each method call to both the inner
+	// Destination and the penTracker, which tracks the registers.
+	teeDestination
+	regs    penTracker
+	opacity map[int]float32
+
+	// path is the index of the next path.
+	path int
+	// restore lists the inner CREG registers, and their values, that need to
+	// be restored when the current path ends.
+	restore []opacityRestore
+}
+
+type opacityRestore struct {
+	index uint8
+	c     color.RGBA
+}
+
+func (d *opacityDestination) Reset(m Metadata) {
+	d.teeDestination.Reset(m)
+	d.path = 0
+	d.restore = d.restore[:0]
+}
+
+func (d *opacityDestination) StartPath(adj uint8, x, y float32) {
+	o, ok := d.opacity[d.path]
+	d.path++
+	if ok && o != 1 {
+		if !(o > 0) {
+			o = 0
+		} else if o > 1 {
+			o = 1
+		}
+		i := (d.regs.cSel - adj) & 0x3f
+		c := d.regs.cReg[i]
+		if validAlphaPremulColor(c) {
+			d.setCReg(i, c, o)
+		} else if c.A == 0x00 && c.B&0x80 != 0 {
+			// The gradient's stops' colors are in CREG[cBase:cBase+nStops].
+			nStops, cBase := int(c.R&0x3f), c.G&0x3f
+			for j := 0; j < nStops; j++ {
+				k := (cBase + uint8(j)) & 0x3f
+				d.setCReg(k, d.regs.cReg[k], o)
+			}
+		}
+		if len(d.restore) > 0 {
+			d.d0.SetCSel(d.regs.cSel)
+		}
+	}
+	d.teeDestination.StartPath(adj, x, y)
+}
+
+// setCReg sets inner's CREG[i] to c with its alpha multiplied by o, leaving
+// inner's CSEL for the caller to restore. A register that has already been
+// set for the current path is left alone, so that it is neither dimmed twice
+// nor restored to its dimmed color.
+func (d *opacityDestination) setCReg(i uint8, c color.RGBA, o float32) {
+	for _, r := range d.restore {
+		if r.index == i {
+			return
+		}
+	}
+	d.restore = append(d.restore, opacityRestore{index: i, c: c})
+	mul := func(x uint8) uint8 { return uint8(float32(x)*o + 0.5) }
+	d.d0.SetCSel(i)
+	d.d0.SetCReg(0, false, RGBAColor(color.RGBA{mul(c.R), mul(c.G), mul(c.B), mul(c.A)}))
+}
+
+func (d *opacityDestination) ClosePathEndPath() {
+	d.teeDestination.ClosePathEndPath()
+	if len(d.restore) == 0 {
+		return
+	}
+	for _, r := range d.restore {
+		d.d0.SetCSel(r.index)
+		d.d0.SetCReg(0, false, RGBAColor(r.c))
+	}
+	d.d0.SetCSel(d.regs.cSel)
+	d.restore = d.restore[:0]
+}
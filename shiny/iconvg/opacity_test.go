@@ -0,0 +1,146 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// rasterizeWithOpacity is like rasterize, but with an OpacityDestination.
+func rasterizeWithOpacity(src []byte, width, height int, opacity map[int]float32) (*image.RGBA, error) {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	var z Rasterizer
+	z.SetDstImage(dst, dst.Bounds(), draw.Src)
+	if err := Decode(OpacityDestination(&z, opacity), src, nil); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+func TestOpacityDestination(t *testing.T) {
+	// Three side by side squares. The first and third are filled with the
+	// same CREG register.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: Rectangle{Max: [2]float32{30, 10}},
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0xff, 0x00, 0x00, 0xff}))
+	e.SetCReg(1, false, RGBAColor(color.RGBA{0x00, 0x00, 0xff, 0xff}))
+	for i, adj := range []uint8{0, 1, 0} {
+		x := float32(10 * i)
+		e.StartPath(adj, x, 0)
+		e.AbsHLineTo(x + 10)
+		e.AbsVLineTo(10)
+		e.AbsHLineTo(x)
+		e.ClosePathEndPath()
+	}
+	src, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	dst, err := rasterizeWithOpacity(src, 30, 10, map[int]float32{0: 0.5, 1: 0, 7: 0.5})
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	for _, tc := range []struct {
+		x    int
+		want color.RGBA
+	}{
+		{5, color.RGBA{0x80, 0x00, 0x00, 0x80}},
+		{15, color.RGBA{}},
+		{25, color.RGBA{0xff, 0x00, 0x00, 0xff}},
+	} {
+		if got := dst.RGBAAt(tc.x, 5); got != tc.want {
+			t.Errorf("x=%d: got %v, want %v", tc.x, got, tc.want)
+		}
+	}
+
+	// A gradient's stops are all made more transparent. Every path of this
+	// graphic is filled with a gradient.
+	gradient, err := ioutil.ReadFile(filepath.FromSlash("testdata/gradient.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	r, err := RecordRaw(gradient, nil)
+	if err != nil {
+		t.Fatalf("RecordRaw: %v", err)
+	}
+	half := map[int]float32{}
+	for i := 0; i < r.NumPaths(); i++ {
+		half[i] = 0.5
+	}
+	want, err := rasterize(gradient, 64, 64, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	got, err := rasterizeWithOpacity(gradient, 64, 64, half)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			w := want.RGBAAt(x, y)
+			w = color.RGBA{w.R / 2, w.G / 2, w.B / 2, w.A / 2}
+			if g := got.RGBAAt(x, y); !closeRGBA(g, w, 2) {
+				t.Fatalf("gradient: (%d, %d): got %v, want %v", x, y, g, w)
+			}
+		}
+	}
+}
+
+func TestOpacityDestinationGradientHighBits(t *testing.T) {
+	// Two side by side squares, both filled with the same gradient, whose
+	// CREG register's R has bits set above the low 6 bits that count the
+	// stops. Only the first square is made more transparent.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: Rectangle{Max: [2]float32{20, 10}},
+		Palette: DefaultPalette,
+	})
+	e.SetLinearGradient(10, 10, 0, 0, 20, 0, GradientSpreadPad, []GradientStop{
+		{Offset: 0, Color: color.RGBA{0xff, 0x00, 0x00, 0xff}},
+		{Offset: 1, Color: color.RGBA{0x00, 0x00, 0xff, 0xff}},
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{R: 0x40 | 2, G: 10, B: 0x80 | 10, A: 0x00}))
+	for i := 0; i < 2; i++ {
+		x := float32(10 * i)
+		e.StartPath(0, x, 0)
+		e.AbsHLineTo(x + 10)
+		e.AbsVLineTo(10)
+		e.AbsHLineTo(x)
+		e.ClosePathEndPath()
+	}
+	src, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	want, err := rasterize(src, 20, 10, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	got, err := rasterizeWithOpacity(src, 20, 10, map[int]float32{0: 0.5})
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			w := want.RGBAAt(x, y)
+			if x < 10 {
+				w = color.RGBA{w.R / 2, w.G / 2, w.B / 2, w.A / 2}
+			}
+			if g := got.RGBAAt(x, y); !closeRGBA(g, w, 2) {
+				t.Fatalf("(%d, %d): got %v, want %v", x, y, g, w)
+			}
+		}
+	}
+}
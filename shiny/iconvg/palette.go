@@ -0,0 +1,166 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidPaletteColor = errors.New("iconvg: invalid alpha-premultiplied palette color")
+	errInvalidPaletteText  = errors.New("iconvg: invalid palette text")
+)
+
+// Merge returns a copy of p where each entry whose mask element is true is
+// replaced by the corresponding entry of overlay. For example, a theme that
+// only overrides the palette's first color would pass a mask whose only true
+// element is mask[0].
+func (p Palette) Merge(overlay Palette, mask [64]bool) Palette {
+	for i, m := range mask {
+		if m {
+			p[i] = overlay[i]
+		}
+	}
+	return p
+}
+
+// MarshalText implements the encoding.TextMarshaler interface. The text is a
+// comma-separated list of the palette's 64 colors, in #rrggbbaa form. Unlike
+// the palette's alpha-premultiplied colors, the #rrggbbaa form has straight
+// alpha, as in CSS, so that #ff000080 is a half-transparent pure red.
+//
+// It returns an error if any color is not a valid alpha-premultiplied color.
+func (p Palette) MarshalText() ([]byte, error) {
+	b := make([]byte, 0, len(p)*len("#rrggbbaa,"))
+	for i, c := range p {
+		if !validAlphaPremulColor(c) {
+			return nil, errInvalidPaletteColor
+		}
+		if i != 0 {
+			b = append(b, ',')
+		}
+		b = appendStraightHex(b, c)
+	}
+	return b, nil
+}
+
+// appendStraightHex appends the #rrggbbaa form, with straight alpha, of the
+// valid alpha-premultiplied color c.
+func appendStraightHex(b []byte, c color.RGBA) []byte {
+	r, g, bb := unpremul(c.R, c.A), unpremul(c.G, c.A), unpremul(c.B, c.A)
+	return append(b, fmt.Sprintf("#%02x%02x%02x%02x", r, g, bb, c.A)...)
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. It
+// accepts the form that MarshalText produces, with optional spaces around
+// each color, and with upper or lower case hexadecimal digits. It also
+// accepts fewer than 64 colors, in which case the remaining entries are set
+// to those of the DefaultPalette.
+//
+// A fully transparent color, such as #ff000000, becomes transparent black,
+// the only fully transparent alpha-premultiplied color.
+func (p *Palette) UnmarshalText(text []byte) error {
+	colors, err := parsePaletteText(text)
+	if err != nil {
+		return err
+	}
+	*p = DefaultPalette
+	copy(p[:], colors)
+	return nil
+}
+
+// parsePaletteText parses the text form of a palette, as described by
+// Palette.UnmarshalText, returning its 1 to 64 alpha-premultiplied colors.
+func parsePaletteText(text []byte) ([]color.RGBA, error) {
+	fields := strings.Split(string(text), ",")
+	if len(fields) > len(Palette{}) {
+		return nil, errInvalidPaletteText
+	}
+	colors := make([]color.RGBA, len(fields))
+	for i, f := range fields {
+		f = strings.TrimSpace(f)
+		if len(f) != len("#rrggbbaa") || f[0] != '#' {
+			return nil, errInvalidPaletteText
+		}
+		u, err := strconv.ParseUint(f[1:], 16, 32)
+		if err != nil {
+			return nil, errInvalidPaletteText
+		}
+		a := uint8(u)
+		colors[i] = color.RGBA{
+			R: premul(uint8(u>>24), a),
+			G: premul(uint8(u>>16), a),
+			B: premul(uint8(u>>8), a),
+			A: a,
+		}
+	}
+	return colors, nil
+}
+
+// DecodeWithPaletteFile decodes an IconVG graphic, like Decode, with palette
+// colors from paletteText, such as the contents of a theme file stored
+// separately from the graphic. The text has the form that Palette's
+// MarshalText method produces.
+//
+// The text's colors replace the first entries of whichever palette is
+// otherwise in effect, so a text with fewer than 64 colors leaves the rest
+// unchanged. The opts' PaletteOverrides, if any, still apply on top.
+func DecodeWithPaletteFile(dst Destination, src []byte, paletteText []byte, opts *DecodeOptions) error {
+	colors, err := parsePaletteText(paletteText)
+	if err != nil {
+		return err
+	}
+	o := DecodeOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	overrides := make(map[int]color.RGBA, len(colors)+len(o.PaletteOverrides))
+	for i, c := range colors {
+		overrides[i] = c
+	}
+	for i, c := range o.PaletteOverrides {
+		overrides[i] = c
+	}
+	o.PaletteOverrides = overrides
+	return Decode(dst, src, &o)
+}
+
+// FirstCustomPaletteIndex returns the lowest index at which an IconVG
+// graphic's suggested palette differs from the DefaultPalette, and true, or
+// -1 and false if it does not differ, such as when the graphic has no
+// suggested palette. Only the graphic's metadata is decoded.
+func FirstCustomPaletteIndex(src []byte) (int, bool, error) {
+	m, err := DecodeMetadata(src)
+	if err != nil {
+		return -1, false, err
+	}
+	for i, c := range m.Palette {
+		if c != DefaultPalette[i] {
+			return i, true, nil
+		}
+	}
+	return -1, false, nil
+}
+
+// unpremul returns the straight alpha form of the alpha-premultiplied color
+// channel value x, rounded to nearest.
+func unpremul(x, a uint8) uint8 {
+	if a == 0 {
+		return 0
+	}
+	return uint8((uint32(x)*0xff + uint32(a)/2) / uint32(a))
+}
+
+// premul returns the alpha-premultiplied form of the straight alpha color
+// channel value x, rounded to nearest. Rounding to nearest in both premul and
+// unpremul means that a valid alpha-premultiplied color survives a round
+// trip through straight alpha unchanged.
+func premul(x, a uint8) uint8 {
+	return uint8((uint32(x)*uint32(a) + 0x7f) / 0xff)
+}
@@ -0,0 +1,215 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestPaletteMerge(t *testing.T) {
+	base := DefaultPalette
+	overlay := Palette{}
+	for i := range overlay {
+		overlay[i] = color.RGBA{uint8(i), 0x00, 0x00, 0xff}
+	}
+
+	if got := base.Merge(overlay, [64]bool{}); got != base {
+		t.Errorf("empty mask: got %v, want %v", got, base)
+	}
+
+	full := [64]bool{}
+	for i := range full {
+		full[i] = true
+	}
+	if got := base.Merge(overlay, full); got != overlay {
+		t.Errorf("full mask: got %v, want %v", got, overlay)
+	}
+
+	got := base.Merge(overlay, [64]bool{2: true, 63: true})
+	want := base
+	want[2] = overlay[2]
+	want[63] = overlay[63]
+	if got != want {
+		t.Errorf("partial mask: got %v, want %v", got, want)
+	}
+	if base != DefaultPalette {
+		t.Errorf("Merge modified its receiver")
+	}
+}
+
+func TestPaletteText(t *testing.T) {
+	p := DefaultPalette
+	p[0] = color.RGBA{0x80, 0x00, 0x00, 0x80}
+	p[1] = color.RGBA{0x00, 0x00, 0x00, 0x00}
+	p[2] = color.RGBA{0x12, 0x34, 0x56, 0xff}
+	text, err := p.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText: %v", err)
+	}
+	wantPrefix := "#ff000080,#00000000,#123456ff,#000000ff,"
+	if got := string(text); !strings.HasPrefix(got, wantPrefix) {
+		t.Errorf("MarshalText: got %q, want prefix %q", got, wantPrefix)
+	}
+
+	var q Palette
+	if err := q.UnmarshalText(text); err != nil {
+		t.Fatalf("UnmarshalText: %v", err)
+	}
+	if q != p {
+		t.Errorf("round trip: got %v, want %v", q, p)
+	}
+
+	// Every valid alpha-premultiplied color survives a round trip.
+	for a := 0; a < 0x100; a++ {
+		for x := 0; x <= a; x++ {
+			c := color.RGBA{uint8(x), uint8(x), uint8(a - x), uint8(a)}
+			if got := premul(unpremul(c.R, c.A), c.A); got != c.R {
+				t.Fatalf("round trip of %v: got R=%#02x", c, got)
+			}
+		}
+	}
+}
+
+func TestPaletteUnmarshalText(t *testing.T) {
+	testCases := []struct {
+		text    string
+		want    []color.RGBA
+		wantErr bool
+	}{{
+		// A fully transparent color becomes transparent black.
+		text: "#FF000000, #ffffff00",
+		want: []color.RGBA{{}, {}},
+	}, {
+		text: " #FF0000FF ,#00ff0080",
+		want: []color.RGBA{{0xff, 0x00, 0x00, 0xff}, {0x00, 0x80, 0x00, 0x80}},
+	}, {
+		text:    "#ff0000",
+		wantErr: true,
+	}, {
+		text:    "ff0000ff",
+		wantErr: true,
+	}, {
+		text:    "#gg0000ff",
+		wantErr: true,
+	}, {
+		text:    "",
+		wantErr: true,
+	}, {
+		text:    strings.Repeat("#000000ff,", 64) + "#000000ff",
+		wantErr: true,
+	}}
+
+	for _, tc := range testCases {
+		var p Palette
+		err := p.UnmarshalText([]byte(tc.text))
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: got nil error, want non-nil", tc.text)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tc.text, err)
+			continue
+		}
+		want := DefaultPalette
+		copy(want[:], tc.want)
+		if p != want {
+			t.Errorf("%q: got %v, want %v", tc.text, p[:len(tc.want)], tc.want)
+		}
+	}
+
+	// An invalid alpha-premultiplied color cannot be marshaled.
+	p := DefaultPalette
+	p[5] = color.RGBA{0xff, 0x00, 0x00, 0x00}
+	if _, err := p.MarshalText(); err == nil {
+		t.Error("MarshalText of invalid color: got nil error, want non-nil")
+	}
+}
+
+func TestDecodeWithPaletteFile(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	for i := 0; i < 3; i++ {
+		e.SetCReg(0, false, PaletteIndexColor(uint8(i)))
+		e.StartPath(0, -8, -8)
+		e.AbsHLineTo(+8)
+		e.AbsVLineTo(+8)
+		e.ClosePathEndPath()
+	}
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	blue := color.RGBA{0x00, 0x00, 0xff, 0xff}
+	var r pathsRecorder
+	if err := DecodeWithPaletteFile(&r, ivgData, []byte("#ff000080, #00ff00ff"), &DecodeOptions{
+		PaletteOverrides: map[int]color.RGBA{1: blue},
+	}); err != nil {
+		t.Fatalf("DecodeWithPaletteFile: %v", err)
+	}
+	want := []color.RGBA{
+		{0x80, 0x00, 0x00, 0x80},
+		// The PaletteOverrides apply on top of the palette file.
+		blue,
+		// The palette file's two colors leave the rest of the palette as is.
+		DefaultPalette[2],
+	}
+	if len(r.paths) != len(want) {
+		t.Fatalf("paths: got %d, want %d", len(r.paths), len(want))
+	}
+	for i, p := range r.paths {
+		if p.Fill != want[i] {
+			t.Errorf("path #%d: got %v, want %v", i, p.Fill, want[i])
+		}
+	}
+
+	if err := DecodeWithPaletteFile(&r, ivgData, []byte("red"), nil); err == nil {
+		t.Error("invalid palette file: got nil error, want non-nil")
+	}
+}
+
+func TestFirstCustomPaletteIndex(t *testing.T) {
+	pal := DefaultPalette
+	pal[5] = color.RGBA{0x00, 0x00, 0xff, 0xff}
+	pal[9] = color.RGBA{0x00, 0xff, 0x00, 0xff}
+	testCases := []struct {
+		palette   Palette
+		wantIndex int
+		wantOK    bool
+	}{
+		{DefaultPalette, -1, false},
+		{pal, 5, true},
+	}
+	for _, tc := range testCases {
+		var e Encoder
+		e.Reset(Metadata{
+			ViewBox: DefaultViewBox,
+			Palette: tc.palette,
+		})
+		ivgData, err := e.Bytes()
+		if err != nil {
+			t.Fatalf("Bytes: %v", err)
+		}
+		gotIndex, gotOK, err := FirstCustomPaletteIndex(ivgData)
+		if err != nil {
+			t.Errorf("FirstCustomPaletteIndex: %v", err)
+			continue
+		}
+		if gotIndex != tc.wantIndex || gotOK != tc.wantOK {
+			t.Errorf("got %d, %t, want %d, %t", gotIndex, gotOK, tc.wantIndex, tc.wantOK)
+		}
+	}
+
+	if _, _, err := FirstCustomPaletteIndex([]byte("bad")); err == nil {
+		t.Error("invalid graphic: got nil error, want non-nil")
+	}
+}
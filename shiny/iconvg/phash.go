@@ -0,0 +1,90 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// phashSize is the width and height of the grayscale grid that
+	// PerceptualHash rasterizes to.
+	phashSize = 32
+	// phashLowSize is the width and height of the block of lowest frequency
+	// DCT coefficients that make up the hash.
+	phashLowSize = 8
+)
+
+// PerceptualHash returns a 64-bit perceptual hash, or pHash, of the IconVG
+// graphic src, for finding visually similar graphics. Similar graphics have
+// hashes that differ in few bits, as measured by their Hamming distance.
+//
+// The graphic is rasterized onto a 32 × 32 white background and converted to
+// grayscale. Bit i of the hash, for i in [0, 64), is whether the i'th of the
+// 8 × 8 lowest frequency coefficients of its two-dimensional discrete cosine
+// transform, in row-major order, is greater than their median. The zero
+// frequency coefficient, which is the average brightness, is excluded from
+// the median.
+func PerceptualHash(src []byte, opts *DecodeOptions) (uint64, error) {
+	img, err := rasterize(src, phashSize, phashSize, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	// Composite onto white and take the relative luminance, in [0, 1].
+	var gray [phashSize][phashSize]float64
+	for y := 0; y < phashSize; y++ {
+		for x := 0; x < phashSize; x++ {
+			i := img.PixOffset(x, y)
+			p := img.Pix[i : i+4 : i+4]
+			white := float64(0xff - p[3])
+			r := float64(p[0]) + white
+			g := float64(p[1]) + white
+			b := float64(p[2]) + white
+			gray[y][x] = (0.2126*r + 0.7152*g + 0.0722*b) / 0xff
+		}
+	}
+
+	// cos[u][x] is the DCT-II basis function for frequency u at position x.
+	var cos [phashLowSize][phashSize]float64
+	for u := range cos {
+		for x := range cos[u] {
+			cos[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * phashSize))
+		}
+	}
+	// Transform the rows, then the columns, keeping only the low frequencies.
+	var rows [phashSize][phashLowSize]float64
+	for y := range rows {
+		for u := range rows[y] {
+			sum := 0.0
+			for x := 0; x < phashSize; x++ {
+				sum += gray[y][x] * cos[u][x]
+			}
+			rows[y][u] = sum
+		}
+	}
+	var coeffs [phashLowSize * phashLowSize]float64
+	for v := 0; v < phashLowSize; v++ {
+		for u := 0; u < phashLowSize; u++ {
+			sum := 0.0
+			for y := 0; y < phashSize; y++ {
+				sum += rows[y][u] * cos[v][y]
+			}
+			coeffs[v*phashLowSize+u] = sum
+		}
+	}
+
+	sorted := append([]float64(nil), coeffs[1:]...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	hash := uint64(0)
+	for i, c := range coeffs {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"testing"
+)
+
+func hammingDistance(a, b uint64) int {
+	n := 0
+	for x := a ^ b; x != 0; x &= x - 1 {
+		n++
+	}
+	return n
+}
+
+func TestPerceptualHash(t *testing.T) {
+	encode := func(c color.RGBA, points ...float32) []byte {
+		var e Encoder
+		e.Reset(Metadata{
+			ViewBox: DefaultViewBox,
+			Palette: DefaultPalette,
+		})
+		e.SetCReg(0, false, RGBAColor(c))
+		e.StartPath(0, points[0], points[1])
+		for i := 2; i < len(points); i += 2 {
+			e.AbsLineTo(points[i], points[i+1])
+		}
+		e.ClosePathEndPath()
+		b, err := e.Bytes()
+		if err != nil {
+			t.Fatalf("Bytes: %v", err)
+		}
+		return b
+	}
+	black := color.RGBA{0x00, 0x00, 0x00, 0xff}
+	gray := color.RGBA{0x20, 0x20, 0x20, 0xff}
+
+	triangle := encode(black, -28, +24, -12, -28, +28, +16)
+	similar := encode(gray, -27, +24, -12, -27, +28, +17)
+	different := encode(black, -32, -32, 0, -32, 0, +32, -32, +32)
+
+	hash := func(src []byte) uint64 {
+		h, err := PerceptualHash(src, nil)
+		if err != nil {
+			t.Fatalf("PerceptualHash: %v", err)
+		}
+		return h
+	}
+	h0, h1, h2 := hash(triangle), hash(similar), hash(different)
+	if h := hash(triangle); h != h0 {
+		t.Errorf("hash is not deterministic: %#016x and %#016x", h0, h)
+	}
+	if d := hammingDistance(h0, h1); d > 8 {
+		t.Errorf("similar graphics: distance %d is too large: %#016x vs %#016x", d, h0, h1)
+	}
+	if d := hammingDistance(h0, h2); d < 16 {
+		t.Errorf("different graphics: distance %d is too small: %#016x vs %#016x", d, h0, h2)
+	}
+
+	if _, err := PerceptualHash([]byte("not an IconVG graphic"), nil); err == nil {
+		t.Error("invalid graphic: got nil error")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+)
+
+// EncodePalettedPNG rasterizes the IconVG graphic src to a width × height
+// image and writes it to w as an 8-bit paletted PNG.
+//
+// If opts.Background is non-nil, the graphic is composited over it, as per the
+// Rasterizer's SetBackground method, instead of over transparent black.
+//
+// The PNG's palette consists of transparent black, the background color, the
+// graphic's custom palette and the flat colors that its paths are filled
+// with, up to a total of 256 colors. Each pixel, including the partially
+// covered pixels on a path's anti-aliased edges, is quantized to the nearest
+// palette color. For icons drawn in a few flat colors, this is much smaller
+// than a true color PNG.
+func EncodePalettedPNG(w io.Writer, src []byte, width, height int, opts *DecodeOptions) error {
+	var background color.Color
+	if opts != nil {
+		background = opts.Background
+	}
+	rgba, err := rasterizeOnto(src, width, height, background, opts)
+	if err != nil {
+		return err
+	}
+	var c colorCollector
+	if err := Decode(&c, src, opts); err != nil {
+		return err
+	}
+
+	pal := color.Palette{color.RGBA{}}
+	seen := map[color.RGBA]bool{{}: true}
+	if background != nil {
+		if b := color.RGBAModel.Convert(background).(color.RGBA); !seen[b] {
+			seen[b] = true
+			pal = append(pal, b)
+		}
+	}
+	for _, list := range [2][]color.RGBA{c.metadata.Palette[:], c.colors} {
+		for _, x := range list {
+			if len(pal) == 256 {
+				break
+			}
+			if !seen[x] {
+				seen[x] = true
+				pal = append(pal, x)
+			}
+		}
+	}
+
+	dst := image.NewPaletted(rgba.Bounds(), pal)
+	draw.Draw(dst, dst.Bounds(), rgba, image.Point{}, draw.Src)
+	return png.Encode(w, dst)
+}
+
+// colorCollector is a Destination that records the distinct flat colors that
+// paths are filled with, in order of first use. If stops is set, the colors
+// of gradients' stops are also recorded.
+type colorCollector struct {
+	segmenter
+	stops  bool
+	colors []color.RGBA
+}
+
+func (c *colorCollector) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	c.colors = c.colors[:0]
+}
+
+func (c *colorCollector) beginPath() {
+	if validAlphaPremulColor(c.fill) {
+		c.add(c.fill)
+	} else if c.stops && c.fill.A == 0x00 && c.fill.B&0x80 != 0 {
+		nStops := int(c.fill.R & 0x3f)
+		cBase := int(c.fill.G & 0x3f)
+		for i := 0; i < nStops; i++ {
+			if x := c.cReg[(cBase+i)&0x3f]; validAlphaPremulColor(x) {
+				c.add(x)
+			}
+		}
+	}
+}
+
+func (c *colorCollector) add(x color.RGBA) {
+	for _, y := range c.colors {
+		if y == x {
+			return
+		}
+	}
+	c.colors = append(c.colors, x)
+}
+
+func (c *colorCollector) addSegment(s Segment) {}
+func (c *colorCollector) endPath()             {}
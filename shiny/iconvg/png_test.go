@@ -0,0 +1,98 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodePalettedPNG(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	const width, height = 64, 64
+	buf := new(bytes.Buffer)
+	if err := EncodePalettedPNG(buf, ivgData, width, height, nil); err != nil {
+		t.Fatalf("EncodePalettedPNG: %v", err)
+	}
+	got, err := png.Decode(buf)
+	if err != nil {
+		t.Fatalf("png.Decode: %v", err)
+	}
+	p, ok := got.(*image.Paletted)
+	if !ok {
+		t.Fatalf("got %T, want *image.Paletted", got)
+	}
+	if c := color.RGBAModel.Convert(p.Palette[0]); c != (color.RGBA{}) {
+		t.Errorf("palette[0]: got %v, want transparent black", c)
+	}
+
+	// Every pixel should be the palette color nearest to the true color
+	// rendering.
+	want, err := rasterize(ivgData, width, height, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if g, w := p.ColorIndexAt(x, y), uint8(p.Palette.Index(want.At(x, y))); g != w {
+				t.Fatalf("at (%d, %d): got index %d, want %d", x, y, g, w)
+			}
+		}
+	}
+}
+
+func TestEncodePalettedPNGBackground(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	const width, height = 64, 64
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	buf := new(bytes.Buffer)
+	if err := EncodePalettedPNG(buf, ivgData, width, height, &DecodeOptions{Background: white}); err != nil {
+		t.Fatalf("EncodePalettedPNG: %v", err)
+	}
+	got, err := png.Decode(buf)
+	if err != nil {
+		t.Fatalf("png.Decode: %v", err)
+	}
+	p, ok := got.(*image.Paletted)
+	if !ok {
+		t.Fatalf("got %T, want *image.Paletted", got)
+	}
+	if c := color.RGBAModel.Convert(p.Palette[1]); c != white {
+		t.Errorf("palette[1]: got %v, want %v", c, white)
+	}
+
+	// The corners, outside of the cowbell, are the background.
+	if c := color.RGBAModel.Convert(p.At(0, 0)); c != white {
+		t.Errorf("at (0, 0): got %v, want %v", c, white)
+	}
+
+	// Every pixel should be the palette color nearest to the true color
+	// rendering over the background.
+	want, err := rasterizeOnto(ivgData, width, height, white, nil)
+	if err != nil {
+		t.Fatalf("rasterizeOnto: %v", err)
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if g, w := p.ColorIndexAt(x, y), uint8(p.Palette.Index(want.At(x, y))); g != w {
+				t.Fatalf("at (%d, %d): got index %d, want %d", x, y, g, w)
+			}
+			if w := want.RGBAAt(x, y); w.A != 0xff {
+				t.Fatalf("at (%d, %d): true color: got %v, want an opaque color", x, y, w)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"errors"
+
+	"golang.org/x/image/math/f32"
+)
+
+var errInvalidMaxError = errors.New("iconvg: invalid maximum error")
+
+// maxPolylineDepth bounds how many times a curve is halved, as float32
+// rounding can stop a curve that is tiny, compared to its coordinates, from
+// ever looking flat enough. 2^24 pieces is finer than float32 precision.
+const maxPolylineDepth = 24
+
+// Polylines decodes the IconVG graphic src, returning one polyline per
+// sub-path of every path, regardless of color and level of detail. Each
+// sub-path is closed, so its polyline ends with its first point.
+//
+// No point of the graphic's true curves and arcs is more than maxError, in
+// graphic coordinate space, from its polyline, up to float32 rounding. Half of
+// that error is allowed for converting arcs to cubic Bézier curves, which
+// overrides opts.ArcApproximator, and half for flattening the curves. Curves
+// are flattened by adaptive subdivision: a curve is halved until its control
+// points are within the error of its chord, and, as a curve lies within the
+// convex hull of its control points, so is the curve. Flat parts of a curve
+// therefore need fewer points than sharply bent ones.
+//
+// It returns an error if maxError is not positive and finite.
+func Polylines(src []byte, maxError float32, opts *DecodeOptions) ([][]f32.Vec2, error) {
+	if !(maxError > 0) || isNaNOrInfinity(maxError) {
+		return nil, errInvalidMaxError
+	}
+	var o DecodeOptions
+	if opts != nil {
+		o = *opts
+	}
+	o.ArcApproximator = ArcTolerance(maxError / 2)
+	c := &polylineCollector{tolerance: maxError / 2}
+	if err := Decode(c, src, &o); err != nil {
+		return nil, err
+	}
+	return c.polylines, nil
+}
+
+type polylineCollector struct {
+	segmenter
+	tolerance float32
+	segs      []Segment
+	polylines [][]f32.Vec2
+}
+
+func (c *polylineCollector) Reset(m Metadata) {
+	c.segmenter.reset(m, c)
+	c.segs = c.segs[:0]
+	c.polylines = nil
+}
+
+func (c *polylineCollector) beginPath()           { c.segs = c.segs[:0] }
+func (c *polylineCollector) addSegment(s Segment) { c.segs = append(c.segs, s) }
+
+func (c *polylineCollector) endPath() {
+	for _, sub := range splitSubpaths(c.segs) {
+		var pen f32.Vec2
+		var polyline []f32.Vec2
+		for i := range sub {
+			s := &sub[i]
+			switch s.Op {
+			case SegmentOpMoveTo, SegmentOpLineTo:
+				polyline = append(polyline, s.Args[0])
+			case SegmentOpQuadTo:
+				c1, c2 := quadToCubic(pen, s.Args[0], s.Args[1])
+				polyline = flattenAdaptive(polyline, c.tolerance, pen, c1, c2, s.Args[1], 0)
+			case SegmentOpCubeTo:
+				polyline = flattenAdaptive(polyline, c.tolerance, pen, s.Args[0], s.Args[1], s.Args[2], 0)
+			}
+			pen = s.end()
+		}
+		if len(polyline) > 1 && polyline[len(polyline)-1] != polyline[0] {
+			polyline = append(polyline, polyline[0])
+		}
+		c.polylines = append(c.polylines, polyline)
+	}
+}
+
+// flattenAdaptive appends to dst the points, after p0, of a polyline that is
+// within the tolerance of the cubic Bézier curve (p0, p1, p2, p3).
+func flattenAdaptive(dst []f32.Vec2, tolerance float32, p0, p1, p2, p3 f32.Vec2, depth int) []f32.Vec2 {
+	if depth >= maxPolylineDepth ||
+		(distanceToLine(p1, p0, p3) <= tolerance && distanceToLine(p2, p0, p3) <= tolerance) {
+		return append(dst, p3)
+	}
+	// Split the curve in half, by de Casteljau's algorithm.
+	p01, p12, p23 := lerp(0.5, p0, p1), lerp(0.5, p1, p2), lerp(0.5, p2, p3)
+	p012, p123 := lerp(0.5, p01, p12), lerp(0.5, p12, p23)
+	mid := lerp(0.5, p012, p123)
+	dst = flattenAdaptive(dst, tolerance, p0, p01, p012, mid, depth+1)
+	return flattenAdaptive(dst, tolerance, mid, p123, p23, p3, depth+1)
+}
@@ -0,0 +1,87 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+// distanceToPolyline returns the distance from p to the nearest point of the
+// polyline.
+func distanceToPolyline(p f32.Vec2, polyline []f32.Vec2) float32 {
+	d := float32(math.Inf(+1))
+	for i := 1; i < len(polyline); i++ {
+		d = min32(d, distanceToLine(p, polyline[i-1], polyline[i]))
+	}
+	return d
+}
+
+func TestPolylines(t *testing.T) {
+	// A circle of radius 20, made of two arcs, and a cubic Bézier curve.
+	var e Encoder
+	e.HighResolutionCoordinates = true
+	e.Reset(Metadata{
+		ViewBox: Rectangle{Min: f32.Vec2{-32, -32}, Max: f32.Vec2{32, 32}},
+		Palette: DefaultPalette,
+	})
+	e.StartPath(0, -20, 0)
+	e.AbsArcTo(20, 20, 0, false, false, 20, 0)
+	e.AbsArcTo(20, 20, 0, false, false, -20, 0)
+	e.ClosePathAbsMoveTo(-30, 30)
+	e.AbsCubeTo(-30, -30, 30, -30, 30, 30)
+	e.ClosePathEndPath()
+	src, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	prevN := 0
+	for _, maxError := range []float32{1, 1.0 / 16, 1.0 / 256} {
+		polylines, err := Polylines(src, maxError, nil)
+		if err != nil {
+			t.Fatalf("maxError=%v: Polylines: %v", maxError, err)
+		}
+		if len(polylines) != 2 {
+			t.Fatalf("maxError=%v: got %d polylines, want 2", maxError, len(polylines))
+		}
+		for i, polyline := range polylines {
+			if first, last := polyline[0], polyline[len(polyline)-1]; first != last {
+				t.Errorf("maxError=%v: polyline #%d: first %v != last %v", maxError, i, first, last)
+			}
+		}
+
+		// Sample the true curves, and check that each sample is near its
+		// polyline. Allow a little for float32 rounding.
+		const n = 1000
+		slack := maxError + 1e-4
+		for j := 0; j < n; j++ {
+			theta := 2 * math.Pi * float64(j) / n
+			p := f32.Vec2{float32(20 * math.Cos(theta)), float32(20 * math.Sin(theta))}
+			if d := distanceToPolyline(p, polylines[0]); d > slack {
+				t.Fatalf("maxError=%v: circle at %v: distance %v", maxError, p, d)
+			}
+			p = cubeAt(f32.Vec2{-30, 30}, f32.Vec2{-30, -30}, f32.Vec2{30, -30}, f32.Vec2{30, 30}, float32(j)/n)
+			if d := distanceToPolyline(p, polylines[1]); d > slack {
+				t.Fatalf("maxError=%v: cubic at %v: distance %v", maxError, p, d)
+			}
+		}
+
+		// A smaller error needs more points.
+		n0 := len(polylines[0]) + len(polylines[1])
+		if n0 <= prevN {
+			t.Errorf("maxError=%v: got %d points, want more than %d", maxError, n0, prevN)
+		}
+		prevN = n0
+	}
+
+	for _, maxError := range []float32{0, -1, float32(math.NaN())} {
+		if _, err := Polylines(src, maxError, nil); err != errInvalidMaxError {
+			t.Errorf("maxError=%v: got %v, want %v", maxError, err, errInvalidMaxError)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"sync"
+)
+
+// defaultMaxPooledSegments is the default value of a DecoderPool's
+// MaxSegments.
+const defaultMaxPooledSegments = 1 << 16
+
+// SegmentBuffer is a Destination that records every path of an IconVG
+// graphic as segments. Its memory is reused after each Reset, so decoding
+// many graphics into the same SegmentBuffer, such as one from a DecoderPool,
+// allocates little once the buffer has grown.
+type SegmentBuffer struct {
+	segmenter
+	paths []ResolvedPath
+	segs  []Segment
+	// ends[i] is the end of the i'th path's segments in segs.
+	ends []int
+}
+
+// Reset resets the SegmentBuffer for the given Metadata, discarding any
+// previously recorded paths.
+func (b *SegmentBuffer) Reset(m Metadata) {
+	b.segmenter.reset(m, b)
+	b.clear()
+}
+
+// clear discards the recorded paths, zeroing them so that no data from one
+// graphic remains in the buffer while it is used for another.
+func (b *SegmentBuffer) clear() {
+	for i := range b.paths {
+		b.paths[i] = ResolvedPath{}
+	}
+	for i := range b.segs {
+		b.segs[i] = Segment{}
+	}
+	b.paths = b.paths[:0]
+	b.segs = b.segs[:0]
+	b.ends = b.ends[:0]
+}
+
+// Paths returns the recorded paths. The paths, and their segments, are only
+// valid until the SegmentBuffer is next Reset or put back in a DecoderPool.
+func (b *SegmentBuffer) Paths() []ResolvedPath {
+	start := 0
+	for i, end := range b.ends {
+		b.paths[i].Segments = b.segs[start:end:end]
+		start = end
+	}
+	return b.paths
+}
+
+func (b *SegmentBuffer) beginPath() {
+	b.paths = append(b.paths, ResolvedPath{Fill: b.fill, LOD0: b.lod0, LOD1: b.lod1})
+	b.ends = append(b.ends, len(b.segs))
+}
+
+func (b *SegmentBuffer) addSegment(s Segment) {
+	b.segs = append(b.segs, s)
+	b.ends[len(b.ends)-1] = len(b.segs)
+}
+
+func (b *SegmentBuffer) endPath() {}
+
+// DecoderPool is a pool of SegmentBuffers, for reducing allocations when
+// decoding many graphics, such as in a server. It is safe for concurrent
+// use. The zero value is ready to use.
+type DecoderPool struct {
+	// MaxSegments bounds the memory that the pool retains: a SegmentBuffer
+	// that has grown to hold more segments is dropped, instead of being put
+	// back in the pool. If zero, it is 65536.
+	MaxSegments int
+
+	pool sync.Pool
+}
+
+// Get returns an empty SegmentBuffer from the pool, or a new one if the pool
+// is empty.
+func (p *DecoderPool) Get() *SegmentBuffer {
+	if b, ok := p.pool.Get().(*SegmentBuffer); ok {
+		return b
+	}
+	return &SegmentBuffer{}
+}
+
+// Put clears the SegmentBuffer and puts it back in the pool. It must not be
+// used, nor any paths previously returned by its Paths method, afterwards.
+func (p *DecoderPool) Put(b *SegmentBuffer) {
+	max := p.MaxSegments
+	if max <= 0 {
+		max = defaultMaxPooledSegments
+	}
+	if cap(b.segs) > max {
+		return
+	}
+	b.clear()
+	b.segmenter.reset(Metadata{}, b)
+	p.pool.Put(b)
+}
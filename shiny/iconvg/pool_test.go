@@ -0,0 +1,115 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDecoderPool(t *testing.T) {
+	var p DecoderPool
+	for _, tc := range testdataTestCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		var want pathsRecorder
+		if err := Decode(&want, ivgData, nil); err != nil {
+			t.Errorf("%s: Decode: %v", tc.filename, err)
+			continue
+		}
+		// Decode twice with the same pooled buffer, to check that it is
+		// reset between uses.
+		for i := 0; i < 2; i++ {
+			b := p.Get()
+			if len(b.Paths()) != 0 {
+				t.Errorf("%s: #%d: Get returned a non-empty buffer", tc.filename, i)
+			}
+			if err := Decode(b, ivgData, nil); err != nil {
+				t.Errorf("%s: #%d: Decode: %v", tc.filename, i, err)
+				continue
+			}
+			got := b.Paths()
+			if len(got) == 0 && len(want.paths) == 0 {
+				got = nil
+			}
+			if !reflect.DeepEqual(got, want.paths) {
+				t.Errorf("%s: #%d: paths differ from Decode's", tc.filename, i)
+			}
+			p.Put(b)
+		}
+	}
+}
+
+func TestDecoderPoolMaxSegments(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	p := DecoderPool{MaxSegments: 1}
+	b := p.Get()
+	if err := Decode(b, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	p.Put(b)
+	// The buffer grew past MaxSegments, so it was dropped. The pool may
+	// also drop buffers at any time, so only check that a different,
+	// empty, buffer comes back.
+	if b1 := p.Get(); b1 == b || len(b1.Paths()) != 0 {
+		t.Errorf("Get: got the dropped buffer, or a non-empty one")
+	}
+}
+
+func TestSegmentBufferAllocs(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var b SegmentBuffer
+	var r pathsRecorder
+	reused := testing.AllocsPerRun(10, func() { Decode(&b, ivgData, nil) })
+	fresh := testing.AllocsPerRun(10, func() { Decode(&r, ivgData, nil) })
+	if reused >= fresh {
+		t.Errorf("allocations: reused buffer: %v, fresh buffers: %v", reused, fresh)
+	}
+}
+
+func BenchmarkDecodePooled(b *testing.B) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		b.Fatalf("ReadFile: %v", err)
+	}
+	var p DecoderPool
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf := p.Get()
+			if err := Decode(buf, ivgData, nil); err != nil {
+				b.Fatal(err)
+			}
+			p.Put(buf)
+		}
+	})
+}
+
+func BenchmarkDecodeUnpooled(b *testing.B) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		b.Fatalf("ReadFile: %v", err)
+	}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var r pathsRecorder
+			if err := Decode(&r, ivgData, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -8,9 +8,9 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
-	"math"
 
 	"golang.org/x/exp/shiny/iconvg/internal/gradient"
+	"golang.org/x/image/math/f32"
 	"golang.org/x/image/math/f64"
 	"golang.org/x/image/vector"
 )
@@ -35,6 +35,23 @@ type Rasterizer struct {
 	r      image.Rectangle
 	drawOp draw.Op
 
+	// clip, if non-empty, restricts drawing to that part of dst. mask holds
+	// each path's coverage when clipping.
+	clip image.Rectangle
+	mask *image.Alpha
+
+	// background, if non-nil, is drawn onto dst before the graphic.
+	background image.Image
+
+	// hinting is the hinting mode. Unless it is HintingNone, each path's ops
+	// are recorded in hintOps, in pixel space, and only passed to z once the
+	// path is complete and has been hinted. hintPen and hintStart are the
+	// current point and the current sub-path's start.
+	hinting   HintingMode
+	hintOps   []hintOp
+	hintPen   f32.Vec2
+	hintStart f32.Vec2
+
 	// scale and bias transforms the metadata.ViewBox rectangle to the (0, 0) -
 	// (r.Dx(), r.Dy()) rectangle.
 	scaleX float32
@@ -82,7 +99,42 @@ func (z *Rasterizer) SetDstImage(dst draw.Image, r image.Rectangle, drawOp draw.
 	z.recalcTransform()
 }
 
+// SetClip sets the Rasterizer to only draw onto the part of the destination
+// image inside the clip rectangle, in dst's coordinate space. Pixels outside
+// of it are left unchanged, even if the graphic's paths cover them. Passing
+// an empty rectangle disables clipping.
+//
+// Unlike the rectangle passed to SetDstImage, the clip rectangle does not
+// affect how the graphic is scaled: it is as if the unclipped graphic was
+// drawn through a rectangular stencil. As the clip rectangle has integer
+// coordinates, a pixel is either wholly inside or wholly outside it, and
+// pixels partially covered by a path have the same anti-aliased coverage as
+// they would without clipping.
+func (z *Rasterizer) SetClip(clip image.Rectangle) {
+	if clip.Empty() {
+		clip = image.Rectangle{}
+	}
+	z.clip = clip
+}
+
+// SetBackground sets the Rasterizer to fill the destination rectangle, as
+// passed to SetDstImage and restricted by any clip rectangle, with the
+// background color at the start of each Decode, replacing what was there.
+// The graphic is then composited on top, with the draw.Over operator
+// whatever the SetDstImage operator, so that a draw.Src operator does not
+// replace the background. Passing nil, the default, leaves the destination
+// image unchanged.
+func (z *Rasterizer) SetBackground(background color.Color) {
+	if background == nil {
+		z.background = nil
+		return
+	}
+	z.background = image.NewUniform(background)
+}
+
 // Reset resets the Rasterizer for the given Metadata.
+//
+// If a background was set, Reset fills the destination with it.
 func (z *Rasterizer) Reset(m Metadata) {
 	z.metadata = m
 	z.lod0 = 0
@@ -96,6 +148,14 @@ func (z *Rasterizer) Reset(m Metadata) {
 	z.cReg = m.Palette
 	z.nReg = [64]float32{}
 	z.recalcTransform()
+
+	if z.dst != nil && z.background != nil {
+		r := z.r
+		if z.clip != (image.Rectangle{}) {
+			r = r.Intersect(z.clip)
+		}
+		draw.Draw(z.dst, r, z.background, image.Point{}, draw.Src)
+	}
 }
 
 func (z *Rasterizer) recalcTransform() {
@@ -139,7 +199,7 @@ func (z *Rasterizer) absVec2(x, y float32) (zx, zy float32) {
 }
 
 func (z *Rasterizer) relVec2(x, y float32) (zx, zy float32) {
-	px, py := z.z.Pen()
+	px, py := z.pen()
 	return px + z.relX(x), py + z.relY(y)
 }
 
@@ -152,7 +212,7 @@ func (z *Rasterizer) relVec2(x, y float32) (zx, zy float32) {
 // command or if the previous command was not [a quadratic or cubic command],
 // assume the first control point is coincident with the current point.)"
 func (z *Rasterizer) implicitSmoothPoint(thisSmoothType uint8) (zx, zy float32) {
-	px, py := z.z.Pen()
+	px, py := z.pen()
 	if z.prevSmoothType != thisSmoothType {
 		return px, py
 	}
@@ -247,20 +307,52 @@ func (z *Rasterizer) StartPath(adj uint8, x, y float32) {
 	if z.firstStartPath {
 		z.firstStartPath = false
 		z.z.DrawOp = z.drawOp
+		// With a background, even the first path is composited over what is
+		// already in dst, which is the background, rather than replacing it.
+		if z.background != nil {
+			z.z.DrawOp = draw.Over
+		}
 	}
 	z.prevSmoothType = smoothTypeNone
-	z.z.MoveTo(z.absVec2(x, y))
+	z.hintOps = z.hintOps[:0]
+	z.moveTo(z.absVec2(x, y))
 }
 
 func (z *Rasterizer) ClosePathEndPath() {
 	if z.disabled {
 		return
 	}
-	z.z.ClosePath()
+	z.closePath()
+	if z.hinting != HintingNone {
+		z.flushHintOps()
+	}
 	if z.dst == nil {
 		return
 	}
-	z.z.Draw(z.dst, z.r, z.fill, image.Point{})
+	if z.clip.Empty() || z.r.In(z.clip) {
+		z.z.Draw(z.dst, z.r, z.fill, image.Point{})
+		return
+	}
+	z.drawClipped()
+}
+
+// drawClipped draws the current path's coverage mask, clipped to z.clip.
+func (z *Rasterizer) drawClipped() {
+	c := z.r.Intersect(z.clip)
+	if c.Empty() {
+		return
+	}
+	size := z.r.Size()
+	if z.mask == nil || z.mask.Rect.Size() != size {
+		z.mask = image.NewAlpha(image.Rectangle{Max: size})
+	}
+	op := z.z.DrawOp
+	z.z.DrawOp = draw.Src
+	z.z.Draw(z.mask, z.mask.Rect, image.Opaque, image.Point{})
+	z.z.DrawOp = op
+
+	p := c.Min.Sub(z.r.Min)
+	draw.DrawMask(z.dst, c, z.fill, p, z.mask, p, op)
 }
 
 func (z *Rasterizer) ClosePathAbsMoveTo(x, y float32) {
@@ -268,8 +360,8 @@ func (z *Rasterizer) ClosePathAbsMoveTo(x, y float32) {
 		return
 	}
 	z.prevSmoothType = smoothTypeNone
-	z.z.ClosePath()
-	z.z.MoveTo(z.absVec2(x, y))
+	z.closePath()
+	z.moveTo(z.absVec2(x, y))
 }
 
 func (z *Rasterizer) ClosePathRelMoveTo(x, y float32) {
@@ -277,44 +369,44 @@ func (z *Rasterizer) ClosePathRelMoveTo(x, y float32) {
 		return
 	}
 	z.prevSmoothType = smoothTypeNone
-	z.z.ClosePath()
-	z.z.MoveTo(z.relVec2(x, y))
+	z.closePath()
+	z.moveTo(z.relVec2(x, y))
 }
 
 func (z *Rasterizer) AbsHLineTo(x float32) {
 	if z.disabled {
 		return
 	}
-	_, py := z.z.Pen()
+	_, py := z.pen()
 	z.prevSmoothType = smoothTypeNone
-	z.z.LineTo(z.absX(x), py)
+	z.lineTo(z.absX(x), py)
 }
 
 func (z *Rasterizer) RelHLineTo(x float32) {
 	if z.disabled {
 		return
 	}
-	px, py := z.z.Pen()
+	px, py := z.pen()
 	z.prevSmoothType = smoothTypeNone
-	z.z.LineTo(px+z.relX(x), py)
+	z.lineTo(px+z.relX(x), py)
 }
 
 func (z *Rasterizer) AbsVLineTo(y float32) {
 	if z.disabled {
 		return
 	}
-	px, _ := z.z.Pen()
+	px, _ := z.pen()
 	z.prevSmoothType = smoothTypeNone
-	z.z.LineTo(px, z.absY(y))
+	z.lineTo(px, z.absY(y))
 }
 
 func (z *Rasterizer) RelVLineTo(y float32) {
 	if z.disabled {
 		return
 	}
-	px, py := z.z.Pen()
+	px, py := z.pen()
 	z.prevSmoothType = smoothTypeNone
-	z.z.LineTo(px, py+z.relY(y))
+	z.lineTo(px, py+z.relY(y))
 }
 
 func (z *Rasterizer) AbsLineTo(x, y float32) {
@@ -322,7 +414,7 @@ func (z *Rasterizer) AbsLineTo(x, y float32) {
 		return
 	}
 	z.prevSmoothType = smoothTypeNone
-	z.z.LineTo(z.absVec2(x, y))
+	z.lineTo(z.absVec2(x, y))
 }
 
 func (z *Rasterizer) RelLineTo(x, y float32) {
@@ -330,7 +422,7 @@ func (z *Rasterizer) RelLineTo(x, y float32) {
 		return
 	}
 	z.prevSmoothType = smoothTypeNone
-	z.z.LineTo(z.relVec2(x, y))
+	z.lineTo(z.relVec2(x, y))
 }
 
 func (z *Rasterizer) AbsSmoothQuadTo(x, y float32) {
@@ -341,7 +433,7 @@ func (z *Rasterizer) AbsSmoothQuadTo(x, y float32) {
 	x, y = z.absVec2(x, y)
 	z.prevSmoothType = smoothTypeQuad
 	z.prevSmoothPointX, z.prevSmoothPointY = x1, y1
-	z.z.QuadTo(x1, y1, x, y)
+	z.quadTo(x1, y1, x, y)
 }
 
 func (z *Rasterizer) RelSmoothQuadTo(x, y float32) {
@@ -352,7 +444,7 @@ func (z *Rasterizer) RelSmoothQuadTo(x, y float32) {
 	x, y = z.relVec2(x, y)
 	z.prevSmoothType = smoothTypeQuad
 	z.prevSmoothPointX, z.prevSmoothPointY = x1, y1
-	z.z.QuadTo(x1, y1, x, y)
+	z.quadTo(x1, y1, x, y)
 }
 
 func (z *Rasterizer) AbsQuadTo(x1, y1, x, y float32) {
@@ -363,7 +455,7 @@ func (z *Rasterizer) AbsQuadTo(x1, y1, x, y float32) {
 	x, y = z.absVec2(x, y)
 	z.prevSmoothType = smoothTypeQuad
 	z.prevSmoothPointX, z.prevSmoothPointY = x1, y1
-	z.z.QuadTo(x1, y1, x, y)
+	z.quadTo(x1, y1, x, y)
 }
 
 func (z *Rasterizer) RelQuadTo(x1, y1, x, y float32) {
@@ -374,7 +466,7 @@ func (z *Rasterizer) RelQuadTo(x1, y1, x, y float32) {
 	x, y = z.relVec2(x, y)
 	z.prevSmoothType = smoothTypeQuad
 	z.prevSmoothPointX, z.prevSmoothPointY = x1, y1
-	z.z.QuadTo(x1, y1, x, y)
+	z.quadTo(x1, y1, x, y)
 }
 
 func (z *Rasterizer) AbsSmoothCubeTo(x2, y2, x, y float32) {
@@ -386,7 +478,7 @@ func (z *Rasterizer) AbsSmoothCubeTo(x2, y2, x, y float32) {
 	x, y = z.absVec2(x, y)
 	z.prevSmoothType = smoothTypeCube
 	z.prevSmoothPointX, z.prevSmoothPointY = x2, y2
-	z.z.CubeTo(x1, y1, x2, y2, x, y)
+	z.cubeTo(x1, y1, x2, y2, x, y)
 }
 
 func (z *Rasterizer) RelSmoothCubeTo(x2, y2, x, y float32) {
@@ -398,7 +490,7 @@ func (z *Rasterizer) RelSmoothCubeTo(x2, y2, x, y float32) {
 	x, y = z.relVec2(x, y)
 	z.prevSmoothType = smoothTypeCube
 	z.prevSmoothPointX, z.prevSmoothPointY = x2, y2
-	z.z.CubeTo(x1, y1, x2, y2, x, y)
+	z.cubeTo(x1, y1, x2, y2, x, y)
 }
 
 func (z *Rasterizer) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
@@ -410,7 +502,7 @@ func (z *Rasterizer) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
 	x, y = z.absVec2(x, y)
 	z.prevSmoothType = smoothTypeCube
 	z.prevSmoothPointX, z.prevSmoothPointY = x2, y2
-	z.z.CubeTo(x1, y1, x2, y2, x, y)
+	z.cubeTo(x1, y1, x2, y2, x, y)
 }
 
 func (z *Rasterizer) RelCubeTo(x1, y1, x2, y2, x, y float32) {
@@ -422,7 +514,7 @@ func (z *Rasterizer) RelCubeTo(x1, y1, x2, y2, x, y float32) {
 	x, y = z.relVec2(x, y)
 	z.prevSmoothType = smoothTypeCube
 	z.prevSmoothPointX, z.prevSmoothPointY = x2, y2
-	z.z.CubeTo(x1, y1, x2, y2, x, y)
+	z.cubeTo(x1, y1, x2, y2, x, y)
 }
 
 func (z *Rasterizer) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
@@ -431,28 +523,6 @@ func (z *Rasterizer) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep boo
 	}
 	z.prevSmoothType = smoothTypeNone
 
-	// We follow the "Conversion from endpoint to center parameterization"
-	// algorithm as per
-	// https://www.w3.org/TR/SVG/implnote.html#ArcConversionEndpointToCenter
-
-	// There seems to be a bug in the spec's "implementation notes".
-	//
-	// Actual implementations, such as
-	//	- https://git.gnome.org/browse/librsvg/tree/rsvg-path.c
-	//	- http://svn.apache.org/repos/asf/xmlgraphics/batik/branches/svg11/sources/org/apache/batik/ext/awt/geom/ExtendedGeneralPath.java
-	//	- https://java.net/projects/svgsalamander/sources/svn/content/trunk/svg-core/src/main/java/com/kitfox/svg/pathcmd/Arc.java
-	//	- https://github.com/millermedeiros/SVGParser/blob/master/com/millermedeiros/geom/SVGArc.as
-	// do something slightly different (marked with a †).
-
-	// (†) The Abs isn't part of the spec. Neither is checking that Rx and Ry
-	// are non-zero (and non-NaN).
-	Rx := math.Abs(float64(rx))
-	Ry := math.Abs(float64(ry))
-	if !(Rx > 0 && Ry > 0) {
-		z.z.LineTo(x, y)
-		return
-	}
-
 	// We work in IconVG coordinates (e.g. from -32 to +32 by default), rather
 	// than destination image coordinates (e.g. the width of the dst image),
 	// since the rx and ry radii also need to be scaled, but their scaling
@@ -460,110 +530,15 @@ func (z *Rasterizer) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep boo
 	// xAxisRotation.
 	//
 	// We convert back to destination image coordinates via absX and absY calls
-	// later, during arcSegmentTo.
-	penX, penY := z.z.Pen()
-	x1 := float64(z.unabsX(penX))
-	y1 := float64(z.unabsY(penY))
-	x2 := float64(x)
-	y2 := float64(y)
-
-	phi := 2 * math.Pi * float64(xAxisRotation)
-
-	// Step 1: Compute (x1′, y1′)
-	halfDx := (x1 - x2) / 2
-	halfDy := (y1 - y2) / 2
-	cosPhi := math.Cos(phi)
-	sinPhi := math.Sin(phi)
-	x1Prime := +cosPhi*halfDx + sinPhi*halfDy
-	y1Prime := -sinPhi*halfDx + cosPhi*halfDy
-
-	// Step 2: Compute (cx′, cy′)
-	rxSq := Rx * Rx
-	rySq := Ry * Ry
-	x1PrimeSq := x1Prime * x1Prime
-	y1PrimeSq := y1Prime * y1Prime
-
-	// (†) Check that the radii are large enough.
-	radiiCheck := x1PrimeSq/rxSq + y1PrimeSq/rySq
-	if radiiCheck > 1 {
-		c := math.Sqrt(radiiCheck)
-		Rx *= c
-		Ry *= c
-		rxSq = Rx * Rx
-		rySq = Ry * Ry
-	}
-
-	denom := rxSq*y1PrimeSq + rySq*x1PrimeSq
-	step2 := 0.0
-	if a := rxSq*rySq/denom - 1; a > 0 {
-		step2 = math.Sqrt(a)
-	}
-	if largeArc == sweep {
-		step2 = -step2
-	}
-	cxPrime := +step2 * Rx * y1Prime / Ry
-	cyPrime := -step2 * Ry * x1Prime / Rx
-
-	// Step 3: Compute (cx, cy) from (cx′, cy′)
-	cx := +cosPhi*cxPrime - sinPhi*cyPrime + (x1+x2)/2
-	cy := +sinPhi*cxPrime + cosPhi*cyPrime + (y1+y2)/2
-
-	// Step 4: Compute θ1 and Δθ
-	ax := (+x1Prime - cxPrime) / Rx
-	ay := (+y1Prime - cyPrime) / Ry
-	bx := (-x1Prime - cxPrime) / Rx
-	by := (-y1Prime - cyPrime) / Ry
-	theta1 := angle(1, 0, ax, ay)
-	deltaTheta := angle(ax, ay, bx, by)
-	if sweep {
-		if deltaTheta < 0 {
-			deltaTheta += 2 * math.Pi
-		}
-	} else {
-		if deltaTheta > 0 {
-			deltaTheta -= 2 * math.Pi
-		}
+	// later, during arcCubeTo.
+	penX, penY := z.pen()
+	if !arcToCubics(z.unabsX(penX), z.unabsY(penY), rx, ry, xAxisRotation, largeArc, sweep, x, y, z.arcCubeTo) {
+		z.lineTo(z.absVec2(x, y))
 	}
+}
 
-	// This ends the
-	// https://www.w3.org/TR/SVG/implnote.html#ArcConversionEndpointToCenter
-	// algorithm. What follows below is specific to this implementation.
-
-	// We approximate an arc by one or more cubic Bézier curves.
-	n := int(math.Ceil(math.Abs(deltaTheta) / (math.Pi/2 + 0.001)))
-	for i := 0; i < n; i++ {
-		z.arcSegmentTo(cx, cy,
-			theta1+deltaTheta*float64(i+0)/float64(n),
-			theta1+deltaTheta*float64(i+1)/float64(n),
-			Rx, Ry, cosPhi, sinPhi,
-		)
-	}
-}
-
-// arcSegmentTo approximates an arc by a cubic Bézier curve. The mathematical
-// formulae for the control points are the same as that used by librsvg.
-func (z *Rasterizer) arcSegmentTo(cx, cy, theta1, theta2, rx, ry, cosPhi, sinPhi float64) {
-	halfDeltaTheta := (theta2 - theta1) * 0.5
-	q := math.Sin(halfDeltaTheta * 0.5)
-	t := (8 * q * q) / (3 * math.Sin(halfDeltaTheta))
-	cos1 := math.Cos(theta1)
-	sin1 := math.Sin(theta1)
-	cos2 := math.Cos(theta2)
-	sin2 := math.Sin(theta2)
-	x1 := rx * (+cos1 - t*sin1)
-	y1 := ry * (+sin1 + t*cos1)
-	x2 := rx * (+cos2 + t*sin2)
-	y2 := ry * (+sin2 - t*cos2)
-	x3 := rx * (+cos2)
-	y3 := ry * (+sin2)
-	z.z.CubeTo(
-		z.absX(float32(cx+cosPhi*x1-sinPhi*y1)),
-		z.absY(float32(cy+sinPhi*x1+cosPhi*y1)),
-		z.absX(float32(cx+cosPhi*x2-sinPhi*y2)),
-		z.absY(float32(cy+sinPhi*x2+cosPhi*y2)),
-		z.absX(float32(cx+cosPhi*x3-sinPhi*y3)),
-		z.absY(float32(cy+sinPhi*x3+cosPhi*y3)),
-	)
+func (z *Rasterizer) arcCubeTo(x1, y1, x2, y2, x, y float32) {
+	z.cubeTo(z.absX(x1), z.absY(y1), z.absX(x2), z.absY(y2), z.absX(x), z.absY(y))
 }
 
 func (z *Rasterizer) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
@@ -571,22 +546,21 @@ func (z *Rasterizer) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep boo
 	z.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, z.unabsX(ax), z.unabsY(ay))
 }
 
-// angle returns the angle between the u and v vectors.
-func angle(ux, uy, vx, vy float64) float64 {
-	uNorm := math.Sqrt(ux*ux + uy*uy)
-	vNorm := math.Sqrt(vx*vx + vy*vy)
-	norm := uNorm * vNorm
-	cos := (ux*vx + uy*vy) / norm
-	ret := 0.0
-	if cos <= -1 {
-		ret = math.Pi
-	} else if cos >= +1 {
-		ret = 0
-	} else {
-		ret = math.Acos(cos)
-	}
-	if ux*vy < uy*vx {
-		return -ret
-	}
-	return +ret
+// rasterize decodes the IconVG graphic src onto a new width × height RGBA
+// image.
+func rasterize(src []byte, width, height int, opts *DecodeOptions) (*image.RGBA, error) {
+	return rasterizeOnto(src, width, height, nil, opts)
+}
+
+// rasterizeOnto is like rasterize, but composites the graphic over the
+// background color, if it is non-nil.
+func rasterizeOnto(src []byte, width, height int, background color.Color, opts *DecodeOptions) (*image.RGBA, error) {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	var z Rasterizer
+	z.SetDstImage(dst, dst.Bounds(), draw.Src)
+	z.SetBackground(background)
+	if err := Decode(&z, src, opts); err != nil {
+		return nil, err
+	}
+	return dst, nil
 }
@@ -0,0 +1,160 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+// RawOp is one styling or drawing operation of a RawRecording: an opcode and
+// its operands.
+type RawOp struct {
+	// Opcode is the op's opcode. An implicitly repeated drawing op has the
+	// same Opcode as the op that it repeats.
+	Opcode byte
+
+	// Drawing is whether Opcode is a drawing opcode, as opposed to a styling
+	// opcode. The same byte value means different things in the two modes.
+	Drawing bool
+
+	// Implicit is whether the op is an implicitly repeated drawing op, whose
+	// encoded form has operands but no opcode byte.
+	Implicit bool
+
+	// Start and End are the [start, end) byte offsets, in the source, of the
+	// op's encoded form.
+	Start, End int
+
+	// Path is the index of the path that the op is part of, or -1 if it is
+	// not part of a path. A path's ops run from its start path styling op to
+	// its end path drawing op, inclusive.
+	Path int
+}
+
+// RawRecording is the exact sequence of ops in an IconVG graphic's encoded
+// form, recorded by RecordRaw.
+//
+// Edits can be made by splicing new bytes into the source, leaving the
+// encoded form of the rest of the graphic, and so its numbers' precision,
+// untouched.
+type RawRecording struct {
+	// HeaderLen is the length, in bytes, of the magic identifier and
+	// metadata, which precede the first op.
+	HeaderLen int
+
+	// Ops are the ops, in order. Together with the header, they cover the
+	// source exactly.
+	Ops []RawOp
+
+	src      []byte
+	numPaths int
+	opts     DecodeOptions
+}
+
+// RecordRaw decodes an IconVG graphic, recording the byte span of its header
+// and of each of its ops. If opts has a Trace callback, it is also called.
+//
+// The src bytes are retained by the RawRecording, and should not be modified
+// while it is in use.
+func RecordRaw(src []byte, opts *DecodeOptions) (*RawRecording, error) {
+	r := &RawRecording{src: src}
+	if opts != nil {
+		r.opts = *opts
+	}
+	o := r.opts
+	trace := o.Trace
+	r.opts.Trace = nil
+	r.opts.Profile = nil
+
+	drawing, path := false, -1
+	o.Trace = func(ev TraceEvent) {
+		if trace != nil {
+			trace(ev)
+		}
+		end := ev.Offset + ev.Length
+		switch ev.Kind {
+		case TraceOpcode, TracePathStart, TracePathEnd:
+			op := RawOp{
+				Drawing: drawing,
+				Start:   ev.Offset,
+				End:     end,
+				Path:    -1,
+			}
+			if ev.Length == 0 {
+				op.Implicit = true
+				op.Opcode = r.Ops[len(r.Ops)-1].Opcode
+			} else {
+				op.Opcode = src[ev.Offset]
+			}
+			if ev.Kind == TracePathStart {
+				path = r.numPaths
+				r.numPaths++
+				drawing = true
+			}
+			if drawing {
+				op.Path = path
+			}
+			if ev.Kind == TracePathEnd {
+				drawing = false
+			}
+			r.Ops = append(r.Ops, op)
+		default:
+			if n := len(r.Ops); n > 0 {
+				r.Ops[n-1].End = end
+			} else {
+				r.HeaderLen = end
+			}
+		}
+	}
+	if err := Decode(nil, src, &o); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Source returns the recorded IconVG graphic's encoded form.
+func (r *RawRecording) Source() []byte {
+	return r.src
+}
+
+// NumPaths returns the number of paths in the graphic.
+func (r *RawRecording) NumPaths() int {
+	return r.numPaths
+}
+
+// PathSpan returns the [start, end) byte offsets, in the source, of the i'th
+// path's encoded form, from its start path opcode to its end path opcode
+// inclusive.
+func (r *RawRecording) PathSpan(i int) (start, end int) {
+	start, end = -1, -1
+	for _, op := range r.Ops {
+		if op.Path != i {
+			continue
+		}
+		if start < 0 {
+			start = op.Start
+		}
+		end = op.End
+	}
+	return start, end
+}
+
+// SplicePath returns a copy of the source with the i'th path's encoded form
+// replaced by the given bytes, which should run from a start path opcode to
+// an end path opcode, inclusive. If they are empty, the path is removed.
+//
+// The bytes before and after the path are copied as is. The result is
+// decoded, with the DecodeOptions passed to RecordRaw, to check that it is
+// valid.
+func (r *RawRecording) SplicePath(i int, encoded []byte) ([]byte, error) {
+	if i < 0 || r.numPaths <= i {
+		return nil, errPathIndexOutOfRange
+	}
+	start, end := r.PathSpan(i)
+	ret := make([]byte, 0, len(r.src)-(end-start)+len(encoded))
+	ret = append(ret, r.src[:start]...)
+	ret = append(ret, encoded...)
+	ret = append(ret, r.src[end:]...)
+	if err := Decode(nil, ret, &r.opts); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+)
+
+func encodeRawTest(t *testing.T, firstSize float32, first bool) []byte {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x80, 0xff}))
+	if first {
+		e.StartPath(0, -firstSize, -firstSize)
+		e.AbsLineTo(+firstSize, -firstSize)
+		e.AbsLineTo(+firstSize, +firstSize)
+		e.ClosePathEndPath()
+	}
+	e.StartPath(0, 1.25, 2.5)
+	e.RelHLineTo(3.75)
+	e.RelVLineTo(3.75)
+	e.ClosePathEndPath()
+	b, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	return b
+}
+
+func TestRecordRaw(t *testing.T) {
+	src := encodeRawTest(t, 10, true)
+	r, err := RecordRaw(src, nil)
+	if err != nil {
+		t.Fatalf("RecordRaw: %v", err)
+	}
+	if got, want := r.NumPaths(), 2; got != want {
+		t.Fatalf("NumPaths: got %d, want %d", got, want)
+	}
+
+	// The header and ops should cover the source exactly.
+	offset := r.HeaderLen
+	for i, op := range r.Ops {
+		if op.Start != offset {
+			t.Fatalf("op #%d: Start: got %d, want %d", i, op.Start, offset)
+		}
+		offset = op.End
+	}
+	if offset != len(src) {
+		t.Fatalf("end of ops: got %d, want %d", offset, len(src))
+	}
+
+	// The first path's two AbsLineTo calls are encoded as one opcode with 2
+	// reps, so the second is implicit.
+	implicit := 0
+	for _, op := range r.Ops {
+		if op.Implicit {
+			implicit++
+			if op.Path != 0 || !op.Drawing || op.Opcode != 0x01 {
+				t.Errorf("implicit op: got %+v", op)
+			}
+		}
+	}
+	if implicit != 1 {
+		t.Errorf("implicit ops: got %d, want 1", implicit)
+	}
+	if op := r.Ops[0]; op.Path != -1 || op.Drawing {
+		t.Errorf("Ops[0]: got %+v, want a styling op outside of any path", op)
+	}
+	if op := r.Ops[len(r.Ops)-1]; op.Path != 1 || !op.Drawing || op.Opcode != 0xe1 {
+		t.Errorf("last op: got %+v, want the end of path 1", op)
+	}
+
+	// Replacing the first path with that of another graphic should give that
+	// other graphic.
+	other := encodeRawTest(t, 20, true)
+	otherR, err := RecordRaw(other, nil)
+	if err != nil {
+		t.Fatalf("RecordRaw(other): %v", err)
+	}
+	start, end := otherR.PathSpan(0)
+	got, err := r.SplicePath(0, other[start:end])
+	if err != nil {
+		t.Fatalf("SplicePath: %v", err)
+	}
+	if !bytes.Equal(got, other) {
+		t.Errorf("SplicePath:\ngot  % x\nwant % x", got, other)
+	}
+
+	// Removing the first path.
+	got, err = r.SplicePath(0, nil)
+	if err != nil {
+		t.Fatalf("SplicePath(nil): %v", err)
+	}
+	if want := encodeRawTest(t, 0, false); !bytes.Equal(got, want) {
+		t.Errorf("SplicePath(nil):\ngot  % x\nwant % x", got, want)
+	}
+
+	// An incomplete path is invalid.
+	if _, err := r.SplicePath(1, other[start:end-1]); err == nil {
+		t.Errorf("SplicePath(incomplete): got nil error, want non-nil")
+	}
+	if _, err := r.SplicePath(2, nil); err != errPathIndexOutOfRange {
+		t.Errorf("SplicePath(2): got %v, want %v", err, errPathIndexOutOfRange)
+	}
+}
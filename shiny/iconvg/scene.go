@@ -0,0 +1,180 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+
+	"golang.org/x/image/math/f32"
+)
+
+// maxSceneGradientStops is the maximum number of stops of a gradient that
+// fits in the 64 NREG registers, along with its 6 transform values.
+const maxSceneGradientStops = 64 - 6
+
+// SceneNode is a decoded IconVG graphic, retained so that it can be drawn
+// many times, at any scale, without being parsed again. It is built by
+// BuildScene.
+//
+// Its fields can be modified before drawing, such as to change a path's fill
+// color for theming.
+type SceneNode struct {
+	// Metadata is the graphic's metadata. The Destination is Reset with it.
+	Metadata Metadata
+
+	// Paths are the graphic's paths, in drawing order. IconVG has no groups,
+	// so the paths are the scene's leaves, directly below its root.
+	Paths []ScenePath
+}
+
+// ScenePath is one path of a SceneNode, with its styling resolved.
+type ScenePath struct {
+	// Fill is the path's alpha-premultiplied fill color. It is ignored if
+	// Gradient is non-nil.
+	Fill color.RGBA
+
+	// Gradient is the path's gradient fill, or nil if it is filled with a
+	// flat color.
+	Gradient *SceneGradient
+
+	// LOD0 and LOD1 are the path's level of detail range.
+	LOD0, LOD1 float32
+
+	// Segments are the path's segments, in graphic coordinate space. Arcs
+	// are converted to cubic Bézier curves.
+	Segments []Segment
+}
+
+// SceneGradient is a gradient fill of a ScenePath. Its fields have the same
+// meaning as the Encoder's SetGradient method's arguments.
+type SceneGradient struct {
+	Radial    bool
+	Transform f32.Aff3
+	Spread    GradientSpread
+	Stops     []GradientStop
+}
+
+// BuildScene decodes an IconVG graphic into a SceneNode.
+func BuildScene(src []byte, opts *DecodeOptions) (*SceneNode, error) {
+	var b sceneBuilder
+	if err := Decode(&b, src, opts); err != nil {
+		return nil, err
+	}
+	return &b.scene, nil
+}
+
+// Draw draws the scene to dst, as a Decode of the graphic would. It Resets
+// dst with the scene's Metadata, and then passes each path with absolute
+// drawing ops, preceded by the styling ops that set its fill and level of
+// detail. A gradient with more than 58 stops is not drawn.
+func (n *SceneNode) Draw(dst Destination) {
+	dst.Reset(n.Metadata)
+	lod0, lod1 := float32(0), positiveInfinity
+	for i := range n.Paths {
+		p := &n.Paths[i]
+		if len(p.Segments) == 0 {
+			continue
+		}
+		if g := p.Gradient; g != nil {
+			if len(g.Stops) > maxSceneGradientStops {
+				continue
+			}
+			drawSceneGradient(dst, g)
+		} else {
+			dst.SetCSel(0)
+			dst.SetCReg(0, false, RGBAColor(p.Fill))
+		}
+		if p.LOD0 != lod0 || p.LOD1 != lod1 {
+			lod0, lod1 = p.LOD0, p.LOD1
+			dst.SetLOD(lod0, lod1)
+		}
+		emitPath(dst, 0, p.Segments)
+	}
+}
+
+// drawSceneGradient sets the registers for the gradient, with its transform
+// in NREG[0:6], its stops' offsets in NREG[6:] and its stops' colors in
+// CREG[0:], and then sets CREG[CSEL] to the gradient, just past the stops.
+func drawSceneGradient(dst Destination, g *SceneGradient) {
+	const cBase, nBase = 0, 6
+	dst.SetNSel(0)
+	for _, v := range g.Transform {
+		dst.SetNReg(0, true, v)
+	}
+	dst.SetCSel(cBase)
+	for _, s := range g.Stops {
+		r, gg, b, a := s.Color.RGBA()
+		dst.SetCReg(0, true, RGBAColor(color.RGBA{
+			R: uint8(r >> 8),
+			G: uint8(gg >> 8),
+			B: uint8(b >> 8),
+			A: uint8(a >> 8),
+		}))
+		dst.SetNReg(0, true, s.Offset)
+	}
+	bFlags := uint8(0x80)
+	if g.Radial {
+		bFlags = 0xc0
+	}
+	dst.SetCReg(0, false, RGBAColor(color.RGBA{
+		R: uint8(len(g.Stops)),
+		G: cBase | uint8(g.Spread<<6),
+		B: nBase | bFlags,
+		A: 0x00,
+	}))
+}
+
+// sceneBuilder is a Destination that builds a SceneNode.
+type sceneBuilder struct {
+	segmenter
+	scene SceneNode
+}
+
+func (b *sceneBuilder) Reset(m Metadata) {
+	b.segmenter.reset(m, b)
+	b.scene = SceneNode{Metadata: m}
+}
+
+func (b *sceneBuilder) beginPath() {
+	p := ScenePath{
+		Fill: b.fill,
+		LOD0: b.lod0,
+		LOD1: b.lod1,
+	}
+	if b.fill.A == 0 && b.fill.B&0x80 != 0 {
+		p.Fill = color.RGBA{}
+		p.Gradient = b.gradient(b.fill)
+	}
+	b.scene.Paths = append(b.scene.Paths, p)
+}
+
+func (b *sceneBuilder) addSegment(s Segment) {
+	p := &b.scene.Paths[len(b.scene.Paths)-1]
+	p.Segments = append(p.Segments, s)
+}
+
+func (b *sceneBuilder) endPath() {}
+
+// gradient returns the gradient that the CREG value c refers to.
+func (s *segmenter) gradient(c color.RGBA) *SceneGradient {
+	nStops := int(c.R & 0x3f)
+	cBase := int(c.G & 0x3f)
+	nBase := int(c.B & 0x3f)
+	g := &SceneGradient{
+		Radial: c.B&0x40 != 0,
+		Spread: GradientSpread(c.G >> 6),
+		Stops:  make([]GradientStop, nStops),
+	}
+	for i := range g.Transform {
+		g.Transform[i] = s.nReg[(nBase-6+i)&0x3f]
+	}
+	for i := range g.Stops {
+		g.Stops[i] = GradientStop{
+			Offset: s.nReg[(nBase+i)&0x3f],
+			Color:  s.cReg[(cBase+i)&0x3f],
+		}
+	}
+	return g
+}
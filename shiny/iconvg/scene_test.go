@@ -0,0 +1,77 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildScene(t *testing.T) {
+	for _, tc := range testdataTestCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		scene, err := BuildScene(ivgData, nil)
+		if err != nil {
+			t.Errorf("%s: BuildScene: %v", tc.filename, err)
+			continue
+		}
+		// Draw the scene twice, to check that drawing does not change it.
+		// The scene's segments are in graphic coordinate space, whereas the
+		// Rasterizer works in pixel space, so rounding can differ slightly.
+		for i := 0; i < 2; i++ {
+			got := image.NewRGBA(image.Rect(0, 0, 64, 64))
+			var z Rasterizer
+			z.SetDstImage(got, got.Bounds(), draw.Src)
+			scene.Draw(&z)
+			if diff, err := CompareRender(ivgData, got, 0.002, nil); err != nil {
+				t.Errorf("%s: draw #%d: diff %v: %v", tc.filename, i, diff, err)
+			}
+		}
+	}
+}
+
+func TestSceneTheming(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0xff, 0xff}))
+	e.StartPath(0, -32, -32)
+	e.AbsHLineTo(+32)
+	e.AbsVLineTo(+32)
+	e.AbsHLineTo(-32)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	scene, err := BuildScene(ivgData, nil)
+	if err != nil {
+		t.Fatalf("BuildScene: %v", err)
+	}
+	if len(scene.Paths) != 1 {
+		t.Fatalf("paths: got %d, want 1", len(scene.Paths))
+	}
+	red := color.RGBA{0xff, 0x00, 0x00, 0xff}
+	scene.Paths[0].Fill = red
+
+	dst := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	var z Rasterizer
+	z.SetDstImage(dst, dst.Bounds(), draw.Src)
+	scene.Draw(&z)
+	if got := dst.RGBAAt(4, 4); got != red {
+		t.Errorf("got %v, want %v", got, red)
+	}
+}
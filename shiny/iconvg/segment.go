@@ -0,0 +1,387 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"math"
+
+	"golang.org/x/image/math/f32"
+)
+
+// SegmentOp is a vector path segment's operator.
+type SegmentOp uint32
+
+const (
+	SegmentOpMoveTo SegmentOp = iota
+	SegmentOpLineTo
+	SegmentOpQuadTo
+	SegmentOpCubeTo
+)
+
+// Segment is a segment of a vector path.
+//
+// Unlike the Destination methods, a Segment's coordinates are always absolute,
+// in graphic coordinate space (defined by the metadata's ViewBox). Relative,
+// horizontal, vertical, smooth and arc drawing ops are converted to the four
+// SegmentOps. Every sub-path starts with a MoveTo and, as IconVG paths are
+// filled, is implicitly closed by a straight line back to that MoveTo's point.
+type Segment struct {
+	// Op is the operator.
+	Op SegmentOp
+	// Args is up to three (x, y) coordinates. For QuadTo and CubeTo, the
+	// control points come before the end point.
+	Args [3]f32.Vec2
+}
+
+// end returns the point that the segment ends at.
+func (s *Segment) end() f32.Vec2 {
+	switch s.Op {
+	case SegmentOpQuadTo:
+		return s.Args[1]
+	case SegmentOpCubeTo:
+		return s.Args[2]
+	}
+	return s.Args[0]
+}
+
+// segmentSink receives the paths converted by a segmenter.
+type segmentSink interface {
+	// beginPath is called when a path starts, before its first segment (which
+	// is always a MoveTo). The segmenter's fill field holds the path's fill.
+	beginPath()
+	// addSegment is called for each segment of the path.
+	addSegment(s Segment)
+	// endPath is called when a path ends.
+	endPath()
+}
+
+// segmenter implements the Destination methods other than Reset, tracking
+// the virtual machine's registers and converting the drawing ops to absolute
+// Segments, which are passed to a segmentSink.
+//
+// It is designed to be embedded in Destination implementations whose Reset
+// method calls the segmenter's reset method with that implementation as the
+// sink.
+type segmenter struct {
+	sink segmentSink
+
+	metadata Metadata
+
+	lod0 float32
+	lod1 float32
+	cSel uint8
+	nSel uint8
+
+	// fill is the fill color, CREG[CSEL-ADJ], of the current path. It is
+	// either a flat color or a gradient.
+	fill color.RGBA
+
+	start f32.Vec2
+	pen   f32.Vec2
+
+	prevSmoothType  uint8
+	prevSmoothPoint f32.Vec2
+
+	cReg [64]color.RGBA
+	nReg [64]float32
+}
+
+func (s *segmenter) reset(m Metadata, sink segmentSink) {
+	*s = segmenter{
+		sink:     sink,
+		metadata: m,
+		lod1:     positiveInfinity,
+		cReg:     m.Palette,
+	}
+}
+
+func (s *segmenter) SetCSel(cSel uint8) { s.cSel = cSel & 0x3f }
+func (s *segmenter) SetNSel(nSel uint8) { s.nSel = nSel & 0x3f }
+
+func (s *segmenter) SetCReg(adj uint8, incr bool, c Color) {
+	s.cReg[(s.cSel-adj)&0x3f] = c.Resolve(&s.metadata.Palette, &s.cReg)
+	if incr {
+		s.cSel++
+	}
+}
+
+func (s *segmenter) SetNReg(adj uint8, incr bool, f float32) {
+	s.nReg[(s.nSel-adj)&0x3f] = f
+	if incr {
+		s.nSel++
+	}
+}
+
+func (s *segmenter) SetLOD(lod0, lod1 float32) {
+	s.lod0, s.lod1 = lod0, lod1
+}
+
+func (s *segmenter) emit(op SegmentOp, args ...f32.Vec2) {
+	seg := Segment{Op: op}
+	copy(seg.Args[:], args)
+	s.pen = seg.end()
+	if s.sink != nil {
+		s.sink.addSegment(seg)
+	}
+}
+
+func (s *segmenter) moveTo(p f32.Vec2) {
+	s.start = p
+	s.prevSmoothType = smoothTypeNone
+	s.emit(SegmentOpMoveTo, p)
+}
+
+func (s *segmenter) rel(x, y float32) f32.Vec2 {
+	return f32.Vec2{s.pen[0] + x, s.pen[1] + y}
+}
+
+// implicitSmoothPoint returns the implicit control point for smooth-quadratic
+// and smooth-cubic Bézier curves. See the Rasterizer method of the same name.
+func (s *segmenter) implicitSmoothPoint(thisSmoothType uint8) f32.Vec2 {
+	if s.prevSmoothType != thisSmoothType {
+		return s.pen
+	}
+	return f32.Vec2{
+		2*s.pen[0] - s.prevSmoothPoint[0],
+		2*s.pen[1] - s.prevSmoothPoint[1],
+	}
+}
+
+func (s *segmenter) StartPath(adj uint8, x, y float32) {
+	s.fill = s.cReg[(s.cSel-adj)&0x3f]
+	if s.sink != nil {
+		s.sink.beginPath()
+	}
+	s.moveTo(f32.Vec2{x, y})
+}
+
+func (s *segmenter) ClosePathEndPath() {
+	s.pen = s.start
+	if s.sink != nil {
+		s.sink.endPath()
+	}
+}
+
+func (s *segmenter) ClosePathAbsMoveTo(x, y float32) {
+	s.pen = s.start
+	s.moveTo(f32.Vec2{x, y})
+}
+
+func (s *segmenter) ClosePathRelMoveTo(x, y float32) {
+	s.pen = s.start
+	s.moveTo(s.rel(x, y))
+}
+
+func (s *segmenter) lineTo(p f32.Vec2) {
+	s.prevSmoothType = smoothTypeNone
+	s.emit(SegmentOpLineTo, p)
+}
+
+func (s *segmenter) AbsHLineTo(x float32)   { s.lineTo(f32.Vec2{x, s.pen[1]}) }
+func (s *segmenter) RelHLineTo(x float32)   { s.lineTo(s.rel(x, 0)) }
+func (s *segmenter) AbsVLineTo(y float32)   { s.lineTo(f32.Vec2{s.pen[0], y}) }
+func (s *segmenter) RelVLineTo(y float32)   { s.lineTo(s.rel(0, y)) }
+func (s *segmenter) AbsLineTo(x, y float32) { s.lineTo(f32.Vec2{x, y}) }
+func (s *segmenter) RelLineTo(x, y float32) { s.lineTo(s.rel(x, y)) }
+
+func (s *segmenter) quadTo(p1, p f32.Vec2) {
+	s.prevSmoothType = smoothTypeQuad
+	s.prevSmoothPoint = p1
+	s.emit(SegmentOpQuadTo, p1, p)
+}
+
+func (s *segmenter) AbsSmoothQuadTo(x, y float32) {
+	s.quadTo(s.implicitSmoothPoint(smoothTypeQuad), f32.Vec2{x, y})
+}
+
+func (s *segmenter) RelSmoothQuadTo(x, y float32) {
+	s.quadTo(s.implicitSmoothPoint(smoothTypeQuad), s.rel(x, y))
+}
+
+func (s *segmenter) AbsQuadTo(x1, y1, x, y float32) {
+	s.quadTo(f32.Vec2{x1, y1}, f32.Vec2{x, y})
+}
+
+func (s *segmenter) RelQuadTo(x1, y1, x, y float32) {
+	s.quadTo(s.rel(x1, y1), s.rel(x, y))
+}
+
+func (s *segmenter) cubeTo(p1, p2, p f32.Vec2) {
+	s.prevSmoothType = smoothTypeCube
+	s.prevSmoothPoint = p2
+	s.emit(SegmentOpCubeTo, p1, p2, p)
+}
+
+func (s *segmenter) AbsSmoothCubeTo(x2, y2, x, y float32) {
+	s.cubeTo(s.implicitSmoothPoint(smoothTypeCube), f32.Vec2{x2, y2}, f32.Vec2{x, y})
+}
+
+func (s *segmenter) RelSmoothCubeTo(x2, y2, x, y float32) {
+	s.cubeTo(s.implicitSmoothPoint(smoothTypeCube), s.rel(x2, y2), s.rel(x, y))
+}
+
+func (s *segmenter) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
+	s.cubeTo(f32.Vec2{x1, y1}, f32.Vec2{x2, y2}, f32.Vec2{x, y})
+}
+
+func (s *segmenter) RelCubeTo(x1, y1, x2, y2, x, y float32) {
+	s.cubeTo(s.rel(x1, y1), s.rel(x2, y2), s.rel(x, y))
+}
+
+func (s *segmenter) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	if !arcToCubics(s.pen[0], s.pen[1], rx, ry, xAxisRotation, largeArc, sweep, x, y, s.arcCubeTo) {
+		s.lineTo(f32.Vec2{x, y})
+		return
+	}
+	s.prevSmoothType = smoothTypeNone
+}
+
+func (s *segmenter) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	p := s.rel(x, y)
+	s.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, p[0], p[1])
+}
+
+func (s *segmenter) arcCubeTo(x1, y1, x2, y2, x, y float32) {
+	s.emit(SegmentOpCubeTo, f32.Vec2{x1, y1}, f32.Vec2{x2, y2}, f32.Vec2{x, y})
+}
+
+// flattenTolerance is the default maximum distance, in graphic coordinate
+// space, between a curve and the line segments that approximate it.
+const flattenTolerance = 1.0 / 64
+
+// flatten appends to dst the points of a polyline approximating the segments,
+// which should form a single sub-path (a MoveTo followed by zero or more
+// other segments). The first point appended is the MoveTo's point.
+func flatten(dst []f32.Vec2, segs []Segment, tolerance float32) []f32.Vec2 {
+	var pen f32.Vec2
+	for i := range segs {
+		seg := &segs[i]
+		switch seg.Op {
+		case SegmentOpMoveTo, SegmentOpLineTo:
+			dst = append(dst, seg.Args[0])
+		case SegmentOpQuadTo:
+			c1, c2 := quadToCubic(pen, seg.Args[0], seg.Args[1])
+			n := flattenCount(tolerance, pen, c1, c2, seg.Args[1])
+			for j := 1; j <= n; j++ {
+				dst = append(dst, quadAt(pen, seg.Args[0], seg.Args[1], float32(j)/float32(n)))
+			}
+		case SegmentOpCubeTo:
+			n := flattenCount(tolerance, pen, seg.Args[0], seg.Args[1], seg.Args[2])
+			for j := 1; j <= n; j++ {
+				dst = append(dst, cubeAt(pen, seg.Args[0], seg.Args[1], seg.Args[2], float32(j)/float32(n)))
+			}
+		}
+		pen = seg.end()
+	}
+	return dst
+}
+
+// flattenCount returns the number of line segments needed to approximate the
+// cubic Bézier curve (p0, p1, p2, p3) to within the tolerance.
+func flattenCount(tolerance float32, p0, p1, p2, p3 f32.Vec2) int {
+	// The maximum distance between a curve and its chord is bounded by the
+	// control points' distance from it. Subdividing into n pieces divides
+	// that deviation by n*n.
+	dx := math.Max(
+		math.Abs(float64(p0[0]-2*p1[0]+p2[0])),
+		math.Abs(float64(p1[0]-2*p2[0]+p3[0])),
+	)
+	dy := math.Max(
+		math.Abs(float64(p0[1]-2*p1[1]+p2[1])),
+		math.Abs(float64(p1[1]-2*p2[1]+p3[1])),
+	)
+	d := math.Sqrt(dx*dx+dy*dy) * 3 / 4
+	if !(tolerance > 0) {
+		tolerance = flattenTolerance
+	}
+	n := int(math.Ceil(math.Sqrt(d / float64(tolerance))))
+	if n < 1 {
+		return 1
+	}
+	if n > 1024 {
+		return 1024
+	}
+	return n
+}
+
+// quadToCubic returns the control points of the cubic Bézier curve that is
+// equivalent to the quadratic Bézier curve (p0, p1, p2).
+func quadToCubic(p0, p1, p2 f32.Vec2) (c1, c2 f32.Vec2) {
+	return lerp(2.0/3, p0, p1), lerp(2.0/3, p2, p1)
+}
+
+func lerp(t float32, p, q f32.Vec2) f32.Vec2 {
+	return f32.Vec2{p[0] + t*(q[0]-p[0]), p[1] + t*(q[1]-p[1])}
+}
+
+func quadAt(p0, p1, p2 f32.Vec2, t float32) f32.Vec2 {
+	return lerp(t, lerp(t, p0, p1), lerp(t, p1, p2))
+}
+
+func cubeAt(p0, p1, p2, p3 f32.Vec2, t float32) f32.Vec2 {
+	p01, p12, p23 := lerp(t, p0, p1), lerp(t, p1, p2), lerp(t, p2, p3)
+	return lerp(t, lerp(t, p01, p12), lerp(t, p12, p23))
+}
+
+// splitSubpaths splits segs into sub-paths, each starting with a MoveTo. The
+// returned slices share segs' backing array.
+func splitSubpaths(segs []Segment) (subpaths [][]Segment) {
+	for i := 0; i < len(segs); {
+		j := i + 1
+		for ; j < len(segs) && segs[j].Op != SegmentOpMoveTo; j++ {
+		}
+		subpaths = append(subpaths, segs[i:j])
+		i = j
+	}
+	return subpaths
+}
+
+// signedArea returns the signed area of the implicitly closed polygon. It is
+// positive if the polygon is clockwise, in graphic coordinate space, where the
+// Y axis increases down.
+func signedArea(polygon []f32.Vec2) float32 {
+	area := float32(0)
+	for i := range polygon {
+		p, q := polygon[i], polygon[(i+1)%len(polygon)]
+		area += p[0]*q[1] - q[0]*p[1]
+	}
+	return area / 2
+}
+
+// polygonContains returns whether the implicitly closed polygon contains the
+// point p, under the even-odd fill rule.
+func polygonContains(polygon []f32.Vec2, p f32.Vec2) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a[1] > p[1]) != (b[1] > p[1]) &&
+			p[0] < (b[0]-a[0])*(p[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// reverseSubpath appends to dst the sub-path segs (a MoveTo followed by zero
+// or more other segments) traversed in the opposite direction.
+func reverseSubpath(dst []Segment, segs []Segment) []Segment {
+	if len(segs) == 0 {
+		return dst
+	}
+	dst = append(dst, Segment{Op: SegmentOpMoveTo, Args: [3]f32.Vec2{segs[len(segs)-1].end()}})
+	for i := len(segs) - 1; i > 0; i-- {
+		seg, prev := &segs[i], segs[i-1].end()
+		switch seg.Op {
+		case SegmentOpLineTo:
+			dst = append(dst, Segment{Op: SegmentOpLineTo, Args: [3]f32.Vec2{prev}})
+		case SegmentOpQuadTo:
+			dst = append(dst, Segment{Op: SegmentOpQuadTo, Args: [3]f32.Vec2{seg.Args[0], prev}})
+		case SegmentOpCubeTo:
+			dst = append(dst, Segment{Op: SegmentOpCubeTo, Args: [3]f32.Vec2{seg.Args[1], seg.Args[0], prev}})
+		}
+	}
+	return dst
+}
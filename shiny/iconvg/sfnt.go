@@ -0,0 +1,207 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"math"
+
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/f32"
+	"golang.org/x/image/math/fixed"
+)
+
+// SFNTGlyphBuilder is a Destination that converts an IconVG graphic to a font
+// glyph's outline, in the form of golang.org/x/image/font/sfnt Segments.
+//
+// A glyph has only one color, so all of the graphic's visible paths are
+// merged into one outline. Each contour's winding direction is normalized
+// according to the non-zero winding rule: outer contours, which the filled
+// region is inside, are given the direction required by the font format, and
+// holes are given the opposite direction. Contours that do not bound the
+// filled region, such as one nested inside, and winding the same way as,
+// another, are dropped.
+type SFNTGlyphBuilder struct {
+	// PPEM is the number of pixels in 1 em. The graphic's ViewBox is scaled,
+	// preserving its aspect ratio, so that its larger dimension spans 1 em,
+	// and translated so that its bottom left corner is at the origin: the
+	// glyph sits on the baseline. Paths are selected by their level of detail
+	// as if the graphic was rasterized PPEM pixels high.
+	//
+	// If zero, 1 em is 64 pixels.
+	PPEM fixed.Int26_6
+
+	// Cubic is whether to produce cubic Bézier curves, as used by CFF fonts,
+	// instead of quadratic Bézier curves, as used by TrueType fonts.
+	//
+	// It also selects the winding direction. With the Y axis increasing up,
+	// TrueType outer contours are clockwise and CFF outer contours are
+	// counter-clockwise.
+	Cubic bool
+
+	segmenter
+
+	visible  bool
+	segs     []Segment
+	contours [][]Segment
+}
+
+// Reset resets the SFNTGlyphBuilder for the given Metadata.
+func (b *SFNTGlyphBuilder) Reset(m Metadata) {
+	b.segmenter.reset(m, b)
+	b.visible = false
+	b.segs = b.segs[:0]
+	b.contours = b.contours[:0]
+}
+
+func (b *SFNTGlyphBuilder) ppem() float32 {
+	if b.PPEM <= 0 {
+		return 64
+	}
+	return float32(b.PPEM) / 64
+}
+
+func (b *SFNTGlyphBuilder) beginPath() {
+	h := b.ppem()
+	b.visible = (b.fill.A != 0 || b.fill.B&0x80 != 0) && b.lod0 <= h && h < b.lod1
+	b.segs = b.segs[:0]
+}
+
+func (b *SFNTGlyphBuilder) addSegment(s Segment) {
+	if b.visible {
+		b.segs = append(b.segs, s)
+	}
+}
+
+func (b *SFNTGlyphBuilder) endPath() {
+	for _, c := range splitSubpaths(b.segs) {
+		b.contours = append(b.contours, append([]Segment(nil), c...))
+	}
+	b.segs = b.segs[:0]
+}
+
+// Segments returns the glyph's outline. The Y axis increases down, as per the
+// sfnt package's convention.
+func (b *SFNTGlyphBuilder) Segments() sfnt.Segments {
+	if len(b.contours) == 0 {
+		return nil
+	}
+
+	vb := &b.metadata.ViewBox
+	dx, dy := vb.AspectRatio()
+	scale := b.ppem() / float32(math.Max(float64(dx), float64(dy)))
+	transform := func(p f32.Vec2) fixed.Point26_6 {
+		return fixed.Point26_6{
+			X: fixed.Int26_6(math.Floor(float64((p[0]-vb.Min[0])*scale*64) + 0.5)),
+			Y: fixed.Int26_6(math.Floor(float64((p[1]-vb.Max[1])*scale*64) + 0.5)),
+		}
+	}
+	// The output has 1/64th pixel precision, so there is no point converting
+	// curves more precisely than that.
+	tolerance := 1 / (64 * scale)
+
+	polygons := make([][]f32.Vec2, len(b.contours))
+	for i, c := range b.contours {
+		polygons[i] = flatten(nil, c, tolerance)
+	}
+
+	var (
+		ret      sfnt.Segments
+		reversed []Segment
+	)
+	roles := classifySubpaths(polygons)
+	for i, c := range b.contours {
+		// A contour that does not bound the filled region, such as one nested
+		// inside, and winding the same way as, another, is dropped.
+		if roles[i] == subpathNone {
+			continue
+		}
+		// With the Y axis increasing down, a positive area means clockwise on
+		// screen, which is counter-clockwise with the Y axis increasing up.
+		if wantPositive := (roles[i] == subpathOuter) == b.Cubic; (signedArea(polygons[i]) > 0) != wantPositive {
+			reversed = reverseSubpath(reversed[:0], c)
+			c = reversed
+		}
+
+		var pen f32.Vec2
+		for k := range c {
+			seg := &c[k]
+			switch seg.Op {
+			case SegmentOpMoveTo, SegmentOpLineTo:
+				ret = append(ret, sfnt.Segment{
+					Op:   sfnt.SegmentOp(seg.Op),
+					Args: [3]fixed.Point26_6{transform(seg.Args[0])},
+				})
+			case SegmentOpQuadTo:
+				if !b.Cubic {
+					ret = append(ret, sfnt.Segment{
+						Op:   sfnt.SegmentOpQuadTo,
+						Args: [3]fixed.Point26_6{transform(seg.Args[0]), transform(seg.Args[1])},
+					})
+					break
+				}
+				c1, c2 := quadToCubic(pen, seg.Args[0], seg.Args[1])
+				ret = append(ret, sfnt.Segment{
+					Op:   sfnt.SegmentOpCubeTo,
+					Args: [3]fixed.Point26_6{transform(c1), transform(c2), transform(seg.Args[1])},
+				})
+			case SegmentOpCubeTo:
+				if b.Cubic {
+					ret = append(ret, sfnt.Segment{
+						Op:   sfnt.SegmentOpCubeTo,
+						Args: [3]fixed.Point26_6{transform(seg.Args[0]), transform(seg.Args[1]), transform(seg.Args[2])},
+					})
+					break
+				}
+				cubicToQuads(pen, seg.Args[0], seg.Args[1], seg.Args[2], tolerance, func(q1, q2 f32.Vec2) {
+					ret = append(ret, sfnt.Segment{
+						Op:   sfnt.SegmentOpQuadTo,
+						Args: [3]fixed.Point26_6{transform(q1), transform(q2)},
+					})
+				})
+			}
+			pen = seg.end()
+		}
+	}
+	return ret
+}
+
+// cubicToQuads approximates the cubic Bézier curve (p0, p1, p2, p3) by one or
+// more quadratic Bézier curves, calling quadTo with each one's control and end
+// points.
+func cubicToQuads(p0, p1, p2, p3 f32.Vec2, tolerance float32, quadTo func(q1, q2 f32.Vec2)) {
+	// The distance between a cubic curve and its best single quadratic
+	// approximation is at most √3/36 times the length of the cubic's third
+	// difference, p3 - 3*p2 + 3*p1 - p0. Splitting the cubic into n pieces
+	// divides that third difference by n*n*n.
+	tx := float64(p3[0] - 3*p2[0] + 3*p1[0] - p0[0])
+	ty := float64(p3[1] - 3*p2[1] + 3*p1[1] - p0[1])
+	e := math.Sqrt(3) / 36 * math.Sqrt(tx*tx+ty*ty)
+	n := 1
+	if tolerance > 0 {
+		n = int(math.Ceil(math.Cbrt(e / float64(tolerance))))
+	}
+	if n < 1 {
+		n = 1
+	} else if n > 64 {
+		n = 64
+	}
+
+	for ; n > 0; n-- {
+		// Split off the first 1/n of what remains of the cubic.
+		t := 1 / float32(n)
+		p01, p12, p23 := lerp(t, p0, p1), lerp(t, p1, p2), lerp(t, p2, p3)
+		p012, p123 := lerp(t, p01, p12), lerp(t, p12, p23)
+		p0123 := lerp(t, p012, p123)
+
+		// The quadratic's control point is the average of the two points
+		// found by extending the cubic's first and last control lines.
+		q1 := f32.Vec2{
+			(3*(p01[0]+p012[0]) - p0[0] - p0123[0]) / 4,
+			(3*(p01[1]+p012[1]) - p0[1] - p0123[1]) / 4,
+		}
+		quadTo(q1, p0123)
+		p0, p1, p2 = p0123, p123, p23
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/f32"
+)
+
+func TestSFNTGlyphBuilder(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	for _, cubic := range []bool{false, true} {
+		b := &SFNTGlyphBuilder{Cubic: cubic}
+		if err := Decode(b, ivgData, nil); err != nil {
+			t.Fatalf("cubic=%t: Decode: %v", cubic, err)
+		}
+		segs := b.Segments()
+
+		// The action-info graphic is a circle with two rectangular holes.
+		var areas []float32
+		var polygon []f32.Vec2
+		for i, s := range segs {
+			if s.Op == sfnt.SegmentOpMoveTo && i != 0 {
+				areas = append(areas, signedArea(polygon))
+				polygon = polygon[:0]
+			}
+			if cubic && s.Op == sfnt.SegmentOpQuadTo {
+				t.Fatalf("cubic=%t: segment #%d: got QuadTo", cubic, i)
+			}
+			if !cubic && s.Op == sfnt.SegmentOpCubeTo {
+				t.Fatalf("cubic=%t: segment #%d: got CubeTo", cubic, i)
+			}
+			for _, a := range s.Args[:sfntNArgs[s.Op]] {
+				polygon = append(polygon, f32.Vec2{float32(a.X), float32(a.Y)})
+			}
+		}
+		areas = append(areas, signedArea(polygon))
+
+		if b := segs.Bounds(); b.Min.X < 0 || b.Max.X > 64*64 || b.Min.Y < -64*64 || b.Max.Y > 0 {
+			t.Errorf("cubic=%t: bounds: got %v, want within one em above the baseline", cubic, b)
+		}
+
+		if len(areas) != 3 {
+			t.Fatalf("cubic=%t: got %d contours, want 3", cubic, len(areas))
+		}
+		// With the Y axis increasing down, a TrueType outer contour (which is
+		// clockwise with the Y axis increasing up) has negative area.
+		outerPositive := cubic
+		for i, a := range areas {
+			if wantPositive := (i == 0) == outerPositive; (a > 0) != wantPositive {
+				t.Errorf("cubic=%t: contour #%d: area %g has the wrong sign", cubic, i, a)
+			}
+		}
+	}
+}
+
+var sfntNArgs = [...]int{
+	sfnt.SegmentOpMoveTo: 1,
+	sfnt.SegmentOpLineTo: 1,
+	sfnt.SegmentOpQuadTo: 2,
+	sfnt.SegmentOpCubeTo: 3,
+}
+
+func TestCubicToQuads(t *testing.T) {
+	p0, p1, p2, p3 := f32.Vec2{0, 0}, f32.Vec2{0, 10}, f32.Vec2{10, 10}, f32.Vec2{10, 0}
+	const tolerance = 0.01
+	pen, n := p0, 0
+	cubicToQuads(p0, p1, p2, p3, tolerance, func(q1, q2 f32.Vec2) {
+		for i := 0; i <= 8; i++ {
+			q := quadAt(pen, q1, q2, float32(i)/8)
+			// Compare against the closest of a dense sampling of the cubic.
+			best := float32(1e9)
+			for j := 0; j <= 1000; j++ {
+				c := cubeAt(p0, p1, p2, p3, float32(j)/1000)
+				dx, dy := c[0]-q[0], c[1]-q[1]
+				if d := dx*dx + dy*dy; d < best {
+					best = d
+				}
+			}
+			if best > 4*tolerance*tolerance {
+				t.Fatalf("quad #%d: point %v is too far from the cubic", n, q)
+			}
+		}
+		pen, n = q2, n+1
+	})
+	if pen != p3 {
+		t.Errorf("end point: got %v, want %v", pen, p3)
+	}
+	if n < 2 {
+		t.Errorf("got %d quads, want more than 1", n)
+	}
+}
+
+func TestSFNTGlyphBuilderSameDirectionContours(t *testing.T) {
+	// Two concentric squares that wind the same way. Under the non-zero
+	// winding rule, the inner square is filled, not a hole, so the glyph is
+	// just the outer square.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, -30, -30)
+	e.AbsHLineTo(+30)
+	e.AbsVLineTo(+30)
+	e.AbsHLineTo(-30)
+	e.ClosePathAbsMoveTo(-10, -10)
+	e.AbsHLineTo(+10)
+	e.AbsVLineTo(+10)
+	e.AbsHLineTo(-10)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	b := &SFNTGlyphBuilder{}
+	if err := Decode(b, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	nContours := 0
+	for _, s := range b.Segments() {
+		if s.Op == sfnt.SegmentOpMoveTo {
+			nContours++
+		}
+	}
+	if nContours != 1 {
+		t.Errorf("got %d contours, want 1", nContours)
+	}
+}
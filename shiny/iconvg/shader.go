@@ -0,0 +1,266 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"encoding/binary"
+	"math"
+
+	"golang.org/x/image/math/f32"
+)
+
+// ShaderPathTag is the kind of a ShaderPathRecord.
+type ShaderPathTag uint32
+
+const (
+	// ShaderPathTagPath starts a path. Every following record, up to the
+	// next ShaderPathTagPath record, is one of that path's segments. Its
+	// Points[0] and Points[1] are the minimum and maximum corners of a
+	// bounding box of those segments' points, including control points.
+	ShaderPathTagPath ShaderPathTag = iota
+	// ShaderPathTagLine is a line segment from Points[0] to Points[1].
+	ShaderPathTagLine
+	// ShaderPathTagQuad is a quadratic Bézier segment from Points[0] to
+	// Points[2], with control point Points[1].
+	ShaderPathTagQuad
+	// ShaderPathTagCubic is a cubic Bézier segment from Points[0] to
+	// Points[3], with control points Points[1] and Points[2].
+	ShaderPathTagCubic
+)
+
+// ShaderPathRecord is one record of a ShaderPathExporter's buffer.
+//
+// Its Bytes encoding is 40 bytes, little-endian, in field order: Tag, Color,
+// then Points' 8 float32 values. That matches the layout, including the
+// 40-byte array stride, of the WGSL PathRecord struct in ShaderPathWGSL.
+type ShaderPathRecord struct {
+	// Tag is the kind of record.
+	Tag ShaderPathTag
+	// Color is the path's alpha-premultiplied fill color, packed as
+	// R | G<<8 | B<<16 | A<<24, so that WGSL's unpack4x8unorm function
+	// returns it as an RGBA vec4. Every record of a path has the same Color.
+	Color uint32
+	// Points are the record's points, in graphic coordinate space. Unused
+	// points are zero.
+	Points [4]f32.Vec2
+}
+
+// shaderPathRecordSize is the size, in bytes, of an encoded ShaderPathRecord.
+const shaderPathRecordSize = 40
+
+// ShaderPathWGSL is a WGSL stub that declares the layout of a
+// ShaderPathExporter's buffer, bound as a read-only storage buffer, and
+// evaluates the fill color at a point in graphic coordinate space.
+//
+// It is a starting point, not a complete renderer. It visits every record
+// for every point, with no anti-aliasing, and approximates each curve by 8
+// line segments.
+const ShaderPathWGSL = `struct PathRecord {
+	tag: u32,
+	color: u32,
+	points: array<vec2<f32>, 4>,
+};
+
+@group(0) @binding(0) var<storage, read> records: array<PathRecord>;
+
+fn path_point(r: PathRecord, t: f32) -> vec2<f32> {
+	let s = 1.0 - t;
+	if (r.tag == 1u) {
+		return s*r.points[0] + t*r.points[1];
+	}
+	if (r.tag == 2u) {
+		return s*s*r.points[0] + 2.0*s*t*r.points[1] + t*t*r.points[2];
+	}
+	return s*s*s*r.points[0] + 3.0*s*s*t*r.points[1] + 3.0*s*t*t*r.points[2] + t*t*t*r.points[3];
+}
+
+fn path_winding(a: vec2<f32>, b: vec2<f32>, p: vec2<f32>) -> i32 {
+	let cross = (b.x - a.x)*(p.y - a.y) - (p.x - a.x)*(b.y - a.y);
+	if (a.y <= p.y) {
+		if (b.y > p.y && cross > 0.0) {
+			return 1;
+		}
+	} else if (b.y <= p.y && cross < 0.0) {
+		return -1;
+	}
+	return 0;
+}
+
+fn path_fill(p: vec2<f32>) -> vec4<f32> {
+	var dst = vec4<f32>(0.0);
+	var src = vec4<f32>(0.0);
+	var winding = 0;
+	let n = arrayLength(&records);
+	for (var i = 0u; i < n; i++) {
+		let r = records[i];
+		if (r.tag == 0u) {
+			if (winding != 0) {
+				dst = src + dst*(1.0 - src.a);
+			}
+			src = unpack4x8unorm(r.color);
+			winding = 0;
+			continue;
+		}
+		var steps = 8u;
+		if (r.tag == 1u) {
+			steps = 1u;
+		}
+		var a = r.points[0];
+		for (var j = 1u; j <= steps; j++) {
+			let b = path_point(r, f32(j) / f32(steps));
+			winding += path_winding(a, b, p);
+			a = b;
+		}
+	}
+	if (winding != 0) {
+		dst = src + dst*(1.0 - src.a);
+	}
+	return dst;
+}
+`
+
+// ShaderPathExporter is a Destination that converts an IconVG graphic to a
+// buffer of tagged path records, for evaluating its fill on a GPU.
+//
+// Each sub-path's segments are recorded in order, followed by a line segment
+// that explicitly closes it, so that each record can be evaluated
+// independently of the others, under the non-zero winding rule. There are no
+// records for move-to operations. Arcs are approximated by cubic Bézier
+// curves. Paths filled with gradients are not recorded.
+type ShaderPathExporter struct {
+	// Height is the height, in pixels, that the graphic is intended to be
+	// rendered at, which selects paths by their level of detail.
+	//
+	// If zero, it is the height of the ViewBox.
+	Height float32
+
+	segmenter
+
+	records []ShaderPathRecord
+	color   uint32
+	path    int
+	first   f32.Vec2
+	last    f32.Vec2
+	visible bool
+}
+
+// Records returns the path records.
+func (e *ShaderPathExporter) Records() []ShaderPathRecord {
+	return e.records
+}
+
+// Bytes returns the path records encoded as the buffer that ShaderPathWGSL
+// declares.
+func (e *ShaderPathExporter) Bytes() []byte {
+	b := make([]byte, len(e.records)*shaderPathRecordSize)
+	for i, r := range e.records {
+		c := b[i*shaderPathRecordSize:]
+		binary.LittleEndian.PutUint32(c[0:], uint32(r.Tag))
+		binary.LittleEndian.PutUint32(c[4:], r.Color)
+		for j, p := range r.Points {
+			binary.LittleEndian.PutUint32(c[8+8*j:], math.Float32bits(p[0]))
+			binary.LittleEndian.PutUint32(c[12+8*j:], math.Float32bits(p[1]))
+		}
+	}
+	return b
+}
+
+// Reset resets the ShaderPathExporter for the given Metadata.
+func (e *ShaderPathExporter) Reset(m Metadata) {
+	e.segmenter.reset(m, e)
+	e.records = e.records[:0]
+	e.visible = false
+}
+
+func (e *ShaderPathExporter) height() float32 {
+	if e.Height > 0 {
+		return e.Height
+	}
+	_, dy := e.metadata.ViewBox.AspectRatio()
+	return dy
+}
+
+func (e *ShaderPathExporter) beginPath() {
+	h := e.height()
+	e.visible = e.lod0 <= h && h < e.lod1 &&
+		e.fill.A != 0 && validAlphaPremulColor(e.fill)
+	if !e.visible {
+		return
+	}
+	e.color = uint32(e.fill.R) | uint32(e.fill.G)<<8 | uint32(e.fill.B)<<16 | uint32(e.fill.A)<<24
+	e.path = len(e.records)
+	e.first, e.last = f32.Vec2{}, f32.Vec2{}
+	e.records = append(e.records, ShaderPathRecord{
+		Tag:   ShaderPathTagPath,
+		Color: e.color,
+	})
+}
+
+func (e *ShaderPathExporter) addSegment(s Segment) {
+	if !e.visible {
+		return
+	}
+	r := ShaderPathRecord{Color: e.color}
+	switch s.Op {
+	case SegmentOpMoveTo:
+		e.closeSubpath()
+		e.first = s.Args[0]
+		e.last = s.Args[0]
+		return
+	case SegmentOpLineTo:
+		r.Tag = ShaderPathTagLine
+		r.Points[1] = s.Args[0]
+	case SegmentOpQuadTo:
+		r.Tag = ShaderPathTagQuad
+		r.Points[1], r.Points[2] = s.Args[0], s.Args[1]
+	case SegmentOpCubeTo:
+		r.Tag = ShaderPathTagCubic
+		r.Points[1], r.Points[2], r.Points[3] = s.Args[0], s.Args[1], s.Args[2]
+	}
+	r.Points[0] = e.last
+	e.records = append(e.records, r)
+	e.last = s.end()
+}
+
+func (e *ShaderPathExporter) endPath() {
+	if !e.visible {
+		return
+	}
+	e.closeSubpath()
+	e.visible = false
+
+	// Fill in the path record's bounding box.
+	records := e.records[e.path+1:]
+	if len(records) == 0 {
+		// Drop a path with no segments.
+		e.records = e.records[:e.path]
+		return
+	}
+	min, max := records[0].Points[0], records[0].Points[0]
+	for _, r := range records {
+		n := int(r.Tag) + 1
+		for _, p := range r.Points[:n] {
+			min[0] = min32(min[0], p[0])
+			min[1] = min32(min[1], p[1])
+			max[0] = max32(max[0], p[0])
+			max[1] = max32(max[1], p[1])
+		}
+	}
+	e.records[e.path].Points[0] = min
+	e.records[e.path].Points[1] = max
+}
+
+// closeSubpath adds a line segment from the current point back to the start
+// of the sub-path, if they differ.
+func (e *ShaderPathExporter) closeSubpath() {
+	if e.last != e.first {
+		e.records = append(e.records, ShaderPathRecord{
+			Tag:    ShaderPathTagLine,
+			Color:  e.color,
+			Points: [4]f32.Vec2{e.last, e.first},
+		})
+	}
+	e.last = e.first
+}
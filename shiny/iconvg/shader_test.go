@@ -0,0 +1,95 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"encoding/binary"
+	"image/color"
+	"math"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestShaderPathExporter(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x40, 0x00, 0x80}))
+	e.StartPath(0, -10, -10)
+	e.AbsHLineTo(+10)
+	e.AbsVLineTo(+10)
+	e.AbsHLineTo(-10)
+	e.AbsVLineTo(-10)
+	e.ClosePathAbsMoveTo(0, 0)
+	e.AbsLineTo(5, 0)
+	e.AbsQuadTo(5, 5, 0, 5)
+	e.ClosePathEndPath()
+	e.StartPath(0, 20, 0)
+	e.AbsArcTo(10, 10, 0, false, true, -20, 0)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var x ShaderPathExporter
+	if err := Decode(&x, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	records := x.Records()
+	const wantColor = 0x80004000
+	want := []ShaderPathRecord{
+		{ShaderPathTagPath, wantColor, [4]f32.Vec2{{-10, -10}, {10, 10}}},
+		{ShaderPathTagLine, wantColor, [4]f32.Vec2{{-10, -10}, {10, -10}}},
+		{ShaderPathTagLine, wantColor, [4]f32.Vec2{{10, -10}, {10, 10}}},
+		{ShaderPathTagLine, wantColor, [4]f32.Vec2{{10, 10}, {-10, 10}}},
+		{ShaderPathTagLine, wantColor, [4]f32.Vec2{{-10, 10}, {-10, -10}}},
+		{ShaderPathTagLine, wantColor, [4]f32.Vec2{{0, 0}, {5, 0}}},
+		{ShaderPathTagQuad, wantColor, [4]f32.Vec2{{5, 0}, {5, 5}, {0, 5}}},
+		// The implicit closing line is explicit.
+		{ShaderPathTagLine, wantColor, [4]f32.Vec2{{0, 5}, {0, 0}}},
+	}
+	if len(records) < len(want) {
+		t.Fatalf("got %d records, want at least %d", len(records), len(want))
+	}
+	for i, w := range want {
+		if got := records[i]; got != w {
+			t.Errorf("record #%d: got %v, want %v", i, got, w)
+		}
+	}
+
+	// The arc is approximated by cubic Bézier curves.
+	rest := records[len(want):]
+	if len(rest) < 3 || rest[0].Tag != ShaderPathTagPath {
+		t.Fatalf("arc path: got %v", rest)
+	}
+	for i, r := range rest[1 : len(rest)-1] {
+		if r.Tag != ShaderPathTagCubic {
+			t.Errorf("arc path record #%d: got tag %d, want %d", i+1, r.Tag, ShaderPathTagCubic)
+		}
+	}
+	if got := rest[len(rest)-1]; got.Tag != ShaderPathTagLine || got.Points[1] != (f32.Vec2{20, 0}) {
+		t.Errorf("arc path closing record: got %v", got)
+	}
+
+	b := x.Bytes()
+	if got, want := len(b), 40*len(records); got != want {
+		t.Fatalf("len(Bytes): got %d, want %d", got, want)
+	}
+	// Check record #6, the quadratic Bézier segment.
+	c := b[6*40:]
+	if got := binary.LittleEndian.Uint32(c[0:]); got != uint32(ShaderPathTagQuad) {
+		t.Errorf("Bytes: tag: got %d, want %d", got, ShaderPathTagQuad)
+	}
+	if got := binary.LittleEndian.Uint32(c[4:]); got != wantColor {
+		t.Errorf("Bytes: color: got %#08x, want %#08x", got, uint32(wantColor))
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(c[20:])); got != 5 {
+		t.Errorf("Bytes: control point's y: got %v, want 5", got)
+	}
+}
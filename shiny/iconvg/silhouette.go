@@ -0,0 +1,100 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+)
+
+// silhouetteDestination is a Destination that merges the visible paths that
+// share a level of detail range into one path, forwarded to an inner
+// Destination when decoding finishes. Styling ops are not forwarded.
+type silhouetteDestination struct {
+	inner Destination
+
+	segmenter
+
+	rewrite func(segs []Segment) []Segment
+	color   color.RGBA
+	colored bool
+	visible bool
+	segs    []Segment
+	groups  []silhouetteGroup
+}
+
+// silhouetteGroup is the merged path of a level of detail range.
+type silhouetteGroup struct {
+	lod0, lod1 float32
+	segs       []Segment
+}
+
+func (d *silhouetteDestination) abortErr() error {
+	if a, ok := d.inner.(aborter); ok {
+		return a.abortErr()
+	}
+	return nil
+}
+
+func (d *silhouetteDestination) Reset(m Metadata) {
+	d.segmenter.reset(m, d)
+	if d.rewrite == nil {
+		d.rewrite = windingRewriter(WindingOuterClockwise)
+	}
+	d.colored = false
+	d.visible = false
+	d.segs = d.segs[:0]
+	d.groups = d.groups[:0]
+	d.inner.Reset(m)
+}
+
+func (d *silhouetteDestination) beginPath() {
+	d.visible = d.fill.A != 0 || d.fill.B&0x80 != 0
+	if d.visible && !d.colored {
+		d.colored = true
+		d.color = d.fill
+		if !validAlphaPremulColor(d.color) {
+			d.color = color.RGBA{0x00, 0x00, 0x00, 0xff}
+		}
+	}
+	d.segs = d.segs[:0]
+}
+
+func (d *silhouetteDestination) addSegment(s Segment) {
+	if d.visible {
+		d.segs = append(d.segs, s)
+	}
+}
+
+func (d *silhouetteDestination) endPath() {
+	if !d.visible {
+		return
+	}
+	segs := d.rewrite(d.segs)
+	for i := range d.groups {
+		if g := &d.groups[i]; g.lod0 == d.lod0 && g.lod1 == d.lod1 {
+			g.segs = append(g.segs, segs...)
+			return
+		}
+	}
+	d.groups = append(d.groups, silhouetteGroup{
+		lod0: d.lod0,
+		lod1: d.lod1,
+		segs: append([]Segment(nil), segs...),
+	})
+}
+
+func (d *silhouetteDestination) finish() {
+	if len(d.groups) == 0 {
+		return
+	}
+	d.inner.SetCReg(0, false, RGBAColor(d.color))
+	for _, g := range d.groups {
+		if len(g.segs) == 0 {
+			continue
+		}
+		d.inner.SetLOD(g.lod0, g.lod1)
+		emitPath(d.inner, 0, g.segs)
+	}
+}
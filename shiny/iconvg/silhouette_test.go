@@ -0,0 +1,208 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeMergeToSilhouette(t *testing.T) {
+	blue := color.RGBA{0x00, 0x00, 0xff, 0xff}
+	red := color.RGBA{0xff, 0x00, 0x00, 0xff}
+
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	// A transparent path, which is not part of the silhouette.
+	e.SetCReg(0, false, RGBAColor(color.RGBA{}))
+	e.StartPath(0, +20, +20)
+	e.AbsHLineTo(+30)
+	e.AbsVLineTo(+30)
+	e.ClosePathEndPath()
+	// A clockwise square.
+	e.SetCReg(0, false, RGBAColor(blue))
+	e.StartPath(0, -20, -20)
+	e.AbsHLineTo(0)
+	e.AbsVLineTo(0)
+	e.AbsHLineTo(-20)
+	e.ClosePathEndPath()
+	// An overlapping counter-clockwise square.
+	e.SetCReg(0, false, RGBAColor(red))
+	e.StartPath(0, -10, -10)
+	e.AbsVLineTo(+10)
+	e.AbsHLineTo(+10)
+	e.AbsVLineTo(-10)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	opts := &DecodeOptions{MergeToSilhouette: true}
+	var r pathsRecorder
+	if err := Decode(&r, ivgData, opts); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(r.paths) != 1 {
+		t.Fatalf("got %d paths, want 1", len(r.paths))
+	}
+	if got := r.paths[0].Fill; got != blue {
+		t.Errorf("fill: got %v, want %v", got, blue)
+	}
+	if got, want := len(splitSubpaths(r.paths[0].Segments)), 2; got != want {
+		t.Errorf("sub-paths: got %d, want %d", got, want)
+	}
+
+	dst, err := rasterize(ivgData, 64, 64, opts)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	testCases := []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{22, 22, blue},
+		// The overlap does not cancel out.
+		{27, 27, blue},
+		{37, 37, blue},
+		{55, 55, color.RGBA{}},
+		{5, 5, color.RGBA{}},
+	}
+	for _, tc := range testCases {
+		if got := dst.RGBAAt(tc.x, tc.y); got != tc.want {
+			t.Errorf("at (%d, %d): got %v, want %v", tc.x, tc.y, got, tc.want)
+		}
+	}
+}
+
+func TestDecodeMergeToSilhouetteLOD(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/lod-polygon.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var unmerged, merged pathsRecorder
+	if err := Decode(&unmerged, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if err := Decode(&merged, ivgData, &DecodeOptions{MergeToSilhouette: true}); err != nil {
+		t.Fatalf("Decode(merged): %v", err)
+	}
+
+	type lod struct{ lod0, lod1 float32 }
+	var want []lod
+	seen := map[lod]bool{}
+	for _, p := range unmerged.paths {
+		if l := (lod{p.LOD0, p.LOD1}); !seen[l] {
+			seen[l] = true
+			want = append(want, l)
+		}
+	}
+	if len(merged.paths) != len(want) {
+		t.Fatalf("got %d paths, want %d", len(merged.paths), len(want))
+	}
+	for i, p := range merged.paths {
+		if got := (lod{p.LOD0, p.LOD1}); got != want[i] {
+			t.Errorf("path #%d: LOD: got %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+// TestDecodeMergeToSilhouetteWithOtherOptions tests that the options that
+// wrap the Destination outside of the silhouette still let it emit its merged
+// path when decoding finishes.
+func TestDecodeMergeToSilhouetteWithOtherOptions(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var want pathsRecorder
+	if err := Decode(&want, ivgData, &DecodeOptions{MergeToSilhouette: true}); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(want.paths) != 1 {
+		t.Fatalf("got %d paths, want 1", len(want.paths))
+	}
+
+	testCases := []struct {
+		desc string
+		opts DecodeOptions
+	}{{
+		desc: "MaxFlattenedSegments",
+		opts: DecodeOptions{MaxFlattenedSegments: 1e6},
+	}, {
+		desc: "ArcApproximator",
+		opts: DecodeOptions{ArcApproximator: ArcTolerance(1.0 / 64)},
+	}}
+	for _, tc := range testCases {
+		tc.opts.MergeToSilhouette = true
+		var got pathsRecorder
+		if err := Decode(&got, ivgData, &tc.opts); err != nil {
+			t.Errorf("%s: Decode: %v", tc.desc, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.paths, want.paths) {
+			t.Errorf("%s: got %d paths, want the same path as without the option", tc.desc, len(got.paths))
+		}
+	}
+}
+
+func TestDecodeMergeToSilhouetteCoverage(t *testing.T) {
+	// Two concentric squares that wind the same way. Under the non-zero
+	// winding rule, the inner square is filled, not a hole.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, -30, -30)
+	e.AbsHLineTo(+30)
+	e.AbsVLineTo(+30)
+	e.AbsHLineTo(-30)
+	e.ClosePathAbsMoveTo(-10, -10)
+	e.AbsHLineTo(+10)
+	e.AbsVLineTo(+10)
+	e.AbsHLineTo(-10)
+	e.ClosePathEndPath()
+	squares, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	cowbell, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	// The silhouette covers every pixel that the original covers.
+	for _, tc := range []struct {
+		desc    string
+		ivgData []byte
+	}{
+		{"squares", squares},
+		{"cowbell", cowbell},
+	} {
+		want, err := rasterize(tc.ivgData, 64, 64, nil)
+		if err != nil {
+			t.Fatalf("%s: rasterize: %v", tc.desc, err)
+		}
+		got, err := rasterize(tc.ivgData, 64, 64, &DecodeOptions{MergeToSilhouette: true})
+		if err != nil {
+			t.Fatalf("%s: rasterize: %v", tc.desc, err)
+		}
+		for y := 0; y < 64; y++ {
+			for x := 0; x < 64; x++ {
+				if w, g := want.RGBAAt(x, y).A, got.RGBAAt(x, y).A; w == 0xff && g != 0xff {
+					t.Fatalf("%s: at (%d, %d): got alpha %#02x, want 0xff", tc.desc, x, y, g)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+// Breakdown is the number of bytes that an IconVG graphic's encoded form
+// spends on each of its parts.
+type Breakdown struct {
+	// Magic is the size of the magic identifier.
+	Magic int
+
+	// NumMetadataChunks is the size of the number of metadata chunks.
+	NumMetadataChunks int
+
+	// MetadataChunks are the sizes of each metadata chunk, in order,
+	// including each chunk's length and Metadata Identifier.
+	MetadataChunks []MetadataChunkSize
+
+	// Styling is the total size of the styling opcodes and their operands.
+	// This includes the opcodes that start a path.
+	Styling int
+
+	// Drawing is the total size of the drawing opcodes and their operands.
+	// This includes the opcodes that end a path.
+	Drawing int
+}
+
+// MetadataChunkSize is the size of a metadata chunk.
+type MetadataChunkSize struct {
+	// MID is the chunk's Metadata Identifier, such as 1 for the suggested
+	// palette.
+	MID uint32
+	// Size is the chunk's size in bytes.
+	Size int
+}
+
+// Total returns the total size of the graphic.
+func (b *Breakdown) Total() int {
+	n := b.Magic + b.NumMetadataChunks + b.Styling + b.Drawing
+	for _, c := range b.MetadataChunks {
+		n += c.Size
+	}
+	return n
+}
+
+// SizeBreakdown decodes an IconVG graphic and reports how many bytes it
+// spends on its magic identifier, on each metadata chunk, and on its styling
+// and drawing opcodes.
+func SizeBreakdown(src []byte) (Breakdown, error) {
+	b := Breakdown{}
+	opcodes, drawing, needMID := false, false, false
+	cur := &b.Styling
+	p := func(x []byte, k TraceEventKind, format string, args ...interface{}) {
+		n := len(x)
+		switch k {
+		case TraceMagic:
+			b.Magic += n
+			return
+		case TraceMetadataChunk:
+			b.MetadataChunks = append(b.MetadataChunks, MetadataChunkSize{Size: n})
+			needMID = true
+			return
+		case TraceOpcode, TracePathStart, TracePathEnd:
+			opcodes = true
+		}
+
+		if !opcodes {
+			if len(b.MetadataChunks) == 0 {
+				b.NumMetadataChunks += n
+				return
+			}
+			c := &b.MetadataChunks[len(b.MetadataChunks)-1]
+			if needMID {
+				c.MID, _ = buffer(x).decodeNatural()
+				needMID = false
+			}
+			c.Size += n
+			return
+		}
+
+		// A path's start opcode, and its operands, are styling. Its end
+		// opcode is drawing. Everything in between is drawing. Operands are
+		// counted with their opcode.
+		switch k {
+		case TracePathStart:
+			cur, drawing = &b.Styling, true
+		case TracePathEnd:
+			cur, drawing = &b.Drawing, false
+		case TraceOpcode:
+			if drawing {
+				cur = &b.Drawing
+			} else {
+				cur = &b.Styling
+			}
+		}
+		*cur += n
+	}
+
+	m := Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	}
+	if err := decode(nil, p, &m, false, src, nil); err != nil {
+		return Breakdown{}, err
+	}
+	return b, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestSizeBreakdown(t *testing.T) {
+	for _, tc := range testdataTestCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		b, err := SizeBreakdown(ivgData)
+		if err != nil {
+			t.Errorf("%s: SizeBreakdown: %v", tc.filename, err)
+			continue
+		}
+		if got, want := b.Total(), len(ivgData); got != want {
+			t.Errorf("%s: Total: got %d, want %d", tc.filename, got, want)
+		}
+	}
+
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/favicon.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	b, err := SizeBreakdown(ivgData)
+	if err != nil {
+		t.Fatalf("SizeBreakdown: %v", err)
+	}
+	if b.Magic != 4 || b.NumMetadataChunks != 1 {
+		t.Errorf("Magic, NumMetadataChunks: got %d, %d, want 4, 1", b.Magic, b.NumMetadataChunks)
+	}
+	want := []MetadataChunkSize{{MID: midSuggestedPalette, Size: 6}}
+	if len(b.MetadataChunks) != 1 || b.MetadataChunks[0] != want[0] {
+		t.Errorf("MetadataChunks: got %v, want %v", b.MetadataChunks, want)
+	}
+	if b.Styling == 0 || b.Drawing == 0 || b.Drawing < b.Styling {
+		t.Errorf("Styling, Drawing: got %d, %d", b.Styling, b.Drawing)
+	}
+}
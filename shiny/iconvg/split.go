@@ -0,0 +1,28 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+// SplitPaths splits an IconVG graphic into one graphic per path, in the order
+// that the paths are encoded. Each has the original metadata, and draws only
+// its path, the same as the path would be drawn in the original graphic.
+//
+// The paths are copied as encoded, not re-encoded, so no precision is lost.
+// Each graphic also retains the styling opcodes, from the original graphic,
+// that precede its path, as they may set its path's registers.
+func SplitPaths(src []byte) ([][]byte, error) {
+	// DecodeLazy does not check every number's value, so check them first.
+	if err := Decode(nil, src, nil); err != nil {
+		return nil, err
+	}
+	l, err := DecodeLazy(src, nil)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([][]byte, l.NumPaths())
+	for i := range ret {
+		ret[i] = l.pathSource(i)
+	}
+	return ret, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitPaths(t *testing.T) {
+	for _, tc := range testdataTestCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		var want pathsRecorder
+		if err := Decode(&want, ivgData, nil); err != nil {
+			t.Errorf("%s: Decode: %v", tc.filename, err)
+			continue
+		}
+		wantMetadata := want.metadata
+
+		split, err := SplitPaths(ivgData)
+		if err != nil {
+			t.Errorf("%s: SplitPaths: %v", tc.filename, err)
+			continue
+		}
+		if len(split) != len(want.paths) {
+			t.Errorf("%s: got %d graphics, want %d", tc.filename, len(split), len(want.paths))
+			continue
+		}
+		for i, s := range split {
+			var got pathsRecorder
+			if err := Decode(&got, s, nil); err != nil {
+				t.Errorf("%s: graphic #%d: Decode: %v", tc.filename, i, err)
+				continue
+			}
+			if got.metadata != wantMetadata {
+				t.Errorf("%s: graphic #%d: Metadata: got %v, want %v", tc.filename, i, got.metadata, wantMetadata)
+			}
+			if len(got.paths) != 1 {
+				t.Errorf("%s: graphic #%d: got %d paths, want 1", tc.filename, i, len(got.paths))
+				continue
+			}
+			if !reflect.DeepEqual(got.paths[0], want.paths[i]) {
+				t.Errorf("%s: graphic #%d:\ngot  %v\nwant %v", tc.filename, i, got.paths[0], want.paths[i])
+			}
+		}
+	}
+}
+
+func TestSplitPathsInvalid(t *testing.T) {
+	// The line's coordinate is missing.
+	if _, err := SplitPaths([]byte("\x89IVG\x00\xc0\x80\x80\x00")); err == nil {
+		t.Error("got nil error, want non-nil")
+	}
+}
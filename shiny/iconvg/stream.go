@@ -0,0 +1,259 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"io"
+
+	"golang.org/x/image/math/f32"
+)
+
+// streamFlushThreshold is the number of buffered bytes above which a
+// StreamEncoder writes them to its io.Writer.
+const streamFlushThreshold = 4096
+
+// StreamEncoder is like an Encoder, but it writes the encoded form to an
+// io.Writer as it is produced, instead of buffering all of it until Bytes is
+// called. This bounds its memory use when encoding large graphics.
+//
+// The magic identifier and metadata are written as soon as they are complete.
+// Subsequent opcodes are buffered and written in batches, so the output is
+// only complete once Close is called.
+//
+// Like an Encoder, its methods do not return errors. Instead, the first error
+// encountered, whether in encoding or in writing, is returned by Close.
+type StreamEncoder struct {
+	// HighResolutionCoordinates is like the Encoder field of the same name.
+	HighResolutionCoordinates bool
+
+	w   io.Writer
+	e   Encoder
+	err error
+}
+
+// NewStreamEncoder returns a new StreamEncoder that writes to w.
+func NewStreamEncoder(w io.Writer) *StreamEncoder {
+	return &StreamEncoder{w: w}
+}
+
+// Close flushes any buffered output. It returns the first error encountered
+// while encoding or writing, if any. It does not close the underlying
+// io.Writer.
+func (s *StreamEncoder) Close() error {
+	if s.e.mode == modeInitial {
+		s.e.appendDefaultMetadata()
+	}
+	s.flush(true)
+	return s.err
+}
+
+// flush writes the buffered bytes to s.w, if there are enough of them or if
+// force is true. It also flushes long runs of pending drawing ops, at a
+// boundary where doing so does not change the encoded form, so that they do
+// not accumulate without bound.
+func (s *StreamEncoder) flush(force bool) {
+	if s.err == nil {
+		s.err = s.e.err
+	}
+	if s.err != nil {
+		return
+	}
+	if op := drawOps[s.e.drawOp]; op.nArgs != 0 {
+		if n := len(s.e.drawArgs) / int(op.nArgs); n >= int(op.maxRepCount) && n%int(op.maxRepCount) == 0 {
+			s.e.flushDrawOps()
+		}
+	}
+	if len(s.e.buf) == 0 || (!force && len(s.e.buf) < streamFlushThreshold) {
+		return
+	}
+	if _, s.err = s.w.Write(s.e.buf); s.err == nil {
+		s.e.buf = s.e.buf[:0]
+	}
+}
+
+// CSel returns the current CSEL register value.
+func (s *StreamEncoder) CSel() uint8 {
+	x := s.e.CSel()
+	s.flush(false)
+	return x
+}
+
+// NSel returns the current NSEL register value.
+func (s *StreamEncoder) NSel() uint8 {
+	x := s.e.NSel()
+	s.flush(false)
+	return x
+}
+
+// LOD returns the current LOD register values.
+func (s *StreamEncoder) LOD() (lod0, lod1 float32) {
+	lod0, lod1 = s.e.LOD()
+	s.flush(false)
+	return lod0, lod1
+}
+
+// Reset resets the StreamEncoder for the given Metadata, which is written
+// immediately. Unlike Encoder.Reset, this does not reset any prior output,
+// which has already been written, or any prior error.
+func (s *StreamEncoder) Reset(m Metadata) {
+	s.e.Reset(m)
+	s.flush(true)
+}
+
+func (s *StreamEncoder) SetCSel(cSel uint8) {
+	s.e.SetCSel(cSel)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) SetNSel(nSel uint8) {
+	s.e.SetNSel(nSel)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) SetCReg(adj uint8, incr bool, c Color) {
+	s.e.SetCReg(adj, incr, c)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) SetNReg(adj uint8, incr bool, f float32) {
+	s.e.SetNReg(adj, incr, f)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) SetLOD(lod0, lod1 float32) {
+	s.e.SetLOD(lod0, lod1)
+	s.flush(false)
+}
+
+// SetGradient is like the Encoder method of the same name.
+func (s *StreamEncoder) SetGradient(cBase, nBase uint8, radial bool, transform f32.Aff3, spread GradientSpread, stops []GradientStop) {
+	s.e.SetGradient(cBase, nBase, radial, transform, spread, stops)
+	s.flush(false)
+}
+
+// SetLinearGradient is like the Encoder method of the same name.
+func (s *StreamEncoder) SetLinearGradient(cBase, nBase uint8, x1, y1, x2, y2 float32, spread GradientSpread, stops []GradientStop) {
+	s.e.SetLinearGradient(cBase, nBase, x1, y1, x2, y2, spread, stops)
+	s.flush(false)
+}
+
+// SetCircularGradient is like the Encoder method of the same name.
+func (s *StreamEncoder) SetCircularGradient(cBase, nBase uint8, cx, cy, rx, ry float32, spread GradientSpread, stops []GradientStop) {
+	s.e.SetCircularGradient(cBase, nBase, cx, cy, rx, ry, spread, stops)
+	s.flush(false)
+}
+
+// SetRadialGradient is like the Encoder method of the same name.
+func (s *StreamEncoder) SetRadialGradient(cBase, nBase uint8, cx, cy, r float32, spread GradientSpread, stops []GradientStop) {
+	s.e.SetRadialGradient(cBase, nBase, cx, cy, r, spread, stops)
+	s.flush(false)
+}
+
+// SetEllipticalGradient is like the Encoder method of the same name.
+func (s *StreamEncoder) SetEllipticalGradient(cBase, nBase uint8, cx, cy, rx, ry, sx, sy float32, spread GradientSpread, stops []GradientStop) {
+	s.e.SetEllipticalGradient(cBase, nBase, cx, cy, rx, ry, sx, sy, spread, stops)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) StartPath(adj uint8, x, y float32) {
+	s.e.HighResolutionCoordinates = s.HighResolutionCoordinates
+	s.e.StartPath(adj, x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) AbsHLineTo(x float32) {
+	s.e.AbsHLineTo(x)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) RelHLineTo(x float32) {
+	s.e.RelHLineTo(x)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) AbsVLineTo(y float32) {
+	s.e.AbsVLineTo(y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) RelVLineTo(y float32) {
+	s.e.RelVLineTo(y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) AbsLineTo(x, y float32) {
+	s.e.AbsLineTo(x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) RelLineTo(x, y float32) {
+	s.e.RelLineTo(x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) AbsSmoothQuadTo(x, y float32) {
+	s.e.AbsSmoothQuadTo(x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) RelSmoothQuadTo(x, y float32) {
+	s.e.RelSmoothQuadTo(x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) AbsQuadTo(x1, y1, x, y float32) {
+	s.e.AbsQuadTo(x1, y1, x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) RelQuadTo(x1, y1, x, y float32) {
+	s.e.RelQuadTo(x1, y1, x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) AbsSmoothCubeTo(x2, y2, x, y float32) {
+	s.e.AbsSmoothCubeTo(x2, y2, x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) RelSmoothCubeTo(x2, y2, x, y float32) {
+	s.e.RelSmoothCubeTo(x2, y2, x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
+	s.e.AbsCubeTo(x1, y1, x2, y2, x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) RelCubeTo(x1, y1, x2, y2, x, y float32) {
+	s.e.RelCubeTo(x1, y1, x2, y2, x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) ClosePathEndPath() {
+	s.e.ClosePathEndPath()
+	s.flush(false)
+}
+
+func (s *StreamEncoder) ClosePathAbsMoveTo(x, y float32) {
+	s.e.ClosePathAbsMoveTo(x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) ClosePathRelMoveTo(x, y float32) {
+	s.e.ClosePathRelMoveTo(x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	s.e.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	s.flush(false)
+}
+
+func (s *StreamEncoder) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	s.e.RelArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	s.flush(false)
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// countingWriter is an io.Writer that counts the calls to Write.
+type countingWriter struct {
+	bytes.Buffer
+	nWrites int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.nWrites++
+	return w.Buffer.Write(p)
+}
+
+// encodeStar encodes a graphic with a many-pointed star, plus a gradient, to
+// dst.
+func encodeStar(dst Destination, setGradient func(cBase, nBase uint8, x1, y1, x2, y2 float32, spread GradientSpread, stops []GradientStop)) {
+	dst.Reset(Metadata{ViewBox: DefaultViewBox, Palette: DefaultPalette})
+	setGradient(10, 10, -30, 0, 30, 0, GradientSpreadPad, []GradientStop{
+		{Offset: 0, Color: color.RGBA{0xff, 0x00, 0x00, 0xff}},
+		{Offset: 1, Color: color.RGBA{0x00, 0x00, 0xff, 0xff}},
+	})
+	const n = 5000
+	dst.StartPath(0, 30, 0)
+	for i := 1; i < n; i++ {
+		r := float32(30)
+		if i&1 != 0 {
+			r = 15
+		}
+		theta := 2 * math.Pi * float64(i) / n
+		dst.AbsLineTo(r*float32(math.Cos(theta)), r*float32(math.Sin(theta)))
+	}
+	dst.ClosePathEndPath()
+	dst.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x80, 0x00, 0xff}))
+	dst.StartPath(0, -2, -2)
+	dst.AbsArcTo(2, 2, 0, true, false, 2, 2)
+	dst.RelVLineTo(-4)
+	dst.ClosePathEndPath()
+}
+
+func TestStreamEncoder(t *testing.T) {
+	var e Encoder
+	encodeStar(&e, e.SetLinearGradient)
+	want, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Encoder: %v", err)
+	}
+
+	w := &countingWriter{}
+	s := NewStreamEncoder(w)
+	encodeStar(s, s.SetLinearGradient)
+	if err := s.Close(); err != nil {
+		t.Fatalf("StreamEncoder: %v", err)
+	}
+	if got := w.Bytes(); !bytes.Equal(got, want) {
+		t.Fatalf("StreamEncoder and Encoder output differ: got %d bytes, want %d bytes", len(got), len(want))
+	}
+	if w.nWrites < 3 {
+		t.Errorf("got %d writes, want the output to be streamed", w.nWrites)
+	}
+	if n := len(s.e.buf); n > streamFlushThreshold {
+		t.Errorf("buffered %d bytes, want at most %d", n, streamFlushThreshold)
+	}
+}
+
+type errWriter struct{}
+
+var errWrite = errors.New("write failed")
+
+func (errWriter) Write(p []byte) (int, error) { return 0, errWrite }
+
+func TestStreamEncoderWriteError(t *testing.T) {
+	s := NewStreamEncoder(errWriter{})
+	encodeStar(s, s.SetLinearGradient)
+	if err := s.Close(); err != errWrite {
+		t.Fatalf("got %v, want %v", err, errWrite)
+	}
+}
+
+func TestStreamEncoderDefaultMetadata(t *testing.T) {
+	w := &bytes.Buffer{}
+	s := NewStreamEncoder(w)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got, want := w.String(), "\x89IVG\x00"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"math"
+
+	"golang.org/x/image/math/f32"
+)
+
+// CapStyle is the shape at the ends of a stroke's open sub-paths, such as
+// dashes.
+type CapStyle uint8
+
+const (
+	// CapButt ends a stroke exactly at its end point.
+	CapButt CapStyle = iota
+	// CapRound ends a stroke with a semicircle.
+	CapRound
+	// CapSquare ends a stroke with a half square, extending it by half of
+	// its width.
+	CapSquare
+)
+
+// StrokeOptions are the parameters of a StrokeDestination.
+type StrokeOptions struct {
+	// Width is the width of the stroke, in graphic coordinate space. If it
+	// is not positive, nothing is drawn.
+	Width float32
+
+	// Cap is the shape at the ends of the dashes. IconVG paths are always
+	// closed, so an undashed stroke has no ends.
+	Cap CapStyle
+
+	// Dashes are the lengths, in graphic coordinate space, of alternating
+	// dashes and gaps, starting with a dash. An odd number of lengths is
+	// repeated to give an even number, as for SVG's stroke-dasharray. If
+	// empty, or if any length is negative or they are all zero, the stroke
+	// is solid.
+	Dashes []float32
+
+	// DashOffset is the distance into the dash pattern at which each
+	// sub-path starts.
+	DashOffset float32
+}
+
+// StrokeDestination returns a Destination that forwards each method call to
+// inner, except that each path is replaced by a filled outline of its
+// stroke, with the same fill color or gradient.
+//
+// Curves are flattened to line segments, to within 1/64th of a unit, before
+// being dashed and stroked, and the stroke's corners are always rounded. The
+// outline consists of many overlapping pieces, all wound in the same
+// direction, so it relies on inner using the non-zero winding fill rule, as
+// the Rasterizer does.
+func StrokeDestination(inner Destination, o StrokeOptions) Destination {
+	return &pathRewriter{
+		inner:   inner,
+		rewrite: strokeRewriter(o),
+	}
+}
+
+// strokeRewriter returns a pathRewriter rewrite function that replaces a path
+// with the outline of its stroke.
+func strokeRewriter(o StrokeOptions) func(segs []Segment) []Segment {
+	dashes := o.Dashes
+	if len(dashes)%2 == 1 {
+		dashes = append(append([]float32(nil), dashes...), dashes...)
+	}
+	total := float32(0)
+	for _, d := range dashes {
+		if !(d >= 0) {
+			total = 0
+			break
+		}
+		total += d
+	}
+	if !(total > 0) || isNaNOrInfinity(total) {
+		dashes = nil
+	}
+
+	s := stroker{width: o.Width, cap: o.Cap}
+	return func(segs []Segment) []Segment {
+		s.ret = s.ret[:0]
+		if !(s.width > 0) {
+			return s.ret
+		}
+		for _, sub := range splitSubpaths(segs) {
+			polyline := flatten(nil, sub, flattenTolerance)
+			// IconVG paths are implicitly closed.
+			polyline = append(polyline, polyline[0])
+			if dashes == nil {
+				s.strokePolyline(polyline, true)
+				continue
+			}
+			for _, dash := range dashPolyline(polyline, dashes, total, o.DashOffset) {
+				s.strokePolyline(dash, false)
+			}
+		}
+		return s.ret
+	}
+}
+
+// dashPolyline splits the polyline into the pieces covered by the dash
+// pattern, whose lengths sum to total, starting at offset into the pattern.
+func dashPolyline(polyline []f32.Vec2, dashes []float32, total, offset float32) (pieces [][]f32.Vec2) {
+	// Find the dash or gap, and how much of it remains, at the offset.
+	offset = float32(math.Mod(float64(offset), float64(total)))
+	if offset < 0 {
+		offset += total
+	}
+	k := 0
+	for offset >= dashes[k] && offset > 0 {
+		offset -= dashes[k]
+		k = (k + 1) % len(dashes)
+	}
+	remaining := dashes[k] - offset
+
+	var piece []f32.Vec2
+	if k%2 == 0 {
+		piece = append(piece, polyline[0])
+	}
+	for i := 1; i < len(polyline); i++ {
+		a, b := polyline[i-1], polyline[i]
+		l := float32(math.Hypot(float64(b[0]-a[0]), float64(b[1]-a[1])))
+		t := float32(0)
+		for l-t > remaining {
+			t += remaining
+			p := lerp(t/l, a, b)
+			if k%2 == 0 {
+				pieces = append(pieces, append(piece, p))
+				piece = nil
+			} else {
+				piece = []f32.Vec2{p}
+			}
+			k = (k + 1) % len(dashes)
+			remaining = dashes[k]
+		}
+		remaining -= l - t
+		if k%2 == 0 {
+			piece = append(piece, b)
+		}
+	}
+	if len(piece) >= 2 {
+		pieces = append(pieces, piece)
+	}
+	return pieces
+}
+
+// stroker builds the outlines of stroked polylines.
+type stroker struct {
+	width float32
+	cap   CapStyle
+	ret   []Segment
+}
+
+// strokePolyline adds the outline of the polyline's stroke: a quadrilateral
+// for each line segment and a circle for each join and round cap. If closed,
+// the polyline's last point should equal its first, and it has no caps.
+func (s *stroker) strokePolyline(polyline []f32.Vec2, closed bool) {
+	r := s.width / 2
+	n := len(polyline)
+	if n < 2 {
+		return
+	}
+	if !closed {
+		switch s.cap {
+		case CapRound:
+			s.circle(polyline[0], r)
+			s.circle(polyline[n-1], r)
+		case CapSquare:
+			// Extend the end segments, copying the polyline first.
+			polyline = append([]f32.Vec2(nil), polyline...)
+			polyline[0] = extend(polyline[1], polyline[0], r)
+			polyline[n-1] = extend(polyline[n-2], polyline[n-1], r)
+		}
+	}
+	for i := 1; i < n; i++ {
+		s.quad(polyline[i-1], polyline[i], r)
+		if i < n-1 || closed {
+			s.circle(polyline[i], r)
+		}
+	}
+}
+
+// extend returns b moved a distance d further away from a. It returns b if a
+// equals b.
+func extend(a, b f32.Vec2, d float32) f32.Vec2 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	l := float32(math.Hypot(float64(dx), float64(dy)))
+	if l == 0 {
+		return b
+	}
+	return f32.Vec2{b[0] + dx*d/l, b[1] + dy*d/l}
+}
+
+// quad adds the rectangle of half-width r around the line segment from a to
+// b, wound clockwise.
+func (s *stroker) quad(a, b f32.Vec2, r float32) {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	l := float32(math.Hypot(float64(dx), float64(dy)))
+	if l == 0 {
+		return
+	}
+	// (nx, ny) is to the left of the direction of travel, with the Y axis
+	// increasing down, so going forwards on the left and back on the right
+	// is clockwise.
+	nx, ny := dy*r/l, -dx*r/l
+	s.ret = append(s.ret,
+		Segment{Op: SegmentOpMoveTo, Args: [3]f32.Vec2{{a[0] + nx, a[1] + ny}}},
+		Segment{Op: SegmentOpLineTo, Args: [3]f32.Vec2{{b[0] + nx, b[1] + ny}}},
+		Segment{Op: SegmentOpLineTo, Args: [3]f32.Vec2{{b[0] - nx, b[1] - ny}}},
+		Segment{Op: SegmentOpLineTo, Args: [3]f32.Vec2{{a[0] - nx, a[1] - ny}}},
+	)
+}
+
+// circle adds the circle of radius r centered on c, approximated by cubic
+// Bézier curves and wound clockwise.
+func (s *stroker) circle(c f32.Vec2, r float32) {
+	// k is the distance from an end point to its control point of a cubic
+	// approximation of a quarter circle of radius r.
+	const kappa = 0.5522847498
+	k := kappa * r
+	x, y := c[0], c[1]
+	s.ret = append(s.ret,
+		Segment{Op: SegmentOpMoveTo, Args: [3]f32.Vec2{{x + r, y}}},
+		Segment{Op: SegmentOpCubeTo, Args: [3]f32.Vec2{{x + r, y + k}, {x + k, y + r}, {x, y + r}}},
+		Segment{Op: SegmentOpCubeTo, Args: [3]f32.Vec2{{x - k, y + r}, {x - r, y + k}, {x - r, y}}},
+		Segment{Op: SegmentOpCubeTo, Args: [3]f32.Vec2{{x - r, y - k}, {x - k, y - r}, {x, y - r}}},
+		Segment{Op: SegmentOpCubeTo, Args: [3]f32.Vec2{{x + k, y - r}, {x + r, y - k}, {x + r, y}}},
+	)
+}
@@ -0,0 +1,121 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/draw"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestStrokeDestination(t *testing.T) {
+	// A square, clockwise from its top left corner.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.StartPath(0, -20, -20)
+	e.AbsHLineTo(+20)
+	e.AbsVLineTo(+20)
+	e.AbsHLineTo(-20)
+	e.ClosePathEndPath()
+	square, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	// The graphic is rasterized at 1 pixel per unit. The in and out values
+	// are the left edges of pixels, in graphic coordinate space, just below
+	// the top edge of the square. The dash patterns start at the square's top
+	// left corner, and the top edge's dashes are away from the other edges'
+	// strokes.
+	testCases := []struct {
+		desc    string
+		o       StrokeOptions
+		in, out []float32
+	}{{
+		desc: "solid",
+		o:    StrokeOptions{Width: 4},
+		in:   []float32{-20, -15, -5, 0, 5, 15, 20},
+	}, {
+		desc: "butt dashes",
+		o:    StrokeOptions{Width: 4, Dashes: []float32{10}},
+		in:   []float32{-17, -15, -11, 1, 5, 9},
+		out:  []float32{-9, -5, -1, 11, 15, 17},
+	}, {
+		desc: "square dashes",
+		o:    StrokeOptions{Width: 4, Cap: CapSquare, Dashes: []float32{10}},
+		in:   []float32{-15, -9, -1, 1, 5, 11},
+		out:  []float32{-7, -3, 13, 15},
+	}, {
+		desc: "round dashes",
+		o:    StrokeOptions{Width: 4, Cap: CapRound, Dashes: []float32{10}},
+		in:   []float32{-15, -10, -1, 5, 10},
+		out:  []float32{-7, -3, 13, 15},
+	}, {
+		desc: "offset dashes",
+		o:    StrokeOptions{Width: 4, Dashes: []float32{10, 10}, DashOffset: 15},
+		in:   []float32{-15, -9, -6, 5, 9, 14},
+		out:  []float32{-17, -4, 1, 16},
+	}, {
+		desc: "no width",
+		o:    StrokeOptions{Dashes: []float32{10}},
+		out:  []float32{-15, -5, 0, 5, 15},
+	}}
+
+	for _, tc := range testCases {
+		dst := image.NewAlpha(image.Rect(0, 0, 64, 64))
+		var z Rasterizer
+		z.SetDstImage(dst, dst.Bounds(), draw.Src)
+		if err := Decode(StrokeDestination(&z, tc.o), square, nil); err != nil {
+			t.Errorf("%s: Decode: %v", tc.desc, err)
+			continue
+		}
+		at := func(x, y float32) uint8 {
+			return dst.AlphaAt(int(x+32), int(y+32)).A
+		}
+		for _, x := range tc.in {
+			if a := at(x, -20); a != 0xff {
+				t.Errorf("%s: at (%v, -20): got alpha %#02x, want 0xff", tc.desc, x, a)
+			}
+		}
+		for _, x := range tc.out {
+			if a := at(x, -20); a != 0x00 {
+				t.Errorf("%s: at (%v, -20): got alpha %#02x, want 0x00", tc.desc, x, a)
+			}
+		}
+		if a := at(0, 0); a != 0x00 {
+			t.Errorf("%s: at the center: got alpha %#02x, want 0x00", tc.desc, a)
+		}
+	}
+}
+
+func TestDashPolyline(t *testing.T) {
+	polyline := []f32.Vec2{{0, 0}, {10, 0}, {10, 10}}
+	got := dashPolyline(polyline, []float32{4, 2}, 6, 1)
+	want := [][]f32.Vec2{
+		{{0, 0}, {3, 0}},
+		{{5, 0}, {9, 0}},
+		{{10, 1}, {10, 5}},
+		{{10, 7}, {10, 10}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot  %v\nwant %v", got, want)
+	}
+
+	// A dash can span a vertex.
+	got = dashPolyline(polyline, []float32{12, 2}, 14, 0)
+	want = [][]f32.Vec2{
+		{{0, 0}, {10, 0}, {10, 2}},
+		{{10, 4}, {10, 10}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("spanning a vertex:\ngot  %v\nwant %v", got, want)
+	}
+}
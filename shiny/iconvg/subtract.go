@@ -0,0 +1,246 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"sort"
+
+	"golang.org/x/image/math/f32"
+)
+
+// Subtract returns an IconVG graphic that is base with the region filled by
+// cutter cut out of it, for cut-out effects. Each of base's paths keeps its
+// fill color or gradient, and level of detail, but loses the parts covered by
+// any of cutter's paths that are not fully transparent, whatever their level
+// of detail. The result has base's Metadata.
+//
+// The two graphics' view boxes are reconciled by mapping cutter's ViewBox
+// onto base's, as if both were rendered onto the same image. The options, if
+// non-nil, are used to decode both graphics.
+//
+// Curves are flattened to line segments, to within 1/64th of a unit, so the
+// result's paths are polygons. Each path is decomposed, under IconVG's
+// non-zero winding fill rule, into trapezoids with horizontal tops and
+// bottoms, which are encoded as the path's sub-paths.
+func Subtract(base, cutter []byte, opts *DecodeOptions) ([]byte, error) {
+	b, err := BuildScene(base, opts)
+	if err != nil {
+		return nil, err
+	}
+	c, err := BuildScene(cutter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Map cutter's ViewBox onto base's.
+	bvb, cvb := b.Metadata.ViewBox, c.Metadata.ViewBox
+	bdx, bdy := bvb.AspectRatio()
+	cdx, cdy := cvb.AspectRatio()
+	sx, sy := float32(1), float32(1)
+	if cdx > 0 {
+		sx = bdx / cdx
+	}
+	if cdy > 0 {
+		sy = bdy / cdy
+	}
+	var cutEdges []booleanEdge
+	for i := range c.Paths {
+		p := &c.Paths[i]
+		if p.Gradient == nil && p.Fill.A == 0 {
+			continue
+		}
+		for _, sub := range splitSubpaths(p.Segments) {
+			polygon := flatten(nil, sub, flattenTolerance)
+			for j, q := range polygon {
+				polygon[j] = f32.Vec2{
+					bvb.Min[0] + sx*(q[0]-cvb.Min[0]),
+					bvb.Min[1] + sy*(q[1]-cvb.Min[1]),
+				}
+			}
+			cutEdges = appendBooleanEdges(cutEdges, polygon, 1)
+		}
+	}
+
+	for i := range b.Paths {
+		p := &b.Paths[i]
+		var edges []booleanEdge
+		for _, sub := range splitSubpaths(p.Segments) {
+			edges = appendBooleanEdges(edges, flatten(nil, sub, flattenTolerance), 0)
+		}
+		p.Segments = subtractEdges(append(edges, cutEdges...))
+	}
+
+	e := &viewBoxEncoder{viewBox: bvb}
+	b.Draw(e)
+	return e.Bytes()
+}
+
+// booleanEdge is a non-horizontal edge of a polygon, from p to q, in a
+// boolean operation. Its p is above its q: y increases down.
+type booleanEdge struct {
+	p, q [2]float64
+	// dir is +1 if the polygon's edge goes down, from p to q, and -1 if it
+	// goes up.
+	dir int
+	// operand is 0 for the edges of the region being subtracted from, and 1
+	// for the edges of the region being subtracted.
+	operand int
+}
+
+// x returns the edge's x coordinate at y.
+func (e *booleanEdge) x(y float64) float64 {
+	return e.p[0] + (y-e.p[1])*(e.q[0]-e.p[0])/(e.q[1]-e.p[1])
+}
+
+// appendBooleanEdges appends the non-horizontal edges of the implicitly
+// closed polygon.
+func appendBooleanEdges(edges []booleanEdge, polygon []f32.Vec2, operand int) []booleanEdge {
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		e := booleanEdge{
+			p:       [2]float64{float64(a[0]), float64(a[1])},
+			q:       [2]float64{float64(b[0]), float64(b[1])},
+			dir:     +1,
+			operand: operand,
+		}
+		if e.p[1] == e.q[1] {
+			continue
+		}
+		if e.p[1] > e.q[1] {
+			e.p, e.q, e.dir = e.q, e.p, -1
+		}
+		edges = append(edges, e)
+	}
+	return edges
+}
+
+// subtractEdges returns the sub-paths of trapezoids that cover the region
+// that is inside operand 0's edges and outside operand 1's edges, under the
+// non-zero winding rule.
+//
+// The plane is cut into horizontal slabs at every vertex and every crossing
+// of two edges, so that no edges cross within a slab. Within a slab, the
+// edges are ordered from left to right, and each operand's winding number is
+// tracked from edge to edge. Each run of the slab that is in the result is a
+// trapezoid between two edges, and a trapezoid between the same two edges as
+// one that ends where the slab starts extends that one instead.
+func subtractEdges(edges []booleanEdge) []Segment {
+	var ys []float64
+	for i := range edges {
+		ei := &edges[i]
+		ys = append(ys, ei.p[1], ei.q[1])
+		for j := i + 1; j < len(edges); j++ {
+			if y, ok := crossingY(ei, &edges[j]); ok {
+				ys = append(ys, y)
+			}
+		}
+	}
+	sort.Float64s(ys)
+
+	type trapezoid struct {
+		left, right int
+		y0, y1      float64
+	}
+	var (
+		done, open, next []trapezoid
+		active           booleanEdgesByX
+	)
+	for k := 1; k < len(ys); k++ {
+		y0, y1 := ys[k-1], ys[k]
+		if !(y0 < y1) {
+			continue
+		}
+		mid := (y0 + y1) / 2
+		active.xs, active.indexes = active.xs[:0], active.indexes[:0]
+		for i := range edges {
+			if e := &edges[i]; e.p[1] <= y0 && y1 <= e.q[1] {
+				active.xs = append(active.xs, e.x(mid))
+				active.indexes = append(active.indexes, i)
+			}
+		}
+		sort.Sort(&active)
+
+		next = next[:0]
+		var winding [2]int
+		left := -1
+		for _, i := range active.indexes {
+			e := &edges[i]
+			wasIn := winding[0] != 0 && winding[1] == 0
+			winding[e.operand] += e.dir
+			isIn := winding[0] != 0 && winding[1] == 0
+			if !wasIn && isIn {
+				left = i
+			} else if wasIn && !isIn {
+				t := trapezoid{left: left, right: i, y0: y0, y1: y1}
+				for j, o := range open {
+					if o.left == left && o.right == i && o.y1 == y0 {
+						t.y0 = o.y0
+						open[j].left = -1
+						break
+					}
+				}
+				next = append(next, t)
+			}
+		}
+		for _, o := range open {
+			if o.left >= 0 {
+				done = append(done, o)
+			}
+		}
+		open, next = next, open
+	}
+	done = append(done, open...)
+
+	var segs []Segment
+	for _, t := range done {
+		l, r := &edges[t.left], &edges[t.right]
+		corners := [4]f32.Vec2{
+			{float32(l.x(t.y0)), float32(t.y0)},
+			{float32(r.x(t.y0)), float32(t.y0)},
+			{float32(r.x(t.y1)), float32(t.y1)},
+			{float32(l.x(t.y1)), float32(t.y1)},
+		}
+		segs = append(segs, Segment{Op: SegmentOpMoveTo, Args: [3]f32.Vec2{corners[0]}})
+		for _, c := range corners[1:] {
+			segs = append(segs, Segment{Op: SegmentOpLineTo, Args: [3]f32.Vec2{c}})
+		}
+	}
+	return segs
+}
+
+// crossingY returns the y coordinate at which the edges cross, if they do,
+// strictly between their end points.
+func crossingY(a, b *booleanEdge) (float64, bool) {
+	y0, y1 := a.p[1], a.q[1]
+	if y0 < b.p[1] {
+		y0 = b.p[1]
+	}
+	if y1 > b.q[1] {
+		y1 = b.q[1]
+	}
+	if !(y0 < y1) {
+		return 0, false
+	}
+	// The horizontal distance between the edges varies linearly with y.
+	d0, d1 := a.x(y0)-b.x(y0), a.x(y1)-b.x(y1)
+	if (d0 < 0) == (d1 < 0) || d0 == 0 || d1 == 0 {
+		return 0, false
+	}
+	return y0 + (y1-y0)*d0/(d0-d1), true
+}
+
+// booleanEdgesByX sorts the indexes of the edges active in a slab by their x
+// coordinates at the middle of the slab.
+type booleanEdgesByX struct {
+	xs      []float64
+	indexes []int
+}
+
+func (a *booleanEdgesByX) Len() int           { return len(a.indexes) }
+func (a *booleanEdgesByX) Less(i, j int) bool { return a.xs[i] < a.xs[j] }
+func (a *booleanEdgesByX) Swap(i, j int) {
+	a.xs[i], a.xs[j] = a.xs[j], a.xs[i]
+	a.indexes[i], a.indexes[j] = a.indexes[j], a.indexes[i]
+}
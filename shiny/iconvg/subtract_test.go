@@ -0,0 +1,117 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestSubtract(t *testing.T) {
+	// The base is a red square, from (-20, -20) to (+20, +20), in the
+	// default ViewBox.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+		Comment: "base",
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0xff, 0x00, 0x00, 0xff}))
+	e.StartPath(0, -20, -20)
+	e.AbsHLineTo(+20)
+	e.AbsVLineTo(+20)
+	e.AbsHLineTo(-20)
+	e.ClosePathEndPath()
+	base, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	// The cutter is a square, from (8, 8) to (24, 24), in a ViewBox from
+	// (0, 0) to (32, 32). Mapped onto the base's ViewBox, it is from (-16,
+	// -16) to (+16, +16). It is counter-clockwise, to check that the
+	// winding direction does not matter, and a fully transparent path, which
+	// does not cut, covers everything.
+	e = Encoder{}
+	e.Reset(Metadata{
+		ViewBox: Rectangle{Max: f32.Vec2{32, 32}},
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, 8, 8)
+	e.AbsVLineTo(24)
+	e.AbsHLineTo(24)
+	e.AbsVLineTo(8)
+	e.ClosePathEndPath()
+	e.SetCReg(0, false, RGBAColor(color.RGBA{}))
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(32)
+	e.AbsVLineTo(32)
+	e.AbsHLineTo(0)
+	e.ClosePathEndPath()
+	cutter, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	got, err := Subtract(base, cutter, nil)
+	if err != nil {
+		t.Fatalf("Subtract: %v", err)
+	}
+	m, err := DecodeMetadata(got)
+	if err != nil {
+		t.Fatalf("DecodeMetadata: %v", err)
+	}
+	if m.Comment != "base" {
+		t.Errorf("Comment: got %q, want %q", m.Comment, "base")
+	}
+
+	// Rasterize at 1 pixel per unit, so that pixel (x, y) covers the unit
+	// square whose top left is (x-32, y-32).
+	dst, err := rasterize(got, 64, 64, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	red := color.RGBA{0xff, 0x00, 0x00, 0xff}
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			gx, gy := x-32, y-32
+			inBase := -20 <= gx && gx < 20 && -20 <= gy && gy < 20
+			inCutter := -16 <= gx && gx < 16 && -16 <= gy && gy < 16
+			want := color.RGBA{}
+			if inBase && !inCutter {
+				want = red
+			}
+			if g := dst.RGBAAt(x, y); g != want {
+				t.Fatalf("(%d, %d): got %v, want %v", gx, gy, g, want)
+			}
+		}
+	}
+}
+
+func TestSubtractEdgesCrossing(t *testing.T) {
+	// Two overlapping squares, with a diamond cut out of them. The diamond's
+	// edges cross the squares' edges.
+	var edges []booleanEdge
+	edges = appendBooleanEdges(edges, []f32.Vec2{{0, 0}, {4, 0}, {4, 4}, {0, 4}}, 0)
+	edges = appendBooleanEdges(edges, []f32.Vec2{{2, 2}, {6, 2}, {6, 6}, {2, 6}}, 0)
+	edges = appendBooleanEdges(edges, []f32.Vec2{{3, 1}, {5, 3}, {3, 5}, {1, 3}}, 1)
+
+	area := float32(0)
+	for _, sub := range splitSubpaths(subtractEdges(edges)) {
+		a := signedArea(flatten(nil, sub, flattenTolerance))
+		if a < 0 {
+			t.Fatalf("sub-path %v: negative area %v", sub, a)
+		}
+		area += a
+	}
+	// The squares' union is 16 + 16 - 4 = 28. The diamond's area is 8, all
+	// of which is inside the union.
+	if want := float32(20); abs32(area-want) > 1e-4 {
+		t.Errorf("area: got %v, want %v", area, want)
+	}
+}
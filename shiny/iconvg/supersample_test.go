@@ -0,0 +1,138 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestRasterizeSupersampled(t *testing.T) {
+	// An opaque black square, with its left 1.5 units covered by white. At 4
+	// × 4 pixels, the second column of pixels is half white.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: Rectangle{Max: f32.Vec2{4, 4}},
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(4)
+	e.AbsVLineTo(4)
+	e.AbsHLineTo(0)
+	e.ClosePathEndPath()
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0xff, 0xff, 0xff, 0xff}))
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(1.5)
+	e.AbsVLineTo(4)
+	e.AbsHLineTo(0)
+	e.ClosePathEndPath()
+	src, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	direct, err := rasterize(src, 4, 4, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	got, err := RasterizeSupersampled(src, 4, 4, 4, nil)
+	if err != nil {
+		t.Fatalf("RasterizeSupersampled: %v", err)
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			g, d := got.RGBAAt(x, y), direct.RGBAAt(x, y)
+			if x != 1 {
+				if g != d {
+					t.Errorf("(%d, %d): got %v, want %v", x, y, g, d)
+				}
+				continue
+			}
+			// Averaging in linear light gives a lighter gray than averaging
+			// the sRGB values.
+			want := color.RGBA{0xbc, 0xbc, 0xbc, 0xff}
+			if !closeRGBA(g, want, 1) {
+				t.Errorf("(%d, %d): got %v, want %v", x, y, g, want)
+			}
+			if d.R > 0x88 {
+				t.Errorf("(%d, %d): direct: got %v, want a darker gray", x, y, d)
+			}
+		}
+	}
+
+	// A factor of 1 is the same as rasterizing directly.
+	got, err = RasterizeSupersampled(src, 4, 4, 1, nil)
+	if err != nil {
+		t.Fatalf("RasterizeSupersampled: %v", err)
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if g, d := got.RGBAAt(x, y), direct.RGBAAt(x, y); g != d {
+				t.Errorf("factor 1: (%d, %d): got %v, want %v", x, y, g, d)
+			}
+		}
+	}
+
+	if _, err := RasterizeSupersampled(src, 4, 4, 0, nil); err != errInvalidSupersamplingFactor {
+		t.Errorf("factor 0: got %v, want %v", err, errInvalidSupersamplingFactor)
+	}
+}
+
+func TestDownscaleLinearTransparent(t *testing.T) {
+	// Half of the samples are transparent, and half are opaque red. The
+	// transparent ones halve the alpha, but do not darken the color.
+	got, err := RasterizeSupersampled(halfRedGraphic(t), 1, 1, 2, nil)
+	if err != nil {
+		t.Fatalf("RasterizeSupersampled: %v", err)
+	}
+	if got, want := got.RGBAAt(0, 0), (color.RGBA{0x80, 0x00, 0x00, 0x80}); !closeRGBA(got, want, 1) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// halfRedGraphic returns a 2 × 2 graphic whose left half is opaque red.
+func halfRedGraphic(t *testing.T) []byte {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: Rectangle{Max: f32.Vec2{2, 2}},
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0xff, 0x00, 0x00, 0xff}))
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(1)
+	e.AbsVLineTo(2)
+	e.AbsHLineTo(0)
+	e.ClosePathEndPath()
+	src, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	return src
+}
+
+func benchmarkRasterizeSupersampled(b *testing.B, factor int) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/cowbell.ivg"))
+	if err != nil {
+		b.Fatalf("ReadFile: %v", err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := RasterizeSupersampled(ivgData, 64, 64, factor, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRasterizeSupersampled1 is direct rendering, the baseline for the
+// other factors.
+func BenchmarkRasterizeSupersampled1(b *testing.B) { benchmarkRasterizeSupersampled(b, 1) }
+func BenchmarkRasterizeSupersampled2(b *testing.B) { benchmarkRasterizeSupersampled(b, 2) }
+func BenchmarkRasterizeSupersampled4(b *testing.B) { benchmarkRasterizeSupersampled(b, 4) }
+func BenchmarkRasterizeSupersampled8(b *testing.B) { benchmarkRasterizeSupersampled(b, 8) }
@@ -0,0 +1,264 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+
+	"golang.org/x/image/math/f32"
+)
+
+// SVGEncoder is a Destination that converts an IconVG graphic to SVG.
+//
+// Flat colors become fill attributes. Gradients become linearGradient and
+// radialGradient elements, inside a defs element, that the paths' fill
+// attributes refer to. SVG has no equivalent of GradientSpreadNone, so it is
+// approximated by a spreadMethod of "pad". Arcs are approximated by cubic
+// Bézier curves.
+type SVGEncoder struct {
+	// Height is the height, in pixels, that the SVG is intended to be
+	// rendered at. SVG has no concept of level of detail, so paths are
+	// selected as if the IconVG graphic was rendered at that height.
+	//
+	// If zero, it is the height of the ViewBox.
+	Height float32
+
+	segmenter
+
+	defs      []byte
+	body      []byte
+	gradients map[string]string
+	visible   bool
+	subpath   bool
+}
+
+// Bytes returns the SVG.
+func (e *SVGEncoder) Bytes() []byte {
+	vb := &e.metadata.ViewBox
+	dx, dy := vb.AspectRatio()
+	b := []byte(fmt.Sprintf("<svg xmlns=\"http://www.w3.org/2000/svg\" viewBox=\"%s %s %s %s\">\n",
+		svgNumber(vb.Min[0]), svgNumber(vb.Min[1]), svgNumber(dx), svgNumber(dy)))
+	if len(e.defs) > 0 {
+		b = append(b, "<defs>\n"...)
+		b = append(b, e.defs...)
+		b = append(b, "</defs>\n"...)
+	}
+	b = append(b, e.body...)
+	b = append(b, "</svg>\n"...)
+	return b
+}
+
+// Reset resets the SVGEncoder for the given Metadata.
+func (e *SVGEncoder) Reset(m Metadata) {
+	e.segmenter.reset(m, e)
+	e.defs = e.defs[:0]
+	e.body = e.body[:0]
+	e.gradients = nil
+	e.visible = false
+}
+
+func (e *SVGEncoder) height() float32 {
+	if e.Height > 0 {
+		return e.Height
+	}
+	_, dy := e.metadata.ViewBox.AspectRatio()
+	return dy
+}
+
+func (e *SVGEncoder) beginPath() {
+	h := e.height()
+	e.visible = false
+	if !(e.lod0 <= h && h < e.lod1) {
+		return
+	}
+
+	fill := ""
+	if validAlphaPremulColor(e.fill) {
+		if e.fill.A == 0 {
+			return
+		}
+		fill = svgFill(e.fill)
+	} else if e.fill.A == 0x00 && e.fill.B&0x80 != 0 {
+		id := e.gradient(e.fill)
+		if id == "" {
+			return
+		}
+		fill = fmt.Sprintf("fill=\"url(#%s)\"", id)
+	} else {
+		return
+	}
+	e.visible = true
+	e.subpath = false
+	e.body = append(e.body, "<path "...)
+	e.body = append(e.body, fill...)
+	e.body = append(e.body, " d=\""...)
+}
+
+func (e *SVGEncoder) addSegment(s Segment) {
+	if !e.visible {
+		return
+	}
+	switch s.Op {
+	case SegmentOpMoveTo:
+		if e.subpath {
+			e.body = append(e.body, "Z "...)
+		}
+		e.subpath = true
+		e.body = appendSVGPoints(append(e.body, 'M'), s.Args[:1])
+	case SegmentOpLineTo:
+		e.body = appendSVGPoints(append(e.body, 'L'), s.Args[:1])
+	case SegmentOpQuadTo:
+		e.body = appendSVGPoints(append(e.body, 'Q'), s.Args[:2])
+	case SegmentOpCubeTo:
+		e.body = appendSVGPoints(append(e.body, 'C'), s.Args[:3])
+	}
+}
+
+func (e *SVGEncoder) endPath() {
+	if !e.visible {
+		return
+	}
+	e.body = append(e.body, "Z\"/>\n"...)
+	e.visible = false
+}
+
+// gradient adds the gradient encoded by rgba, and the CREG and NREG registers,
+// to e's defs, if an identical gradient was not already added, and returns its
+// id. It returns "" if the gradient is invalid.
+func (e *SVGEncoder) gradient(rgba color.RGBA) string {
+	nStops := int(rgba.R & 0x3f)
+	cBase := int(rgba.G & 0x3f)
+	nBase := int(rgba.B & 0x3f)
+	radial := (rgba.B>>6)&0x01 != 0
+
+	spread := "pad"
+	switch GradientSpread(rgba.G >> 6) {
+	case GradientSpreadReflect:
+		spread = "reflect"
+	case GradientSpreadRepeat:
+		spread = "repeat"
+	}
+
+	// The affine transformation matrix, stored in 6 contiguous NREG
+	// registers, goes from graphic coordinate space to gradient coordinate
+	// space. SVG's gradientTransform goes the other way.
+	var m [6]float64
+	for i := range m {
+		m[i] = float64(e.nReg[(nBase-6+i)&0x3f])
+	}
+
+	// The element is built without its id, which is then used to look for
+	// duplicates.
+	var elem, attrs string
+	if radial {
+		det := m[0]*m[4] - m[1]*m[3]
+		if det == 0 {
+			return ""
+		}
+		ia, ib := +m[4]/det, -m[1]/det
+		id, ie := -m[3]/det, +m[0]/det
+		ic := -ia*m[2] - ib*m[5]
+		iff := -id*m[2] - ie*m[5]
+		elem = "radialGradient"
+		attrs = fmt.Sprintf("gradientUnits=\"userSpaceOnUse\" cx=\"0\" cy=\"0\" r=\"1\" "+
+			"gradientTransform=\"matrix(%s %s %s %s %s %s)\" spreadMethod=\"%s\"",
+			svgNumber64(ia), svgNumber64(id), svgNumber64(ib), svgNumber64(ie), svgNumber64(ic), svgNumber64(iff), spread)
+	} else {
+		// The offset is a*x + b*y + c, which is 0 and 1 at these two points
+		// on the line through the origin parallel to (a, b). The offset is
+		// constant along lines perpendicular to that one.
+		d := m[0]*m[0] + m[1]*m[1]
+		if d == 0 {
+			return ""
+		}
+		ux, uy := m[0]/d, m[1]/d
+		elem = "linearGradient"
+		attrs = fmt.Sprintf("gradientUnits=\"userSpaceOnUse\" x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\" "+
+			"spreadMethod=\"%s\"",
+			svgNumber64(-m[2]*ux), svgNumber64(-m[2]*uy), svgNumber64((1-m[2])*ux), svgNumber64((1-m[2])*uy), spread)
+	}
+
+	stops := ""
+	prevN := negativeInfinity
+	for i := 0; i < nStops; i++ {
+		c := e.cReg[(cBase+i)&0x3f]
+		if !validAlphaPremulColor(c) {
+			return ""
+		}
+		n := e.nReg[(nBase+i)&0x3f]
+		if !(0 <= n && n <= 1) || !(n > prevN) {
+			return ""
+		}
+		prevN = n
+		stops += fmt.Sprintf("<stop offset=\"%s\" %s/>\n", svgNumber(n), svgStopColor(c))
+	}
+
+	key := elem + " " + attrs + "\n" + stops
+	if id, ok := e.gradients[key]; ok {
+		return id
+	}
+	if e.gradients == nil {
+		e.gradients = map[string]string{}
+	}
+	id := "g" + strconv.Itoa(len(e.gradients))
+	e.gradients[key] = id
+	e.defs = append(e.defs, fmt.Sprintf("<%s id=\"%s\" %s>\n%s</%s>\n", elem, id, attrs, stops, elem)...)
+	return id
+}
+
+// svgFill returns the fill attributes for the alpha-premultiplied color c.
+func svgFill(c color.RGBA) string {
+	s := "fill=\"" + svgColor(c) + "\""
+	if c.A != 0xff {
+		s += " fill-opacity=\"" + strconv.FormatFloat(float64(c.A)/0xff, 'g', 4, 64) + "\""
+	}
+	return s
+}
+
+// svgStopColor returns the stop-color attributes for the alpha-premultiplied
+// color c.
+func svgStopColor(c color.RGBA) string {
+	s := "stop-color=\"" + svgColor(c) + "\""
+	if c.A != 0xff {
+		s += " stop-opacity=\"" + strconv.FormatFloat(float64(c.A)/0xff, 'g', 4, 64) + "\""
+	}
+	return s
+}
+
+// svgColor returns the #rrggbb form of the alpha-premultiplied color c, which
+// should not be fully transparent.
+func svgColor(c color.RGBA) string {
+	if c.A == 0 {
+		return "#000000"
+	}
+	a := uint32(c.A)
+	return fmt.Sprintf("#%02x%02x%02x", uint32(c.R)*0xff/a, uint32(c.G)*0xff/a, uint32(c.B)*0xff/a)
+}
+
+func svgNumber(f float32) string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
+
+func svgNumber64(f float64) string {
+	if f == 0 {
+		// Avoid printing negative zero.
+		f = 0
+	}
+	return strconv.FormatFloat(float64(float32(f)), 'g', -1, 32)
+}
+
+func appendSVGPoints(b []byte, points []f32.Vec2) []byte {
+	for i, p := range points {
+		if i != 0 {
+			b = append(b, ' ')
+		}
+		b = strconv.AppendFloat(b, float64(p[0]), 'g', -1, 32)
+		b = append(b, ' ')
+		b = strconv.AppendFloat(b, float64(p[1]), 'g', -1, 32)
+	}
+	return append(b, ' ')
+}
@@ -0,0 +1,97 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSVGEncoder(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/action-info.lores.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var e SVGEncoder
+	if err := Decode(&e, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	got := string(e.Bytes())
+	want := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="-24 -24 48 48">
+<path fill="#000000" d="M0 -20 ` +
+		`C-11.046875 -20 -20 -11.046875 -20 0 C-20 11.046875 -11.046875 20 0 20 ` +
+		`C11.046875 20 20 11.046875 20 0 C20 -11.046875 11.046875 -20 0 -20 Z ` +
+		`M2 10 L-2 10 L-2 -2 L2 -2 L2 10 Z M2 -6 L-2 -6 L-2 -10 L2 -10 L2 -6 Z"/>
+</svg>
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSVGEncoderGradients(t *testing.T) {
+	stops := []GradientStop{
+		{Offset: 0, Color: color.RGBA{0xff, 0x00, 0x00, 0xff}},
+		{Offset: 0.5, Color: color.RGBA{0x00, 0x00, 0x40, 0x80}},
+		{Offset: 1, Color: color.RGBA{0x00, 0xff, 0x00, 0xff}},
+	}
+	var enc Encoder
+	enc.Reset(Metadata{ViewBox: DefaultViewBox, Palette: DefaultPalette})
+	enc.SetLinearGradient(10, 10, -20, 4, 12, 4, GradientSpreadNone, stops)
+	enc.StartPath(0, -30, -30)
+	enc.AbsHLineTo(30)
+	enc.AbsVLineTo(-10)
+	enc.ClosePathEndPath()
+	// A second path with the same gradient should share its definition.
+	enc.StartPath(0, -30, 0)
+	enc.AbsHLineTo(30)
+	enc.AbsVLineTo(10)
+	enc.ClosePathEndPath()
+	enc.SetCircularGradient(10, 10, 8, 16, 0, 4, GradientSpreadReflect, stops)
+	enc.StartPath(0, -30, 20)
+	enc.AbsHLineTo(30)
+	enc.AbsVLineTo(30)
+	enc.ClosePathEndPath()
+	ivgData, err := enc.Bytes()
+	if err != nil {
+		t.Fatalf("Encoder.Bytes: %v", err)
+	}
+
+	var e SVGEncoder
+	if err := Decode(&e, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	got := e.Bytes()
+
+	// The linear gradient is horizontal, so its y1 and y2 could be any equal
+	// values.
+	for _, want := range []string{
+		`<linearGradient id="g0" gradientUnits="userSpaceOnUse" x1="-20" y1="0" x2="12" y2="0" spreadMethod="pad">
+<stop offset="0" stop-color="#ff0000"/>
+<stop offset="0.5" stop-color="#00007f" stop-opacity="0.502"/>
+<stop offset="1" stop-color="#00ff00"/>
+</linearGradient>
+`,
+		`<radialGradient id="g1" gradientUnits="userSpaceOnUse" cx="0" cy="0" r="1" ` +
+			`gradientTransform="matrix(4 0 0 4 8 16)" spreadMethod="reflect">`,
+	} {
+		if !bytes.Contains(got, []byte(want)) {
+			t.Errorf("output does not contain\n%s\ngot:\n%s", want, got)
+		}
+	}
+	if n := strings.Count(string(got), `fill="url(#g0)"`); n != 2 {
+		t.Errorf("got %d references to g0, want 2", n)
+	}
+	if n := strings.Count(string(got), `fill="url(#g1)"`); n != 1 {
+		t.Errorf("got %d references to g1, want 1", n)
+	}
+	if n := strings.Count(string(got), `Gradient id=`); n != 2 {
+		t.Errorf("got %d gradient definitions, want 2", n)
+	}
+}
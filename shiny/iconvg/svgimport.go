@@ -0,0 +1,428 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"encoding/xml"
+	"errors"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/math/f32"
+)
+
+var errUnsupportedSVG = errors.New("iconvg: unsupported SVG")
+
+// IconVGToSVGToIconVG converts an IconVG graphic to SVG, with an SVGEncoder,
+// and then back to IconVG, and reports whether the result draws the same
+// paths as the original: the same segments, in graphic coordinate space, the
+// same flat colors, allowing for the rounding of SVG's non-premultiplied
+// colors, and the same gradients.
+//
+// Only the paths that the SVGEncoder selects, by their level of detail, are
+// compared. A difference means that the SVG conversion lost fidelity. For
+// example, SVG has no equivalent of GradientSpreadNone.
+func IconVGToSVGToIconVG(src []byte) (equal bool, err error) {
+	var want roundTripRecorder
+	if err := Decode(&want, src, nil); err != nil {
+		return false, err
+	}
+	var s SVGEncoder
+	if err := Decode(&s, src, nil); err != nil {
+		return false, err
+	}
+	dst, err := importSVG(s.Bytes())
+	if err != nil {
+		return false, err
+	}
+	var got roundTripRecorder
+	if err := Decode(&got, dst, nil); err != nil {
+		return false, err
+	}
+
+	if got.metadata.ViewBox != want.metadata.ViewBox || len(got.paths) != len(want.paths) {
+		return false, nil
+	}
+	for i := range want.paths {
+		if !got.paths[i].equal(&want.paths[i]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// roundTripPath is a path recorded by a roundTripRecorder.
+type roundTripPath struct {
+	// fill is the flat fill color, if gradient is nil.
+	fill     color.RGBA
+	gradient *roundTripGradient
+	segs     []Segment
+}
+
+// roundTripGradient is a gradient, resolved from the CREG and NREG registers.
+type roundTripGradient struct {
+	radial bool
+	spread GradientSpread
+	matrix [6]float32
+	stops  []GradientStop
+}
+
+func (p *roundTripPath) equal(q *roundTripPath) bool {
+	if (p.gradient == nil) != (q.gradient == nil) || len(p.segs) != len(q.segs) {
+		return false
+	}
+	for i := range p.segs {
+		if p.segs[i].Op != q.segs[i].Op {
+			return false
+		}
+		for j, a := range p.segs[i].Args {
+			b := q.segs[i].Args[j]
+			if !closeFloat32(a[0], b[0]) || !closeFloat32(a[1], b[1]) {
+				return false
+			}
+		}
+	}
+	if p.gradient == nil {
+		return closeColor(p.fill, q.fill)
+	}
+
+	g, h := p.gradient, q.gradient
+	if g.radial != h.radial || g.spread != h.spread || len(g.stops) != len(h.stops) {
+		return false
+	}
+	// A linear gradient only depends on the matrix's first row.
+	n := 3
+	if g.radial {
+		n = 6
+	}
+	for i := 0; i < n; i++ {
+		if !closeFloat32(g.matrix[i], h.matrix[i]) {
+			return false
+		}
+	}
+	for i := range g.stops {
+		if g.stops[i].Offset != h.stops[i].Offset ||
+			!closeColor(g.stops[i].Color.(color.RGBA), h.stops[i].Color.(color.RGBA)) {
+			return false
+		}
+	}
+	return true
+}
+
+// closeFloat32 returns whether a and b are equal, allowing for floating point
+// error from converting to and from decimal, and inverting matrices.
+func closeFloat32(a, b float32) bool {
+	d := math.Abs(float64(a) - float64(b))
+	return d <= 1e-4 || d <= 1e-4*math.Max(math.Abs(float64(a)), math.Abs(float64(b)))
+}
+
+// closeColor returns whether the alpha-premultiplied colors c0 and c1 differ
+// by at most 1 in each channel.
+func closeColor(c0, c1 color.RGBA) bool {
+	close := func(x, y uint8) bool { return x-y <= 1 || y-x <= 1 }
+	return close(c0.R, c1.R) && close(c0.G, c1.G) && close(c0.B, c1.B) && close(c0.A, c1.A)
+}
+
+// roundTripRecorder is a Destination that records the paths that an
+// SVGEncoder, with a zero Height, would convert.
+type roundTripRecorder struct {
+	segmenter
+	paths   []roundTripPath
+	visible bool
+}
+
+func (r *roundTripRecorder) Reset(m Metadata) {
+	r.segmenter.reset(m, r)
+	r.paths = nil
+}
+
+func (r *roundTripRecorder) beginPath() {
+	_, h := r.metadata.ViewBox.AspectRatio()
+	r.visible = r.lod0 <= h && h < r.lod1 && (r.fill.A != 0 || r.fill.B&0x80 != 0)
+	if !r.visible {
+		return
+	}
+	p := roundTripPath{fill: r.fill}
+	if r.fill.A == 0 {
+		nStops := int(r.fill.R & 0x3f)
+		cBase := int(r.fill.G & 0x3f)
+		nBase := int(r.fill.B & 0x3f)
+		g := &roundTripGradient{
+			radial: (r.fill.B>>6)&0x01 != 0,
+			spread: GradientSpread(r.fill.G >> 6),
+		}
+		for i := range g.matrix {
+			g.matrix[i] = r.nReg[(nBase-6+i)&0x3f]
+		}
+		for i := 0; i < nStops; i++ {
+			g.stops = append(g.stops, GradientStop{
+				Offset: r.nReg[(nBase+i)&0x3f],
+				Color:  r.cReg[(cBase+i)&0x3f],
+			})
+		}
+		p.gradient = g
+	}
+	r.paths = append(r.paths, p)
+}
+
+func (r *roundTripRecorder) addSegment(s Segment) {
+	if r.visible {
+		p := &r.paths[len(r.paths)-1]
+		p.segs = append(p.segs, s)
+	}
+}
+
+func (r *roundTripRecorder) endPath() {}
+
+// svgDocument is the subset of SVG that an SVGEncoder produces.
+type svgDocument struct {
+	ViewBox string `xml:"viewBox,attr"`
+	Defs    struct {
+		LinearGradients []svgGradient `xml:"linearGradient"`
+		RadialGradients []svgGradient `xml:"radialGradient"`
+	} `xml:"defs"`
+	Paths []struct {
+		Fill        string `xml:"fill,attr"`
+		FillOpacity string `xml:"fill-opacity,attr"`
+		D           string `xml:"d,attr"`
+	} `xml:"path"`
+}
+
+type svgGradient struct {
+	ID                string `xml:"id,attr"`
+	X1                string `xml:"x1,attr"`
+	Y1                string `xml:"y1,attr"`
+	X2                string `xml:"x2,attr"`
+	Y2                string `xml:"y2,attr"`
+	GradientTransform string `xml:"gradientTransform,attr"`
+	SpreadMethod      string `xml:"spreadMethod,attr"`
+	Stops             []struct {
+		Offset      string `xml:"offset,attr"`
+		StopColor   string `xml:"stop-color,attr"`
+		StopOpacity string `xml:"stop-opacity,attr"`
+	} `xml:"stop"`
+}
+
+// importSVG converts SVG, as produced by an SVGEncoder, to IconVG. It returns
+// an error for any other SVG.
+func importSVG(src []byte) ([]byte, error) {
+	var doc svgDocument
+	if err := xml.Unmarshal(src, &doc); err != nil {
+		return nil, err
+	}
+	vb, err := parseSVGNumbers(doc.ViewBox, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	gradients := map[string]*svgGradient{}
+	for i := range doc.Defs.LinearGradients {
+		g := &doc.Defs.LinearGradients[i]
+		gradients["url(#"+g.ID+")"] = g
+	}
+	for i := range doc.Defs.RadialGradients {
+		g := &doc.Defs.RadialGradients[i]
+		g.GradientTransform = strings.TrimSpace(g.GradientTransform)
+		gradients["url(#"+g.ID+")"] = g
+	}
+
+	e := &Encoder{}
+	e.Reset(Metadata{
+		ViewBox: Rectangle{
+			Min: f32.Vec2{vb[0], vb[1]},
+			Max: f32.Vec2{vb[0] + vb[2], vb[1] + vb[3]},
+		},
+		Palette: DefaultPalette,
+	})
+	e.HighResolutionCoordinates = true
+	for _, p := range doc.Paths {
+		if g := gradients[p.Fill]; g != nil {
+			if err := setSVGGradient(e, g); err != nil {
+				return nil, err
+			}
+		} else {
+			c, err := parseSVGColor(p.Fill, p.FillOpacity)
+			if err != nil {
+				return nil, err
+			}
+			e.SetCReg(0, false, RGBAColor(c))
+		}
+		if err := drawSVGPathData(e, p.D); err != nil {
+			return nil, err
+		}
+	}
+	return e.Bytes()
+}
+
+// setSVGGradient sets e's CREG[CSEL] to the gradient g. The gradient's stops
+// and matrix are stored starting at CREG[1] and NREG[0].
+func setSVGGradient(e *Encoder, g *svgGradient) error {
+	spread := GradientSpreadPad
+	switch g.SpreadMethod {
+	case "reflect":
+		spread = GradientSpreadReflect
+	case "repeat":
+		spread = GradientSpreadRepeat
+	}
+	stops := make([]GradientStop, len(g.Stops))
+	for i, s := range g.Stops {
+		offset, err := strconv.ParseFloat(s.Offset, 32)
+		if err != nil {
+			return err
+		}
+		c, err := parseSVGColor(s.StopColor, s.StopOpacity)
+		if err != nil {
+			return err
+		}
+		stops[i] = GradientStop{Offset: float32(offset), Color: c}
+	}
+
+	if g.GradientTransform == "" {
+		x, err := parseSVGNumbers(g.X1+" "+g.Y1+" "+g.X2+" "+g.Y2, 4)
+		if err != nil {
+			return err
+		}
+		e.SetLinearGradient(1, 6, x[0], x[1], x[2], x[3], spread, stops)
+		return nil
+	}
+
+	// The gradientTransform goes from gradient coordinate space to graphic
+	// coordinate space. IconVG's matrix goes the other way.
+	s := g.GradientTransform
+	if !strings.HasPrefix(s, "matrix(") || !strings.HasSuffix(s, ")") {
+		return errUnsupportedSVG
+	}
+	m, err := parseSVGNumbers(s[len("matrix("):len(s)-1], 6)
+	if err != nil {
+		return err
+	}
+	a, d, b, ee, c, f := float64(m[0]), float64(m[1]), float64(m[2]), float64(m[3]), float64(m[4]), float64(m[5])
+	det := a*ee - b*d
+	if det == 0 {
+		return errUnsupportedSVG
+	}
+	ia, ib := +ee/det, -b/det
+	id, ie := -d/det, +a/det
+	e.SetGradient(1, 6, true, f32.Aff3{
+		float32(ia), float32(ib), float32(-ia*c - ib*f),
+		float32(id), float32(ie), float32(-id*c - ie*f),
+	}, spread, stops)
+	return nil
+}
+
+// drawSVGPathData draws the SVG path data, which may only contain absolute
+// M, L, Q, C and Z commands, each of which is followed by its coordinates.
+// Every sub-path must be closed.
+func drawSVGPathData(e *Encoder, d string) error {
+	started, closed := false, false
+	var x [6]float32
+	fields := strings.Fields(d)
+	for len(fields) > 0 {
+		f := fields[0]
+		fields = fields[1:]
+		n := 0
+		switch f[0] {
+		case 'M', 'L':
+			n = 2
+		case 'Q':
+			n = 4
+		case 'C':
+			n = 6
+		case 'Z':
+			if f != "Z" {
+				return errUnsupportedSVG
+			}
+		default:
+			return errUnsupportedSVG
+		}
+		op := f[0]
+		if n > 0 {
+			if f = f[1:]; f != "" {
+				fields = append([]string{f}, fields...)
+			}
+			if len(fields) < n {
+				return errUnsupportedSVG
+			}
+			for i := 0; i < n; i++ {
+				v, err := strconv.ParseFloat(fields[i], 32)
+				if err != nil {
+					return err
+				}
+				x[i] = float32(v)
+			}
+			fields = fields[n:]
+		}
+
+		if (op == 'M') != (!started || closed) {
+			return errUnsupportedSVG
+		}
+		switch op {
+		case 'M':
+			if !started {
+				e.StartPath(0, x[0], x[1])
+			} else {
+				e.ClosePathAbsMoveTo(x[0], x[1])
+			}
+			started, closed = true, false
+		case 'L':
+			e.AbsLineTo(x[0], x[1])
+		case 'Q':
+			e.AbsQuadTo(x[0], x[1], x[2], x[3])
+		case 'C':
+			e.AbsCubeTo(x[0], x[1], x[2], x[3], x[4], x[5])
+		case 'Z':
+			closed = true
+		}
+	}
+	if !started || !closed {
+		return errUnsupportedSVG
+	}
+	e.ClosePathEndPath()
+	return nil
+}
+
+// parseSVGColor parses an SVGEncoder's #rrggbb color and optional opacity as
+// an alpha-premultiplied color.
+func parseSVGColor(s, opacity string) (color.RGBA, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, errUnsupportedSVG
+	}
+	rgb, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	a := uint32(0xff)
+	if opacity != "" {
+		o, err := strconv.ParseFloat(opacity, 64)
+		if err != nil {
+			return color.RGBA{}, err
+		}
+		if !(0 <= o && o <= 1) {
+			return color.RGBA{}, errUnsupportedSVG
+		}
+		a = uint32(o*0xff + 0.5)
+	}
+	premul := func(x uint64) uint8 { return uint8((uint32(x&0xff)*a + 0x7f) / 0xff) }
+	return color.RGBA{premul(rgb >> 16), premul(rgb >> 8), premul(rgb), uint8(a)}, nil
+}
+
+// parseSVGNumbers parses exactly n space separated numbers.
+func parseSVGNumbers(s string, n int) ([]float32, error) {
+	fields := strings.Fields(s)
+	if len(fields) != n {
+		return nil, errUnsupportedSVG
+	}
+	ret := make([]float32, n)
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 32)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = float32(v)
+	}
+	return ret, nil
+}
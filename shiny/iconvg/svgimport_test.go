@@ -0,0 +1,87 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestIconVGToSVGToIconVG(t *testing.T) {
+	for _, tc := range testdataTestCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		equal, err := IconVGToSVGToIconVG(ivgData)
+		if err != nil {
+			t.Errorf("%s: IconVGToSVGToIconVG: %v", tc.filename, err)
+			continue
+		}
+		// The gradient test case's first gradient uses GradientSpreadNone,
+		// which SVG does not have.
+		if want := tc.filename != "testdata/gradient"; equal != want {
+			t.Errorf("%s: got %t, want %t", tc.filename, equal, want)
+		}
+	}
+}
+
+func TestIconVGToSVGToIconVGGradients(t *testing.T) {
+	stops := []GradientStop{
+		{Offset: 0, Color: color.RGBA{0x80, 0x00, 0x00, 0x80}},
+		{Offset: 0.5, Color: color.RGBA{0x00, 0xff, 0x00, 0xff}},
+		{Offset: 1, Color: color.RGBA{0x00, 0x00, 0x00, 0x00}},
+	}
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetLinearGradient(10, 10, -20, -20, 20, 0, GradientSpreadPad, stops)
+	e.StartPath(0, -30, -30)
+	e.AbsHLineTo(0)
+	e.AbsVLineTo(0)
+	e.ClosePathEndPath()
+	e.SetEllipticalGradient(20, 30, 10, 10, 8, 0, 0, 4, GradientSpreadReflect, stops)
+	e.StartPath(0, 0, 0)
+	e.AbsArcTo(10, 10, 0, false, true, 20, 20)
+	e.AbsQuadTo(30, 0, 20, 0)
+	e.ClosePathEndPath()
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x20, 0x30, 0x40, 0x60}))
+	e.StartPath(0, 30, 30)
+	e.RelLineTo(-2, 1)
+	e.ClosePathRelMoveTo(-4, 0)
+	e.RelLineTo(-2, 1)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	equal, err := IconVGToSVGToIconVG(ivgData)
+	if err != nil {
+		t.Fatalf("IconVGToSVGToIconVG: %v", err)
+	}
+	if !equal {
+		t.Errorf("got false, want true")
+	}
+}
+
+func TestImportSVGUnsupported(t *testing.T) {
+	testCases := []string{
+		`<svg viewBox="0 0 10 10"><path fill="red" d="M0 0 L10 0 Z"/></svg>`,
+		`<svg viewBox="0 0 10 10"><path fill="#ff0000" d="M0 0 l10 0 Z"/></svg>`,
+		`<svg viewBox="0 0 10 10"><path fill="#ff0000" d="M0 0 L10 0"/></svg>`,
+		`<svg viewBox="0 0 10"></svg>`,
+	}
+	for _, tc := range testCases {
+		if _, err := importSVG([]byte(tc)); err == nil {
+			t.Errorf("%s: got nil error, want non-nil", tc)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+// aborter is an optional interface that a Destination can implement to abort
+// decoding. Decode calls abortErr after each opcode, returning any non-nil
+// error.
+type aborter interface {
+	abortErr() error
+}
+
+// finisher is an optional interface that a Destination can implement to be
+// told that decoding has successfully reached the end of the graphic.
+type finisher interface {
+	finish()
+}
+
+// autoCloser is an optional interface that a Destination can implement to be
+// told that the graphic ended in the middle of a path, which is about to be
+// closed because of the AutoClosePaths decode option.
+type autoCloser interface {
+	autoClosePath()
+}
+
+// teeDestination is a Destination that forwards each method call to two other
+// Destinations, in order. Either may be nil.
+type teeDestination struct {
+	d0, d1 Destination
+}
+
+func (t *teeDestination) abortErr() error {
+	for _, d := range [2]Destination{t.d0, t.d1} {
+		if a, ok := d.(aborter); ok {
+			if err := a.abortErr(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *teeDestination) finish() {
+	for _, d := range [2]Destination{t.d0, t.d1} {
+		if f, ok := d.(finisher); ok {
+			f.finish()
+		}
+	}
+}
+
+func (t *teeDestination) autoClosePath() {
+	for _, d := range [2]Destination{t.d0, t.d1} {
+		if c, ok := d.(autoCloser); ok {
+			c.autoClosePath()
+		}
+	}
+}
+
+func (t *teeDestination) Reset(m Metadata) {
+	if t.d0 != nil {
+		t.d0.Reset(m)
+	}
+	if t.d1 != nil {
+		t.d1.Reset(m)
+	}
+}
+
+func (t *teeDestination) SetCSel(cSel uint8) {
+	if t.d0 != nil {
+		t.d0.SetCSel(cSel)
+	}
+	if t.d1 != nil {
+		t.d1.SetCSel(cSel)
+	}
+}
+
+func (t *teeDestination) SetNSel(nSel uint8) {
+	if t.d0 != nil {
+		t.d0.SetNSel(nSel)
+	}
+	if t.d1 != nil {
+		t.d1.SetNSel(nSel)
+	}
+}
+
+func (t *teeDestination) SetCReg(adj uint8, incr bool, c Color) {
+	if t.d0 != nil {
+		t.d0.SetCReg(adj, incr, c)
+	}
+	if t.d1 != nil {
+		t.d1.SetCReg(adj, incr, c)
+	}
+}
+
+func (t *teeDestination) SetNReg(adj uint8, incr bool, f float32) {
+	if t.d0 != nil {
+		t.d0.SetNReg(adj, incr, f)
+	}
+	if t.d1 != nil {
+		t.d1.SetNReg(adj, incr, f)
+	}
+}
+
+func (t *teeDestination) SetLOD(lod0, lod1 float32) {
+	if t.d0 != nil {
+		t.d0.SetLOD(lod0, lod1)
+	}
+	if t.d1 != nil {
+		t.d1.SetLOD(lod0, lod1)
+	}
+}
+
+func (t *teeDestination) StartPath(adj uint8, x, y float32) {
+	if t.d0 != nil {
+		t.d0.StartPath(adj, x, y)
+	}
+	if t.d1 != nil {
+		t.d1.StartPath(adj, x, y)
+	}
+}
+
+func (t *teeDestination) ClosePathEndPath() {
+	if t.d0 != nil {
+		t.d0.ClosePathEndPath()
+	}
+	if t.d1 != nil {
+		t.d1.ClosePathEndPath()
+	}
+}
+
+func (t *teeDestination) ClosePathAbsMoveTo(x, y float32) {
+	if t.d0 != nil {
+		t.d0.ClosePathAbsMoveTo(x, y)
+	}
+	if t.d1 != nil {
+		t.d1.ClosePathAbsMoveTo(x, y)
+	}
+}
+
+func (t *teeDestination) ClosePathRelMoveTo(x, y float32) {
+	if t.d0 != nil {
+		t.d0.ClosePathRelMoveTo(x, y)
+	}
+	if t.d1 != nil {
+		t.d1.ClosePathRelMoveTo(x, y)
+	}
+}
+
+func (t *teeDestination) AbsHLineTo(x float32) {
+	if t.d0 != nil {
+		t.d0.AbsHLineTo(x)
+	}
+	if t.d1 != nil {
+		t.d1.AbsHLineTo(x)
+	}
+}
+
+func (t *teeDestination) RelHLineTo(x float32) {
+	if t.d0 != nil {
+		t.d0.RelHLineTo(x)
+	}
+	if t.d1 != nil {
+		t.d1.RelHLineTo(x)
+	}
+}
+
+func (t *teeDestination) AbsVLineTo(y float32) {
+	if t.d0 != nil {
+		t.d0.AbsVLineTo(y)
+	}
+	if t.d1 != nil {
+		t.d1.AbsVLineTo(y)
+	}
+}
+
+func (t *teeDestination) RelVLineTo(y float32) {
+	if t.d0 != nil {
+		t.d0.RelVLineTo(y)
+	}
+	if t.d1 != nil {
+		t.d1.RelVLineTo(y)
+	}
+}
+
+func (t *teeDestination) AbsLineTo(x, y float32) {
+	if t.d0 != nil {
+		t.d0.AbsLineTo(x, y)
+	}
+	if t.d1 != nil {
+		t.d1.AbsLineTo(x, y)
+	}
+}
+
+func (t *teeDestination) RelLineTo(x, y float32) {
+	if t.d0 != nil {
+		t.d0.RelLineTo(x, y)
+	}
+	if t.d1 != nil {
+		t.d1.RelLineTo(x, y)
+	}
+}
+
+func (t *teeDestination) AbsSmoothQuadTo(x, y float32) {
+	if t.d0 != nil {
+		t.d0.AbsSmoothQuadTo(x, y)
+	}
+	if t.d1 != nil {
+		t.d1.AbsSmoothQuadTo(x, y)
+	}
+}
+
+func (t *teeDestination) RelSmoothQuadTo(x, y float32) {
+	if t.d0 != nil {
+		t.d0.RelSmoothQuadTo(x, y)
+	}
+	if t.d1 != nil {
+		t.d1.RelSmoothQuadTo(x, y)
+	}
+}
+
+func (t *teeDestination) AbsQuadTo(x1, y1, x, y float32) {
+	if t.d0 != nil {
+		t.d0.AbsQuadTo(x1, y1, x, y)
+	}
+	if t.d1 != nil {
+		t.d1.AbsQuadTo(x1, y1, x, y)
+	}
+}
+
+func (t *teeDestination) RelQuadTo(x1, y1, x, y float32) {
+	if t.d0 != nil {
+		t.d0.RelQuadTo(x1, y1, x, y)
+	}
+	if t.d1 != nil {
+		t.d1.RelQuadTo(x1, y1, x, y)
+	}
+}
+
+func (t *teeDestination) AbsSmoothCubeTo(x2, y2, x, y float32) {
+	if t.d0 != nil {
+		t.d0.AbsSmoothCubeTo(x2, y2, x, y)
+	}
+	if t.d1 != nil {
+		t.d1.AbsSmoothCubeTo(x2, y2, x, y)
+	}
+}
+
+func (t *teeDestination) RelSmoothCubeTo(x2, y2, x, y float32) {
+	if t.d0 != nil {
+		t.d0.RelSmoothCubeTo(x2, y2, x, y)
+	}
+	if t.d1 != nil {
+		t.d1.RelSmoothCubeTo(x2, y2, x, y)
+	}
+}
+
+func (t *teeDestination) AbsCubeTo(x1, y1, x2, y2, x, y float32) {
+	if t.d0 != nil {
+		t.d0.AbsCubeTo(x1, y1, x2, y2, x, y)
+	}
+	if t.d1 != nil {
+		t.d1.AbsCubeTo(x1, y1, x2, y2, x, y)
+	}
+}
+
+func (t *teeDestination) RelCubeTo(x1, y1, x2, y2, x, y float32) {
+	if t.d0 != nil {
+		t.d0.RelCubeTo(x1, y1, x2, y2, x, y)
+	}
+	if t.d1 != nil {
+		t.d1.RelCubeTo(x1, y1, x2, y2, x, y)
+	}
+}
+
+func (t *teeDestination) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	if t.d0 != nil {
+		t.d0.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	}
+	if t.d1 != nil {
+		t.d1.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	}
+}
+
+func (t *teeDestination) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	if t.d0 != nil {
+		t.d0.RelArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	}
+	if t.d1 != nil {
+		t.d1.RelArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"math"
+)
+
+var errInvalidThumbnailSize = errors.New("iconvg: invalid thumbnail size")
+
+// DecodeThumbnail decodes the IconVG graphic src once, returning both its
+// Metadata and a size × size pixel thumbnail, for the common case of storing
+// a graphic's metadata alongside a preview. It is cheaper than calling
+// DecodeMetadata and then rasterizing, which parses the metadata twice.
+//
+// The graphic is scaled to fit, centered, in the thumbnail, preserving its
+// aspect ratio, so that a graphic wider than it is high leaves transparent
+// bands above and below it. The returned Metadata is as the Rasterizer sees
+// it, so that it reflects opts' Palette and ViewBoxOverride, if any.
+//
+// It returns an error if size is less than 1.
+func DecodeThumbnail(src []byte, size int, opts *DecodeOptions) (Metadata, *image.RGBA, error) {
+	if size < 1 {
+		return Metadata{}, nil, errInvalidThumbnailSize
+	}
+	t := &thumbnailDestination{
+		dst: image.NewRGBA(image.Rect(0, 0, size, size)),
+	}
+	if err := Decode(t, src, opts); err != nil {
+		return Metadata{}, nil, err
+	}
+	return t.metadata, t.dst, nil
+}
+
+// thumbnailDestination is a Rasterizer that, when Reset, fits the ViewBox to
+// its square destination image.
+type thumbnailDestination struct {
+	Rasterizer
+	dst *image.RGBA
+}
+
+func (t *thumbnailDestination) Reset(m Metadata) {
+	t.SetDstImage(t.dst, fitSquare(t.dst.Bounds().Dx(), m.ViewBox), draw.Src)
+	t.Rasterizer.Reset(m)
+}
+
+// fitSquare returns the largest rectangle, with vb's aspect ratio, centered in
+// the size × size square whose top left is the origin, rounded to whole
+// pixels, but at least 1 pixel wide and high. It returns the whole square if
+// vb is empty.
+func fitSquare(size int, vb Rectangle) image.Rectangle {
+	r := image.Rect(0, 0, size, size)
+	dx, dy := vb.AspectRatio()
+	if !(dx > 0 && dy > 0) || isNaNOrInfinity(dx) || isNaNOrInfinity(dy) {
+		return r
+	}
+	if dx > dy {
+		h := int(math.Floor(float64(size)*float64(dy)/float64(dx) + 0.5))
+		if h < 1 {
+			h = 1
+		}
+		r.Min.Y = (size - h) / 2
+		r.Max.Y = r.Min.Y + h
+	} else if dy > dx {
+		w := int(math.Floor(float64(size)*float64(dx)/float64(dy) + 0.5))
+		if w < 1 {
+			w = 1
+		}
+		r.Min.X = (size - w) / 2
+		r.Max.X = r.Min.X + w
+	}
+	return r
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestDecodeThumbnail(t *testing.T) {
+	// An opaque rectangle, twice as wide as it is high, that covers its
+	// ViewBox.
+	m := Metadata{
+		ViewBox: Rectangle{Max: f32.Vec2{40, 20}},
+		Palette: DefaultPalette,
+		Comment: "wide",
+	}
+	var e Encoder
+	e.Reset(m)
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(40)
+	e.AbsVLineTo(20)
+	e.AbsHLineTo(0)
+	e.ClosePathEndPath()
+	src, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	gotM, thumb, err := DecodeThumbnail(src, 16, nil)
+	if err != nil {
+		t.Fatalf("DecodeThumbnail: %v", err)
+	}
+	if gotM != m {
+		t.Errorf("Metadata: got %v, want %v", gotM, m)
+	}
+	if got, want := thumb.Bounds(), image.Rect(0, 0, 16, 16); got != want {
+		t.Fatalf("Bounds: got %v, want %v", got, want)
+	}
+	// The rectangle covers rows 4 to 12.
+	for y := 0; y < 16; y++ {
+		want := uint8(0x00)
+		if 4 <= y && y < 12 {
+			want = 0xff
+		}
+		for x := 0; x < 16; x++ {
+			if got := thumb.RGBAAt(x, y).A; got != want {
+				t.Fatalf("(%d, %d): alpha: got %#02x, want %#02x", x, y, got, want)
+			}
+		}
+	}
+
+	if _, _, err := DecodeThumbnail(src, 0, nil); err != errInvalidThumbnailSize {
+		t.Errorf("size 0: got %v, want %v", err, errInvalidThumbnailSize)
+	}
+}
+
+func TestFitSquare(t *testing.T) {
+	testCases := []struct {
+		vb   Rectangle
+		want image.Rectangle
+	}{
+		{DefaultViewBox, image.Rect(0, 0, 10, 10)},
+		{Rectangle{Max: f32.Vec2{30, 10}}, image.Rect(0, 3, 10, 6)},
+		{Rectangle{Max: f32.Vec2{10, 20}}, image.Rect(2, 0, 7, 10)},
+		{Rectangle{Max: f32.Vec2{1000, 1}}, image.Rect(0, 4, 10, 5)},
+		{Rectangle{}, image.Rect(0, 0, 10, 10)},
+	}
+	for _, tc := range testCases {
+		if got := fitSquare(10, tc.vb); got != tc.want {
+			t.Errorf("%v: got %v, want %v", tc.vb, got, tc.want)
+		}
+	}
+}
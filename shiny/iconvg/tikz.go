@@ -0,0 +1,135 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/math/f32"
+)
+
+// TikZExporter is a Destination that converts an IconVG graphic to a TikZ
+// picture, for the PGF/TikZ package for TeX and LaTeX.
+//
+// Each path becomes a \fill command, under TikZ's default non-zero winding
+// rule, with coordinates in TeX points. TikZ's Y axis increases upwards, so
+// the graphic is flipped vertically to keep it the right way up. Quadratic
+// Bézier curves are converted to cubic ones, and arcs are approximated by
+// cubic ones. Paths filled with gradients are not drawn.
+type TikZExporter struct {
+	// Width is the width, in TeX points, of the picture. The height follows
+	// from the ViewBox's aspect ratio. If zero, one unit of graphic
+	// coordinate space is one point.
+	//
+	// TikZ has no concept of level of detail, so paths are selected as if
+	// the IconVG graphic was rendered at a height, in pixels, equal to the
+	// picture's height in points.
+	Width float32
+
+	segmenter
+
+	buf     []byte
+	scale   float32
+	height  float32
+	visible bool
+	subpath bool
+	last    f32.Vec2
+}
+
+// Bytes returns the TikZ picture.
+func (e *TikZExporter) Bytes() []byte {
+	b := append([]byte(nil), e.buf...)
+	return append(b, "\\end{tikzpicture}\n"...)
+}
+
+// Reset resets the TikZExporter for the given Metadata, and starts a picture
+// that is sized to its ViewBox.
+func (e *TikZExporter) Reset(m Metadata) {
+	e.segmenter.reset(m, e)
+	e.buf = e.buf[:0]
+	e.visible = false
+
+	dx, dy := m.ViewBox.AspectRatio()
+	e.scale = 1
+	if e.Width > 0 && dx > 0 {
+		e.scale = e.Width / dx
+	}
+	e.height = dy * e.scale
+	e.buf = append(e.buf, "\\begin{tikzpicture}[x=1pt,y=1pt]\n"...)
+	e.buf = append(e.buf, fmt.Sprintf("\\useasboundingbox (0,0) rectangle (%s,%s);\n",
+		tikzNumber(dx*e.scale), tikzNumber(e.height))...)
+}
+
+// point returns p, in graphic coordinate space, in TikZ's coordinate syntax.
+func (e *TikZExporter) point(p f32.Vec2) string {
+	vb := &e.metadata.ViewBox
+	x := (p[0] - vb.Min[0]) * e.scale
+	y := (vb.Max[1] - p[1]) * e.scale
+	return "(" + tikzNumber(x) + "," + tikzNumber(y) + ")"
+}
+
+func (e *TikZExporter) beginPath() {
+	// Gradients, and invalid colors, are skipped.
+	e.visible = e.lod0 <= e.height && e.height < e.lod1 &&
+		e.fill.A != 0 && validAlphaPremulColor(e.fill)
+	if !e.visible {
+		return
+	}
+	// TikZ colors are not alpha-premultiplied.
+	a := uint32(e.fill.A)
+	e.buf = append(e.buf, fmt.Sprintf("\\fill[fill={rgb,255:red,%d;green,%d;blue,%d}",
+		uint32(e.fill.R)*0xff/a, uint32(e.fill.G)*0xff/a, uint32(e.fill.B)*0xff/a)...)
+	if a != 0xff {
+		e.buf = append(e.buf, ", fill opacity="...)
+		e.buf = strconv.AppendFloat(e.buf, float64(a)/0xff, 'f', 4, 64)
+	}
+	e.buf = append(e.buf, "]"...)
+	e.subpath = false
+}
+
+func (e *TikZExporter) addSegment(s Segment) {
+	if !e.visible {
+		return
+	}
+	switch s.Op {
+	case SegmentOpMoveTo:
+		if e.subpath {
+			e.buf = append(e.buf, " -- cycle"...)
+		}
+		e.subpath = true
+		e.buf = append(e.buf, "\n\t"+e.point(s.Args[0])...)
+	case SegmentOpLineTo:
+		e.buf = append(e.buf, " -- "+e.point(s.Args[0])...)
+	case SegmentOpQuadTo:
+		c1, c2 := quadToCubic(e.last, s.Args[0], s.Args[1])
+		e.buf = append(e.buf, " .. controls "+e.point(c1)+" and "+e.point(c2)+" .. "+e.point(s.Args[1])...)
+	case SegmentOpCubeTo:
+		e.buf = append(e.buf, " .. controls "+e.point(s.Args[0])+" and "+e.point(s.Args[1])+
+			" .. "+e.point(s.Args[2])...)
+	}
+	e.last = s.end()
+}
+
+func (e *TikZExporter) endPath() {
+	if !e.visible {
+		return
+	}
+	e.buf = append(e.buf, " -- cycle;\n"...)
+	e.visible = false
+}
+
+// tikzNumber formats f in fixed point notation, as TeX does not parse
+// exponents, with at most 4 decimal places.
+func tikzNumber(f float32) string {
+	s := strconv.FormatFloat(float64(f), 'f', 4, 32)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestTikZExporter(t *testing.T) {
+	testCases := []struct {
+		filename string
+		width    float32
+		want     []string
+		wantN    map[string]int
+	}{{
+		filename: "action-info.lores",
+		want: []string{
+			"\\begin{tikzpicture}[x=1pt,y=1pt]\n\\useasboundingbox (0,0) rectangle (48,48);\n",
+			"\\fill[fill={rgb,255:red,0;green,0;blue,0}]\n\t(24,44) .. controls (12.9531,44) and (4,35.0469) .. (4,24)",
+			" -- cycle;\n\\end{tikzpicture}\n",
+		},
+		wantN: map[string]int{
+			"\\fill[": 1,
+			"\n\t(":   3,
+			"cycle":   3,
+		},
+	}, {
+		filename: "action-info.lores",
+		width:    12,
+		want: []string{
+			"\\useasboundingbox (0,0) rectangle (12,12);\n",
+			"\n\t(6,11) .. controls (3.2383,11) and (1,8.7617) .. (1,6)",
+		},
+	}, {
+		filename: "lod-polygon",
+		want: []string{
+			// The picture is 64 points high, so the triangle is drawn
+			// instead of the pentagon.
+			"\n\t(60,32) -- (18,7.75) -- (18,56.25) -- cycle;\n",
+		},
+		wantN: map[string]int{
+			"\\fill[": 3,
+		},
+	}}
+
+	for _, tc := range testCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/" + tc.filename + ".ivg"))
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		e := &TikZExporter{Width: tc.width}
+		if err := Decode(e, ivgData, nil); err != nil {
+			t.Errorf("%s: Decode: %v", tc.filename, err)
+			continue
+		}
+		got := e.Bytes()
+		for _, want := range tc.want {
+			if !bytes.Contains(got, []byte(want)) {
+				t.Errorf("%s: output does not contain %q:\n%s", tc.filename, want, got)
+			}
+		}
+		for s, wantN := range tc.wantN {
+			if n := bytes.Count(got, []byte(s)); n != wantN {
+				t.Errorf("%s: got %d instances of %q, want %d", tc.filename, n, s, wantN)
+			}
+		}
+	}
+}
+
+func TestTikZNumber(t *testing.T) {
+	testCases := []struct {
+		f    float32
+		want string
+	}{
+		{0, "0"},
+		{-0.00001, "0"},
+		{1.5, "1.5"},
+		{-2, "-2"},
+		{1e-5, "0"},
+		{1234567, "1234567"},
+	}
+	for _, tc := range testCases {
+		if got := tikzNumber(tc.f); got != tc.want {
+			t.Errorf("tikzNumber(%v): got %q, want %q", tc.f, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+// PathTopology describes the sub-paths of a path.
+type PathTopology struct {
+	// NumSubpaths is the number of sub-paths.
+	NumSubpaths int
+
+	// Holes are the indexes of the sub-paths that are holes: those that wind
+	// in the opposite direction to the sub-path with the largest area.
+	// Sub-paths with zero area are never holes.
+	Holes []int
+}
+
+// TopologyAnalyzer is a Destination that reports the sub-paths, and holes, of
+// each path of an IconVG graphic. Curves are flattened to line segments, to
+// within 1/64th of a unit, before the sub-paths' areas are computed.
+//
+// A path whose holes wind the same way as its outer contour renders
+// differently under the even-odd and non-zero winding fill rules. IconVG
+// uses the non-zero rule, under which such a hole is filled in.
+type TopologyAnalyzer struct {
+	// Report has an element for each path decoded since the last Reset, in
+	// order, including paths that are fully transparent or outside of every
+	// level of detail.
+	Report []PathTopology
+
+	segmenter
+	segs []Segment
+}
+
+// Reset resets the TopologyAnalyzer for the given Metadata.
+func (a *TopologyAnalyzer) Reset(m Metadata) {
+	a.segmenter.reset(m, a)
+	a.Report = nil
+	a.segs = a.segs[:0]
+}
+
+func (a *TopologyAnalyzer) beginPath()           { a.segs = a.segs[:0] }
+func (a *TopologyAnalyzer) addSegment(s Segment) { a.segs = append(a.segs, s) }
+
+func (a *TopologyAnalyzer) endPath() {
+	subpaths := splitSubpaths(a.segs)
+	areas := make([]float32, len(subpaths))
+	largest := float32(0)
+	for i, sub := range subpaths {
+		areas[i] = signedArea(flatten(nil, sub, flattenTolerance))
+		if abs32(areas[i]) > abs32(largest) {
+			largest = areas[i]
+		}
+	}
+
+	t := PathTopology{NumSubpaths: len(subpaths)}
+	for i, area := range areas {
+		if (area > 0 && largest < 0) || (area < 0 && largest > 0) {
+			t.Holes = append(t.Holes, i)
+		}
+	}
+	a.Report = append(a.Report, t)
+}
+
+func abs32(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,58 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopologyAnalyzer(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	// Path #0 is a counter-clockwise square with two clockwise holes, and a
+	// counter-clockwise island inside the first hole.
+	e.StartPath(0, -30, -30)
+	e.AbsVLineTo(+30)
+	e.AbsHLineTo(+30)
+	e.AbsVLineTo(-30)
+	e.ClosePathAbsMoveTo(-20, -20)
+	e.AbsHLineTo(0)
+	e.AbsVLineTo(0)
+	e.AbsHLineTo(-20)
+	e.ClosePathAbsMoveTo(-15, -15)
+	e.AbsVLineTo(-5)
+	e.AbsHLineTo(-5)
+	e.AbsVLineTo(-15)
+	e.ClosePathAbsMoveTo(10, 10)
+	e.AbsHLineTo(20)
+	e.AbsVLineTo(20)
+	e.AbsHLineTo(10)
+	e.ClosePathEndPath()
+	// Path #1 is a single triangle.
+	e.StartPath(0, 0, 0)
+	e.AbsLineTo(10, 0)
+	e.AbsLineTo(0, 10)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var a TopologyAnalyzer
+	if err := Decode(&a, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := []PathTopology{
+		{NumSubpaths: 4, Holes: []int{1, 3}},
+		{NumSubpaths: 1},
+	}
+	if !reflect.DeepEqual(a.Report, want) {
+		t.Errorf("got %+v, want %+v", a.Report, want)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TraceEventKind distinguishes kinds of TraceEvents.
+type TraceEventKind uint8
+
+const (
+	// TraceMagic is the magic identifier.
+	TraceMagic TraceEventKind = iota
+	// TraceMetadata is part of the metadata other than a chunk's start, such
+	// as the number of metadata chunks or a chunk's Metadata Identifier.
+	TraceMetadata
+	// TraceMetadataChunk is the start of a metadata chunk: its length.
+	TraceMetadataChunk
+	// TraceOpcode is a styling or drawing opcode, other than those that start
+	// or end a path. An implicitly repeated drawing op also generates a
+	// TraceOpcode event, with zero Length.
+	TraceOpcode
+	// TracePathStart is a styling opcode that starts a path.
+	TracePathStart
+	// TracePathEnd is a drawing opcode that ends a path.
+	TracePathEnd
+	// TraceOperand is data following an opcode or within a metadata chunk,
+	// such as a number or a color, other than a coordinate number.
+	TraceOperand
+	// TraceCoordinate is a coordinate number operand. Its Length is the
+	// width, 1, 2 or 4 bytes, of that number's encoding.
+	TraceCoordinate
+)
+
+// TraceEvent is a structured description of part of an IconVG graphic's
+// encoded form, passed to a DecodeOptions.Trace callback.
+type TraceEvent struct {
+	Kind TraceEventKind
+
+	// Offset and Length locate the event's bytes within the encoded form.
+	Offset int
+	Length int
+
+	// Description is a human-readable description of those bytes, the same
+	// as that in a disassembly, such as "L (absolute lineTo), 2 reps".
+	Description string
+}
+
+// ProfileEvent describes the decoding of one opcode, passed to a
+// DecodeOptions.Profile callback.
+type ProfileEvent struct {
+	Opcode byte
+
+	// Drawing is whether Opcode is a drawing opcode, as opposed to a styling
+	// opcode. The same byte value means different things in the two modes.
+	Drawing bool
+
+	// Duration is the time spent decoding the opcode and its operands,
+	// including the time spent in the Destination's methods.
+	Duration time.Duration
+}
+
+// DecodeStats summarizes the decoding of an IconVG graphic, filled in via
+// DecodeOptions.Stats. Unlike a Profile callback, collecting it has no
+// per-opcode function call overhead.
+type DecodeStats struct {
+	// Opcodes is the number of styling and drawing opcodes decoded.
+	// Implicitly repeated drawing ops are not counted separately.
+	Opcodes int
+
+	// Bytes is the number of bytes decoded, including the magic identifier
+	// and metadata.
+	Bytes int
+
+	// MaxReps is the largest number of times that a single drawing opcode is
+	// implicitly repeated, including the first time.
+	MaxReps int
+
+	// Arcs is the number of arcTo drawing ops, including implicitly repeated
+	// ones.
+	Arcs int
+
+	// TrailingBytes is the number of bytes after the end of the last path,
+	// or after the metadata if there are no paths. They can only hold
+	// styling opcodes, which have no visible effect without a following
+	// path, so a non-zero value can indicate a truncated graphic, or junk
+	// such as another graphic concatenated to this one. If decoding fails,
+	// it includes the bytes decoded before the failure.
+	TrailingBytes int
+}
+
+// tracePrinter returns a printer that converts its calls to TraceEvents.
+//
+// Every printer call passes a sub-slice of src, and a sub-slice shares the
+// end of its underlying array, so a call's offset within src is given by the
+// difference in capacities.
+func tracePrinter(src []byte, trace func(TraceEvent)) printer {
+	return func(b []byte, k TraceEventKind, format string, args ...interface{}) {
+		trace(TraceEvent{
+			Kind:        k,
+			Offset:      cap(src) - cap(b),
+			Length:      len(b),
+			Description: strings.TrimSpace(fmt.Sprintf(format, args...)),
+		})
+	}
+}
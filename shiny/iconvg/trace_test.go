@@ -0,0 +1,205 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	for _, tc := range testdataTestCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		disassembly, err := disassemble(ivgData)
+		if err != nil {
+			t.Errorf("%s: disassemble: %v", tc.filename, err)
+			continue
+		}
+
+		var events []TraceEvent
+		if err := Decode(nil, ivgData, &DecodeOptions{
+			Trace: func(ev TraceEvent) { events = append(events, ev) },
+		}); err != nil {
+			t.Errorf("%s: Decode: %v", tc.filename, err)
+			continue
+		}
+
+		// The events should tile the encoded form, in order, and match the
+		// disassembly line for line.
+		lines := strings.Split(strings.TrimSuffix(string(disassembly), "\n"), "\n")
+		if len(events) != len(lines) {
+			t.Errorf("%s: got %d events, want %d", tc.filename, len(events), len(lines))
+			continue
+		}
+		offset, nStarts, nEnds := 0, 0, 0
+		for i, ev := range events {
+			if ev.Offset != offset {
+				t.Errorf("%s: event #%d: got offset %d, want %d", tc.filename, i, ev.Offset, offset)
+				break
+			}
+			offset += ev.Length
+			if want := strings.TrimSpace(lines[i][14:]); ev.Description != want {
+				t.Errorf("%s: event #%d: got %q, want %q", tc.filename, i, ev.Description, want)
+				break
+			}
+			switch ev.Kind {
+			case TracePathStart:
+				nStarts++
+			case TracePathEnd:
+				nEnds++
+			}
+		}
+		if offset != len(ivgData) {
+			t.Errorf("%s: events covered %d bytes, want %d", tc.filename, offset, len(ivgData))
+		}
+		if nStarts != nEnds {
+			t.Errorf("%s: got %d path starts and %d path ends", tc.filename, nStarts, nEnds)
+		}
+	}
+}
+
+func TestTraceCoordinateWidths(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.StartPath(0, 0, -20)
+	e.AbsLineTo(0.5, 20)
+	e.AbsLineTo(-20, 1000.5)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var got []int
+	if err := Decode(nil, ivgData, &DecodeOptions{
+		Trace: func(ev TraceEvent) {
+			if ev.Kind == TraceCoordinate {
+				got = append(got, ev.Length)
+			}
+		},
+	}); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := []int{1, 1, 2, 1, 1, 4}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	ivgData, err := ioutil.ReadFile(filepath.FromSlash("testdata/lod-polygon.ivg"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var opcodes []TraceEvent
+	if err := Decode(nil, ivgData, &DecodeOptions{
+		Trace: func(ev TraceEvent) {
+			if ev.Kind == TraceOpcode || ev.Kind == TracePathStart || ev.Kind == TracePathEnd {
+				opcodes = append(opcodes, ev)
+			}
+		},
+	}); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	var events []ProfileEvent
+	if err := Decode(nil, ivgData, &DecodeOptions{
+		Profile: func(ev ProfileEvent) { events = append(events, ev) },
+	}); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	// Implicitly repeated drawing ops have a trace event but no opcode byte.
+	i, drawing := 0, false
+	for _, op := range opcodes {
+		if op.Length == 0 {
+			continue
+		}
+		if i >= len(events) {
+			t.Fatalf("got %d events, want more", len(events))
+		}
+		want := ProfileEvent{
+			Opcode:  ivgData[op.Offset],
+			Drawing: drawing,
+		}
+		if got := events[i]; got.Opcode != want.Opcode || got.Drawing != want.Drawing || got.Duration < 0 {
+			t.Errorf("event #%d: got %+v, want %+v", i, got, want)
+		}
+		switch op.Kind {
+		case TracePathStart:
+			drawing = true
+		case TracePathEnd:
+			drawing = false
+		}
+		i++
+	}
+	if i != len(events) {
+		t.Errorf("got %d events, want %d", len(events), i)
+	}
+}
+
+func TestDecodeStats(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	e.StartPath(0, -20, -20)
+	e.AbsLineTo(+20, -20)
+	e.AbsLineTo(+20, +20)
+	e.AbsLineTo(+10, +20)
+	e.AbsArcTo(5, 5, 0, false, true, 0, +20)
+	e.AbsArcTo(5, 5, 0, false, true, -10, +20)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	stats := DecodeStats{Opcodes: 100}
+	if err := Decode(nil, ivgData, &DecodeOptions{Stats: &stats}); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := DecodeStats{
+		Opcodes: 5,
+		Bytes:   len(ivgData),
+		MaxReps: 3,
+		Arcs:    2,
+	}
+	if stats != want {
+		t.Errorf("got %+v, want %+v", stats, want)
+	}
+
+	// Styling opcodes after the last path, here setting CSEL and NSEL, are
+	// trailing bytes.
+	trailing := append(append([]byte(nil), ivgData...), 0x00, 0x41)
+	if err := Decode(nil, trailing, &DecodeOptions{Stats: &stats}); err != nil {
+		t.Fatalf("Decode(trailing): %v", err)
+	}
+	if got, want := stats.TrailingBytes, 2; got != want {
+		t.Errorf("trailing: TrailingBytes: got %d, want %d", got, want)
+	}
+
+	// A truncated graphic's stats cover the opcodes before the truncation.
+	truncated := ivgData[:len(ivgData)-2]
+	if err := Decode(nil, truncated, &DecodeOptions{Stats: &stats}); err == nil {
+		t.Fatalf("Decode(truncated): got nil error, want non-nil")
+	}
+	if stats.Opcodes != 3 || stats.Arcs != 0 || stats.Bytes >= len(truncated) {
+		t.Errorf("truncated: got %+v", stats)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// DecodeError is an error found by ValidateAll, and where it was found.
+type DecodeError struct {
+	// Offset is the offset, within the encoded form, of the metadata chunk
+	// or opcode that is invalid.
+	Offset int
+
+	// Err is the error, the same as that which Decode would return.
+	Err error
+}
+
+func (e DecodeError) Error() string {
+	return fmt.Sprintf("%v (at offset %d)", e.Err, e.Offset)
+}
+
+// ValidateAll checks an IconVG graphic for errors, like Decode with a nil
+// Destination, but reports every error it can find, in order, instead of
+// only the first. It returns nil if the graphic is valid.
+//
+// After an invalid metadata chunk, it continues with the next chunk, whose
+// position follows from the invalid chunk's length. After an invalid
+// opcode, it continues after the next byte that could be the opcode that
+// ends a path, 0xe1. That byte may instead be part of an opcode's operands,
+// so later errors may be spurious, and an invalid opcode outside of a path
+// skips the whole of the next path. A missing magic identifier, or an
+// invalid number or length of metadata chunks, leaves nothing to continue
+// with, and stops it.
+func ValidateAll(src []byte) []DecodeError {
+	all := buffer(src)
+	offset := func(b buffer) int { return len(all) - len(b) }
+
+	if !bytes.HasPrefix(all, magicBytes) {
+		return []DecodeError{{0, errInvalidMagicIdentifier}}
+	}
+	b := all[len(magic):]
+
+	var errs []DecodeError
+	nMetadataChunks, n := b.decodeNatural()
+	if n == 0 {
+		return append(errs, DecodeError{offset(b), errInvalidNumberOfMetadataChunks})
+	}
+	b = b[n:]
+	m := Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	}
+	for ; nMetadataChunks > 0; nMetadataChunks-- {
+		length, n := b.decodeNatural()
+		if n == 0 || uint64(length) > uint64(len(b)-n) {
+			return append(errs, DecodeError{offset(b), errInvalidMetadataChunkLength})
+		}
+		if _, err := decodeMetadataChunk(nil, &m, b, nil); err != nil {
+			errs = append(errs, DecodeError{offset(b), err})
+		}
+		b = b[n+int(length):]
+	}
+
+	mf := modeFunc(decodeStyling)
+	drawing := false
+	for len(b) > 0 {
+		opcode := b[0]
+		mf1, b1, err := mf(nil, nil, b)
+		if err != nil {
+			errs = append(errs, DecodeError{offset(b), err})
+			i := bytes.IndexByte(b[1:], 0xe1)
+			if i < 0 {
+				return errs
+			}
+			b = b[1+i+1:]
+			mf, drawing = decodeStyling, false
+			continue
+		}
+		mf, b = mf1, b1
+		if !drawing {
+			drawing = 0xc0 <= opcode && opcode < 0xc7
+		} else if opcode == 0xe1 {
+			drawing = false
+		}
+	}
+	if drawing {
+		errs = append(errs, DecodeError{offset(b), errUnclosedPath})
+	}
+	return errs
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestValidateAll(t *testing.T) {
+	for _, tc := range testdataTestCases {
+		ivgData, err := ioutil.ReadFile(filepath.FromSlash(tc.filename) + ".ivg")
+		if err != nil {
+			t.Errorf("%s: ReadFile: %v", tc.filename, err)
+			continue
+		}
+		if errs := ValidateAll(ivgData); errs != nil {
+			t.Errorf("%s: got %v, want nil", tc.filename, errs)
+		}
+	}
+
+	if got, want := ValidateAll([]byte("not an IconVG graphic")),
+		[]DecodeError{{0, errInvalidMagicIdentifier}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bad magic: got %v, want %v", got, want)
+	}
+
+	// An invalid ViewBox, with Min greater than Max, can be encoded.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: Rectangle{Min: f32.Vec2{+8, +8}},
+		Palette: DefaultPalette,
+	})
+	viewBoxOffset := len(magic) + 1
+	e.StartPath(0, 0, 0)
+	reservedOffset := len(e.buf)
+	e.AbsLineTo(+8, 0)
+	e.AbsLineTo(+8, +8)
+	e.ClosePathEndPath()
+	e.StartPath(0, 0, 0)
+	e.AbsLineTo(-8, 0)
+	e.AbsLineTo(-8, -8)
+	e.ClosePathEndPath()
+	e.StartPath(0, 0, 0)
+	e.AbsLineTo(+8, +8)
+	e.AbsLineTo(0, +8)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	// Replace the first path's first line's opcode by a reserved one, and
+	// drop the opcode that ends the last path.
+	ivgData[reservedOffset] = 0xea
+	ivgData = ivgData[:len(ivgData)-1]
+
+	got := ValidateAll(ivgData)
+	want := []DecodeError{
+		{viewBoxOffset, errInvalidViewBox},
+		{reservedOffset, errReservedDrawingOpcode},
+		{len(ivgData), errUnclosedPath},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+	if err := Decode(nil, ivgData, nil); err != want[0].Err {
+		t.Errorf("Decode: got %v, want %v", err, want[0].Err)
+	}
+}
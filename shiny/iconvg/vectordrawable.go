@@ -0,0 +1,168 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"fmt"
+	"image/color"
+
+	"golang.org/x/image/math/f32"
+)
+
+// VectorDrawableExporter is a Destination that converts an IconVG graphic to
+// an Android VectorDrawable XML resource: a vector element with one path
+// element per visible IconVG path.
+//
+// The ViewBox becomes the viewportWidth and viewportHeight. As a
+// VectorDrawable's viewport always starts at (0, 0), coordinates are
+// translated by the ViewBox's Min. Each path's segments become its pathData
+// attribute, in SVG path syntax, and arcs become SVG arc commands. Flat
+// colors become fillColor attributes. Paths filled with gradients, which
+// VectorDrawable only supports via nested aapt:attr elements, are skipped,
+// as are fully transparent paths.
+type VectorDrawableExporter struct {
+	// Width is the width, in dp (density-independent pixels), of the
+	// drawable. The height follows from the ViewBox's aspect ratio. Paths
+	// are selected by level of detail as if the graphic was rendered at that
+	// height, in pixels.
+	//
+	// If zero, it is 24, the size of Material Design system icons.
+	Width float32
+
+	segmenter
+
+	body    []byte
+	visible bool
+	subpath bool
+	// inArc is whether segments are being emitted for an arc.
+	inArc bool
+}
+
+// Bytes returns the VectorDrawable XML.
+func (e *VectorDrawableExporter) Bytes() []byte {
+	w, h := e.size()
+	dx, dy := e.metadata.ViewBox.AspectRatio()
+	b := []byte(fmt.Sprintf("<vector xmlns:android=\"http://schemas.android.com/apk/res/android\"\n"+
+		"    android:width=\"%sdp\"\n"+
+		"    android:height=\"%sdp\"\n"+
+		"    android:viewportWidth=\"%s\"\n"+
+		"    android:viewportHeight=\"%s\">\n",
+		svgNumber(w), svgNumber(h), svgNumber(dx), svgNumber(dy)))
+	b = append(b, e.body...)
+	b = append(b, "</vector>\n"...)
+	return b
+}
+
+// Reset resets the VectorDrawableExporter for the given Metadata.
+func (e *VectorDrawableExporter) Reset(m Metadata) {
+	e.segmenter.reset(m, e)
+	e.body = e.body[:0]
+	e.visible = false
+	e.inArc = false
+}
+
+// size returns the drawable's width and height, in dp.
+func (e *VectorDrawableExporter) size() (w, h float32) {
+	w = e.Width
+	if w <= 0 {
+		w = 24
+	}
+	dx, dy := e.metadata.ViewBox.AspectRatio()
+	if dx <= 0 {
+		return w, w
+	}
+	return w, w * dy / dx
+}
+
+func (e *VectorDrawableExporter) AbsArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	e.arcTo(rx, ry, xAxisRotation, largeArc, sweep, f32.Vec2{x, y})
+	e.inArc = true
+	e.segmenter.AbsArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	e.inArc = false
+}
+
+func (e *VectorDrawableExporter) RelArcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, x, y float32) {
+	e.arcTo(rx, ry, xAxisRotation, largeArc, sweep, e.rel(x, y))
+	e.inArc = true
+	e.segmenter.RelArcTo(rx, ry, xAxisRotation, largeArc, sweep, x, y)
+	e.inArc = false
+}
+
+// arcTo adds an SVG arc command, ending at the absolute point end. IconVG's
+// xAxisRotation is in turns, and SVG's is in degrees.
+func (e *VectorDrawableExporter) arcTo(rx, ry, xAxisRotation float32, largeArc, sweep bool, end f32.Vec2) {
+	if !e.visible {
+		return
+	}
+	e.body = append(e.body, 'A')
+	e.body = appendSVGPoints(e.body, []f32.Vec2{{rx, ry}})
+	e.body = append(e.body, svgNumber(360*xAxisRotation)...)
+	e.body = append(e.body, ' ')
+	e.body = append(e.body, vectorDrawableFlag(largeArc), ' ', vectorDrawableFlag(sweep), ' ')
+	e.body = appendSVGPoints(e.body, []f32.Vec2{e.translate(end)})
+}
+
+func vectorDrawableFlag(b bool) byte {
+	if b {
+		return '1'
+	}
+	return '0'
+}
+
+// translate returns p relative to the ViewBox's Min.
+func (e *VectorDrawableExporter) translate(p f32.Vec2) f32.Vec2 {
+	return f32.Vec2{p[0] - e.metadata.ViewBox.Min[0], p[1] - e.metadata.ViewBox.Min[1]}
+}
+
+func (e *VectorDrawableExporter) beginPath() {
+	_, h := e.size()
+	e.visible = e.lod0 <= h && h < e.lod1 &&
+		e.fill.A != 0 && validAlphaPremulColor(e.fill)
+	if !e.visible {
+		return
+	}
+	e.subpath = false
+	e.body = append(e.body, "    <path\n        android:fillColor=\""...)
+	e.body = append(e.body, vectorDrawableColor(e.fill)...)
+	e.body = append(e.body, "\"\n        android:pathData=\""...)
+}
+
+func (e *VectorDrawableExporter) addSegment(s Segment) {
+	if !e.visible || e.inArc {
+		return
+	}
+	var args [3]f32.Vec2
+	for i := range args {
+		args[i] = e.translate(s.Args[i])
+	}
+	switch s.Op {
+	case SegmentOpMoveTo:
+		if e.subpath {
+			e.body = append(e.body, "Z "...)
+		}
+		e.subpath = true
+		e.body = appendSVGPoints(append(e.body, 'M'), args[:1])
+	case SegmentOpLineTo:
+		e.body = appendSVGPoints(append(e.body, 'L'), args[:1])
+	case SegmentOpQuadTo:
+		e.body = appendSVGPoints(append(e.body, 'Q'), args[:2])
+	case SegmentOpCubeTo:
+		e.body = appendSVGPoints(append(e.body, 'C'), args[:3])
+	}
+}
+
+func (e *VectorDrawableExporter) endPath() {
+	if !e.visible {
+		return
+	}
+	e.body = append(e.body, "Z\"/>\n"...)
+	e.visible = false
+}
+
+// vectorDrawableColor returns the #aarrggbb form, with straight alpha, of the
+// valid alpha-premultiplied color c.
+func vectorDrawableColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.A, unpremul(c.R, c.A), unpremul(c.G, c.A), unpremul(c.B, c.A))
+}
@@ -0,0 +1,52 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestVectorDrawableExporter(t *testing.T) {
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.SetCReg(0, false, RGBAColor(color.RGBA{0x00, 0x00, 0x80, 0x80}))
+	e.StartPath(0, -16, 0)
+	e.AbsArcTo(16, 8, 0.25, false, true, +16, 0)
+	e.AbsLineTo(0, +16)
+	e.ClosePathAbsMoveTo(-32, -32)
+	e.AbsQuadTo(-24, -32, -24, -24)
+	e.ClosePathEndPath()
+	// A fully transparent path is skipped.
+	e.SetCReg(0, false, RGBAColor(color.RGBA{}))
+	e.StartPath(0, 0, 0)
+	e.AbsHLineTo(+8)
+	e.AbsVLineTo(+8)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	var x VectorDrawableExporter
+	if err := Decode(&x, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := "<vector xmlns:android=\"http://schemas.android.com/apk/res/android\"\n" +
+		"    android:width=\"24dp\"\n" +
+		"    android:height=\"24dp\"\n" +
+		"    android:viewportWidth=\"64\"\n" +
+		"    android:viewportHeight=\"64\">\n" +
+		"    <path\n" +
+		"        android:fillColor=\"#800000ff\"\n" +
+		"        android:pathData=\"M16 32 A16 8 90 0 1 48 32 L32 48 Z M0 0 Q8 0 8 8 Z\"/>\n" +
+		"</vector>\n"
+	if got := string(x.Bytes()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,327 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"golang.org/x/image/math/f32"
+)
+
+var errEmptyImage = errors.New("iconvg: empty image")
+
+// VectorizeOptions are the optional parameters to EncodeFromImage.
+type VectorizeOptions struct {
+	// MaxColors is the maximum number of colors, and so paths, in the
+	// graphic. The most common colors are kept, and every other pixel is
+	// given the nearest kept color. If zero, it is 16.
+	MaxColors int
+
+	// Tolerance is how far, in pixels, a simplified outline may deviate from
+	// the pixels' edges. Larger values give smaller, smoother graphics. If
+	// zero, it is 1.
+	Tolerance float32
+
+	// ViewBox is the graphic's ViewBox, that the image's bounds are mapped
+	// to. If zero, it is the image's bounds, so that one unit of graphic
+	// coordinate space is one pixel.
+	ViewBox Rectangle
+}
+
+// vectorizeAlphaThreshold is the alpha below which a pixel is transparent.
+const vectorizeAlphaThreshold = 0x80
+
+// EncodeFromImage traces a flat-color image, such as a simple icon, into an
+// IconVG graphic. It is not suitable for photographs or for images with
+// gradients, which have too many colors.
+//
+// The image's colors are reduced to at most opts.MaxColors. Pixels whose
+// alpha is less than one half are transparent. The region of each color
+// becomes one path, drawn in order from the most to the least common color.
+// The region's outline follows the edges of its pixels, with its holes wound
+// in the opposite direction, and is then simplified to within opts.Tolerance
+// by the Ramer-Douglas-Peucker algorithm, unless that would collapse it. The
+// outlines are straight lines: no curves are fitted.
+func EncodeFromImage(img image.Image, opts *VectorizeOptions) ([]byte, error) {
+	b := img.Bounds()
+	if b.Empty() {
+		return nil, errEmptyImage
+	}
+	o := VectorizeOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxColors <= 0 {
+		o.MaxColors = 16
+	}
+	if !(o.Tolerance > 0) {
+		o.Tolerance = 1
+	}
+	if o.ViewBox == (Rectangle{}) {
+		o.ViewBox = Rectangle{
+			Max: f32.Vec2{float32(b.Dx()), float32(b.Dy())},
+		}
+	}
+
+	palette, indexes := quantizeImage(img, o.MaxColors)
+	w, h := b.Dx(), b.Dy()
+	dx, dy := o.ViewBox.AspectRatio()
+	sx, sy := dx/float32(w), dy/float32(h)
+
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: o.ViewBox,
+		Palette: DefaultPalette,
+	})
+	for ci, c := range palette {
+		loops := traceRegion(indexes, w, h, int16(ci))
+		first := true
+		for _, loop := range loops {
+			// Small loops, such as single pixels, can collapse when
+			// simplified, so they are kept as is.
+			if simplified := simplifyPolygon(loop, o.Tolerance); len(simplified) >= 3 {
+				loop = simplified
+			}
+			for i, p := range loop {
+				x := o.ViewBox.Min[0] + p[0]*sx
+				y := o.ViewBox.Min[1] + p[1]*sy
+				switch {
+				case i != 0:
+					e.AbsLineTo(x, y)
+				case first:
+					e.SetCReg(0, false, RGBAColor(c))
+					e.StartPath(0, x, y)
+					first = false
+				default:
+					e.ClosePathAbsMoveTo(x, y)
+				}
+			}
+		}
+		if !first {
+			e.ClosePathEndPath()
+		}
+	}
+	return e.Bytes()
+}
+
+// quantizeImage returns at most maxColors alpha-premultiplied colors, most
+// common first, and the index into those colors of each pixel, in row-major
+// order. Transparent pixels have index -1.
+func quantizeImage(img image.Image, maxColors int) ([]color.RGBA, []int16) {
+	b := img.Bounds()
+	pixels := make([]color.RGBA, 0, b.Dx()*b.Dy())
+	counts := map[color.RGBA]int{}
+	var colors []color.RGBA
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			if c.A < vectorizeAlphaThreshold {
+				c = color.RGBA{}
+			} else {
+				if counts[c] == 0 {
+					colors = append(colors, c)
+				}
+				counts[c]++
+			}
+			pixels = append(pixels, c)
+		}
+	}
+
+	// Sort by decreasing count, breaking ties by first appearance.
+	sort.Stable(byCount{colors, counts})
+	if len(colors) > maxColors {
+		colors = colors[:maxColors]
+	}
+
+	indexOf := map[color.RGBA]int16{}
+	for i, c := range colors {
+		indexOf[c] = int16(i)
+	}
+	indexes := make([]int16, len(pixels))
+	for i, c := range pixels {
+		if c.A == 0 {
+			indexes[i] = -1
+			continue
+		}
+		index, ok := indexOf[c]
+		if !ok {
+			index = nearestColor(colors, c)
+			indexOf[c] = index
+		}
+		indexes[i] = index
+	}
+	return colors, indexes
+}
+
+// byCount sorts colors by decreasing count.
+type byCount struct {
+	colors []color.RGBA
+	counts map[color.RGBA]int
+}
+
+func (b byCount) Len() int           { return len(b.colors) }
+func (b byCount) Less(i, j int) bool { return b.counts[b.colors[i]] > b.counts[b.colors[j]] }
+func (b byCount) Swap(i, j int)      { b.colors[i], b.colors[j] = b.colors[j], b.colors[i] }
+
+// nearestColor returns the index of the color in colors that is nearest to c,
+// by Euclidean distance in RGBA space.
+func nearestColor(colors []color.RGBA, c color.RGBA) int16 {
+	best, bestDist := int16(0), math.MaxInt32
+	for i, x := range colors {
+		dr := int(x.R) - int(c.R)
+		dg := int(x.G) - int(c.G)
+		db := int(x.B) - int(c.B)
+		da := int(x.A) - int(c.A)
+		if d := dr*dr + dg*dg + db*db + da*da; d < bestDist {
+			best, bestDist = int16(i), d
+		}
+	}
+	return best
+}
+
+// traceRegion returns the closed loops that outline the pixels, of a w × h
+// image, whose index is ci. Coordinates are in pixels, and a loop's last
+// point is implicitly joined to its first.
+//
+// Each pixel edge between a pixel in the region and one outside of it is
+// directed so that the region is on its right, with the Y axis increasing
+// down. Following those edges gives outer loops that wind clockwise and holes
+// that wind counter-clockwise, as the non-zero winding rule requires. Only
+// the corners of each loop are returned.
+func traceRegion(indexes []int16, w, h int, ci int16) [][]f32.Vec2 {
+	in := func(x, y int) bool {
+		return 0 <= x && x < w && 0 <= y && y < h && indexes[y*w+x] == ci
+	}
+
+	// Each edge is identified by its start vertex and direction: 0, 1, 2 and
+	// 3 mean right, down, left and up. A vertex (x, y) has key y*(w+1) + x.
+	type edge struct {
+		v   int
+		dir int
+	}
+	deltas := [4][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+	stride := w + 1
+	// outgoing maps a vertex to a bitmask of its outgoing, untraced, edges.
+	outgoing := map[int]uint8{}
+	var order []edge
+	add := func(x, y, dir int) {
+		v := y*stride + x
+		outgoing[v] |= 1 << uint(dir)
+		order = append(order, edge{v, dir})
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !in(x, y) {
+				continue
+			}
+			if !in(x, y-1) {
+				add(x, y, 0)
+			}
+			if !in(x+1, y) {
+				add(x+1, y, 1)
+			}
+			if !in(x, y+1) {
+				add(x+1, y+1, 2)
+			}
+			if !in(x-1, y) {
+				add(x, y+1, 3)
+			}
+		}
+	}
+
+	var loops [][]f32.Vec2
+	for _, start := range order {
+		if outgoing[start.v]&(1<<uint(start.dir)) == 0 {
+			continue
+		}
+		var loop []f32.Vec2
+		e := start
+		for {
+			outgoing[e.v] &^= 1 << uint(e.dir)
+			x, y := e.v%stride, e.v/stride
+			nx, ny := x+deltas[e.dir][0], y+deltas[e.dir][1]
+			v := ny*stride + nx
+
+			// At a vertex where two diagonally adjacent pixels meet, prefer
+			// turning right, then going straight, then turning left, so that
+			// diagonally adjacent pixels are separate loops.
+			next := -1
+			for _, turn := range [3]int{1, 0, 3} {
+				if d := (e.dir + turn) % 4; outgoing[v]&(1<<uint(d)) != 0 {
+					next = d
+					break
+				}
+			}
+			if next != e.dir {
+				// The loop turns, so (nx, ny) is a corner.
+				loop = append(loop, f32.Vec2{float32(nx), float32(ny)})
+			}
+			if next < 0 {
+				break
+			}
+			e = edge{v, next}
+		}
+		if len(loop) >= 3 {
+			loops = append(loops, loop)
+		}
+	}
+	return loops
+}
+
+// simplifyPolygon returns the implicitly closed polygon simplified, by the
+// Ramer-Douglas-Peucker algorithm, to within tolerance of the original.
+func simplifyPolygon(polygon []f32.Vec2, tolerance float32) []f32.Vec2 {
+	if len(polygon) < 4 {
+		return polygon
+	}
+	// Split the polygon into two polylines at the point farthest from the
+	// first point.
+	far, farDist := 0, float32(0)
+	for i, p := range polygon {
+		dx, dy := p[0]-polygon[0][0], p[1]-polygon[0][1]
+		if d := dx*dx + dy*dy; d > farDist {
+			far, farDist = i, d
+		}
+	}
+	closed := append(append([]f32.Vec2(nil), polygon...), polygon[0])
+	ret := simplifyPolyline(nil, closed[:far+1], tolerance)
+	ret = simplifyPolyline(ret[:len(ret)-1], closed[far:], tolerance)
+	return ret[:len(ret)-1]
+}
+
+// simplifyPolyline appends the polyline, simplified by the
+// Ramer-Douglas-Peucker algorithm, to dst. Its first and last points are
+// kept.
+func simplifyPolyline(dst, polyline []f32.Vec2, tolerance float32) []f32.Vec2 {
+	n := len(polyline)
+	if n <= 2 {
+		return append(dst, polyline...)
+	}
+	a, b := polyline[0], polyline[n-1]
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	l := float32(math.Hypot(float64(dx), float64(dy)))
+	far, farDist := 0, float32(-1)
+	for i := 1; i < n-1; i++ {
+		p := polyline[i]
+		var d float32
+		if l == 0 {
+			d = float32(math.Hypot(float64(p[0]-a[0]), float64(p[1]-a[1])))
+		} else {
+			d = abs32((p[0]-a[0])*dy-(p[1]-a[1])*dx) / l
+		}
+		if d > farDist {
+			far, farDist = i, d
+		}
+	}
+	if farDist <= tolerance {
+		return append(dst, a, b)
+	}
+	dst = simplifyPolyline(dst, polyline[:far+1], tolerance)
+	return simplifyPolyline(dst[:len(dst)-1], polyline[far:], tolerance)
+}
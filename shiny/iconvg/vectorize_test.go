@@ -0,0 +1,104 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"golang.org/x/image/math/f32"
+)
+
+func TestEncodeFromImage(t *testing.T) {
+	red := color.RGBA{0xff, 0x00, 0x00, 0xff}
+	blue := color.RGBA{0x00, 0x00, 0xff, 0xff}
+	nearlyBlue := color.RGBA{0x00, 0x10, 0xf0, 0xff}
+
+	src := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(src, image.Rect(2, 2, 14, 14), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(src, image.Rect(5, 5, 9, 9), image.NewUniform(blue), image.Point{}, draw.Src)
+	// A single pixel, diagonally adjacent to the blue square, that is nearly
+	// blue. With MaxColors of 2, it becomes blue.
+	src.SetRGBA(9, 9, nearlyBlue)
+	// Two diagonally adjacent red pixels, outside of the red square.
+	src.SetRGBA(0, 0, red)
+	src.SetRGBA(1, 1, red)
+
+	// A small tolerance only removes collinear points.
+	ivgData, err := EncodeFromImage(src, &VectorizeOptions{
+		MaxColors: 2,
+		Tolerance: 0.25,
+	})
+	if err != nil {
+		t.Fatalf("EncodeFromImage: %v", err)
+	}
+
+	var r pathsRecorder
+	if err := Decode(&r, ivgData, nil); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(r.paths) != 2 {
+		t.Fatalf("got %d paths, want 2", len(r.paths))
+	}
+	// The red path is the two diagonal pixels, and the square with a hole.
+	// The blue path is the blue square and the diagonally adjacent pixel.
+	for i, want := range []struct {
+		fill      color.RGBA
+		nSubpaths int
+	}{{red, 4}, {blue, 2}} {
+		if got := r.paths[i].Fill; got != want.fill {
+			t.Errorf("path #%d: fill: got %v, want %v", i, got, want.fill)
+		}
+		if got := len(splitSubpaths(r.paths[i].Segments)); got != want.nSubpaths {
+			t.Errorf("path #%d: sub-paths: got %d, want %d", i, got, want.nSubpaths)
+		}
+	}
+
+	// The edges are all pixel-aligned, so rendering at the same size should
+	// reproduce the image exactly, other than the quantized color.
+	got, err := rasterize(ivgData, 16, 16, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			want := src.RGBAAt(x, y)
+			if want == nearlyBlue {
+				want = blue
+			}
+			if g := got.RGBAAt(x, y); g != want {
+				t.Fatalf("at (%d, %d): got %v, want %v", x, y, g, want)
+			}
+		}
+	}
+
+	if _, err := EncodeFromImage(image.NewRGBA(image.Rectangle{}), nil); err != errEmptyImage {
+		t.Errorf("empty image: got %v, want %v", err, errEmptyImage)
+	}
+}
+
+func TestSimplifyPolygon(t *testing.T) {
+	// A staircase approximation to a right triangle.
+	polygon := []f32.Vec2{
+		{0, 0}, {1, 0}, {1, 1}, {2, 1}, {2, 2}, {3, 2}, {3, 3}, {0, 3},
+	}
+	got := simplifyPolygon(polygon, 1)
+	want := []f32.Vec2{{0, 0}, {3, 3}, {0, 3}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	// With a smaller tolerance, the stairs are kept.
+	if got := simplifyPolygon(polygon, 0.25); len(got) != len(polygon) {
+		t.Errorf("tolerance 0.25: got %v, want %v", got, polygon)
+	}
+}
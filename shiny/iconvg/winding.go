@@ -0,0 +1,219 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"golang.org/x/image/math/f32"
+)
+
+// Winding is a policy for the direction in which sub-paths wind.
+type Winding uint8
+
+const (
+	// WindingAsIs leaves sub-paths as they are encoded.
+	WindingAsIs Winding = iota
+	// WindingOuterClockwise makes outer contours clockwise, and holes
+	// counter-clockwise.
+	WindingOuterClockwise
+	// WindingOuterCounterClockwise makes outer contours counter-clockwise, and
+	// holes clockwise.
+	WindingOuterCounterClockwise
+)
+
+// pathRewriter is a Destination that buffers each path, as absolute
+// Segments, and then forwards it to an inner Destination after passing it
+// through a rewrite function. Styling ops are forwarded as is.
+//
+// The forwarded drawing ops are all absolute, and arcs are forwarded as cubic
+// Bézier curves.
+type pathRewriter struct {
+	inner   Destination
+	rewrite func(segs []Segment) []Segment
+
+	segmenter
+
+	adj  uint8
+	segs []Segment
+}
+
+func (w *pathRewriter) abortErr() error {
+	if a, ok := w.inner.(aborter); ok {
+		return a.abortErr()
+	}
+	return nil
+}
+
+func (w *pathRewriter) Reset(m Metadata) {
+	w.segmenter.reset(m, w)
+	w.segs = w.segs[:0]
+	w.inner.Reset(m)
+}
+
+func (w *pathRewriter) SetCSel(cSel uint8) {
+	w.segmenter.SetCSel(cSel)
+	w.inner.SetCSel(cSel)
+}
+
+func (w *pathRewriter) SetNSel(nSel uint8) {
+	w.segmenter.SetNSel(nSel)
+	w.inner.SetNSel(nSel)
+}
+
+func (w *pathRewriter) SetCReg(adj uint8, incr bool, c Color) {
+	w.segmenter.SetCReg(adj, incr, c)
+	w.inner.SetCReg(adj, incr, c)
+}
+
+func (w *pathRewriter) SetNReg(adj uint8, incr bool, f float32) {
+	w.segmenter.SetNReg(adj, incr, f)
+	w.inner.SetNReg(adj, incr, f)
+}
+
+func (w *pathRewriter) SetLOD(lod0, lod1 float32) {
+	w.segmenter.SetLOD(lod0, lod1)
+	w.inner.SetLOD(lod0, lod1)
+}
+
+func (w *pathRewriter) StartPath(adj uint8, x, y float32) {
+	w.adj = adj
+	w.segmenter.StartPath(adj, x, y)
+}
+
+func (w *pathRewriter) beginPath()           { w.segs = w.segs[:0] }
+func (w *pathRewriter) addSegment(s Segment) { w.segs = append(w.segs, s) }
+
+func (w *pathRewriter) endPath() {
+	if segs := w.rewrite(w.segs); len(segs) != 0 {
+		emitPath(w.inner, w.adj, segs)
+	}
+}
+
+// emitPath passes a path, as absolute Segments, to dst, filled with
+// CREG[CSEL-adj]. The first segment must be a MoveTo.
+func emitPath(dst Destination, adj uint8, segs []Segment) {
+	for i := range segs {
+		a := &segs[i].Args
+		switch segs[i].Op {
+		case SegmentOpMoveTo:
+			if i == 0 {
+				dst.StartPath(adj, a[0][0], a[0][1])
+			} else {
+				dst.ClosePathAbsMoveTo(a[0][0], a[0][1])
+			}
+		case SegmentOpLineTo:
+			dst.AbsLineTo(a[0][0], a[0][1])
+		case SegmentOpQuadTo:
+			dst.AbsQuadTo(a[0][0], a[0][1], a[1][0], a[1][1])
+		case SegmentOpCubeTo:
+			dst.AbsCubeTo(a[0][0], a[0][1], a[1][0], a[1][1], a[2][0], a[2][1])
+		}
+	}
+	dst.ClosePathEndPath()
+}
+
+// windingRewriter returns a pathRewriter rewrite function that reverses each
+// sub-path, if needed, to wind in the direction required by the Winding
+// policy, as classified by classifySubpaths. Sub-paths that do not bound the
+// path's filled region are dropped, so that the region does not change.
+//
+// Clockwise and counter-clockwise are as seen on screen, with the Y axis
+// increasing down.
+func windingRewriter(winding Winding) func(segs []Segment) []Segment {
+	var ret, reversed []Segment
+	return func(segs []Segment) []Segment {
+		subpaths := splitSubpaths(segs)
+		polygons := make([][]f32.Vec2, len(subpaths))
+		for i, sub := range subpaths {
+			polygons[i] = flatten(nil, sub, flattenTolerance)
+		}
+
+		ret = ret[:0]
+		for i, role := range classifySubpaths(polygons) {
+			if role == subpathNone {
+				continue
+			}
+			sub := subpaths[i]
+			// A positive area means clockwise.
+			wantPositive := (role == subpathOuter) == (winding == WindingOuterClockwise)
+			if (signedArea(polygons[i]) > 0) != wantPositive {
+				reversed = reverseSubpath(reversed[:0], sub)
+				sub = reversed
+			}
+			ret = append(ret, sub...)
+		}
+		if len(ret) == 0 {
+			// Every sub-path is degenerate. Keep the path, which fills
+			// nothing either way.
+			return segs
+		}
+		return ret
+	}
+}
+
+// subpathRole is how a sub-path bounds its path's filled region.
+type subpathRole uint8
+
+const (
+	// subpathNone means that the sub-path does not bound the filled region:
+	// the region is the same on both sides of it, such as for a sub-path
+	// nested inside, and winding the same way as, another, or for one with
+	// zero area.
+	subpathNone subpathRole = iota
+	// subpathOuter means that the region is inside the sub-path.
+	subpathOuter
+	// subpathHole means that the region is outside the sub-path.
+	subpathHole
+)
+
+// classifySubpaths returns the role of each of a path's sub-paths, flattened
+// to polygons, under IconVG's non-zero winding fill rule. The sub-paths are
+// assumed not to cross themselves or each other.
+//
+// Just inside and just outside of a sub-path, the other sub-paths' winding
+// number is the same, w, and the sub-path adds its own winding, +1 or -1, to
+// the inside. The sub-path is an outer boundary if that makes the winding
+// number non-zero inside but not outside, and a hole if it moves the winding
+// number from non-zero outside to zero inside. Testing by nesting depth
+// alone would treat a sub-path nested inside another that winds the same way
+// as a hole, although the non-zero rule fills it.
+func classifySubpaths(polygons [][]f32.Vec2) []subpathRole {
+	roles := make([]subpathRole, len(polygons))
+	for i, polygon := range polygons {
+		area := signedArea(polygon)
+		if area == 0 || len(polygon) < 2 {
+			continue
+		}
+		// A clockwise polygon's winding number, inside, is +1.
+		own := -1
+		if area > 0 {
+			own = +1
+		}
+		// Sample the other sub-paths' winding number at the middle of the
+		// polygon's longest edge, which is least likely to touch them.
+		var mid f32.Vec2
+		longest := float32(-1)
+		for k := range polygon {
+			a, b := polygon[k], polygon[(k+1)%len(polygon)]
+			dx, dy := b[0]-a[0], b[1]-a[1]
+			if d := dx*dx + dy*dy; d > longest {
+				longest = d
+				mid = f32.Vec2{(a[0] + b[0]) / 2, (a[1] + b[1]) / 2}
+			}
+		}
+		w := 0
+		for j, other := range polygons {
+			if j != i {
+				w += windingNumber(other, mid)
+			}
+		}
+		switch inside, outside := w+own != 0, w != 0; {
+		case inside && !outside:
+			roles[i] = subpathOuter
+		case !inside && outside:
+			roles[i] = subpathHole
+		}
+	}
+	return roles
+}
@@ -0,0 +1,142 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iconvg
+
+import (
+	"testing"
+)
+
+func TestNormalizeWinding(t *testing.T) {
+	// A path whose outer square is clockwise and whose inner square, a hole,
+	// is counter-clockwise, followed by a path whose single square is
+	// counter-clockwise.
+	var e Encoder
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	e.StartPath(0, -30, -30)
+	e.AbsHLineTo(+30)
+	e.AbsVLineTo(+30)
+	e.AbsHLineTo(-30)
+	e.ClosePathAbsMoveTo(-10, -10)
+	e.AbsVLineTo(+10)
+	e.AbsHLineTo(+10)
+	e.AbsArcTo(10, 10, 0, false, false, +10, -10)
+	e.ClosePathEndPath()
+	e.StartPath(0, 0, 0)
+	e.AbsVLineTo(5)
+	e.AbsHLineTo(5)
+	e.AbsVLineTo(0)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	// areaSigns returns the signs of each sub-path's area, with +1 meaning
+	// clockwise.
+	areaSigns := func(w Winding) (signs []int) {
+		var r pathsRecorder
+		if err := Decode(&r, ivgData, &DecodeOptions{NormalizeWinding: w}); err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		for _, path := range r.paths {
+			for _, sub := range splitSubpaths(path.Segments) {
+				sign := -1
+				if signedArea(flatten(nil, sub, flattenTolerance)) > 0 {
+					sign = +1
+				}
+				signs = append(signs, sign)
+			}
+		}
+		return signs
+	}
+
+	testCases := []struct {
+		w    Winding
+		want []int
+	}{
+		{WindingAsIs, []int{+1, -1, -1}},
+		{WindingOuterClockwise, []int{+1, -1, +1}},
+		{WindingOuterCounterClockwise, []int{-1, +1, -1}},
+	}
+	for _, tc := range testCases {
+		got := areaSigns(tc.w)
+		if len(got) != len(tc.want) {
+			t.Errorf("winding %d: got %v, want %v", tc.w, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("winding %d: got %v, want %v", tc.w, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestNormalizeWindingPreservesFill(t *testing.T) {
+	// square draws a clockwise or counter-clockwise square sub-path, centered
+	// on the origin.
+	var e Encoder
+	square := func(r float32, clockwise bool, first bool) {
+		if first {
+			e.StartPath(0, -r, -r)
+		} else {
+			e.ClosePathAbsMoveTo(-r, -r)
+		}
+		if clockwise {
+			e.AbsHLineTo(+r)
+			e.AbsVLineTo(+r)
+			e.AbsHLineTo(-r)
+		} else {
+			e.AbsVLineTo(+r)
+			e.AbsHLineTo(+r)
+			e.AbsVLineTo(-r)
+		}
+	}
+	e.Reset(Metadata{
+		ViewBox: DefaultViewBox,
+		Palette: DefaultPalette,
+	})
+	// Under the non-zero winding rule, the inner squares that wind the same
+	// way as the square around them are filled, not holes.
+	square(30, true, true)
+	square(20, true, false)
+	square(10, false, false)
+	square(5, false, false)
+	e.ClosePathEndPath()
+	ivgData, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	want, err := rasterize(ivgData, 64, 64, nil)
+	if err != nil {
+		t.Fatalf("rasterize: %v", err)
+	}
+	// The center, inside the 5 and 10 unit squares, is a hole: the winding
+	// number there is 1 + 1 - 1 - 1 = 0. Between 10 and 20 units, it is 2.
+	if a := want.RGBAAt(32, 32).A; a != 0x00 {
+		t.Fatalf("center: got alpha %#02x, want 0x00", a)
+	}
+	if a := want.RGBAAt(32, 32-15).A; a != 0xff {
+		t.Fatalf("between the middle squares: got alpha %#02x, want 0xff", a)
+	}
+	for _, w := range []Winding{WindingOuterClockwise, WindingOuterCounterClockwise} {
+		got, err := rasterize(ivgData, 64, 64, &DecodeOptions{NormalizeWinding: w})
+		if err != nil {
+			t.Fatalf("winding %d: rasterize: %v", w, err)
+		}
+		for y := 0; y < 64; y++ {
+			for x := 0; x < 64; x++ {
+				if g, w0 := got.RGBAAt(x, y), want.RGBAAt(x, y); g != w0 {
+					t.Fatalf("winding %d: at (%d, %d): got %v, want %v", w, x, y, g, w0)
+				}
+			}
+		}
+	}
+}